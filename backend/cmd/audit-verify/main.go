@@ -0,0 +1,40 @@
+// Command audit-verify menelusuri rantai hash audit_logs dan melaporkan mata rantai yang rusak.
+// Exit code 1 jika rantai rusak, 2 jika verifikasi gagal dijalankan.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/database"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+)
+
+func main() {
+	cfg := config.Load()
+
+	db := database.Connect(&cfg.Database)
+	defer db.Close()
+
+	auditService := service.NewAuditService(repository.NewAuditRepository(db))
+	result, err := auditService.VerifyChain(context.Background())
+	if err != nil {
+		log.Printf("Verifikasi gagal dijalankan: %v", err)
+		os.Exit(2)
+	}
+
+	log.Printf("Entri diperiksa: %d, hash terakhir: %s", result.Checked, result.LastHash)
+	if result.Valid {
+		log.Println("✅ Rantai audit utuh")
+		return
+	}
+
+	log.Printf("❌ Rantai audit RUSAK, %d mata rantai bermasalah:", len(result.Breaks))
+	for _, b := range result.Breaks {
+		log.Printf("  - id %d: %s (expected %s, actual %s)", b.ID, b.Reason, b.Expected, b.Actual)
+	}
+	os.Exit(1)
+}
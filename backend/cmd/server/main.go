@@ -13,9 +13,11 @@ import (
 	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/database"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/handler"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/worker"
 )
 
 // @title           Digital Achievement Ledger API
@@ -64,31 +66,101 @@ func main() {
 	}
 	log.Println("MinIO connected successfully")
 
+	// ── Certificate signing key ───────────────────────
+	signer, err := utils.NewCertificateSigner(&cfg.Signing, cfg.App.Env == "production")
+	if err != nil {
+		log.Fatalf("Failed to load signing key: %v", err)
+	}
+	pdfSigner, err := utils.NewPDFSigner(&cfg.PDFSign)
+	if err != nil {
+		log.Fatalf("Failed to load PDF signing certificate: %v", err)
+	}
+	if pdfSigner == nil {
+		log.Println("⚠️  PDF_SIGN_CERT_PATH/PDF_SIGN_KEY_PATH belum diset, PDF sertifikat tidak ditandatangani")
+	}
+	if err := utils.SetPasswordPolicy(&cfg.Password); err != nil {
+		log.Fatalf("Invalid password policy: %v", err)
+	}
+	if err := utils.LoadPDFFonts(cfg.PDFFont.Dir); err != nil {
+		log.Fatalf("Failed to load PDF fonts: %v", err)
+	}
+	if cfg.PDFFont.Dir == "" {
+		log.Println("⚠️  PDF_FONT_DIR belum diset, PDF memakai font Arial (karakter di luar Latin-1 tidak tampil benar)")
+	}
+
+	numbering, err := utils.NewCertificateNumberFormat(&cfg.Numbering)
+	if err != nil {
+		log.Fatalf("Invalid certificate number config: %v", err)
+	}
+
 	// ── Repositories ─────────────────────────────────
 	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	throttleRepo := repository.NewLoginThrottleRepository(db)
 	studentRepo := repository.NewStudentRepository(db)
 	achievementRepo := repository.NewAchievementRepository(db)
-	certificateRepo := repository.NewCertificateRepository(db)
+	importRepo := repository.NewAchievementImportRepository(db)
+	scoringRepo := repository.NewScoringRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	certificateRepo := repository.NewCertificateRepository(db, numbering)
+	templateRepo := repository.NewCertificateTemplateRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	transactor := repository.NewTransactor(db)
 
 	// ── Services ─────────────────────────────────────
-	authService := service.NewAuthService(userRepo, cfg)
-	studentService := service.NewStudentService(studentRepo, storage)
-	achievementService := service.NewAchievementService(achievementRepo, studentRepo, storage)
-	certificateService := service.NewCertificateService(certificateRepo, studentRepo, achievementRepo, storage)
+	auditService := service.NewAuditService(auditRepo)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo, userRepo, sessionRepo, &cfg.MFA, transactor, auditService)
+	authService := service.NewAuthService(userRepo, sessionRepo, throttleRepo, twoFactorService, cfg, transactor, auditService)
+	userService := service.NewUserService(userRepo, sessionRepo, transactor, auditService)
+	studentService := service.NewStudentService(studentRepo, achievementRepo, storage, transactor, auditService)
+	achievementService := service.NewAchievementService(achievementRepo, studentRepo, storage, transactor, auditService)
+	importService := service.NewAchievementImportService(importRepo, achievementRepo, studentRepo, transactor, auditService)
+	scoringService := service.NewScoringService(scoringRepo, studentRepo, transactor, auditService)
+	reportService := service.NewReportService(reportRepo, auditService, cfg.Report.CacheTTL)
+	portfolioService := service.NewPortfolioService(studentRepo, achievementRepo, storage)
+	certificateService := service.NewCertificateService(certificateRepo, studentRepo, achievementRepo, templateRepo, storage, signer, pdfSigner, transactor, auditService)
+	templateService := service.NewCertificateTemplateService(templateRepo, storage, transactor, auditService)
+	jobService := service.NewJobService(jobRepo)
+
+	// ── Background worker ────────────────────────────
+	pool := worker.NewPool(jobRepo, &cfg.Worker)
+	pool.Register(model.JobTypeGenerateCertificatePDF, certificateService.GeneratePDFJob, certificateService.PDFJobDead)
+	pool.Start()
 
 	// ── Handlers ─────────────────────────────────────
 	authHandler := handler.NewAuthHandler(authService)
+	twoFactorHandler := handler.NewTwoFactorHandler(authService, twoFactorService)
+	userHandler := handler.NewUserHandler(userService)
 	studentHandler := handler.NewStudentHandler(studentService)
 	achievementHandler := handler.NewAchievementHandler(achievementService)
+	importHandler := handler.NewAchievementImportHandler(importService)
+	scoringHandler := handler.NewScoringHandler(scoringService)
+	reportHandler := handler.NewReportHandler(reportService)
+	portfolioHandler := handler.NewPortfolioHandler(portfolioService)
 	certificateHandler := handler.NewCertificateHandler(certificateService)
+	templateHandler := handler.NewCertificateTemplateHandler(templateService)
+	jobHandler := handler.NewJobHandler(jobService)
+	auditHandler := handler.NewAuditHandler(auditService)
 
 	// ── Router ────────────────────────────────────────
 	router := handler.NewRouter(
 		authHandler,
+		twoFactorHandler,
+		userHandler,
 		studentHandler,
 		achievementHandler,
+		importHandler,
+		scoringHandler,
+		reportHandler,
+		portfolioHandler,
 		certificateHandler,
+		templateHandler,
+		jobHandler,
+		auditHandler,
 		cfg.JWT.Secret,
+		authService,
 	)
 
 	// ── HTTP Server ───────────────────────────────────
@@ -117,5 +189,12 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+
+	// Tunggu job yang sedang berjalan; sisanya diambil ulang saat start berikutnya
+	workerCtx, workerCancel := context.WithTimeout(context.Background(), cfg.Worker.JobTimeout)
+	defer workerCancel()
+	if err := pool.Stop(workerCtx); err != nil {
+		log.Printf("Worker pool tidak berhenti tepat waktu: %v", err)
+	}
 	log.Println("Server stopped gracefully")
 }
@@ -4,15 +4,25 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	MinIO    MinIOConfig
+	App       AppConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	MinIO     MinIOConfig
+	Signing   SigningConfig
+	PDFSign   PDFSigningConfig
+	PDFFont   PDFFontConfig
+	Numbering CertificateNumberConfig
+	Worker    WorkerConfig
+	Report    ReportConfig
+	Password  PasswordPolicyConfig
+	MFA       MFAConfig
+	Login     LoginThrottleConfig
 }
 
 type AppConfig struct {
@@ -43,6 +53,76 @@ type MinIOConfig struct {
 	UseSSL    bool
 }
 
+// SigningConfig kunci Ed25519 sekolah untuk menandatangani sertifikat
+type SigningConfig struct {
+	PrivateKey string // base64 dari seed 32 byte atau private key 64 byte
+	KeyID      string
+
+	// PreviousKeys public key lama setelah rotasi, format "kid=base64,kid=base64",
+	// agar sertifikat yang ditandatangani sebelum rotasi tetap terverifikasi
+	PreviousKeys string
+}
+
+// PDFSigningConfig sertifikat X.509 untuk tanda tangan PDF (PAdES)
+type PDFSigningConfig struct {
+	CertPath    string // PEM, sertifikat penanda tangan diikuti rantai CA (opsional)
+	KeyPath     string // PEM, RSA atau ECDSA (PKCS#1, PKCS#8, SEC1)
+	Reason      string
+	Location    string
+	ContactInfo string
+}
+
+// PDFFontConfig font TTF Unicode untuk dokumen PDF
+type PDFFontConfig struct {
+	Dir string // folder berisi DejaVuSans.ttf dan DejaVuSans-Bold.ttf; kosong = font inti Arial
+}
+
+// CertificateNumberConfig format nomor surat keterangan prestasi
+type CertificateNumberConfig struct {
+	Template           string // lihat utils.CertificateNumberFormat untuk daftar placeholder
+	ClassificationCode string // kode klasifikasi arsip, mis. "421.2"
+	ResetPolicy        string // yearly | monthly | never
+}
+
+// WorkerConfig pengaturan worker pool antrean job
+type WorkerConfig struct {
+	Concurrency  int
+	PollInterval time.Duration
+	LockTimeout  time.Duration // job running lebih lama dari ini dianggap worker-nya mati
+	JobTimeout   time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// ReportConfig pengaturan API laporan statistik
+type ReportConfig struct {
+	CacheTTL time.Duration // hasil agregasi di-cache selama ini atau sampai ada perubahan data
+}
+
+// PasswordPolicyConfig aturan kekuatan password; huruf selalu wajib
+type PasswordPolicyConfig struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// MFAConfig verifikasi dua langkah (TOTP)
+type MFAConfig struct {
+	Issuer       string        // nama yang tampil di aplikasi authenticator
+	ChallengeTTL time.Duration // batas waktu memasukkan kode setelah password benar
+}
+
+// LoginThrottleConfig perlindungan brute-force login per akun dan per IP
+type LoginThrottleConfig struct {
+	AccountMaxFailures int           // gagal beruntun sebelum akun (email) dikunci
+	IPMaxFailures      int           // gagal beruntun sebelum IP dikunci; satu IP sekolah bisa dipakai banyak user
+	FailureWindow      time.Duration // hitungan gagal diulang dari nol setelah tidak ada kegagalan selama ini
+	LockoutDuration    time.Duration
+	MaxDelay           time.Duration // batas jeda progresif antar percobaan sebelum terkunci
+}
+
 func Load() *Config {
 	// Load .env jika ada (development), di production pakai env variable langsung
 	if err := godotenv.Load(); err != nil {
@@ -52,6 +132,10 @@ func Load() *Config {
 	jwtExpire, _ := strconv.Atoi(getEnv("JWT_EXPIRE_HOURS", "24"))
 	jwtRefreshExpire, _ := strconv.Atoi(getEnv("JWT_REFRESH_EXPIRE_HOURS", "168"))
 	minioSSL, _ := strconv.ParseBool(getEnv("MINIO_USE_SSL", "false"))
+	workerConcurrency, _ := strconv.Atoi(getEnv("WORKER_CONCURRENCY", "2"))
+	passwordMinLength, _ := strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8"))
+	loginMaxFailures, _ := strconv.Atoi(getEnv("LOGIN_MAX_FAILURES", "10"))
+	loginIPMaxFailures, _ := strconv.Atoi(getEnv("LOGIN_IP_MAX_FAILURES", "50"))
 
 	return &Config{
 		App: AppConfig{
@@ -78,6 +162,55 @@ func Load() *Config {
 			Bucket:   getEnv("MINIO_BUCKET", "dal-attachments"),
 			UseSSL:   minioSSL,
 		},
+		Signing: SigningConfig{
+			PrivateKey:   getEnv("CERT_SIGNING_KEY", ""),
+			KeyID:        getEnv("CERT_SIGNING_KEY_ID", "school-key-1"),
+			PreviousKeys: getEnv("CERT_SIGNING_PREVIOUS_KEYS", ""),
+		},
+		PDFSign: PDFSigningConfig{
+			CertPath:    getEnv("PDF_SIGN_CERT_PATH", ""),
+			KeyPath:     getEnv("PDF_SIGN_KEY_PATH", ""),
+			Reason:      getEnv("PDF_SIGN_REASON", "Surat Keterangan Prestasi"),
+			Location:    getEnv("PDF_SIGN_LOCATION", ""),
+			ContactInfo: getEnv("PDF_SIGN_CONTACT", ""),
+		},
+		PDFFont: PDFFontConfig{
+			Dir: getEnv("PDF_FONT_DIR", ""),
+		},
+		Numbering: CertificateNumberConfig{
+			Template:           getEnv("CERT_NUMBER_TEMPLATE", "{code}/SKP/{year}/{seq:4}"),
+			ClassificationCode: getEnv("CERT_CLASSIFICATION_CODE", "421.2"),
+			ResetPolicy:        getEnv("CERT_NUMBER_RESET", "yearly"),
+		},
+		Worker: WorkerConfig{
+			Concurrency:  workerConcurrency,
+			PollInterval: getEnvDuration("WORKER_POLL_INTERVAL", 2*time.Second),
+			LockTimeout:  getEnvDuration("WORKER_LOCK_TIMEOUT", 10*time.Minute),
+			JobTimeout:   getEnvDuration("WORKER_JOB_TIMEOUT", 2*time.Minute),
+			BaseBackoff:  getEnvDuration("WORKER_RETRY_BACKOFF", 30*time.Second),
+			MaxBackoff:   getEnvDuration("WORKER_RETRY_MAX_BACKOFF", time.Hour),
+		},
+		Report: ReportConfig{
+			CacheTTL: getEnvDuration("REPORT_CACHE_TTL", 5*time.Minute),
+		},
+		Password: PasswordPolicyConfig{
+			MinLength:     passwordMinLength,
+			RequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+			RequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		},
+		MFA: MFAConfig{
+			Issuer:       getEnv("MFA_ISSUER", "Digital Achievement Ledger"),
+			ChallengeTTL: getEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+		},
+		Login: LoginThrottleConfig{
+			AccountMaxFailures: loginMaxFailures,
+			IPMaxFailures:      loginIPMaxFailures,
+			FailureWindow:      getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+			LockoutDuration:    getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			MaxDelay:           getEnvDuration("LOGIN_MAX_DELAY", 30*time.Second),
+		},
 	}
 }
 
@@ -86,4 +219,22 @@ func getEnv(key, fallback string) string {
 		return val
 	}
 	return fallback
+}
+
+// getEnvBool membaca boolean ("true", "1", "false", ...); nilai tidak valid memakai fallback
+func getEnvBool(key string, fallback bool) bool {
+	b, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+// getEnvDuration membaca durasi format Go, mis. "30s", "10m"
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
 }
\ No newline at end of file
@@ -2,6 +2,8 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log"
 
 	"github.com/google/uuid"
@@ -17,7 +19,13 @@ func NewSeeder(db *sqlx.DB) *Seeder {
 	return &Seeder{db: db}
 }
 
-// SeedAdminUser membuat user admin default jika belum ada
+const (
+	defaultAdminEmail    = "admin@sekolah.sch.id"
+	defaultAdminPassword = "Admin@123"
+)
+
+// SeedAdminUser membuat user admin default jika belum ada. Admin default wajib
+// mengganti password saat login pertama.
 func (s *Seeder) SeedAdminUser(ctx context.Context) error {
 	// Cek apakah sudah ada admin
 	var count int
@@ -28,22 +36,22 @@ func (s *Seeder) SeedAdminUser(ctx context.Context) error {
 
 	if count > 0 {
 		log.Println("Admin user already exists, skipping seed")
-		return nil
+		return s.flagDefaultAdminPassword(ctx)
 	}
 
 	// Hash password default
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("Admin@123"), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(defaultAdminPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO users (id, name, email, password, role, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		INSERT INTO users (id, name, email, password, role, is_active, must_change_password, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE, NOW(), NOW())
 	`,
 		uuid.New(),
 		"Administrator",
-		"admin@sekolah.sch.id",
+		defaultAdminEmail,
 		string(hashedPassword),
 		"admin",
 		true,
@@ -54,9 +62,34 @@ func (s *Seeder) SeedAdminUser(ctx context.Context) error {
 	}
 
 	log.Println("✅ Default admin user created:")
-	log.Println("   Email   : admin@sekolah.sch.id")
-	log.Println("   Password: Admin@123")
-	log.Println("   ⚠️  Segera ganti password setelah login pertama!")
+	log.Println("   Email   : " + defaultAdminEmail)
+	log.Println("   Password: " + defaultAdminPassword)
+	log.Println("   ⚠️  Password wajib diganti saat login pertama (PUT /api/v1/auth/password)")
+
+	return nil
+}
 
+// flagDefaultAdminPassword mewajibkan ganti password jika admin default dari
+// instalasi lama masih memakai password bawaan
+func (s *Seeder) flagDefaultAdminPassword(ctx context.Context) error {
+	var hash string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT password FROM users WHERE email = $1 AND NOT must_change_password", defaultAdminEmail).Scan(&hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(defaultAdminPassword)) != nil {
+		return nil
+	}
+
+	if _, err := s.db.ExecContext(ctx,
+		"UPDATE users SET must_change_password = TRUE, updated_at = NOW() WHERE email = $1", defaultAdminEmail); err != nil {
+		return err
+	}
+	log.Println("⚠️  Admin default masih memakai password bawaan, wajib diganti saat login berikutnya")
 	return nil
 }
\ No newline at end of file
@@ -29,9 +29,12 @@ func NewAchievementHandler(svc service.AchievementService) *AchievementHandler {
 // @Accept       json
 // @Produce      json
 // @Param        student_id   query    string  false  "Filter by student ID"
+// @Param        status       query    string  false  "Filter by status (draft, submitted, verified, rejected)"
 // @Param        search       query    string  false  "Search by competition name"
 // @Param        category_id  query    int     false  "Filter by category ID"
 // @Param        level_id     query    int     false  "Filter by level ID"
+// @Param        rank_id      query    int     false  "Filter by rank ID"
+// @Param        rank_kind    query    string  false  "Filter by rank kind (position, medal, honourable_mention, participation)"
 // @Param        year         query    int     false  "Filter by year"
 // @Param        page         query    int     false  "Page number"
 // @Param        per_page     query    int     false  "Items per page"
@@ -44,6 +47,8 @@ func (h *AchievementHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	filter := model.AchievementFilter{
 		StudentID: q.Get("student_id"),
+		Status:    q.Get("status"),
+		RankKind:  q.Get("rank_kind"),
 		Search:    q.Get("search"),
 		Page:      parseIntQuery(q.Get("page"), 1),
 		PerPage:   parseIntQuery(q.Get("per_page"), 10),
@@ -59,6 +64,11 @@ func (h *AchievementHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 			filter.LevelID = &v
 		}
 	}
+	if rk := q.Get("rank_id"); rk != "" {
+		if v, err := strconv.Atoi(rk); err == nil {
+			filter.RankID = &v
+		}
+	}
 	if y := q.Get("year"); y != "" {
 		if v, err := strconv.Atoi(y); err == nil {
 			filter.Year = &v
@@ -104,7 +114,7 @@ func (h *AchievementHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 // Create adds a new achievement
 // @Summary      Create an achievement
-// @Description  Create a new achievement record for a student
+// @Description  Create a new achievement record for a student. For a team achievement send team_name and participants (roles: captain, member, coach); student_id is then optional and defaults to the captain.
 // @Tags         achievements
 // @Accept       json
 // @Produce      json
@@ -122,27 +132,7 @@ func (h *AchievementHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errs := utils.ValidationErrors{}
-	req.CompetitionName = utils.SanitizeString(req.CompetitionName)
-	req.Organizer = utils.SanitizeString(req.Organizer)
-
-	if req.StudentID == "" {
-		errs["student_id"] = "Student ID wajib diisi"
-	}
-	if req.CompetitionName == "" {
-		errs["competition_name"] = "Nama lomba wajib diisi"
-	}
-	if req.Organizer == "" {
-		errs["organizer"] = "Penyelenggara wajib diisi"
-	}
-	if req.Rank == "" {
-		errs["rank"] = "Juara/peringkat wajib diisi"
-	}
-	if req.Year == 0 {
-		errs["year"] = "Tahun wajib diisi"
-	}
-
-	if errs.HasErrors() {
+	if errs := service.ValidateCreateAchievement(&req); errs.HasErrors() {
 		response.BadRequest(w, "Validasi gagal", errs)
 		return
 	}
@@ -154,6 +144,14 @@ func (h *AchievementHandler) Create(w http.ResponseWriter, r *http.Request) {
 			response.NotFound(w, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrRankNotFound) {
+			response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"rank_id": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrParticipantPrimary) {
+			response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"student_id": err.Error()})
+			return
+		}
 		response.InternalError(w, "Gagal membuat data prestasi")
 		return
 	}
@@ -163,7 +161,7 @@ func (h *AchievementHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // Update modifies an existing achievement
 // @Summary      Update an achievement
-// @Description  Update details of an existing achievement
+// @Description  Update details of an existing achievement. A submitted or verified achievement returns to draft and must be verified again.
 // @Tags         achievements
 // @Accept       json
 // @Produce      json
@@ -196,12 +194,17 @@ func (h *AchievementHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	achievement, err := h.svc.Update(r.Context(), id, req)
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	achievement, err := h.svc.Update(r.Context(), id, req, actorID)
 	if err != nil {
 		if errors.Is(err, service.ErrAchievementNotFound) {
 			response.NotFound(w, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrRankNotFound) {
+			response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"rank_id": err.Error()})
+			return
+		}
 		response.InternalError(w, "Gagal mengupdate data prestasi")
 		return
 	}
@@ -209,6 +212,49 @@ func (h *AchievementHandler) Update(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, "Data prestasi berhasil diupdate", achievement)
 }
 
+// UpdateParticipants replaces the members of a team achievement
+// @Summary      Update team participants
+// @Description  Replace the team name and participants (roles: captain, member, coach). An empty list turns the team achievement back into an individual one. A submitted or verified achievement returns to draft.
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                           true  "Achievement ID"
+// @Param        request  body      model.UpdateParticipantsRequest  true  "Team participants"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /achievements/{id}/participants [put]
+func (h *AchievementHandler) UpdateParticipants(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req model.UpdateParticipantsRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	req.TeamName = utils.SanitizeString(req.TeamName)
+	if errs := service.ValidateParticipants(req.TeamName, req.Participants); errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	achievement, err := h.svc.UpdateParticipants(r.Context(), id, req, actorID)
+	if err != nil {
+		if errors.Is(err, service.ErrAchievementNotFound) || errors.Is(err, service.ErrStudentNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengupdate anggota tim")
+		return
+	}
+
+	response.Success(w, "Anggota tim berhasil diupdate", achievement)
+}
+
 // Delete removes an achievement
 // @Summary      Delete an achievement
 // @Description  Delete a specific achievement and all its attachments
@@ -353,3 +399,167 @@ func (h *AchievementHandler) GetLevels(w http.ResponseWriter, r *http.Request) {
 	}
 	response.Success(w, "Data tingkat lomba berhasil diambil", levels)
 }
+
+// GetRanks retrieves the list of ranks
+// @Summary      Get achievement ranks
+// @Description  Get the rank reference list (Juara 1-3, medals, honourable mentions, finalist, participant) ordered from highest
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /achievements/ranks [get]
+func (h *AchievementHandler) GetRanks(w http.ResponseWriter, r *http.Request) {
+	ranks, err := h.svc.GetRanks(r.Context())
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil data peringkat")
+		return
+	}
+	response.Success(w, "Data peringkat berhasil diambil", ranks)
+}
+
+// UnmatchedRanks reports rank texts that are not mapped to a rank
+// @Summary      Get unmatched rank texts
+// @Description  Free-text rank values of existing achievements that could not be normalised, with the number of achievements using each
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /achievements/ranks/unmatched [get]
+func (h *AchievementHandler) UnmatchedRanks(w http.ResponseWriter, r *http.Request) {
+	unmatched, err := h.svc.GetUnmatchedRanks(r.Context())
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil laporan peringkat")
+		return
+	}
+	response.Success(w, "Laporan peringkat yang belum terpetakan berhasil diambil", unmatched)
+}
+
+// Submit sends an achievement for verification
+// @Summary      Submit an achievement
+// @Description  Submit a draft (or a corrected rejected) achievement for verification
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Router       /achievements/{id}/submit [post]
+func (h *AchievementHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	achievement, err := h.svc.Submit(r.Context(), chi.URLParam(r, "id"), actorID)
+	if err != nil {
+		writeAchievementStatusError(w, err)
+		return
+	}
+
+	response.Success(w, "Prestasi berhasil diajukan untuk diverifikasi", achievement)
+}
+
+// Verify marks a submitted achievement as verified
+// @Summary      Verify an achievement
+// @Description  Mark a submitted achievement as verified so it can be included in certificates. Only admins or headmasters can access this endpoint.
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "Achievement ID"
+// @Param        request  body      model.ReviewAchievementRequest  false  "Reviewer comment"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      403      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      409      {object}  response.Response
+// @Router       /achievements/{id}/verify [post]
+func (h *AchievementHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	// Komentar opsional, body boleh kosong
+	var req model.ReviewAchievementRequest
+	if err := utils.DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	achievement, err := h.svc.Verify(r.Context(), chi.URLParam(r, "id"), req, actorID)
+	if err != nil {
+		writeAchievementStatusError(w, err)
+		return
+	}
+
+	response.Success(w, "Prestasi berhasil diverifikasi", achievement)
+}
+
+// Reject rejects a submitted or verified achievement
+// @Summary      Reject an achievement
+// @Description  Reject an achievement with a reviewer comment. Only admins or headmasters can access this endpoint.
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "Achievement ID"
+// @Param        request  body      model.ReviewAchievementRequest  true  "Reviewer comment"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      403      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      409      {object}  response.Response
+// @Router       /achievements/{id}/reject [post]
+func (h *AchievementHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	var req model.ReviewAchievementRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	achievement, err := h.svc.Reject(r.Context(), chi.URLParam(r, "id"), req, actorID)
+	if err != nil {
+		writeAchievementStatusError(w, err)
+		return
+	}
+
+	response.Success(w, "Prestasi ditolak", achievement)
+}
+
+// History lists the verification history of an achievement
+// @Summary      Get achievement status history
+// @Description  Get the status changes of an achievement with reviewer comments
+// @Tags         achievements
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /achievements/{id}/history [get]
+func (h *AchievementHandler) History(w http.ResponseWriter, r *http.Request) {
+	events, err := h.svc.GetStatusHistory(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrAchievementNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil riwayat status prestasi")
+		return
+	}
+
+	response.Success(w, "Riwayat status prestasi berhasil diambil", events)
+}
+
+func writeAchievementStatusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrAchievementNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, service.ErrAchievementCommentMissing):
+		response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"comment": err.Error()})
+	case errors.Is(err, service.ErrAchievementTransition):
+		response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+	default:
+		response.BadRequest(w, err.Error(), nil)
+	}
+}
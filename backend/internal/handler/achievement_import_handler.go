@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type AchievementImportHandler struct {
+	svc service.AchievementImportService
+}
+
+func NewAchievementImportHandler(svc service.AchievementImportService) *AchievementImportHandler {
+	return &AchievementImportHandler{svc: svc}
+}
+
+// Stage uploads a spreadsheet of achievements into a staging batch
+// @Summary      Stage an achievement import
+// @Description  Upload CSV or XLSX (max 10MB) with columns NISN/Nama, Kelas, Nama Lomba, Penyelenggara, Kategori, Tingkat, Juara, Tahun. Students are matched by NISN or by name and class; nothing is created until the batch is approved.
+// @Tags         achievement-imports
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file  true  "CSV or XLSX file"
+// @Security     BearerAuth
+// @Success      201   {object}  response.Response
+// @Failure      400   {object}  response.Response
+// @Failure      500   {object}  response.Response
+// @Router       /achievements/imports [post]
+func (h *AchievementImportHandler) Stage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, utils.MaxSpreadsheetSize)
+	if err := r.ParseMultipartForm(utils.MaxSpreadsheetSize); err != nil {
+		response.BadRequest(w, "File terlalu besar atau format tidak valid", nil)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.BadRequest(w, "File import tidak ditemukan dalam request", nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.InternalError(w, "Gagal membaca file")
+		return
+	}
+
+	createdBy := middleware.GetUserIDFromContext(r.Context())
+	batch, err := h.svc.Stage(r.Context(), header.Filename, data, createdBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrUnsupportedSpreadsheet),
+			errors.Is(err, utils.ErrInvalidSpreadsheet),
+			errors.Is(err, service.ErrImportHeaderNotFound),
+			errors.Is(err, service.ErrImportEmpty),
+			errors.Is(err, service.ErrImportTooManyRows):
+			response.BadRequest(w, err.Error(), nil)
+			return
+		}
+		response.InternalError(w, "Gagal memproses file import prestasi")
+		return
+	}
+
+	response.Created(w, "File berhasil diproses, tinjau hasilnya sebelum disetujui", batch)
+}
+
+// GetAll lists achievement import batches
+// @Summary      Get achievement import batches
+// @Description  Get a paginated list of achievement import batches with row status counts
+// @Tags         achievement-imports
+// @Accept       json
+// @Produce      json
+// @Param        status    query    string  false  "Filter by batch status (pending, approved, discarded)"
+// @Param        page      query    int     false  "Page number"
+// @Param        per_page  query    int     false  "Items per page"
+// @Security     BearerAuth
+// @Success      200  {object}  response.PaginatedResponse
+// @Failure      500  {object}  response.Response
+// @Router       /achievements/imports [get]
+func (h *AchievementImportHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := model.AchievementImportFilter{
+		Status:  q.Get("status"),
+		Page:    parseIntQuery(q.Get("page"), 1),
+		PerPage: parseIntQuery(q.Get("per_page"), 10),
+	}
+
+	batches, pagination, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil data import prestasi")
+		return
+	}
+
+	response.Paginated(w, "Data import prestasi berhasil diambil", batches, pagination)
+}
+
+// GetByID retrieves an import batch with its staged rows
+// @Summary      Get achievement import batch
+// @Description  Get an import batch with every staged row, its student match, errors and warnings
+// @Tags         achievement-imports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Batch ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /achievements/imports/{id} [get]
+func (h *AchievementImportHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	batch, err := h.svc.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrImportBatchNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil data import prestasi")
+		return
+	}
+
+	response.Success(w, "Data import prestasi berhasil diambil", batch)
+}
+
+// UpdateRow corrects or confirms a staged row
+// @Summary      Update a staged import row
+// @Description  Pick the student, category or level, confirm warnings (fuzzy match, possible duplicate) or skip the row
+// @Tags         achievement-imports
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                   true  "Batch ID"
+// @Param        rowId    path      string                                   true  "Row ID"
+// @Param        request  body      model.UpdateAchievementImportRowRequest  true  "Row correction"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      409      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /achievements/imports/{id}/rows/{rowId} [put]
+func (h *AchievementImportHandler) UpdateRow(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateAchievementImportRowRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	row, err := h.svc.UpdateRow(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "rowId"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportBatchNotFound),
+			errors.Is(err, service.ErrImportRowNotFound),
+			errors.Is(err, service.ErrStudentNotFound):
+			response.NotFound(w, err.Error())
+		case errors.Is(err, service.ErrImportBatchNotPending),
+			errors.Is(err, service.ErrImportRowImported):
+			response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+		default:
+			response.BadRequest(w, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(w, "Baris import berhasil diupdate", row)
+}
+
+// Approve creates achievements from the ready rows of a batch
+// @Summary      Approve an achievement import
+// @Description  Create achievements for all ready rows in one transaction. Refused while any row still needs review or is invalid.
+// @Tags         achievement-imports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Batch ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /achievements/imports/{id}/approve [post]
+func (h *AchievementImportHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	reviewedBy := middleware.GetUserIDFromContext(r.Context())
+
+	batch, err := h.svc.Approve(r.Context(), chi.URLParam(r, "id"), reviewedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportBatchNotFound):
+			response.NotFound(w, err.Error())
+		case errors.Is(err, service.ErrImportBatchNotPending),
+			errors.Is(err, service.ErrImportBatchUnresolved),
+			errors.Is(err, service.ErrImportBatchNoReadyRow),
+			errors.Is(err, service.ErrImportBatchChanged):
+			response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+		default:
+			response.InternalError(w, "Gagal menyetujui import prestasi")
+		}
+		return
+	}
+
+	response.Success(w, "Import prestasi berhasil disetujui", batch)
+}
+
+// Discard cancels a pending import batch
+// @Summary      Discard an achievement import
+// @Description  Cancel a pending import batch without creating any achievement
+// @Tags         achievement-imports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Batch ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /achievements/imports/{id}/discard [post]
+func (h *AchievementImportHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	reviewedBy := middleware.GetUserIDFromContext(r.Context())
+
+	batch, err := h.svc.Discard(r.Context(), chi.URLParam(r, "id"), reviewedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportBatchNotFound):
+			response.NotFound(w, err.Error())
+		case errors.Is(err, service.ErrImportBatchNotPending):
+			response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+		default:
+			response.InternalError(w, "Gagal membatalkan import prestasi")
+		}
+		return
+	}
+
+	response.Success(w, "Import prestasi dibatalkan", batch)
+}
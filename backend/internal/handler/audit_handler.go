@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+)
+
+type AuditHandler struct {
+	svc service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// GetAll browses the audit ledger
+// @Summary      Get audit logs
+// @Description  Get a paginated list of audit ledger entries, newest first. Only admins can access this endpoint.
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Param        action       query    string  false  "Filter by action (e.g. student.update)"
+// @Param        entity_type  query    string  false  "Filter by entity type (student, achievement, certificate, user)"
+// @Param        entity_id    query    string  false  "Filter by entity ID"
+// @Param        actor_id     query    string  false  "Filter by actor user ID"
+// @Param        from         query    string  false  "From date (YYYY-MM-DD)"
+// @Param        to           query    string  false  "To date, inclusive (YYYY-MM-DD)"
+// @Param        page         query    int     false  "Page number"
+// @Param        per_page     query    int     false  "Items per page"
+// @Security     BearerAuth
+// @Success      200  {object}  response.PaginatedResponse
+// @Failure      400  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /admin/audit-logs [get]
+func (h *AuditHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := model.AuditLogFilter{
+		Action:     q.Get("action"),
+		EntityType: q.Get("entity_type"),
+		EntityID:   q.Get("entity_id"),
+		ActorID:    q.Get("actor_id"),
+		Page:       parseIntQuery(q.Get("page"), 1),
+		PerPage:    parseIntQuery(q.Get("per_page"), 10),
+	}
+
+	errs := utils.ValidationErrors{}
+	if v := q.Get("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			errs["from"] = "Format tanggal tidak valid, gunakan YYYY-MM-DD"
+		} else {
+			filter.From = &t
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			errs["to"] = "Format tanggal tidak valid, gunakan YYYY-MM-DD"
+		} else {
+			end := t.AddDate(0, 0, 1)
+			filter.To = &end
+		}
+	}
+	if errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	logs, pagination, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil data audit log")
+		return
+	}
+
+	response.Paginated(w, "Data audit log berhasil diambil", logs, pagination)
+}
+
+// Verify walks the audit hash chain
+// @Summary      Verify audit chain
+// @Description  Walk the whole audit ledger and report entries whose hash or previous-hash link is broken. Only admins can access this endpoint.
+// @Tags         audit
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /admin/audit-logs/verify [get]
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	result, err := h.svc.VerifyChain(r.Context())
+	if err != nil {
+		response.InternalError(w, "Gagal memverifikasi rantai audit")
+		return
+	}
+
+	if !result.Valid {
+		response.Success(w, "Rantai audit RUSAK, ada entri yang diubah atau dihapus", result)
+		return
+	}
+	response.Success(w, "Rantai audit utuh", result)
+}
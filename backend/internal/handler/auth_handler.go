@@ -3,12 +3,16 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 type AuthHandler struct {
@@ -21,7 +25,7 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 
 // Login allows a user to authenticate
 // @Summary      User Login
-// @Description  Authenticate user with email and password
+// @Description  Authenticate user with email and password. Admins, headmasters and users with 2FA enabled get an mfa challenge instead of tokens; continue with /auth/mfa/verify or, when mfa.enrollment_required is true, /auth/mfa/enroll.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -30,6 +34,7 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 // @Failure      400      {object}  response.Response
 // @Failure      401      {object}  response.Response
 // @Failure      403      {object}  response.Response
+// @Failure      429      {object}  response.Response
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req service.LoginRequest
@@ -58,6 +63,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Proses login
+	req.UserAgent = r.UserAgent()
 	result, err := h.authService.Login(r.Context(), req)
 	if err != nil {
 		switch {
@@ -65,12 +71,18 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			response.Unauthorized(w, err.Error())
 		case errors.Is(err, service.ErrAccountDisabled):
 			response.Forbidden(w, err.Error())
+		case errors.Is(err, service.ErrTooManyLoginAttempts):
+			writeLoginThrottled(w, err)
 		default:
 			response.InternalError(w, "Terjadi kesalahan server")
 		}
 		return
 	}
 
+	if result.MFA != nil {
+		response.Success(w, "Masukkan kode verifikasi dua langkah", result)
+		return
+	}
 	response.Success(w, "Login berhasil", result)
 }
 
@@ -109,8 +121,8 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 	if req.Password == "" {
 		errs["password"] = "Password wajib diisi"
-	} else if !utils.IsValidPassword(req.Password) {
-		errs["password"] = "Password minimal 8 karakter dan harus mengandung huruf dan angka"
+	} else if err := utils.CheckPassword(req.Password); err != nil {
+		errs["password"] = err.Error()
 	}
 
 	validRoles := map[string]bool{"operator": true, "admin": true, "headmaster": true}
@@ -138,7 +150,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 // RefreshToken generates a new access token
 // @Summary      Refresh Access Token
-// @Description  Get a new access token using a refresh token
+// @Description  Exchange a refresh token for a new token pair. Each refresh token can be used once; reusing an old one revokes the whole session.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -162,7 +174,14 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 	tokenPair, err := h.authService.RefreshToken(r.Context(), req.RefreshToken)
 	if err != nil {
-		response.Unauthorized(w, err.Error())
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken), errors.Is(err, service.ErrRefreshTokenReused):
+			response.Unauthorized(w, err.Error())
+		case errors.Is(err, service.ErrAccountDisabled):
+			response.Forbidden(w, err.Error())
+		default:
+			response.InternalError(w, "Terjadi kesalahan server")
+		}
 		return
 	}
 
@@ -195,3 +214,247 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 
 	response.Success(w, "Data user berhasil diambil", user)
 }
+
+// ChangePassword changes the password of the current user
+// @Summary      Change password
+// @Description  Change the password of the current user. Other sessions are revoked; the current one stays valid. While must_change_password is set, every other endpoint answers 403.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.ChangePasswordRequest  true  "Current and new password"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      401      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /auth/password [put]
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ChangePasswordRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	errs := utils.ValidationErrors{}
+	if req.CurrentPassword == "" {
+		errs["current_password"] = "Password saat ini wajib diisi"
+	}
+	if req.NewPassword == "" {
+		errs["new_password"] = "Password baru wajib diisi"
+	} else if err := utils.CheckPassword(req.NewPassword); err != nil {
+		errs["new_password"] = err.Error()
+	}
+	if errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	ctx := r.Context()
+	user, err := h.authService.ChangePassword(ctx, middleware.GetUserIDFromContext(ctx), middleware.GetSessionIDFromContext(ctx), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWrongPassword), errors.Is(err, service.ErrSamePassword):
+			response.BadRequest(w, err.Error(), nil)
+		case errors.Is(err, service.ErrUserNotFound):
+			response.NotFound(w, err.Error())
+		default:
+			response.InternalError(w, "Gagal mengganti password")
+		}
+		return
+	}
+
+	response.Success(w, "Password berhasil diganti", user)
+}
+
+// Logout ends the current session
+// @Summary      Logout
+// @Description  Revoke the session of the access token. Its refresh token can no longer be used.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.Logout(r.Context(), middleware.GetSessionIDFromContext(r.Context())); err != nil {
+		response.InternalError(w, "Gagal logout")
+		return
+	}
+
+	response.Success(w, "Logout berhasil", nil)
+}
+
+// LogoutAll ends every session of the current user
+// @Summary      Logout from all devices
+// @Description  Revoke every session of the current user, including the current one
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      401  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.LogoutAll(r.Context(), middleware.GetUserIDFromContext(r.Context())); err != nil {
+		response.InternalError(w, "Gagal logout dari semua perangkat")
+		return
+	}
+
+	response.Success(w, "Logout dari semua perangkat berhasil", nil)
+}
+
+// UserSessions lists the sessions of a user (admin only)
+// @Summary      List user sessions
+// @Description  Every session of a user, newest first, including revoked and expired ones
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/{id}/sessions [get]
+func (h *AuthHandler) UserSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.authService.GetSessions(r.Context(), chi.URLParam(r, "id"), middleware.GetSessionIDFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil sesi user")
+		return
+	}
+
+	response.Success(w, "Sesi user berhasil diambil", sessions)
+}
+
+// RevokeUserSession revokes one session of a user (admin only)
+// @Summary      Revoke user session
+// @Description  Revoke one session of a user; its access and refresh tokens stop working immediately
+// @Tags         users
+// @Produce      json
+// @Param        id         path      string  true  "User ID"
+// @Param        sessionId  path      string  true  "Session ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/{id}/sessions/{sessionId} [delete]
+func (h *AuthHandler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.RevokeSession(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "sessionId"))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrSessionNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mencabut sesi")
+		return
+	}
+
+	response.Success(w, "Sesi berhasil dicabut", nil)
+}
+
+// RevokeUserSessions revokes every session of a user (admin only)
+// @Summary      Revoke all user sessions
+// @Description  Revoke every active session of a user, e.g. when the account may be compromised
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/{id}/sessions [delete]
+func (h *AuthHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	count, err := h.authService.RevokeAllSessions(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mencabut sesi")
+		return
+	}
+
+	response.Success(w, "Semua sesi user berhasil dicabut", map[string]int64{"revoked_sessions": count})
+}
+
+// Lockouts lists locked accounts and IP addresses (admin only)
+// @Summary      List login lockouts
+// @Description  Accounts (by email) and IP addresses that are temporarily locked after too many failed logins
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/lockouts [get]
+func (h *AuthHandler) Lockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := h.authService.GetLockouts(r.Context())
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil data penguncian login")
+		return
+	}
+
+	response.Success(w, "Data penguncian login berhasil diambil", lockouts)
+}
+
+// UnlockUser clears the failed logins of a user (admin only)
+// @Summary      Unlock user login
+// @Description  Unlock a user locked out after too many failed logins and reset its failure count
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/{id}/unlock [post]
+func (h *AuthHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.UnlockUser(r.Context(), chi.URLParam(r, "id")); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal membuka kunci login")
+		return
+	}
+
+	response.Success(w, "Kunci login user berhasil dibuka", nil)
+}
+
+// UnlockIP clears the failed logins of an IP address (admin only)
+// @Summary      Unlock IP address
+// @Description  Unlock an IP address locked out after too many failed logins, e.g. the shared IP of the school network
+// @Tags         users
+// @Produce      json
+// @Param        ip   path      string  true  "IP address"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/lockouts/ip/{ip} [delete]
+func (h *AuthHandler) UnlockIP(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.UnlockIP(r.Context(), chi.URLParam(r, "ip")); err != nil {
+		if errors.Is(err, service.ErrLockoutNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal membuka kunci login")
+		return
+	}
+
+	response.Success(w, "Kunci login IP berhasil dibuka", nil)
+}
+
+// writeLoginThrottled 429 dengan header Retry-After
+func writeLoginThrottled(w http.ResponseWriter, err error) {
+	retryAfter := 1
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		retryAfter = max(int(throttled.RetryAfter.Round(time.Second).Seconds()), 1)
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	response.JSON(w, http.StatusTooManyRequests, false, err.Error(), map[string]int{"retry_after": retryAfter})
+}
@@ -3,7 +3,9 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
@@ -28,7 +30,7 @@ func NewCertificateHandler(svc service.CertificateService) *CertificateHandler {
 // @Accept       json
 // @Produce      json
 // @Param        student_id  query    string  false  "Filter by student ID"
-// @Param        status      query    string  false  "Filter by certificate status (active, revoked)"
+// @Param        status      query    string  false  "Filter by certificate status (active, revoked, superseded)"
 // @Param        page        query    int     false  "Page number"
 // @Param        per_page    query    int     false  "Items per page"
 // @Security     BearerAuth
@@ -127,13 +129,56 @@ func (h *CertificateHandler) Create(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, "Sertifikat berhasil diterbitkan", cert)
 }
 
+// Reissue issues a replacement certificate
+// @Summary      Reissue a certificate
+// @Description  Issue a new certificate with an updated achievement set and mark the old one as superseded
+// @Tags         certificates
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                           true  "Certificate ID to supersede"
+// @Param        request  body      model.ReissueCertificateRequest  true  "Reissue request"
+// @Security     BearerAuth
+// @Success      201      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Router       /certificates/{id}/reissue [post]
+func (h *CertificateHandler) Reissue(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req model.ReissueCertificateRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	req.Reason = utils.SanitizeString(req.Reason)
+	if len(req.AchievementIDs) == 0 {
+		response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"achievement_ids": "Minimal 1 prestasi harus dipilih"})
+		return
+	}
+
+	issuedBy := middleware.GetUserIDFromContext(r.Context())
+	cert, err := h.svc.Reissue(r.Context(), id, req, issuedBy)
+	if err != nil {
+		if errors.Is(err, service.ErrCertificateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	response.Created(w, "Sertifikat berhasil diterbitkan ulang", cert)
+}
+
 // Revoke invalidates a certificate
 // @Summary      Revoke a certificate
-// @Description  Revoke an issued certificate
+// @Description  Revoke an issued certificate. The reason is recorded in the status history and shown to verifiers.
 // @Tags         certificates
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "Certificate ID"
+// @Param        id       path      string                                true  "Certificate ID"
+// @Param        request  body      model.ChangeCertificateStatusRequest  true  "Revocation reason"
 // @Security     BearerAuth
 // @Success      200  {object}  response.Response
 // @Failure      400  {object}  response.Response
@@ -142,7 +187,13 @@ func (h *CertificateHandler) Create(w http.ResponseWriter, r *http.Request) {
 func (h *CertificateHandler) Revoke(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	if err := h.svc.Revoke(r.Context(), id); err != nil {
+	req, ok := decodeStatusChangeRequest(w, r, "Alasan pencabutan wajib diisi")
+	if !ok {
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.svc.Revoke(r.Context(), id, req, actorID); err != nil {
 		if errors.Is(err, service.ErrCertificateNotFound) {
 			response.NotFound(w, err.Error())
 			return
@@ -154,37 +205,220 @@ func (h *CertificateHandler) Revoke(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, "Sertifikat berhasil dicabut", nil)
 }
 
-// Download generates and returns the certificate PDF
+// Reinstate restores a revoked certificate
+// @Summary      Reinstate a certificate
+// @Description  Restore a revoked certificate to active. Only admins or headmasters can access this endpoint.
+// @Tags         certificates
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                true  "Certificate ID"
+// @Param        request  body      model.ChangeCertificateStatusRequest  true  "Reinstatement reason"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /certificates/{id}/reinstate [post]
+func (h *CertificateHandler) Reinstate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	req, ok := decodeStatusChangeRequest(w, r, "Alasan pemulihan wajib diisi")
+	if !ok {
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	if err := h.svc.Reinstate(r.Context(), id, req, actorID); err != nil {
+		if errors.Is(err, service.ErrCertificateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	response.Success(w, "Sertifikat berhasil dipulihkan", nil)
+}
+
+// History lists the status changes of a certificate
+// @Summary      Get certificate status history
+// @Description  List issuance, revocation and reinstatement events of a certificate
+// @Tags         certificates
+// @Produce      json
+// @Param        id   path      string  true  "Certificate ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /certificates/{id}/history [get]
+func (h *CertificateHandler) History(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	events, err := h.svc.GetStatusHistory(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCertificateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil riwayat status sertifikat")
+		return
+	}
+
+	response.Success(w, "Riwayat status sertifikat berhasil diambil", events)
+}
+
+// Drift compares the issued certificate with current data
+// @Summary      Get certificate drift
+// @Description  Compare the student and achievement data frozen at issuance with the current records, so staff can spot edits or deletions made after issuance
+// @Tags         certificates
+// @Produce      json
+// @Param        id   path      string  true  "Certificate ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /certificates/{id}/drift [get]
+func (h *CertificateHandler) Drift(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	drift, err := h.svc.GetDrift(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCertificateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	response.Success(w, "Perbandingan data sertifikat berhasil diambil", drift)
+}
+
+func decodeStatusChangeRequest(w http.ResponseWriter, r *http.Request, reasonRequired string) (model.ChangeCertificateStatusRequest, bool) {
+	var req model.ChangeCertificateStatusRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return req, false
+	}
+
+	req.Reason = utils.SanitizeString(req.Reason)
+	if req.Reason == "" {
+		response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"reason": reasonRequired})
+		return req, false
+	}
+	return req, true
+}
+
+// Download streams the stored certificate PDF
 // @Summary      Download certificate PDF
-// @Description  Generate and download the PDF for a specific certificate
+// @Description  Download the PDF frozen at issuance. The X-Content-SHA256 header carries the hash recorded for the file.
 // @Tags         certificates
 // @Produce      application/pdf
 // @Param        id   path      string  true  "Certificate ID"
 // @Security     BearerAuth
 // @Success      200  {file}    file    "Certificate PDF file"
 // @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
 // @Failure      500  {object}  response.Response
 // @Router       /certificates/{id}/download [get]
 func (h *CertificateHandler) Download(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	pdfBytes, certNumber, err := h.svc.DownloadPDF(r.Context(), id)
+	pdf, err := h.svc.DownloadPDF(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, service.ErrCertificateNotFound) {
+		switch {
+		case errors.Is(err, service.ErrCertificateNotFound):
 			response.NotFound(w, err.Error())
-			return
+		case errors.Is(err, service.ErrCertificatePDFPending), errors.Is(err, service.ErrCertificatePDFFailed):
+			response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+		default:
+			response.InternalError(w, "Gagal mengambil PDF sertifikat")
 		}
-		response.InternalError(w, "Gagal generate PDF")
 		return
 	}
+	defer pdf.Body.Close()
 
 	// Set header untuk download PDF
-	filename := fmt.Sprintf("SKP-%s.pdf", certNumber)
+	filename := fmt.Sprintf("SKP-%s.pdf", strings.ReplaceAll(pdf.CertificateNumber, "/", "-"))
 	w.Header().Set("Content-Type", "application/pdf")
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(pdfBytes)))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", pdf.Size))
+	if pdf.SHA256 != "" {
+		w.Header().Set("X-Content-SHA256", pdf.SHA256)
+		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, pdf.SHA256))
+	}
 	w.WriteHeader(http.StatusOK)
-	w.Write(pdfBytes)
+	io.Copy(w, pdf.Body)
+}
+
+// RegeneratePDF schedules a new PDF for a certificate
+// @Summary      Regenerate certificate PDF
+// @Description  Rebuild and replace the stored PDF of a certificate. The previous file is kept and the request is recorded with the admin and reason. Only admins can access this endpoint.
+// @Tags         certificates
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                 true  "Certificate ID"
+// @Param        request  body      model.RegenerateCertificatePDFRequest  true  "Regeneration reason"
+// @Security     BearerAuth
+// @Success      202  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /certificates/{id}/pdf/regenerate [post]
+func (h *CertificateHandler) RegeneratePDF(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req model.RegenerateCertificatePDFRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	req.Reason = utils.SanitizeString(req.Reason)
+	if req.Reason == "" {
+		response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"reason": "Alasan regenerasi wajib diisi"})
+		return
+	}
+
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	regen, err := h.svc.RegeneratePDF(r.Context(), id, req, actorID)
+	if err != nil {
+		if errors.Is(err, service.ErrCertificateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, true, "Regenerasi PDF sertifikat dijadwalkan", regen)
+}
+
+// PDFRegenerations lists PDF regenerations of a certificate
+// @Summary      Get certificate PDF regenerations
+// @Description  List who regenerated the certificate PDF, when, and the previous/new file hashes
+// @Tags         certificates
+// @Produce      json
+// @Param        id   path      string  true  "Certificate ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /certificates/{id}/pdf/regenerations [get]
+func (h *CertificateHandler) PDFRegenerations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	regens, err := h.svc.GetPDFRegenerations(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrCertificateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil riwayat regenerasi PDF")
+		return
+	}
+
+	response.Success(w, "Riwayat regenerasi PDF berhasil diambil", regens)
 }
 
 // Verify checks the validity of a certificate via its public token
@@ -214,3 +448,21 @@ func (h *CertificateHandler) Verify(w http.ResponseWriter, r *http.Request) {
 
 	response.Success(w, result.Message, result)
 }
+
+// SigningKey returns the school's public key for offline verification
+// @Summary      Get certificate signing public key
+// @Description  Public Ed25519 key used to verify the signature embedded in certificate QR codes offline
+// @Tags         public
+// @Produce      json
+// @Success      200  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /verify/public-key [get]
+func (h *CertificateHandler) SigningKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.svc.SigningKey(r.Context())
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil public key")
+		return
+	}
+
+	response.Success(w, "Public key berhasil diambil", key)
+}
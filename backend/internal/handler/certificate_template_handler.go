@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type CertificateTemplateHandler struct {
+	svc service.CertificateTemplateService
+}
+
+func NewCertificateTemplateHandler(svc service.CertificateTemplateService) *CertificateTemplateHandler {
+	return &CertificateTemplateHandler{svc: svc}
+}
+
+// GetAll lists certificate templates
+// @Summary      List certificate templates
+// @Description  All certificate templates with the definition of their latest version. The default template comes first.
+// @Tags         certificate-templates
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /certificate-templates [get]
+func (h *CertificateTemplateHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil template surat")
+		return
+	}
+
+	response.Success(w, "Template surat berhasil diambil", templates)
+}
+
+// GetByID returns a certificate template with its version history
+// @Summary      Get certificate template
+// @Description  Template with its latest definition and every saved version
+// @Tags         certificate-templates
+// @Produce      json
+// @Param        id   path      string  true  "Template ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /certificate-templates/{id} [get]
+func (h *CertificateTemplateHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := h.svc.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrTemplateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil template surat")
+		return
+	}
+
+	response.Success(w, "Template surat berhasil diambil", tmpl)
+}
+
+// GetVersion returns one version of a certificate template
+// @Summary      Get certificate template version
+// @Description  The frozen definition of a template version, as used by certificates issued with it
+// @Tags         certificate-templates
+// @Produce      json
+// @Param        id       path      string  true  "Template ID"
+// @Param        version  path      int     true  "Version number"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /certificate-templates/{id}/versions/{version} [get]
+func (h *CertificateTemplateHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		response.NotFound(w, service.ErrTemplateVersionNotFound.Error())
+		return
+	}
+
+	v, err := h.svc.GetVersion(r.Context(), chi.URLParam(r, "id"), version)
+	if err != nil {
+		if errors.Is(err, service.ErrTemplateNotFound) || errors.Is(err, service.ErrTemplateVersionNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil versi template")
+		return
+	}
+
+	response.Success(w, "Versi template berhasil diambil", v)
+}
+
+// Create adds a certificate template
+// @Summary      Create certificate template
+// @Description  Create a template (admin only). Texts and signature fields may contain placeholders such as {{.Student.FullName}}, {{.Certificate.Number}} or {{.School.HeadmasterName}}.
+// @Tags         certificate-templates
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.SaveCertificateTemplateRequest  true  "Template"
+// @Security     BearerAuth
+// @Success      201      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      409      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /certificate-templates [post]
+func (h *CertificateTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.SaveCertificateTemplateRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	if errs := service.ValidateCertificateTemplate(&req); errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	createdBy := middleware.GetUserIDFromContext(r.Context())
+	tmpl, err := h.svc.Create(r.Context(), req, createdBy)
+	if err != nil {
+		if errors.Is(err, service.ErrTemplateNameExists) {
+			response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+			return
+		}
+		response.InternalError(w, "Gagal menyimpan template surat")
+		return
+	}
+
+	response.Created(w, "Template surat berhasil dibuat", tmpl)
+}
+
+// Update saves a new version of a certificate template
+// @Summary      Update certificate template
+// @Description  Save the changes as a new version (admin only). Certificates issued earlier keep the version they were issued with.
+// @Tags         certificate-templates
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                                true  "Template ID"
+// @Param        request  body      model.SaveCertificateTemplateRequest  true  "Template"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      409      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /certificate-templates/{id} [put]
+func (h *CertificateTemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req model.SaveCertificateTemplateRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	if errs := service.ValidateCertificateTemplate(&req); errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	updatedBy := middleware.GetUserIDFromContext(r.Context())
+	tmpl, err := h.svc.Update(r.Context(), chi.URLParam(r, "id"), req, updatedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTemplateNotFound):
+			response.NotFound(w, err.Error())
+		case errors.Is(err, service.ErrTemplateNameExists):
+			response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+		default:
+			response.InternalError(w, "Gagal menyimpan template surat")
+		}
+		return
+	}
+
+	response.Success(w, "Template surat berhasil disimpan sebagai versi baru", tmpl)
+}
+
+// SetDefault makes a template the default for new certificates
+// @Summary      Set default certificate template
+// @Description  New certificates without a template_id are issued with the latest version of this template (admin only)
+// @Tags         certificate-templates
+// @Produce      json
+// @Param        id   path      string  true  "Template ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /certificate-templates/{id}/default [post]
+func (h *CertificateTemplateHandler) SetDefault(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := h.svc.SetDefault(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrTemplateNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengubah template default")
+		return
+	}
+
+	response.Success(w, "Template default berhasil diubah", tmpl)
+}
+
+// UploadHeaderImage uploads a letterhead image for templates
+// @Summary      Upload template letterhead
+// @Description  Upload a JPEG or PNG letterhead (admin only, max 5MB). Put the returned URL in header_image_url of a template definition.
+// @Tags         certificate-templates
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        image  formData  file  true  "Letterhead image"
+// @Security     BearerAuth
+// @Success      201    {object}  response.Response
+// @Failure      400    {object}  response.Response
+// @Failure      500    {object}  response.Response
+// @Router       /certificate-templates/header-image [post]
+func (h *CertificateTemplateHandler) UploadHeaderImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 5*1024*1024) // 5MB max
+	if err := r.ParseMultipartForm(5 * 1024 * 1024); err != nil {
+		response.BadRequest(w, "File terlalu besar atau format tidak valid", nil)
+		return
+	}
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		response.BadRequest(w, "File kop surat tidak ditemukan dalam request", nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.InternalError(w, "Gagal membaca file")
+		return
+	}
+
+	image, err := h.svc.UploadHeaderImage(r.Context(), data, header.Header.Get("Content-Type"))
+	if err != nil {
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	response.Created(w, "Kop surat berhasil diupload", image)
+}
+
+// Preview renders an unsaved template with sample data
+// @Summary      Preview certificate template
+// @Description  Render a template definition with sample student data without saving it (admin only)
+// @Tags         certificate-templates
+// @Accept       json
+// @Produce      application/pdf
+// @Param        request  body      model.PreviewCertificateTemplateRequest  true  "Template definition"
+// @Security     BearerAuth
+// @Success      200      {file}    file
+// @Failure      400      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /certificate-templates/preview [post]
+func (h *CertificateTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req model.PreviewCertificateTemplateRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	if errs := service.ValidateCertificateTemplateDefinition(&req.Definition); errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	file, err := h.svc.Preview(r.Context(), req.Definition)
+	if err != nil {
+		response.InternalError(w, "Gagal membuat pratinjau template")
+		return
+	}
+
+	writePreview(w, file)
+}
+
+// PreviewVersion renders a saved template with sample data
+// @Summary      Preview saved certificate template
+// @Description  Render a saved template version (default latest) with sample student data
+// @Tags         certificate-templates
+// @Produce      application/pdf
+// @Param        id       path      string  true   "Template ID"
+// @Param        version  query     int     false  "Version number (default latest)"
+// @Security     BearerAuth
+// @Success      200      {file}    file
+// @Failure      404      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /certificate-templates/{id}/preview [get]
+func (h *CertificateTemplateHandler) PreviewVersion(w http.ResponseWriter, r *http.Request) {
+	version := parseOptionalIntQuery(r.URL.Query().Get("version"))
+	file, err := h.svc.PreviewVersion(r.Context(), chi.URLParam(r, "id"), version)
+	if err != nil {
+		if errors.Is(err, service.ErrTemplateNotFound) || errors.Is(err, service.ErrTemplateVersionNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal membuat pratinjau template")
+		return
+	}
+
+	writePreview(w, file)
+}
+
+// writePreview menampilkan PDF pratinjau langsung di browser
+func writePreview(w http.ResponseWriter, file *model.ReportFile) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, file.FileName))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(file.Data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Data)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type JobHandler struct {
+	svc service.JobService
+}
+
+func NewJobHandler(svc service.JobService) *JobHandler {
+	return &JobHandler{svc: svc}
+}
+
+// GetAll lists background jobs
+// @Summary      Get background jobs
+// @Description  Get a paginated list of background jobs (e.g. certificate PDF generation)
+// @Tags         jobs
+// @Accept       json
+// @Produce      json
+// @Param        type      query    string  false  "Filter by job type"
+// @Param        status    query    string  false  "Filter by job status (pending, running, done, dead)"
+// @Param        page      query    int     false  "Page number"
+// @Param        per_page  query    int     false  "Items per page"
+// @Security     BearerAuth
+// @Success      200  {object}  response.PaginatedResponse
+// @Failure      403  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /admin/jobs [get]
+func (h *JobHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := model.JobFilter{
+		Type:    q.Get("type"),
+		Status:  q.Get("status"),
+		Page:    parseIntQuery(q.Get("page"), 1),
+		PerPage: parseIntQuery(q.Get("per_page"), 10),
+	}
+
+	jobs, pagination, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil data job")
+		return
+	}
+
+	response.Paginated(w, "Data job berhasil diambil", jobs, pagination)
+}
+
+// Retry reschedules a dead job
+// @Summary      Retry a failed job
+// @Description  Reschedule a job that exhausted its attempts (dead-letter) with a fresh attempt budget
+// @Tags         jobs
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Job ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      403  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /admin/jobs/{id}/retry [post]
+func (h *JobHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	job, err := h.svc.Retry(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	response.Success(w, "Job dijadwalkan ulang", job)
+}
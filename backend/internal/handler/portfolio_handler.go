@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type PortfolioHandler struct {
+	svc service.PortfolioService
+}
+
+func NewPortfolioHandler(svc service.PortfolioService) *PortfolioHandler {
+	return &PortfolioHandler{svc: svc}
+}
+
+// Download generates the achievement portfolio of a student
+// @Summary      Download student portfolio
+// @Description  Multi-page transcript-style PDF with the student photo, every verified achievement across years (including team achievements), descriptions and thumbnails of image attachments
+// @Tags         students
+// @Produce      application/pdf
+// @Param        id   path      string  true  "Student ID"
+// @Security     BearerAuth
+// @Success      200  {file}    file
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /students/{id}/portfolio [get]
+func (h *PortfolioHandler) Download(w http.ResponseWriter, r *http.Request) {
+	file, err := h.svc.Generate(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrStudentNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal membuat portofolio prestasi")
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.FileName))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(file.Data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Data)
+}
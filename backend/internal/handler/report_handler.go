@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+)
+
+type ReportHandler struct {
+	svc service.ReportService
+}
+
+func NewReportHandler(svc service.ReportService) *ReportHandler {
+	return &ReportHandler{svc: svc}
+}
+
+// Summary returns the headline numbers for the dashboard
+// @Summary      Get report summary
+// @Description  Total achievements, students with achievements, achievements per status and certificates per status
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        from_year    query     int     false  "First competition year"
+// @Param        to_year      query     int     false  "Last competition year"
+// @Param        status       query     string  false  "Achievement status (default verified, 'all' for every status)"
+// @Param        category_id  query     int     false  "Filter by category"
+// @Param        level_id     query     int     false  "Filter by level"
+// @Param        class        query     string  false  "Filter by student class"
+// @Security     BearerAuth
+// @Success      200          {object}  response.Response
+// @Failure      400          {object}  response.Response
+// @Failure      500          {object}  response.Response
+// @Router       /reports/summary [get]
+func (h *ReportHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.svc.GetSummary(r.Context(), parseReportFilter(r.URL.Query()))
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	response.Success(w, "Ringkasan laporan berhasil diambil", summary)
+}
+
+// AchievementsByYear returns the number of achievements per competition year
+// @Summary      Get achievements per year
+// @Description  Time series of achievements and distinct students per competition year. Years without achievements are returned with zero.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        from_year    query     int     false  "First competition year"
+// @Param        to_year      query     int     false  "Last competition year"
+// @Param        status       query     string  false  "Achievement status (default verified, 'all' for every status)"
+// @Param        category_id  query     int     false  "Filter by category"
+// @Param        level_id     query     int     false  "Filter by level"
+// @Param        class        query     string  false  "Filter by student class"
+// @Security     BearerAuth
+// @Success      200          {object}  response.Response
+// @Failure      400          {object}  response.Response
+// @Failure      500          {object}  response.Response
+// @Router       /reports/achievements/yearly [get]
+func (h *ReportHandler) AchievementsByYear(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.svc.GetAchievementsByYear(r.Context(), parseReportFilter(r.URL.Query()))
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	response.Success(w, "Laporan prestasi per tahun berhasil diambil", counts)
+}
+
+// AchievementBreakdown returns the number of achievements grouped by one dimension
+// @Summary      Get achievement breakdown
+// @Description  Achievements and distinct students grouped by level, category, rank, class or gender. Team achievements count towards the class and gender of every captain and member.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        by           query     string  true   "Dimension: level, category, rank, class, gender"
+// @Param        from_year    query     int     false  "First competition year"
+// @Param        to_year      query     int     false  "Last competition year"
+// @Param        status       query     string  false  "Achievement status (default verified, 'all' for every status)"
+// @Param        category_id  query     int     false  "Filter by category"
+// @Param        level_id     query     int     false  "Filter by level"
+// @Param        class        query     string  false  "Filter by student class"
+// @Security     BearerAuth
+// @Success      200          {object}  response.Response
+// @Failure      400          {object}  response.Response
+// @Failure      500          {object}  response.Response
+// @Router       /reports/achievements/breakdown [get]
+func (h *ReportHandler) AchievementBreakdown(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	items, err := h.svc.GetAchievementBreakdown(r.Context(), q.Get("by"), parseReportFilter(q))
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	response.Success(w, "Rincian prestasi berhasil diambil", items)
+}
+
+// CertificatesByMonth returns certificates issued and revoked per month
+// @Summary      Get certificates per month
+// @Description  Certificates issued and revoked in each month of a year, taken from the certificate status history
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        year  query     int  false  "Year (default current year)"
+// @Security     BearerAuth
+// @Success      200   {object}  response.Response
+// @Failure      400   {object}  response.Response
+// @Failure      500   {object}  response.Response
+// @Router       /reports/certificates/monthly [get]
+func (h *ReportHandler) CertificatesByMonth(w http.ResponseWriter, r *http.Request) {
+	year := parseIntQuery(r.URL.Query().Get("year"), time.Now().Year())
+
+	months, err := h.svc.GetCertificatesByMonth(r.Context(), year)
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	response.Success(w, "Laporan sertifikat per bulan berhasil diambil", months)
+}
+
+// Annual downloads the yearly achievement recap for the education office
+// @Summary      Export annual achievement report
+// @Description  Verified achievements of a year range as a multi-page PDF (letterhead, summary tables, listing per level) or an XLSX workbook with one sheet per category. Defaults to the current year.
+// @Tags         reports
+// @Produce      application/pdf
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        format       query     string  false  "pdf (default) or xlsx"
+// @Param        from_year    query     int     false  "First competition year"
+// @Param        to_year      query     int     false  "Last competition year"
+// @Param        level_id     query     int     false  "Filter by level"
+// @Param        category_id  query     int     false  "Filter by category"
+// @Security     BearerAuth
+// @Success      200          {file}    file
+// @Failure      400          {object}  response.Response
+// @Failure      500          {object}  response.Response
+// @Router       /reports/annual [get]
+func (h *ReportHandler) Annual(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := model.ReportFilter{
+		FromYear:   parseOptionalIntQuery(q.Get("from_year")),
+		ToYear:     parseOptionalIntQuery(q.Get("to_year")),
+		CategoryID: parseOptionalIntQuery(q.Get("category_id")),
+		LevelID:    parseOptionalIntQuery(q.Get("level_id")),
+	}
+
+	file, err := h.svc.ExportAnnual(r.Context(), filter, q.Get("format"))
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, file.FileName))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(file.Data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Data)
+}
+
+func parseReportFilter(q url.Values) model.ReportFilter {
+	return model.ReportFilter{
+		FromYear:   parseOptionalIntQuery(q.Get("from_year")),
+		ToYear:     parseOptionalIntQuery(q.Get("to_year")),
+		Status:     q.Get("status"),
+		CategoryID: parseOptionalIntQuery(q.Get("category_id")),
+		LevelID:    parseOptionalIntQuery(q.Get("level_id")),
+		Class:      q.Get("class"),
+	}
+}
+
+func writeReportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrReportDimension),
+		errors.Is(err, service.ErrReportStatus),
+		errors.Is(err, service.ErrReportYearRange),
+		errors.Is(err, service.ErrReportFormat):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		response.InternalError(w, "Gagal mengambil data laporan")
+	}
+}
@@ -15,25 +15,55 @@ import (
 
 type Router struct {
 	authHandler        *AuthHandler
+	twoFactorHandler   *TwoFactorHandler
+	userHandler        *UserHandler
 	studentHandler     *StudentHandler
 	achievementHandler *AchievementHandler
+	importHandler      *AchievementImportHandler
+	scoringHandler     *ScoringHandler
+	reportHandler      *ReportHandler
+	portfolioHandler   *PortfolioHandler
 	certificateHandler *CertificateHandler
+	templateHandler    *CertificateTemplateHandler
+	jobHandler         *JobHandler
+	auditHandler       *AuditHandler
 	jwtSecret          string
+	sessions           appMiddleware.SessionChecker
 }
 
 func NewRouter(
 	authHandler *AuthHandler,
+	twoFactorHandler *TwoFactorHandler,
+	userHandler *UserHandler,
 	studentHandler *StudentHandler,
 	achievementHandler *AchievementHandler,
+	importHandler *AchievementImportHandler,
+	scoringHandler *ScoringHandler,
+	reportHandler *ReportHandler,
+	portfolioHandler *PortfolioHandler,
 	certificateHandler *CertificateHandler,
+	templateHandler *CertificateTemplateHandler,
+	jobHandler *JobHandler,
+	auditHandler *AuditHandler,
 	jwtSecret string,
+	sessions appMiddleware.SessionChecker,
 ) *Router {
 	return &Router{
 		authHandler:        authHandler,
+		twoFactorHandler:   twoFactorHandler,
+		userHandler:        userHandler,
 		studentHandler:     studentHandler,
 		achievementHandler: achievementHandler,
+		importHandler:      importHandler,
+		scoringHandler:     scoringHandler,
+		reportHandler:      reportHandler,
+		portfolioHandler:   portfolioHandler,
 		certificateHandler: certificateHandler,
+		templateHandler:    templateHandler,
+		jobHandler:         jobHandler,
+		auditHandler:       auditHandler,
 		jwtSecret:          jwtSecret,
+		sessions:           sessions,
 	}
 }
 
@@ -44,6 +74,7 @@ func (ro *Router) Setup() http.Handler {
 	r.Use(chiMiddleware.Recoverer)
 	r.Use(chiMiddleware.RequestID)
 	r.Use(chiMiddleware.RealIP)
+	r.Use(appMiddleware.ClientIP)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "https://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
@@ -66,44 +97,116 @@ func (ro *Router) Setup() http.Handler {
 			r.Post("/login", ro.authHandler.Login)
 			r.Post("/refresh", ro.authHandler.RefreshToken)
 
+			// Langkah kedua login (pakai mfa_token, belum ada access token)
+			r.Post("/mfa/verify", ro.twoFactorHandler.Verify)
+			r.Post("/mfa/enroll", ro.twoFactorHandler.Enroll)
+			r.Post("/mfa/enroll/confirm", ro.twoFactorHandler.ConfirmEnroll)
+
+			// Satu-satunya route yang tetap terbuka bagi user yang wajib mengganti password
+			r.With(appMiddleware.AuthenticatePasswordChange(ro.jwtSecret, ro.sessions)).
+				Put("/password", ro.authHandler.ChangePassword)
+
 			r.Group(func(r chi.Router) {
-				r.Use(appMiddleware.Authenticate(ro.jwtSecret))
+				r.Use(appMiddleware.Authenticate(ro.jwtSecret, ro.sessions))
 				r.Get("/me", ro.authHandler.Me)
+				r.Post("/logout", ro.authHandler.Logout)
+				r.Post("/logout-all", ro.authHandler.LogoutAll)
+				r.Get("/mfa", ro.twoFactorHandler.Status)
+				r.Post("/mfa/setup", ro.twoFactorHandler.Setup)
+				r.Post("/mfa/enable", ro.twoFactorHandler.Enable)
+				r.Post("/mfa/disable", ro.twoFactorHandler.Disable)
+				r.Post("/mfa/recovery-codes", ro.twoFactorHandler.RegenerateRecoveryCodes)
 			})
 		})
 
 		// ── Public: verifikasi QR ─────────────────────────
+		r.Get("/verify/public-key", ro.certificateHandler.SigningKey)
 		r.Get("/verify/{token}", ro.certificateHandler.Verify)
 
 		// ── Protected routes ──────────────────────────────
 		r.Group(func(r chi.Router) {
-			r.Use(appMiddleware.Authenticate(ro.jwtSecret))
+			r.Use(appMiddleware.Authenticate(ro.jwtSecret, ro.sessions))
 
 			// User management (admin only)
 			r.Route("/users", func(r chi.Router) {
 				r.Use(appMiddleware.RequireRole("admin", "headmaster"))
+				r.Get("/", ro.userHandler.GetAll)
 				r.Post("/", ro.authHandler.Register)
+				r.Get("/{id}", ro.userHandler.GetByID)
+				r.Group(func(r chi.Router) {
+					r.Use(appMiddleware.RequireRole("admin"))
+					r.Put("/{id}", ro.userHandler.Update)
+					r.Post("/{id}/activate", ro.userHandler.Activate)
+					r.Post("/{id}/deactivate", ro.userHandler.Deactivate)
+					r.Post("/{id}/reset-password", ro.userHandler.ResetPassword)
+					r.Get("/{id}/sessions", ro.authHandler.UserSessions)
+					r.Delete("/{id}/sessions", ro.authHandler.RevokeUserSessions)
+					r.Delete("/{id}/sessions/{sessionId}", ro.authHandler.RevokeUserSession)
+					r.Delete("/{id}/mfa", ro.twoFactorHandler.ResetUser)
+					r.Post("/{id}/unlock", ro.authHandler.UnlockUser)
+					r.Get("/lockouts", ro.authHandler.Lockouts)
+					r.Delete("/lockouts/ip/{ip}", ro.authHandler.UnlockIP)
+				})
 			})
 
 			// Students
 			r.Route("/students", func(r chi.Router) {
 				r.Get("/", ro.studentHandler.GetAll)
 				r.Post("/", ro.studentHandler.Create)
+				r.Post("/import", ro.studentHandler.Import)
+				r.Get("/leaderboard", ro.scoringHandler.Leaderboard)
 				r.Get("/{id}", ro.studentHandler.GetByID)
 				r.Put("/{id}", ro.studentHandler.Update)
 				r.Delete("/{id}", ro.studentHandler.Delete)
 				r.Post("/{id}/photo", ro.studentHandler.UploadPhoto)
+				r.Get("/{id}/score", ro.scoringHandler.StudentScore)
+				r.Get("/{id}/portfolio", ro.portfolioHandler.Download)
+			})
+
+			// Aturan poin prestasi
+			r.Route("/scoring-rules", func(r chi.Router) {
+				r.Get("/", ro.scoringHandler.GetRules)
+				r.With(appMiddleware.RequireRole("admin")).
+					Put("/", ro.scoringHandler.UpdateRules)
+			})
+
+			// Laporan statistik (admin & kepala sekolah)
+			r.Route("/reports", func(r chi.Router) {
+				r.Use(appMiddleware.RequireRole("admin", "headmaster"))
+				r.Get("/summary", ro.reportHandler.Summary)
+				r.Get("/achievements/yearly", ro.reportHandler.AchievementsByYear)
+				r.Get("/achievements/breakdown", ro.reportHandler.AchievementBreakdown)
+				r.Get("/certificates/monthly", ro.reportHandler.CertificatesByMonth)
+				r.Get("/annual", ro.reportHandler.Annual)
 			})
 
 			// Achievements
 			r.Route("/achievements", func(r chi.Router) {
 				r.Get("/categories", ro.achievementHandler.GetCategories)
 				r.Get("/levels", ro.achievementHandler.GetLevels)
+				r.Get("/ranks", ro.achievementHandler.GetRanks)
+				r.With(appMiddleware.RequireRole("admin", "headmaster")).
+					Get("/ranks/unmatched", ro.achievementHandler.UnmatchedRanks)
+				r.Route("/imports", func(r chi.Router) {
+					r.Get("/", ro.importHandler.GetAll)
+					r.Post("/", ro.importHandler.Stage)
+					r.Get("/{id}", ro.importHandler.GetByID)
+					r.Put("/{id}/rows/{rowId}", ro.importHandler.UpdateRow)
+					r.Post("/{id}/approve", ro.importHandler.Approve)
+					r.Post("/{id}/discard", ro.importHandler.Discard)
+				})
 				r.Get("/", ro.achievementHandler.GetAll)
 				r.Post("/", ro.achievementHandler.Create)
 				r.Get("/{id}", ro.achievementHandler.GetByID)
 				r.Put("/{id}", ro.achievementHandler.Update)
+				r.Put("/{id}/participants", ro.achievementHandler.UpdateParticipants)
 				r.Delete("/{id}", ro.achievementHandler.Delete)
+				r.Get("/{id}/history", ro.achievementHandler.History)
+				r.Post("/{id}/submit", ro.achievementHandler.Submit)
+				r.With(appMiddleware.RequireRole("admin", "headmaster")).
+					Post("/{id}/verify", ro.achievementHandler.Verify)
+				r.With(appMiddleware.RequireRole("admin", "headmaster")).
+					Post("/{id}/reject", ro.achievementHandler.Reject)
 				r.Post("/{id}/attachments", ro.achievementHandler.UploadAttachment)
 				r.Delete("/attachments/{attachmentId}", ro.achievementHandler.DeleteAttachment)
 			})
@@ -114,7 +217,40 @@ func (ro *Router) Setup() http.Handler {
 				r.Post("/", ro.certificateHandler.Create)
 				r.Get("/{id}", ro.certificateHandler.GetByID)
 				r.Get("/{id}/download", ro.certificateHandler.Download)
+				r.Get("/{id}/pdf/regenerations", ro.certificateHandler.PDFRegenerations)
+				r.With(appMiddleware.RequireRole("admin")).
+					Post("/{id}/pdf/regenerate", ro.certificateHandler.RegeneratePDF)
+				r.Get("/{id}/history", ro.certificateHandler.History)
+				r.Get("/{id}/drift", ro.certificateHandler.Drift)
+				r.Post("/{id}/reissue", ro.certificateHandler.Reissue)
 				r.Post("/{id}/revoke", ro.certificateHandler.Revoke)
+				r.With(appMiddleware.RequireRole("admin", "headmaster")).
+					Post("/{id}/reinstate", ro.certificateHandler.Reinstate)
+			})
+
+			// Template surat keterangan (perubahan hanya oleh admin)
+			r.Route("/certificate-templates", func(r chi.Router) {
+				r.Get("/", ro.templateHandler.GetAll)
+				r.Get("/{id}", ro.templateHandler.GetByID)
+				r.Get("/{id}/versions/{version}", ro.templateHandler.GetVersion)
+				r.Get("/{id}/preview", ro.templateHandler.PreviewVersion)
+				r.Group(func(r chi.Router) {
+					r.Use(appMiddleware.RequireRole("admin"))
+					r.Post("/", ro.templateHandler.Create)
+					r.Post("/header-image", ro.templateHandler.UploadHeaderImage)
+					r.Post("/preview", ro.templateHandler.Preview)
+					r.Put("/{id}", ro.templateHandler.Update)
+					r.Post("/{id}/default", ro.templateHandler.SetDefault)
+				})
+			})
+
+			// Admin: antrean job background & ledger audit
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(appMiddleware.RequireRole("admin"))
+				r.Get("/jobs", ro.jobHandler.GetAll)
+				r.Post("/jobs/{id}/retry", ro.jobHandler.Retry)
+				r.Get("/audit-logs", ro.auditHandler.GetAll)
+				r.Get("/audit-logs/verify", ro.auditHandler.Verify)
 			})
 		})
 	})
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type ScoringHandler struct {
+	svc service.ScoringService
+}
+
+func NewScoringHandler(svc service.ScoringService) *ScoringHandler {
+	return &ScoringHandler{svc: svc}
+}
+
+// StudentScore returns the achievement points of a student
+// @Summary      Get student score
+// @Description  Total points of a student's verified achievements with a per-achievement breakdown (level points x rank multiplier x category weight)
+// @Tags         scoring
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true   "Student ID"
+// @Param        year  query     int     false  "Only count achievements of this year"
+// @Security     BearerAuth
+// @Success      200   {object}  response.Response
+// @Failure      404   {object}  response.Response
+// @Failure      500   {object}  response.Response
+// @Router       /students/{id}/score [get]
+func (h *ScoringHandler) StudentScore(w http.ResponseWriter, r *http.Request) {
+	score, err := h.svc.GetStudentScore(r.Context(), chi.URLParam(r, "id"), parseOptionalIntQuery(r.URL.Query().Get("year")))
+	if err != nil {
+		if errors.Is(err, service.ErrStudentNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal menghitung poin prestasi siswa")
+		return
+	}
+
+	response.Success(w, "Poin prestasi siswa berhasil dihitung", score)
+}
+
+// Leaderboard ranks students by achievement points
+// @Summary      Get student leaderboard
+// @Description  Students ordered by total points of their verified achievements. Students with equal points share a position.
+// @Tags         scoring
+// @Accept       json
+// @Produce      json
+// @Param        class          query    string  false  "Filter by class"
+// @Param        year_graduate  query    int     false  "Filter by cohort (graduation year)"
+// @Param        year           query    int     false  "Only count achievements of this year"
+// @Param        page           query    int     false  "Page number"
+// @Param        per_page       query    int     false  "Items per page"
+// @Security     BearerAuth
+// @Success      200  {object}  response.PaginatedResponse
+// @Failure      500  {object}  response.Response
+// @Router       /students/leaderboard [get]
+func (h *ScoringHandler) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := model.LeaderboardFilter{
+		Class:        q.Get("class"),
+		YearGraduate: parseOptionalIntQuery(q.Get("year_graduate")),
+		Year:         parseOptionalIntQuery(q.Get("year")),
+		Page:         parseIntQuery(q.Get("page"), 1),
+		PerPage:      parseIntQuery(q.Get("per_page"), 10),
+	}
+
+	entries, pagination, err := h.svc.GetLeaderboard(r.Context(), filter)
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil peringkat siswa")
+		return
+	}
+
+	response.Paginated(w, "Peringkat siswa berhasil diambil", entries, pagination)
+}
+
+// GetRules lists the scoring rules
+// @Summary      Get scoring rules
+// @Description  Level points (key: level order), rank multipliers (key: rank code) and category weights (key: category type)
+// @Tags         scoring
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /scoring-rules [get]
+func (h *ScoringHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.svc.GetRules(r.Context())
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil aturan poin")
+		return
+	}
+
+	response.Success(w, "Aturan poin berhasil diambil", rules)
+}
+
+// UpdateRules changes scoring rules
+// @Summary      Update scoring rules
+// @Description  Set the value of one or more scoring rules (admin only). Unknown level or category keys are added.
+// @Tags         scoring
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.UpdateScoringRulesRequest  true  "Scoring rules"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /scoring-rules [put]
+func (h *ScoringHandler) UpdateRules(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateScoringRulesRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	if errs := service.ValidateScoringRules(&req); errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	updatedBy := middleware.GetUserIDFromContext(r.Context())
+	rules, err := h.svc.UpdateRules(r.Context(), req, updatedBy)
+	if err != nil {
+		response.InternalError(w, "Gagal menyimpan aturan poin")
+		return
+	}
+
+	response.Success(w, "Aturan poin berhasil disimpan", rules)
+}
+
+// parseOptionalIntQuery mengembalikan nil jika parameter kosong atau bukan angka
+func parseOptionalIntQuery(s string) *int {
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
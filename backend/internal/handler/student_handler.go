@@ -110,23 +110,7 @@ func (h *StudentHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errs := utils.ValidationErrors{}
-	req.NISN = utils.SanitizeString(req.NISN)
-	req.FullName = utils.SanitizeString(req.FullName)
-
-	if req.NISN == "" {
-		errs["nisn"] = "NISN wajib diisi"
-	} else if len(req.NISN) != 10 {
-		errs["nisn"] = "NISN harus 10 digit"
-	}
-	if req.FullName == "" {
-		errs["full_name"] = "Nama lengkap wajib diisi"
-	}
-	if req.Gender != "" && req.Gender != "L" && req.Gender != "P" {
-		errs["gender"] = "Gender harus L atau P"
-	}
-
-	if errs.HasErrors() {
+	if errs := service.ValidateCreateStudent(&req); errs.HasErrors() {
 		response.BadRequest(w, "Validasi gagal", errs)
 		return
 	}
@@ -199,6 +183,7 @@ func (h *StudentHandler) Update(w http.ResponseWriter, r *http.Request) {
 // @Param        id   path      string  true  "Student ID"
 // @Security     BearerAuth
 // @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
 // @Failure      404  {object}  response.Response
 // @Failure      500  {object}  response.Response
 // @Router       /students/{id} [delete]
@@ -210,6 +195,10 @@ func (h *StudentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			response.NotFound(w, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrStudentSoleTeamMember) {
+			response.BadRequest(w, err.Error(), nil)
+			return
+		}
 		response.InternalError(w, "Gagal menghapus data siswa")
 		return
 	}
@@ -272,6 +261,68 @@ func (h *StudentHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, "Foto berhasil diupload", student)
 }
 
+// Import imports students from a CSV or XLSX file
+// @Summary      Import students
+// @Description  Import students from CSV or XLSX (Dapodik export layout supported, max 10MB). Without commit=true only a per-row validation report is returned (dry-run). With commit=true all rows are upserted by NISN in one transaction, or none if any row is invalid.
+// @Tags         students
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file    formData  file    true   "CSV or XLSX file"
+// @Param        commit  query     bool    false  "Save the rows (default false, dry-run)"
+// @Security     BearerAuth
+// @Success      200     {object}  response.Response
+// @Failure      400     {object}  response.Response
+// @Failure      422     {object}  response.Response
+// @Failure      500     {object}  response.Response
+// @Router       /students/import [post]
+func (h *StudentHandler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, utils.MaxSpreadsheetSize)
+	if err := r.ParseMultipartForm(utils.MaxSpreadsheetSize); err != nil {
+		response.BadRequest(w, "File terlalu besar atau format tidak valid", nil)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.BadRequest(w, "File import tidak ditemukan dalam request", nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.InternalError(w, "Gagal membaca file")
+		return
+	}
+
+	commit, _ := strconv.ParseBool(r.URL.Query().Get("commit"))
+
+	report, err := h.svc.Import(r.Context(), header.Filename, data, commit)
+	if err != nil {
+		if errors.Is(err, service.ErrImportInvalidRows) {
+			response.JSON(w, http.StatusUnprocessableEntity, false, err.Error(), report)
+			return
+		}
+		switch {
+		case errors.Is(err, utils.ErrUnsupportedSpreadsheet),
+			errors.Is(err, utils.ErrInvalidSpreadsheet),
+			errors.Is(err, service.ErrImportHeaderNotFound),
+			errors.Is(err, service.ErrImportEmpty),
+			errors.Is(err, service.ErrImportTooManyRows):
+			response.BadRequest(w, err.Error(), nil)
+			return
+		}
+		response.InternalError(w, "Gagal mengimport data siswa")
+		return
+	}
+
+	if report.Committed {
+		response.Success(w, "Import data siswa berhasil disimpan", report)
+		return
+	}
+	response.Success(w, "Hasil validasi import (dry-run), data belum disimpan", report)
+}
+
 func parseIntQuery(s string, defaultVal int) int {
 	if s == "" {
 		return defaultVal
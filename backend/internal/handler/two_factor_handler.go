@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type TwoFactorHandler struct {
+	authService service.AuthService
+	svc         service.TwoFactorService
+}
+
+func NewTwoFactorHandler(authService service.AuthService, svc service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{authService: authService, svc: svc}
+}
+
+// Verify completes a two-step login
+// @Summary      Verify two-factor code
+// @Description  Second login step: exchange the mfa_token from /auth/login and a 6-digit authenticator code (or a recovery code) for a token pair
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      service.MFAVerifyRequest  true  "Challenge token and code"
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      401      {object}  response.Response
+// @Failure      403      {object}  response.Response
+// @Failure      429      {object}  response.Response
+// @Router       /auth/mfa/verify [post]
+func (h *TwoFactorHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req service.MFAVerifyRequest
+	if !decodeMFAVerifyRequest(w, r, &req) {
+		return
+	}
+
+	result, err := h.authService.VerifyMFA(r.Context(), req)
+	if err != nil {
+		// Kode salah pada login diperlakukan sama seperti password salah
+		if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			response.Unauthorized(w, err.Error())
+			return
+		}
+		writeTwoFactorError(w, err, "Terjadi kesalahan server")
+		return
+	}
+
+	response.Success(w, "Login berhasil", result)
+}
+
+// Enroll starts the enrolment required to finish a login
+// @Summary      Start two-factor enrolment at login
+// @Description  For users whose role requires 2FA but who have not enabled it yet (mfa.enrollment_required from /auth/login). Returns the secret and a QR code for the authenticator app.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      service.MFAEnrollRequest  true  "Challenge token"
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      401      {object}  response.Response
+// @Router       /auth/mfa/enroll [post]
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	var req service.MFAEnrollRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+	if req.MFAToken == "" {
+		response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"mfa_token": "Token verifikasi wajib diisi"})
+		return
+	}
+
+	setup, err := h.authService.EnrollMFA(r.Context(), req.MFAToken)
+	if err != nil {
+		writeTwoFactorError(w, err, "Gagal memulai verifikasi dua langkah")
+		return
+	}
+
+	response.Success(w, "Pindai QR code dengan aplikasi authenticator", setup)
+}
+
+// ConfirmEnroll enables 2FA and completes the login
+// @Summary      Confirm two-factor enrolment at login
+// @Description  Enable 2FA with the first authenticator code and finish the login. The recovery codes are returned only in this response.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      service.MFAVerifyRequest  true  "Challenge token and code"
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      401      {object}  response.Response
+// @Router       /auth/mfa/enroll/confirm [post]
+func (h *TwoFactorHandler) ConfirmEnroll(w http.ResponseWriter, r *http.Request) {
+	var req service.MFAVerifyRequest
+	if !decodeMFAVerifyRequest(w, r, &req) {
+		return
+	}
+
+	result, err := h.authService.ConfirmMFAEnrollment(r.Context(), req)
+	if err != nil {
+		writeTwoFactorError(w, err, "Gagal mengaktifkan verifikasi dua langkah")
+		return
+	}
+
+	response.Success(w, "Verifikasi dua langkah aktif, simpan recovery code di tempat aman", result)
+}
+
+// Status returns the 2FA status of the current user
+// @Summary      Two-factor status
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /auth/mfa [get]
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.svc.Status(r.Context(), middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		writeTwoFactorError(w, err, "Gagal mengambil status verifikasi dua langkah")
+		return
+	}
+
+	response.Success(w, "Status verifikasi dua langkah berhasil diambil", status)
+}
+
+// Setup starts 2FA enrolment for the current user
+// @Summary      Start two-factor enrolment
+// @Description  Generate a new TOTP secret and QR code. 2FA is enabled only after POST /auth/mfa/enable.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /auth/mfa/setup [post]
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	setup, err := h.svc.Setup(r.Context(), middleware.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		writeTwoFactorError(w, err, "Gagal memulai verifikasi dua langkah")
+		return
+	}
+
+	response.Success(w, "Pindai QR code dengan aplikasi authenticator", setup)
+}
+
+// Enable confirms 2FA enrolment for the current user
+// @Summary      Enable two-factor authentication
+// @Description  Confirm the enrolment with the first authenticator code. The recovery codes are returned only in this response.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.TwoFactorCodeRequest  true  "Authenticator code"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /auth/mfa/enable [post]
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	var req model.TwoFactorCodeRequest
+	if !decodeTwoFactorCode(w, r, &req) {
+		return
+	}
+
+	codes, err := h.svc.Enable(r.Context(), middleware.GetUserIDFromContext(r.Context()), req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err, "Gagal mengaktifkan verifikasi dua langkah")
+		return
+	}
+
+	response.Success(w, "Verifikasi dua langkah aktif, simpan recovery code di tempat aman", codes)
+}
+
+// Disable turns 2FA off for the current user
+// @Summary      Disable two-factor authentication
+// @Description  Requires the password and an authenticator or recovery code. Not allowed for admins and headmasters.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.DisableTwoFactorRequest  true  "Password and code"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      403      {object}  response.Response
+// @Router       /auth/mfa/disable [post]
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	var req model.DisableTwoFactorRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	errs := utils.ValidationErrors{}
+	if req.Password == "" {
+		errs["password"] = "Password wajib diisi"
+	}
+	if req.Code == "" {
+		errs["code"] = "Kode verifikasi wajib diisi"
+	}
+	if errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	if err := h.svc.Disable(r.Context(), middleware.GetUserIDFromContext(r.Context()), req); err != nil {
+		writeTwoFactorError(w, err, "Gagal menonaktifkan verifikasi dua langkah")
+		return
+	}
+
+	response.Success(w, "Verifikasi dua langkah dinonaktifkan", nil)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+// @Summary      Regenerate recovery codes
+// @Description  Replace every recovery code; the old ones stop working. The new codes are returned only in this response.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.TwoFactorCodeRequest  true  "Authenticator code"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /auth/mfa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req model.TwoFactorCodeRequest
+	if !decodeTwoFactorCode(w, r, &req) {
+		return
+	}
+
+	codes, err := h.svc.RegenerateRecoveryCodes(r.Context(), middleware.GetUserIDFromContext(r.Context()), req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err, "Gagal membuat recovery code")
+		return
+	}
+
+	response.Success(w, "Recovery code baru berhasil dibuat", codes)
+}
+
+// ResetUser removes 2FA from a user who lost their device (admin only)
+// @Summary      Reset user two-factor authentication
+// @Description  Remove the TOTP secret and recovery codes of a user and revoke all of its sessions. Admins and headmasters enrol again at their next login.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /users/{id}/mfa [delete]
+func (h *TwoFactorHandler) ResetUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Reset(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeTwoFactorError(w, err, "Gagal mereset verifikasi dua langkah")
+		return
+	}
+
+	response.Success(w, "Verifikasi dua langkah user berhasil direset", nil)
+}
+
+func decodeMFAVerifyRequest(w http.ResponseWriter, r *http.Request, req *service.MFAVerifyRequest) bool {
+	if err := utils.DecodeJSON(r, req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return false
+	}
+
+	errs := utils.ValidationErrors{}
+	if req.MFAToken == "" {
+		errs["mfa_token"] = "Token verifikasi wajib diisi"
+	}
+	if req.Code == "" {
+		errs["code"] = "Kode verifikasi wajib diisi"
+	}
+	if errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return false
+	}
+
+	req.UserAgent = r.UserAgent()
+	return true
+}
+
+func decodeTwoFactorCode(w http.ResponseWriter, r *http.Request, req *model.TwoFactorCodeRequest) bool {
+	if err := utils.DecodeJSON(r, req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return false
+	}
+	if req.Code == "" {
+		response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"code": "Kode verifikasi wajib diisi"})
+		return false
+	}
+	return true
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFAToken):
+		response.Unauthorized(w, err.Error())
+	case errors.Is(err, service.ErrTooManyLoginAttempts):
+		writeLoginThrottled(w, err)
+	case errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrTwoFactorRequired):
+		response.Forbidden(w, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrMFAEnrollmentRequired),
+		errors.Is(err, service.ErrTwoFactorAlreadyEnabled),
+		errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorSetupMissing),
+		errors.Is(err, service.ErrWrongPassword):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		response.InternalError(w, fallback)
+	}
+}
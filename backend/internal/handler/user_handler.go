@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/service"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type UserHandler struct {
+	svc service.UserService
+}
+
+func NewUserHandler(svc service.UserService) *UserHandler {
+	return &UserHandler{svc: svc}
+}
+
+// GetAll lists users
+// @Summary      List users
+// @Description  Paginated list of staff accounts ordered by name
+// @Tags         users
+// @Produce      json
+// @Param        search     query     string  false  "Search by name or email"
+// @Param        role       query     string  false  "Filter by role (operator, admin, headmaster)"
+// @Param        is_active  query     bool    false  "Filter by account status"
+// @Param        page       query     int     false  "Page number"
+// @Param        per_page   query     int     false  "Items per page"
+// @Security     BearerAuth
+// @Success      200  {object}  response.PaginatedResponse
+// @Failure      500  {object}  response.Response
+// @Router       /users [get]
+func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := model.UserFilter{
+		Search:  utils.SanitizeString(q.Get("search")),
+		Role:    q.Get("role"),
+		Page:    parseIntQuery(q.Get("page"), 1),
+		PerPage: parseIntQuery(q.Get("per_page"), 10),
+	}
+	if active, err := strconv.ParseBool(q.Get("is_active")); err == nil {
+		filter.IsActive = &active
+	}
+
+	users, pagination, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		response.InternalError(w, "Gagal mengambil data user")
+		return
+	}
+
+	response.Paginated(w, "Data user berhasil diambil", users, pagination)
+}
+
+// GetByID returns a user
+// @Summary      Get user
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      500  {object}  response.Response
+// @Router       /users/{id} [get]
+func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	user, err := h.svc.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.NotFound(w, err.Error())
+			return
+		}
+		response.InternalError(w, "Gagal mengambil data user")
+		return
+	}
+
+	response.Success(w, "Data user berhasil diambil", user)
+}
+
+// Update changes the name and role of a user
+// @Summary      Update user
+// @Description  Change the name and role of a user (admin only). The last active admin cannot be demoted.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                   true  "User ID"
+// @Param        request  body      model.UpdateUserRequest  true  "User data"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      409      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /users/{id} [put]
+func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateUserRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	errs := utils.ValidationErrors{}
+	req.Name = utils.SanitizeString(req.Name)
+	if req.Name == "" {
+		errs["name"] = "Nama wajib diisi"
+	}
+	if !model.IsValidRole(req.Role) {
+		errs["role"] = "Role tidak valid (operator, admin, headmaster)"
+	}
+	if errs.HasErrors() {
+		response.BadRequest(w, "Validasi gagal", errs)
+		return
+	}
+
+	user, err := h.svc.Update(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeUserError(w, err, "Gagal menyimpan data user")
+		return
+	}
+
+	response.Success(w, "Data user berhasil diperbarui", user)
+}
+
+// Activate re-enables a user account
+// @Summary      Activate user
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Router       /users/{id}/activate [post]
+func (h *UserHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	user, err := h.svc.Activate(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeUserError(w, err, "Gagal mengaktifkan user")
+		return
+	}
+
+	response.Success(w, "User berhasil diaktifkan", user)
+}
+
+// Deactivate disables a user account
+// @Summary      Deactivate user
+// @Description  Disable a user account and revoke all of its sessions (admin only). Admins cannot deactivate themselves or the last active admin.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Security     BearerAuth
+// @Success      200  {object}  response.Response
+// @Failure      400  {object}  response.Response
+// @Failure      404  {object}  response.Response
+// @Failure      409  {object}  response.Response
+// @Router       /users/{id}/deactivate [post]
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	actorID := middleware.GetUserIDFromContext(r.Context())
+	user, err := h.svc.Deactivate(r.Context(), chi.URLParam(r, "id"), actorID)
+	if err != nil {
+		writeUserError(w, err, "Gagal menonaktifkan user")
+		return
+	}
+
+	response.Success(w, "User berhasil dinonaktifkan", user)
+}
+
+// ResetPassword sets a new password for a user
+// @Summary      Reset user password
+// @Description  Set a new password for a user and revoke all of its sessions (admin only). Without a password, a random temporary password is generated and returned once.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "User ID"
+// @Param        request  body      model.ResetPasswordRequest  true  "New password (optional)"
+// @Security     BearerAuth
+// @Success      200      {object}  response.Response
+// @Failure      400      {object}  response.Response
+// @Failure      404      {object}  response.Response
+// @Failure      500      {object}  response.Response
+// @Router       /users/{id}/reset-password [post]
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		response.BadRequest(w, "Format request tidak valid", err.Error())
+		return
+	}
+
+	if req.Password != "" {
+		if err := utils.CheckPassword(req.Password); err != nil {
+			response.BadRequest(w, "Validasi gagal", utils.ValidationErrors{"password": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.svc.ResetPassword(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeUserError(w, err, "Gagal mereset password")
+		return
+	}
+
+	response.Success(w, "Password berhasil direset", result)
+}
+
+func writeUserError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		response.NotFound(w, err.Error())
+	case errors.Is(err, service.ErrLastAdmin):
+		response.JSON(w, http.StatusConflict, false, err.Error(), nil)
+	case errors.Is(err, service.ErrCannotDeactivateSelf),
+		errors.Is(err, service.ErrUserAlreadyActive),
+		errors.Is(err, service.ErrUserAlreadyInactive):
+		response.BadRequest(w, err.Error(), nil)
+	default:
+		response.InternalError(w, fallback)
+	}
+}
@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
 
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
 	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
 )
@@ -12,14 +15,38 @@ import (
 type contextKey string
 
 const (
-	ContextKeyUserID contextKey = "user_id"
-	ContextKeyEmail  contextKey = "email"
-	ContextKeyRole   contextKey = "role"
-	ContextKeyName   contextKey = "name"
+	ContextKeyUserID    contextKey = "user_id"
+	ContextKeyEmail     contextKey = "email"
+	ContextKeyRole      contextKey = "role"
+	ContextKeyName      contextKey = "name"
+	ContextKeySessionID contextKey = "session_id"
+	ContextKeyIP        contextKey = "client_ip"
 )
 
-// Authenticate memvalidasi JWT dari Authorization header
-func Authenticate(jwtSecret string) func(http.Handler) http.Handler {
+// SessionStatus status sesi token dan pemiliknya
+type SessionStatus struct {
+	Active             bool // belum logout/dicabut dan user masih aktif
+	MustChangePassword bool
+}
+
+// SessionChecker memeriksa sesi token pada setiap request
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userID, sessionID string) (SessionStatus, error)
+}
+
+// Authenticate memvalidasi access token dari Authorization header beserta sesinya.
+// User yang wajib mengganti password ditolak; lihat AuthenticatePasswordChange.
+func Authenticate(jwtSecret string, sessions SessionChecker) func(http.Handler) http.Handler {
+	return authenticate(jwtSecret, sessions, false)
+}
+
+// AuthenticatePasswordChange seperti Authenticate, tetapi tetap mengizinkan user yang
+// wajib mengganti password. Hanya untuk endpoint ganti password.
+func AuthenticatePasswordChange(jwtSecret string, sessions SessionChecker) func(http.Handler) http.Handler {
+	return authenticate(jwtSecret, sessions, true)
+}
+
+func authenticate(jwtSecret string, sessions SessionChecker, allowPasswordChange bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -36,17 +63,34 @@ func Authenticate(jwtSecret string) func(http.Handler) http.Handler {
 
 			tokenString := parts[1]
 			claims, err := utils.ValidateToken(tokenString, jwtSecret)
-			if err != nil {
+			if err != nil || claims.Type != utils.TokenTypeAccess {
 				response.Unauthorized(w, "Token tidak valid atau sudah expired")
 				return
 			}
 
+			status, err := sessions.CheckSession(r.Context(), claims.UserID, claims.SessionID)
+			if err != nil {
+				response.InternalError(w, "Gagal memeriksa sesi")
+				return
+			}
+			if !status.Active {
+				response.Unauthorized(w, "Sesi sudah berakhir, silakan login kembali")
+				return
+			}
+			if status.MustChangePassword && !allowPasswordChange {
+				response.JSON(w, http.StatusForbidden, false,
+					"Anda wajib mengganti password sebelum melanjutkan",
+					map[string]bool{"must_change_password": true})
+				return
+			}
+
 			// Simpan claims ke context
 			ctx := r.Context()
 			ctx = context.WithValue(ctx, ContextKeyUserID, claims.UserID)
 			ctx = context.WithValue(ctx, ContextKeyEmail, claims.Email)
 			ctx = context.WithValue(ctx, ContextKeyRole, claims.Role)
 			ctx = context.WithValue(ctx, ContextKeyName, claims.Name)
+			ctx = context.WithValue(ctx, ContextKeySessionID, claims.SessionID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -84,4 +128,36 @@ func GetUserIDFromContext(ctx context.Context) string {
 func GetRoleFromContext(ctx context.Context) string {
 	val, _ := ctx.Value(ContextKeyRole).(string)
 	return val
+}
+
+func GetNameFromContext(ctx context.Context) string {
+	val, _ := ctx.Value(ContextKeyName).(string)
+	return val
+}
+
+// GetSessionIDFromContext ID sesi dari access token
+func GetSessionIDFromContext(ctx context.Context) string {
+	val, _ := ctx.Value(ContextKeySessionID).(string)
+	return val
+}
+
+// ClientIP menyimpan IP client ke context (pasang setelah chi RealIP)
+func ClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ContextKeyIP, ip)))
+	})
+}
+
+func GetClientIPFromContext(ctx context.Context) string {
+	val, _ := ctx.Value(ContextKeyIP).(string)
+	return val
+}
+
+// GetRequestIDFromContext request ID dari middleware chi RequestID
+func GetRequestIDFromContext(ctx context.Context) string {
+	return chiMiddleware.GetReqID(ctx)
 }
\ No newline at end of file
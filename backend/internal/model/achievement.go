@@ -6,16 +6,27 @@ import (
 	"github.com/google/uuid"
 )
 
+// Status verifikasi prestasi
+const (
+	AchievementStatusDraft     = "draft"
+	AchievementStatusSubmitted = "submitted" // menunggu verifikasi admin/kepala sekolah
+	AchievementStatusVerified  = "verified"  // boleh dimasukkan ke surat keterangan
+	AchievementStatusRejected  = "rejected"
+)
+
 type Achievement struct {
 	ID              uuid.UUID  `db:"id"               json:"id"`
 	StudentID       uuid.UUID  `db:"student_id"       json:"student_id"`
 	CompetitionName string     `db:"competition_name" json:"competition_name"`
 	Organizer       string     `db:"organizer"        json:"organizer"`
 	CategoryID      *int       `db:"category_id"      json:"category_id"`
-	Rank            string     `db:"rank"             json:"rank"`
+	Rank            string     `db:"rank"             json:"rank"`    // teks juara apa adanya, dicetak di surat keterangan
+	RankID          *int       `db:"rank_id"          json:"rank_id"` // referensi tabel ranks, nil jika teks belum dikenali
 	LevelID         *int       `db:"level_id"         json:"level_id"`
 	Year            int        `db:"year"             json:"year"`
 	Description     string     `db:"description"      json:"description"`
+	Status          string     `db:"status"           json:"status"`    // draft | submitted | verified | rejected
+	TeamName        *string    `db:"team_name"        json:"team_name"` // nil untuk prestasi perorangan
 	CreatedBy       *uuid.UUID `db:"created_by"       json:"created_by"`
 	CreatedAt       time.Time  `db:"created_at"       json:"created_at"`
 	UpdatedAt       time.Time  `db:"updated_at"       json:"updated_at"`
@@ -23,8 +34,13 @@ type Achievement struct {
 	// Join fields
 	CategoryName *string `db:"category_name" json:"category_name,omitempty"`
 	LevelName    *string `db:"level_name"    json:"level_name,omitempty"`
+	RankCode     *string `db:"rank_code"     json:"rank_code,omitempty"`
+	RankName     *string `db:"rank_name"     json:"rank_name,omitempty"`
 	StudentName  *string `db:"student_name"  json:"student_name,omitempty"`
 	StudentNISN  *string `db:"student_nisn"  json:"student_nisn,omitempty"`
+
+	// Anggota tim, hanya untuk prestasi tim
+	Participants []AchievementParticipant `db:"-" json:"participants,omitempty"`
 }
 
 type AchievementWithAttachments struct {
@@ -48,9 +64,14 @@ type CreateAchievementRequest struct {
 	Organizer       string `json:"organizer"`
 	CategoryID      *int   `json:"category_id"`
 	Rank            string `json:"rank"`
+	RankID          *int   `json:"rank_id"`
 	LevelID         *int   `json:"level_id"`
 	Year            int    `json:"year"`
 	Description     string `json:"description"`
+
+	// Prestasi tim: student_id boleh kosong, siswa utama diambil dari kapten/anggota pertama
+	TeamName     string               `json:"team_name"`
+	Participants []ParticipantRequest `json:"participants"`
 }
 
 type UpdateAchievementRequest struct {
@@ -58,21 +79,76 @@ type UpdateAchievementRequest struct {
 	Organizer       string `json:"organizer"`
 	CategoryID      *int   `json:"category_id"`
 	Rank            string `json:"rank"`
+	RankID          *int   `json:"rank_id"`
 	LevelID         *int   `json:"level_id"`
 	Year            int    `json:"year"`
 	Description     string `json:"description"`
+	TeamName        string `json:"team_name"` // hanya untuk prestasi tim, kosong = tidak diubah
 }
 
 type AchievementFilter struct {
 	StudentID  string
+	Status     string
 	CategoryID *int
 	LevelID    *int
+	RankID     *int
+	RankKind   string
 	Year       *int
 	Search     string
 	Page       int
 	PerPage    int
 }
 
+// Peran siswa dalam prestasi tim
+const (
+	ParticipantRoleCaptain = "captain"
+	ParticipantRoleMember  = "member"
+	ParticipantRoleCoach   = "coach"
+)
+
+type AchievementParticipant struct {
+	AchievementID uuid.UUID `db:"achievement_id" json:"achievement_id"`
+	StudentID     uuid.UUID `db:"student_id"     json:"student_id"`
+	Role          string    `db:"role"           json:"role"` // captain | member | coach
+	CreatedAt     time.Time `db:"created_at"     json:"created_at"`
+
+	// Join fields
+	StudentName  *string `db:"student_name"  json:"student_name,omitempty"`
+	StudentNISN  *string `db:"student_nisn"  json:"student_nisn,omitempty"`
+	StudentClass *string `db:"student_class" json:"student_class,omitempty"`
+}
+
+type ParticipantRequest struct {
+	StudentID string `json:"student_id"`
+	Role      string `json:"role"`
+}
+
+// UpdateParticipantsRequest mengganti seluruh anggota tim. Daftar kosong mengubah
+// prestasi tim kembali menjadi prestasi perorangan siswa utama.
+type UpdateParticipantsRequest struct {
+	TeamName     string               `json:"team_name"`
+	Participants []ParticipantRequest `json:"participants"`
+}
+
+// AchievementStatusEvent satu baris riwayat verifikasi prestasi
+type AchievementStatusEvent struct {
+	ID            uuid.UUID  `db:"id"             json:"id"`
+	AchievementID uuid.UUID  `db:"achievement_id" json:"achievement_id"`
+	FromStatus    *string    `db:"from_status"    json:"from_status"`
+	ToStatus      string     `db:"to_status"      json:"to_status"`
+	Comment       *string    `db:"comment"        json:"comment"`
+	ActorID       *uuid.UUID `db:"actor_id"       json:"actor_id"`
+	CreatedAt     time.Time  `db:"created_at"     json:"created_at"`
+
+	// Join fields
+	ActorName *string `db:"actor_name" json:"actor_name,omitempty"`
+}
+
+// ReviewAchievementRequest komentar reviewer saat verifikasi/penolakan
+type ReviewAchievementRequest struct {
+	Comment string `json:"comment"`
+}
+
 type AchievementCategory struct {
 	ID   int    `db:"id"   json:"id"`
 	Name string `db:"name" json:"name"`
@@ -83,4 +159,32 @@ type CompetitionLevel struct {
 	ID        int    `db:"id"         json:"id"`
 	Name      string `db:"name"       json:"name"`
 	OrderRank int    `db:"order_rank" json:"order_rank"`
-}
\ No newline at end of file
+}
+
+// Jenis peringkat di tabel ranks
+const (
+	RankKindPosition          = "position"           // juara 1-3
+	RankKindMedal             = "medal"              // medali emas, perak, perunggu
+	RankKindHonourableMention = "honourable_mention" // juara harapan
+	RankKindParticipation     = "participation"      // finalis, peserta
+)
+
+type Rank struct {
+	ID        int    `db:"id"         json:"id"`
+	Code      string `db:"code"       json:"code"`
+	Name      string `db:"name"       json:"name"`
+	Kind      string `db:"kind"       json:"kind"`
+	OrderRank int    `db:"order_rank" json:"order_rank"`
+}
+
+// RankAlias variasi penulisan teks juara (sudah dinormalisasi) untuk suatu peringkat
+type RankAlias struct {
+	Alias  string `db:"alias"   json:"alias"`
+	RankID int    `db:"rank_id" json:"rank_id"`
+}
+
+// UnmatchedRank teks juara yang belum terpetakan ke tabel ranks
+type UnmatchedRank struct {
+	Rank  string `db:"rank"  json:"rank"`
+	Total int    `db:"total" json:"total"`
+}
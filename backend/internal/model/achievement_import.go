@@ -0,0 +1,141 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status batch import prestasi
+const (
+	ImportBatchPending   = "pending" // menunggu ditinjau operator
+	ImportBatchApproved  = "approved"
+	ImportBatchDiscarded = "discarded"
+)
+
+// Status baris staging
+const (
+	ImportRowReady       = "ready"        // siap dibuat saat batch disetujui
+	ImportRowNeedsReview = "needs_review" // ada peringatan yang perlu dikonfirmasi
+	ImportRowInvalid     = "invalid"      // ada kesalahan yang harus diperbaiki atau baris dilewati
+	ImportRowSkipped     = "skipped"
+	ImportRowImported    = "imported"
+)
+
+// Cara siswa dicocokkan
+const (
+	StudentMatchNISN      = "nisn"
+	StudentMatchNameClass = "name_class" // nama dan kelas sama persis
+	StudentMatchFuzzy     = "fuzzy"      // kemiripan nama (Levenshtein)
+	StudentMatchManual    = "manual"     // dipilih operator
+)
+
+// ImportIssues map field -> pesan, disimpan sebagai JSONB
+type ImportIssues map[string]string
+
+func (m ImportIssues) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func (m *ImportIssues) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("unsupported ImportIssues source type %T", src)
+	}
+}
+
+type AchievementImportBatch struct {
+	ID         uuid.UUID  `db:"id"          json:"id"`
+	FileName   string     `db:"file_name"   json:"file_name"`
+	Status     string     `db:"status"      json:"status"` // pending | approved | discarded
+	TotalRows  int        `db:"total_rows"  json:"total_rows"`
+	CreatedBy  *uuid.UUID `db:"created_by"  json:"created_by"`
+	ReviewedBy *uuid.UUID `db:"reviewed_by" json:"reviewed_by"`
+	ReviewedAt *time.Time `db:"reviewed_at" json:"reviewed_at"`
+	CreatedAt  time.Time  `db:"created_at"  json:"created_at"`
+
+	// Ringkasan status baris
+	ReadyRows    int `db:"ready_rows"    json:"ready_rows"`
+	ReviewRows   int `db:"review_rows"   json:"review_rows"`
+	InvalidRows  int `db:"invalid_rows"  json:"invalid_rows"`
+	SkippedRows  int `db:"skipped_rows"  json:"skipped_rows"`
+	ImportedRows int `db:"imported_rows" json:"imported_rows"`
+
+	// Join fields
+	CreatedByName *string `db:"created_by_name" json:"created_by_name,omitempty"`
+}
+
+type AchievementImportRow struct {
+	ID              uuid.UUID    `db:"id"               json:"id"`
+	BatchID         uuid.UUID    `db:"batch_id"         json:"batch_id"`
+	LineNumber      int          `db:"line_number"      json:"line_number"`
+	NISN            string       `db:"nisn"             json:"nisn"`
+	StudentName     string       `db:"student_name"     json:"student_name"`
+	Class           string       `db:"class"            json:"class"`
+	CompetitionName string       `db:"competition_name" json:"competition_name"`
+	Organizer       string       `db:"organizer"        json:"organizer"`
+	CategoryName    string       `db:"category_name"    json:"category_name"`
+	LevelName       string       `db:"level_name"       json:"level_name"`
+	Rank            string       `db:"rank"             json:"rank"`
+	Year            *int         `db:"year"             json:"year"`
+	Description     string       `db:"description"      json:"description"`
+	StudentID       *uuid.UUID   `db:"student_id"       json:"student_id"`
+	MatchMethod     *string      `db:"match_method"     json:"match_method"`
+	MatchScore      *float64     `db:"match_score"      json:"match_score"`
+	CategoryID      *int         `db:"category_id"      json:"category_id"`
+	LevelID         *int         `db:"level_id"         json:"level_id"`
+	DuplicateOf     *uuid.UUID   `db:"duplicate_of"     json:"duplicate_of"`
+	Errors          ImportIssues `db:"errors"           json:"errors,omitempty"`
+	Warnings        ImportIssues `db:"warnings"         json:"warnings,omitempty"`
+	Confirmed       bool         `db:"confirmed"        json:"confirmed"`
+	Skipped         bool         `db:"skipped"          json:"skipped"`
+	Status          string       `db:"status"           json:"status"` // ready | needs_review | invalid | skipped | imported
+	AchievementID   *uuid.UUID   `db:"achievement_id"   json:"achievement_id"`
+	UpdatedAt       time.Time    `db:"updated_at"       json:"updated_at"`
+
+	// Join fields
+	MatchedStudentName  *string `db:"matched_student_name"  json:"matched_student_name,omitempty"`
+	MatchedStudentNISN  *string `db:"matched_student_nisn"  json:"matched_student_nisn,omitempty"`
+	MatchedStudentClass *string `db:"matched_student_class" json:"matched_student_class,omitempty"`
+}
+
+type AchievementImportBatchDetail struct {
+	AchievementImportBatch
+	Rows []*AchievementImportRow `json:"rows"`
+}
+
+// UpdateAchievementImportRowRequest koreksi operator atas satu baris staging.
+// Field yang tidak dikirim tidak diubah.
+type UpdateAchievementImportRowRequest struct {
+	StudentID  *string `json:"student_id"`
+	CategoryID *int    `json:"category_id"`
+	LevelID    *int    `json:"level_id"`
+	Confirmed  *bool   `json:"confirmed"` // menerima peringatan (kecocokan fuzzy, kemungkinan duplikat)
+	Skipped    *bool   `json:"skipped"`
+}
+
+type AchievementImportFilter struct {
+	Status  string
+	Page    int
+	PerPage int
+}
+
+// ImportedAchievement prestasi yang dibuat dari satu baris staging saat batch disetujui
+type ImportedAchievement struct {
+	RowID       uuid.UUID
+	Achievement *Achievement
+}
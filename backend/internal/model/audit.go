@@ -0,0 +1,139 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditGenesisHash prev_hash untuk entri pertama di rantai audit
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+// AuditLog satu entri ledger audit. Setiap entri menyimpan hash entri sebelumnya,
+// sehingga perubahan atau penghapusan baris memutus rantai.
+type AuditLog struct {
+	ID         int64      `db:"id"          json:"id"`
+	OccurredAt time.Time  `db:"occurred_at" json:"occurred_at"`
+	ActorID    *uuid.UUID `db:"actor_id"    json:"actor_id"`
+	ActorName  *string    `db:"actor_name"  json:"actor_name"`
+	ActorRole  *string    `db:"actor_role"  json:"actor_role"`
+	Action     string     `db:"action"      json:"action"` // mis. student.update, certificate.revoke
+	EntityType string     `db:"entity_type" json:"entity_type"`
+	EntityID   *string    `db:"entity_id"   json:"entity_id"`
+	Before     JSONB      `db:"before"      json:"before" swaggertype:"object"`
+	After      JSONB      `db:"after"       json:"after"  swaggertype:"object"`
+	IPAddress  *string    `db:"ip_address"  json:"ip_address"`
+	RequestID  *string    `db:"request_id"  json:"request_id"`
+	PrevHash   string     `db:"prev_hash"   json:"prev_hash"`
+	Hash       string     `db:"hash"        json:"hash"`
+}
+
+// ComputeHash menghitung SHA-256 entri dari PrevHash dan seluruh isinya (kecuali ID dan Hash).
+// Before/After dinormalisasi dulu agar hasilnya sama setelah disimpan sebagai JSONB.
+func (l *AuditLog) ComputeHash() (string, error) {
+	before, err := CanonicalJSON(l.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := CanonicalJSON(l.After)
+	if err != nil {
+		return "", err
+	}
+
+	actorID := ""
+	if l.ActorID != nil {
+		actorID = l.ActorID.String()
+	}
+
+	// Array JSON dipakai sebagai pemisah field yang tidak ambigu
+	fields, err := json.Marshal([]string{
+		l.PrevHash,
+		l.OccurredAt.UTC().Format(time.RFC3339Nano),
+		actorID,
+		derefOrEmpty(l.ActorName),
+		derefOrEmpty(l.ActorRole),
+		l.Action,
+		l.EntityType,
+		derefOrEmpty(l.EntityID),
+		string(before),
+		string(after),
+		derefOrEmpty(l.IPAddress),
+		derefOrEmpty(l.RequestID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CanonicalJSON mengubah JSON menjadi bentuk kanonik (key terurut, tanpa spasi).
+// JSON kosong dianggap null.
+func CanonicalJSON(raw []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return []byte("null"), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func derefOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// AuditEntry data yang dicatat service; aktor diambil dari context request
+// kecuali diisi eksplisit (mis. saat login).
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+
+	ActorID   *uuid.UUID
+	ActorName string
+	ActorRole string
+}
+
+type AuditLogFilter struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	ActorID    string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PerPage    int
+}
+
+// AuditChainBreak satu mata rantai yang rusak
+type AuditChainBreak struct {
+	ID       int64  `json:"id"`
+	Reason   string `json:"reason"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// AuditVerifyResult hasil penelusuran seluruh rantai audit
+type AuditVerifyResult struct {
+	Valid     bool              `json:"valid"`
+	Checked   int64             `json:"checked"`
+	LastHash  string            `json:"last_hash"`
+	Breaks    []AuditChainBreak `json:"breaks"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
@@ -6,6 +6,20 @@ import (
 	"github.com/google/uuid"
 )
 
+// Status sertifikat
+const (
+	CertificateStatusActive     = "active"
+	CertificateStatusRevoked    = "revoked"
+	CertificateStatusSuperseded = "superseded" // digantikan oleh sertifikat hasil penerbitan ulang
+)
+
+// Status pembuatan PDF sertifikat (dikerjakan job background)
+const (
+	PDFStatusPending = "pending"
+	PDFStatusReady   = "ready"
+	PDFStatusFailed  = "failed"
+)
+
 type Certificate struct {
 	ID                uuid.UUID  `db:"id"                 json:"id"`
 	StudentID         uuid.UUID  `db:"student_id"         json:"student_id"`
@@ -15,14 +29,26 @@ type Certificate struct {
 	ValidUntil        *time.Time `db:"valid_until"        json:"valid_until"`
 	QRToken           string     `db:"qr_token"           json:"qr_token"`
 	PDFURL            *string    `db:"pdf_url"            json:"pdf_url"`
-	Status            string     `db:"status"             json:"status"` // active | revoked
+	PDFStatus         string     `db:"pdf_status"         json:"pdf_status"` // pending | ready | failed
+	PDFError          *string    `db:"pdf_error"          json:"pdf_error,omitempty"`
+	PDFSHA256         *string    `db:"pdf_sha256"         json:"pdf_sha256"`
+	Status            string     `db:"status"             json:"status"` // active | revoked | superseded
 	Notes             string     `db:"notes"              json:"notes"`
+	SignedPayload     *string    `db:"signed_payload"     json:"signed_payload,omitempty"`
+	Signature         *string    `db:"signature"          json:"signature,omitempty"`
+	SignatureKeyID    *string    `db:"signature_key_id"   json:"signature_key_id,omitempty"`
+	Supersedes        *uuid.UUID `db:"supersedes"         json:"supersedes"`
+	SupersededBy      *uuid.UUID `db:"superseded_by"      json:"superseded_by"`
+	Snapshot          JSONB      `db:"snapshot"           json:"-"` // lihat CertificateSnapshot
+	TemplateVersionID *uuid.UUID `db:"template_version_id" json:"template_version_id"`
 	CreatedAt         time.Time  `db:"created_at"         json:"created_at"`
 
 	// Join fields
-	StudentName *string `db:"student_name" json:"student_name,omitempty"`
-	StudentNISN *string `db:"student_nisn" json:"student_nisn,omitempty"`
-	IssuedByName *string `db:"issued_by_name" json:"issued_by_name,omitempty"`
+	StudentName     *string `db:"student_name" json:"student_name,omitempty"`
+	StudentNISN     *string `db:"student_nisn" json:"student_nisn,omitempty"`
+	IssuedByName    *string `db:"issued_by_name" json:"issued_by_name,omitempty"`
+	TemplateName    *string `db:"template_name" json:"template_name,omitempty"`
+	TemplateVersion *int    `db:"template_version" json:"template_version,omitempty"`
 }
 
 type CertificateDetail struct {
@@ -31,11 +57,101 @@ type CertificateDetail struct {
 	Achievements []AchievementWithAttachments `json:"achievements"`
 }
 
+// CertificateSnapshot data siswa dan prestasi yang dibekukan saat penerbitan
+type CertificateSnapshot struct {
+	CapturedAt   time.Time                    `json:"captured_at"`
+	Backfilled   bool                         `json:"backfilled,omitempty"` // diisi migrasi, bukan saat penerbitan
+	Student      Student                      `json:"student"`
+	Achievements []AchievementWithAttachments `json:"achievements"`
+}
+
+// FieldDiff satu field yang berbeda antara snapshot dan data live
+type FieldDiff struct {
+	Field    string      `json:"field"`
+	Snapshot interface{} `json:"snapshot"`
+	Live     interface{} `json:"live"`
+}
+
+// Status drift prestasi
+const (
+	DriftStatusChanged = "changed"
+	DriftStatusDeleted = "deleted"
+)
+
+type AchievementDrift struct {
+	AchievementID   uuid.UUID   `json:"achievement_id"`
+	CompetitionName string      `json:"competition_name"` // nama menurut snapshot
+	Status          string      `json:"status"`           // changed | deleted
+	Fields          []FieldDiff `json:"fields,omitempty"`
+}
+
+// CertificateDrift perbedaan isi sertifikat terhadap data live saat ini
+type CertificateDrift struct {
+	CertificateID     uuid.UUID          `json:"certificate_id"`
+	CertificateNumber string             `json:"certificate_number"`
+	SnapshotAt        time.Time          `json:"snapshot_at"`
+	Backfilled        bool               `json:"backfilled"`
+	HasDrift          bool               `json:"has_drift"`
+	StudentDeleted    bool               `json:"student_deleted"`
+	Student           []FieldDiff        `json:"student"`
+	Achievements      []AchievementDrift `json:"achievements"`
+}
+
 type CreateCertificateRequest struct {
 	StudentID      string   `json:"student_id"`
 	AchievementIDs []string `json:"achievement_ids"` // prestasi yang dimasukkan ke surat
 	ValidUntil     string   `json:"valid_until"`     // format: YYYY-MM-DD, opsional
 	Notes          string   `json:"notes"`
+	TemplateID     string   `json:"template_id"` // opsional, default template default sekolah
+}
+
+// ReissueCertificateRequest menerbitkan surat baru yang menggantikan surat lama
+type ReissueCertificateRequest struct {
+	AchievementIDs []string `json:"achievement_ids"` // daftar prestasi terbaru
+	ValidUntil     string   `json:"valid_until"`     // format: YYYY-MM-DD, opsional
+	Notes          string   `json:"notes"`
+	Reason         string   `json:"reason"`
+	TemplateID     string   `json:"template_id"` // opsional, default template surat lama
+}
+
+// CertificateStatusEvent satu baris riwayat status sertifikat
+type CertificateStatusEvent struct {
+	ID            uuid.UUID  `db:"id"             json:"id"`
+	CertificateID uuid.UUID  `db:"certificate_id" json:"certificate_id"`
+	FromStatus    *string    `db:"from_status"    json:"from_status"`
+	ToStatus      string     `db:"to_status"      json:"to_status"`
+	Reason        *string    `db:"reason"         json:"reason"`
+	ActorID       *uuid.UUID `db:"actor_id"       json:"actor_id"`
+	CreatedAt     time.Time  `db:"created_at"     json:"created_at"`
+
+	// Join fields
+	ActorName *string `db:"actor_name" json:"actor_name,omitempty"`
+}
+
+type ChangeCertificateStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CertificatePDFRegeneration catatan regenerasi PDF oleh admin
+type CertificatePDFRegeneration struct {
+	ID                uuid.UUID  `db:"id"                  json:"id"`
+	CertificateID     uuid.UUID  `db:"certificate_id"      json:"certificate_id"`
+	RequestedBy       *uuid.UUID `db:"requested_by"        json:"requested_by"`
+	Reason            string     `db:"reason"              json:"reason"`
+	JobID             *uuid.UUID `db:"job_id"              json:"job_id"`
+	PreviousPDFURL    *string    `db:"previous_pdf_url"    json:"previous_pdf_url"`
+	PreviousPDFSHA256 *string    `db:"previous_pdf_sha256" json:"previous_pdf_sha256"`
+	NewPDFURL         *string    `db:"new_pdf_url"         json:"new_pdf_url"`
+	NewPDFSHA256      *string    `db:"new_pdf_sha256"      json:"new_pdf_sha256"`
+	CreatedAt         time.Time  `db:"created_at"          json:"created_at"`
+	CompletedAt       *time.Time `db:"completed_at"        json:"completed_at"`
+
+	// Join fields
+	RequestedByName *string `db:"requested_by_name" json:"requested_by_name,omitempty"`
+}
+
+type RegenerateCertificatePDFRequest struct {
+	Reason string `json:"reason"`
 }
 
 type CertificateFilter struct {
@@ -45,11 +161,46 @@ type CertificateFilter struct {
 	PerPage   int
 }
 
+// Status tanda tangan digital pada hasil verifikasi
+const (
+	SignatureStatusValid    = "valid"    // tanda tangan cocok dengan data sertifikat
+	SignatureStatusTampered = "tampered" // tanda tangan rusak atau data berubah setelah diterbitkan
+	SignatureStatusUnsigned = "unsigned" // sertifikat lama yang diterbitkan sebelum fitur tanda tangan
+)
+
+// RevocationInfo informasi pencabutan yang ditampilkan ke verifikator
+type RevocationInfo struct {
+	RevokedAt time.Time `json:"revoked_at"`
+	Reason    string    `json:"reason"`
+}
+
+// SupersessionInfo menunjuk ke dokumen yang berlaku saat ini
+type SupersessionInfo struct {
+	CertificateID     uuid.UUID `json:"certificate_id"`
+	CertificateNumber string    `json:"certificate_number"`
+	Status            string    `json:"status"`
+	VerifyURL         string    `json:"verify_url"`
+}
+
 // VerifyResponse untuk endpoint publik verifikasi QR
 type VerifyResponse struct {
-	IsValid       bool             `json:"is_valid"`
-	Certificate   *Certificate     `json:"certificate,omitempty"`
-	Student       *Student         `json:"student,omitempty"`
-	Achievements  []Achievement    `json:"achievements,omitempty"`
-	Message       string           `json:"message"`
+	IsValid         bool              `json:"is_valid"`
+	SignatureStatus string            `json:"signature_status,omitempty"`
+	Certificate     *Certificate      `json:"certificate,omitempty"`
+	Student         *Student          `json:"student,omitempty"`
+	Achievements    []Achievement     `json:"achievements,omitempty"`
+	Revocation      *RevocationInfo   `json:"revocation,omitempty"`
+	SupersededBy    *SupersessionInfo `json:"superseded_by,omitempty"`
+	Message         string            `json:"message"`
+}
+
+// SigningKeyResponse public key sekolah untuk verifikasi offline
+type SigningKeyResponse struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"` // base64, 32 byte
+	PEM       string `json:"pem"`
+
+	// PreviousKeys public key lama (kid → base64) untuk sertifikat sebelum rotasi kunci
+	PreviousKeys map[string]string `json:"previous_keys,omitempty"`
 }
\ No newline at end of file
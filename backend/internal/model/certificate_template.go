@@ -0,0 +1,128 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bahasa template surat keterangan
+const (
+	TemplateLanguageID = "id"
+	TemplateLanguageEN = "en"
+)
+
+// Kolom tabel prestasi yang bisa dipilih template
+const (
+	TemplateColumnNo          = "no"
+	TemplateColumnCompetition = "competition_name"
+	TemplateColumnOrganizer   = "organizer"
+	TemplateColumnCategory    = "category"
+	TemplateColumnRank        = "rank"
+	TemplateColumnLevel       = "level"
+	TemplateColumnYear        = "year"
+)
+
+type CertificateTemplate struct {
+	ID             uuid.UUID  `db:"id"              json:"id"`
+	Name           string     `db:"name"            json:"name"`
+	Description    string     `db:"description"     json:"description"`
+	IsDefault      bool       `db:"is_default"      json:"is_default"`
+	CurrentVersion int        `db:"current_version" json:"current_version"`
+	CreatedBy      *uuid.UUID `db:"created_by"      json:"created_by"`
+	CreatedAt      time.Time  `db:"created_at"      json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"      json:"updated_at"`
+
+	// Join fields: isi versi terbaru
+	CurrentVersionID uuid.UUID `db:"current_version_id" json:"current_version_id"`
+	Definition       JSONB     `db:"definition"         json:"definition"` // lihat CertificateTemplateDefinition
+}
+
+// CertificateTemplateVersion isi template yang dibekukan; dirujuk oleh sertifikat
+type CertificateTemplateVersion struct {
+	ID         uuid.UUID  `db:"id"          json:"id"`
+	TemplateID uuid.UUID  `db:"template_id" json:"template_id"`
+	Version    int        `db:"version"     json:"version"`
+	Definition JSONB      `db:"definition"  json:"definition"`
+	CreatedBy  *uuid.UUID `db:"created_by"  json:"created_by"`
+	CreatedAt  time.Time  `db:"created_at"  json:"created_at"`
+
+	// Join fields
+	TemplateName  *string `db:"template_name"   json:"template_name,omitempty"`
+	CreatedByName *string `db:"created_by_name" json:"created_by_name,omitempty"`
+}
+
+type CertificateTemplateDetail struct {
+	CertificateTemplate
+	Versions []*CertificateTemplateVersion `json:"versions"`
+}
+
+// CertificateTemplateDefinition isi satu versi template. Teks boleh memuat placeholder
+// Go template, mis. {{.Student.FullName}} atau {{.School.HeadmasterName}}.
+type CertificateTemplateDefinition struct {
+	Language       string                         `json:"language"`         // id | en
+	Title          string                         `json:"title"`            // mis. "SURAT KETERANGAN PRESTASI"
+	HeaderImageURL string                         `json:"header_image_url"` // kop surat bergambar, opsional; menggantikan kop teks
+	PrimaryColor   string                         `json:"primary_color"`    // hex, mis. "#003366"
+	OpeningText    string                         `json:"opening_text"`     // sebelum data siswa
+	BodyText       string                         `json:"body_text"`        // sebelum tabel prestasi
+	ClosingText    string                         `json:"closing_text"`     // setelah tabel prestasi
+	Columns        []CertificateTemplateColumn    `json:"columns"`
+	Signatures     []CertificateTemplateSignature `json:"signatures"`
+}
+
+type CertificateTemplateColumn struct {
+	Field string  `json:"field"` // no | competition_name | organizer | category | rank | level | year
+	Label string  `json:"label"` // kosong = judul bawaan sesuai bahasa
+	Width float64 `json:"width"` // bobot relatif, diskalakan ke lebar halaman
+	Align string  `json:"align"` // L | C | R
+}
+
+// CertificateTemplateSignature satu blok tanda tangan; semua field boleh berisi placeholder
+type CertificateTemplateSignature struct {
+	Title string `json:"title"` // mis. "Kepala Sekolah,"
+	Name  string `json:"name"`
+	NIP   string `json:"nip"`
+}
+
+// DefaultCertificateTemplateDefinition tata letak bawaan (sama dengan template "Standar"
+// di migrasi 015); dipakai untuk sertifikat yang tidak merujuk versi template
+func DefaultCertificateTemplateDefinition() CertificateTemplateDefinition {
+	return CertificateTemplateDefinition{
+		Language:     TemplateLanguageID,
+		Title:        "SURAT KETERANGAN PRESTASI",
+		PrimaryColor: "#003366",
+		OpeningText:  "Yang bertanda tangan di bawah ini, Kepala Sekolah menerangkan bahwa siswa berikut:",
+		BodyText:     "Telah meraih prestasi sebagai berikut:",
+		ClosingText:  "Surat keterangan ini dibuat dengan sebenarnya untuk dapat dipergunakan sebagaimana mestinya.",
+		Columns: []CertificateTemplateColumn{
+			{Field: TemplateColumnNo, Label: "No", Width: 8, Align: "C"},
+			{Field: TemplateColumnCompetition, Label: "Nama Lomba", Width: 45, Align: "L"},
+			{Field: TemplateColumnOrganizer, Label: "Penyelenggara", Width: 35, Align: "L"},
+			{Field: TemplateColumnCategory, Label: "Kategori", Width: 22, Align: "C"},
+			{Field: TemplateColumnRank, Label: "Juara", Width: 18, Align: "C"},
+			{Field: TemplateColumnLevel, Label: "Tingkat", Width: 27, Align: "C"},
+			{Field: TemplateColumnYear, Label: "Tahun", Width: 15, Align: "C"},
+		},
+		Signatures: []CertificateTemplateSignature{
+			{Title: "Kepala Sekolah,", Name: "{{.School.HeadmasterName}}", NIP: "{{.School.HeadmasterNIP}}"},
+		},
+	}
+}
+
+// SaveCertificateTemplateRequest membuat template atau versi baru dari template
+type SaveCertificateTemplateRequest struct {
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	Definition  CertificateTemplateDefinition `json:"definition"`
+}
+
+// PreviewCertificateTemplateRequest pratinjau template yang belum disimpan
+type PreviewCertificateTemplateRequest struct {
+	Definition CertificateTemplateDefinition `json:"definition"`
+}
+
+// CertificateTemplateImage hasil upload gambar kop surat
+type CertificateTemplateImage struct {
+	HeaderImageURL string `json:"header_image_url"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status job di antrean
+const (
+	JobStatusPending = "pending" // menunggu dijalankan (termasuk menunggu retry)
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusDead    = "dead" // gagal setelah max_attempts, perlu retry manual
+)
+
+// Tipe job
+const (
+	JobTypeGenerateCertificatePDF = "certificate.generate_pdf"
+)
+
+type Job struct {
+	ID          uuid.UUID  `db:"id"           json:"id"`
+	Type        string     `db:"type"         json:"type"`
+	Payload     JSONB      `db:"payload"      json:"payload" swaggertype:"object"`
+	Status      string     `db:"status"       json:"status"`
+	Attempts    int        `db:"attempts"     json:"attempts"`
+	MaxAttempts int        `db:"max_attempts" json:"max_attempts"`
+	RunAt       time.Time  `db:"run_at"       json:"run_at"`
+	LockedAt    *time.Time `db:"locked_at"    json:"locked_at"`
+	LastError   *string    `db:"last_error"   json:"last_error"`
+	CreatedAt   time.Time  `db:"created_at"   json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"   json:"updated_at"`
+}
+
+type JobFilter struct {
+	Type    string
+	Status  string
+	Page    int
+	PerPage int
+}
+
+// CertificatePDFJobPayload payload job generate PDF sertifikat
+type CertificatePDFJobPayload struct {
+	CertificateID  uuid.UUID  `json:"certificate_id"`
+	RegenerationID *uuid.UUID `json:"regeneration_id,omitempty"` // diisi jika regenerasi oleh admin
+}
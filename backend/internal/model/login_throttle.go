@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Cakupan pembatasan percobaan login
+const (
+	LoginThrottleAccount = "account"
+	LoginThrottleIP      = "ip"
+)
+
+// LoginThrottle catatan percobaan login gagal untuk satu akun (email) atau satu IP
+type LoginThrottle struct {
+	Scope        string     `db:"scope"          json:"scope"`
+	Subject      string     `db:"subject"        json:"subject"` // email atau alamat IP
+	Failures     int        `db:"failures"       json:"failures"`
+	LastFailedAt time.Time  `db:"last_failed_at" json:"last_failed_at"`
+	LockedUntil  *time.Time `db:"locked_until"   json:"locked_until"`
+}
+
+// IsLocked true selama masa kunci belum berakhir
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
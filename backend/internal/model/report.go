@@ -0,0 +1,97 @@
+package model
+
+import "github.com/google/uuid"
+
+// Dimensi rincian laporan prestasi
+const (
+	ReportByLevel    = "level"
+	ReportByCategory = "category"
+	ReportByRank     = "rank"
+	ReportByClass    = "class"
+	ReportByGender   = "gender"
+)
+
+// ReportStatusAll nilai filter status untuk menghitung prestasi semua status
+const ReportStatusAll = "all"
+
+// ReportFilter filter bersama untuk laporan prestasi. Tanpa Status hanya prestasi
+// verified yang dihitung; "all" menghitung semua status.
+type ReportFilter struct {
+	FromYear   *int
+	ToYear     *int
+	Status     string
+	CategoryID *int
+	LevelID    *int
+	Class      string
+}
+
+// ReportSummary angka ringkasan untuk dashboard
+type ReportSummary struct {
+	TotalAchievements        int64            `db:"total_achievements"         json:"total_achievements"`
+	StudentsWithAchievements int64            `db:"students_with_achievements" json:"students_with_achievements"`
+	TotalStudents            int64            `db:"total_students"             json:"total_students"`
+	TeamAchievements         int64            `db:"team_achievements"          json:"team_achievements"`
+	AchievementsByStatus     map[string]int64 `db:"-"                          json:"achievements_by_status"`
+	CertificatesActive       int64            `db:"certificates_active"        json:"certificates_active"`
+	CertificatesRevoked      int64            `db:"certificates_revoked"       json:"certificates_revoked"`
+	CertificatesSuperseded   int64            `db:"certificates_superseded"    json:"certificates_superseded"`
+}
+
+// StatusCount jumlah prestasi per status verifikasi
+type StatusCount struct {
+	Status string `db:"status" json:"status"`
+	Total  int64  `db:"total"  json:"total"`
+}
+
+// YearlyCount jumlah prestasi per tahun lomba
+type YearlyCount struct {
+	Year     int   `db:"year"     json:"year"`
+	Total    int64 `db:"total"    json:"total"`
+	Students int64 `db:"students" json:"students"` // siswa berbeda yang meraih prestasi
+}
+
+// BreakdownItem jumlah prestasi untuk satu nilai dimensi
+type BreakdownItem struct {
+	Key      string `db:"key"      json:"key"`
+	Label    string `db:"label"    json:"label"`
+	Total    int64  `db:"total"    json:"total"`
+	Students int64  `db:"students" json:"students"`
+}
+
+// CertificateMonthly jumlah surat keterangan yang diterbitkan dan dicabut per bulan
+type CertificateMonthly struct {
+	Month   string `db:"month"   json:"month"` // YYYY-MM
+	Issued  int64  `db:"issued"  json:"issued"`
+	Revoked int64  `db:"revoked" json:"revoked"`
+}
+
+// Format file laporan tahunan
+const (
+	ReportFormatPDF  = "pdf"
+	ReportFormatXLSX = "xlsx"
+)
+
+// ReportAchievement satu prestasi verified di laporan tahunan
+type ReportAchievement struct {
+	ID              uuid.UUID `db:"id"`
+	CompetitionName string    `db:"competition_name"`
+	Organizer       string    `db:"organizer"`
+	Rank            string    `db:"rank"`
+	Year            int       `db:"year"`
+	TeamName        *string   `db:"team_name"`
+	LevelName       *string   `db:"level_name"`
+	CategoryID      *int      `db:"category_id"`
+	CategoryName    *string   `db:"category_name"`
+	StudentName     string    `db:"student_name"`
+	StudentNISN     string    `db:"student_nisn"`
+	StudentClass    string    `db:"student_class"`
+
+	Members []string `db:"-"` // kapten dan anggota prestasi tim
+}
+
+// ReportFile file laporan yang siap diunduh
+type ReportFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Jenis aturan poin
+const (
+	ScoringKindLevel    = "level"    // key: competition_levels.order_rank
+	ScoringKindRank     = "rank"     // key: kode peringkat ternormalisasi
+	ScoringKindCategory = "category" // key: achievement_categories.type
+)
+
+type ScoringRule struct {
+	ID          int        `db:"id"          json:"id"`
+	Kind        string     `db:"kind"        json:"kind"`
+	Key         string     `db:"key"         json:"key"`
+	Value       float64    `db:"value"       json:"value"`
+	Description string     `db:"description" json:"description"`
+	UpdatedBy   *uuid.UUID `db:"updated_by"  json:"updated_by"`
+	UpdatedAt   time.Time  `db:"updated_at"  json:"updated_at"`
+}
+
+type UpdateScoringRule struct {
+	Kind  string  `json:"kind"`
+	Key   string  `json:"key"`
+	Value float64 `json:"value"`
+}
+
+type UpdateScoringRulesRequest struct {
+	Rules []UpdateScoringRule `json:"rules"`
+}
+
+// ScoringInput prestasi terverifikasi beserta data yang dibutuhkan untuk menghitung poin
+type ScoringInput struct {
+	AchievementID   uuid.UUID `db:"achievement_id"`
+	StudentID       uuid.UUID `db:"student_id"`
+	StudentName     string    `db:"student_name"`
+	StudentNISN     string    `db:"student_nisn"`
+	StudentClass    string    `db:"student_class"`
+	YearGraduate    *int      `db:"year_graduate"`
+	CompetitionName string    `db:"competition_name"`
+	Year            int       `db:"year"`
+	Rank            string    `db:"rank"`
+	RankCode        *string   `db:"rank_code"`
+	LevelName       *string   `db:"level_name"`
+	LevelOrder      *int      `db:"level_order"`
+	CategoryName    *string   `db:"category_name"`
+	CategoryType    *string   `db:"category_type"`
+}
+
+// AchievementScore rincian poin satu prestasi
+type AchievementScore struct {
+	AchievementID   uuid.UUID `json:"achievement_id"`
+	CompetitionName string    `json:"competition_name"`
+	Year            int       `json:"year"`
+	LevelName       *string   `json:"level_name"`
+	Rank            string    `json:"rank"`
+	RankCode        string    `json:"rank_code"` // kosong jika peringkat tidak dikenali
+	CategoryName    *string   `json:"category_name"`
+	LevelPoints     float64   `json:"level_points"`
+	RankMultiplier  float64   `json:"rank_multiplier"`
+	CategoryWeight  float64   `json:"category_weight"`
+	Points          float64   `json:"points"`
+	Notes           []string  `json:"notes,omitempty"` // alasan poin nol atau berkurang
+}
+
+type StudentScore struct {
+	StudentID        uuid.UUID          `json:"student_id"`
+	NISN             string             `json:"nisn"`
+	FullName         string             `json:"full_name"`
+	Class            string             `json:"class"`
+	YearGraduate     *int               `json:"year_graduate"`
+	TotalPoints      float64            `json:"total_points"`
+	AchievementCount int                `json:"achievement_count"`
+	Breakdown        []AchievementScore `json:"breakdown"`
+}
+
+type LeaderboardEntry struct {
+	Position         int       `json:"position"` // peringkat sama untuk poin yang sama
+	StudentID        uuid.UUID `json:"student_id"`
+	NISN             string    `json:"nisn"`
+	FullName         string    `json:"full_name"`
+	Class            string    `json:"class"`
+	YearGraduate     *int      `json:"year_graduate"`
+	TotalPoints      float64   `json:"total_points"`
+	AchievementCount int       `json:"achievement_count"`
+}
+
+type LeaderboardFilter struct {
+	StudentID    string
+	Class        string
+	YearGraduate *int
+	Year         *int // tahun prestasi
+	Page         int
+	PerPage      int
+}
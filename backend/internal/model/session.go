@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alasan pencabutan sesi
+const (
+	SessionRevokedLogout     = "logout"
+	SessionRevokedLogoutAll  = "logout_all"
+	SessionRevokedAdmin      = "admin"
+	SessionRevokedTokenReuse = "token_reuse"
+	SessionRevokedDeactivate = "deactivated"
+	SessionRevokedPassword   = "password_reset"
+	SessionRevokedPwdChange  = "password_change"
+	SessionRevokedMFAReset   = "mfa_reset"
+	SessionRevokedRoleChange = "role_change"
+)
+
+// Session satu sesi login beserta refresh token yang sedang berlaku
+type Session struct {
+	ID               uuid.UUID  `db:"id"                 json:"id"`
+	UserID           uuid.UUID  `db:"user_id"            json:"user_id"`
+	RefreshTokenHash string     `db:"refresh_token_hash" json:"-"`
+	UserAgent        string     `db:"user_agent"         json:"user_agent"`
+	IPAddress        string     `db:"ip_address"         json:"ip_address"`
+	CreatedAt        time.Time  `db:"created_at"         json:"created_at"`
+	LastUsedAt       time.Time  `db:"last_used_at"       json:"last_used_at"`
+	ExpiresAt        time.Time  `db:"expires_at"         json:"expires_at"`
+	RevokedAt        *time.Time `db:"revoked_at"         json:"revoked_at"`
+	RevokedReason    *string    `db:"revoked_reason"     json:"revoked_reason"`
+
+	// Diisi service: sesi milik token yang sedang dipakai
+	Current bool `db:"-" json:"current"`
+
+	// Join field (FindActive): pemilik sesi wajib mengganti password
+	MustChangePassword bool `db:"must_change_password" json:"-"`
+}
+
+// IsActive true jika sesi belum dicabut dan belum kedaluwarsa
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -48,4 +48,29 @@ type StudentFilter struct {
 	YearGraduate *int
 	Page         int
 	PerPage      int
-}
\ No newline at end of file
+}
+// Aksi per baris import siswa
+const (
+	StudentImportCreate = "create"
+	StudentImportUpdate = "update"
+	StudentImportError  = "error"
+)
+
+// StudentImportRow hasil validasi satu baris file import
+type StudentImportRow struct {
+	Row      int               `json:"row"` // nomor baris di file (mulai 1)
+	NISN     string            `json:"nisn"`
+	FullName string            `json:"full_name"`
+	Action   string            `json:"action"` // create | update | error
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// StudentImportReport laporan import siswa; Committed false berarti dry-run
+type StudentImportReport struct {
+	Committed bool               `json:"committed"`
+	TotalRows int                `json:"total_rows"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Failed    int                `json:"failed"`
+	Rows      []StudentImportRow `json:"rows"`
+}
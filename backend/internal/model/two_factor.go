@@ -0,0 +1,31 @@
+package model
+
+// TwoFactorSetup data enrolment TOTP; secret hanya ditampilkan saat enrolment
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"` // data URI PNG dari otpauth_url
+}
+
+// TwoFactorStatus status 2FA user sendiri
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"` // role admin dan kepala sekolah
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// RecoveryCodesResponse recovery code baru; hanya ditampilkan sekali
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorCodeRequest kode 6 digit dari aplikasi authenticator
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// DisableTwoFactorRequest mematikan 2FA butuh password dan kode authenticator
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
@@ -15,41 +15,102 @@ const (
 )
 
 type User struct {
-	ID        uuid.UUID `db:"id"         json:"id"`
-	Name      string    `db:"name"       json:"name"`
-	Email     string    `db:"email"      json:"email"`
-	Password  string    `db:"password"   json:"-"` // never expose hash
-	Role      Role      `db:"role"       json:"role"`
-	IsActive  bool      `db:"is_active"  json:"is_active"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID                 uuid.UUID  `db:"id"                   json:"id"`
+	Name               string     `db:"name"                 json:"name"`
+	Email              string     `db:"email"                json:"email"`
+	Password           string     `db:"password"             json:"-"` // never expose hash
+	Role               Role       `db:"role"                 json:"role"`
+	IsActive           bool       `db:"is_active"            json:"is_active"`
+	MustChangePassword bool       `db:"must_change_password" json:"must_change_password"`
+	PasswordChangedAt  *time.Time `db:"password_changed_at"  json:"password_changed_at"`
+	TOTPSecret         *string    `db:"totp_secret"          json:"-"`
+	TOTPEnabledAt      *time.Time `db:"totp_enabled_at"      json:"-"`
+	TOTPLastStep       int64      `db:"totp_last_step"       json:"-"`
+	CreatedAt          time.Time  `db:"created_at"           json:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"           json:"updated_at"`
+}
+
+// TwoFactorEnabled true jika enrolment TOTP sudah dikonfirmasi
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
 }
 
 // DTO untuk response login
 type UserResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Role      Role      `json:"role"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                 uuid.UUID  `json:"id"`
+	Name               string     `json:"name"`
+	Email              string     `json:"email"`
+	Role               Role       `json:"role"`
+	IsActive           bool       `json:"is_active"`
+	MustChangePassword bool       `json:"must_change_password"` // true: hanya PUT /auth/password yang diizinkan
+	PasswordChangedAt  *time.Time `json:"password_changed_at"`
+	TwoFactorEnabled   bool       `json:"two_factor_enabled"`
+	CreatedAt          time.Time  `json:"created_at"`
 }
 
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		Role:      u.Role,
-		IsActive:  u.IsActive,
-		CreatedAt: u.CreatedAt,
+		ID:                 u.ID,
+		Name:               u.Name,
+		Email:              u.Email,
+		Role:               u.Role,
+		IsActive:           u.IsActive,
+		MustChangePassword: u.MustChangePassword,
+		PasswordChangedAt:  u.PasswordChangedAt,
+		TwoFactorEnabled:   u.TwoFactorEnabled(),
+		CreatedAt:          u.CreatedAt,
 	}
 }
 
+type UserFilter struct {
+	Search   string // nama atau email
+	Role     string
+	IsActive *bool
+	Page     int
+	PerPage  int
+}
+
+// UpdateUserRequest mengubah nama dan role user (admin)
+type UpdateUserRequest struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+}
+
+// ResetPasswordRequest reset password oleh admin; kosongkan password untuk
+// membuat password sementara acak
+type ResetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// ResetPasswordResponse password sementara hanya ditampilkan sekali
+type ResetPasswordResponse struct {
+	User              UserResponse `json:"user"`
+	TemporaryPassword string       `json:"temporary_password,omitempty"`
+}
+
+// ChangePasswordRequest penggantian password oleh user sendiri
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// IsValidRole true untuk role yang dikenal
+func IsValidRole(role Role) bool {
+	return role == RoleOperator || role == RoleAdmin || role == RoleHeadmaster
+}
+
+// RequiresTwoFactor role yang wajib login dua langkah karena bisa mencabut
+// sertifikat dan mengelola user
+func (r Role) RequiresTwoFactor() bool {
+	return r == RoleAdmin || r == RoleHeadmaster
+}
+
 // JWT Claims custom
 type JWTClaims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
-	Name   string `json:"name"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Name      string `json:"name"`
+	SessionID string `json:"session_id"`
+	Type      string `json:"type"` // diisi saat validasi: "access" | "refresh" | "mfa"
 }
\ No newline at end of file
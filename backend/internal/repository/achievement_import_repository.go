@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+type AchievementImportRepository interface {
+	CreateBatch(ctx context.Context, batch *model.AchievementImportBatch, rows []*model.AchievementImportRow) error
+	FindAllBatches(ctx context.Context, filter model.AchievementImportFilter) ([]*model.AchievementImportBatch, int64, error)
+	FindBatchByID(ctx context.Context, id uuid.UUID) (*model.AchievementImportBatch, error)
+	FindRows(ctx context.Context, batchID uuid.UUID) ([]*model.AchievementImportRow, error)
+	FindRowByID(ctx context.Context, batchID, rowID uuid.UUID) (*model.AchievementImportRow, error)
+	UpdateRow(ctx context.Context, row *model.AchievementImportRow) error
+	Approve(ctx context.Context, batchID, reviewedBy uuid.UUID, items []model.ImportedAchievement) (bool, error)
+	Discard(ctx context.Context, batchID, reviewedBy uuid.UUID) (bool, error)
+}
+
+type achievementImportRepository struct {
+	db *sqlx.DB
+}
+
+func NewAchievementImportRepository(db *sqlx.DB) AchievementImportRepository {
+	return &achievementImportRepository{db: db}
+}
+
+const achievementImportBatchSelect = `
+	SELECT b.*, u.name AS created_by_name,
+	       COUNT(r.id) FILTER (WHERE r.status = 'ready')        AS ready_rows,
+	       COUNT(r.id) FILTER (WHERE r.status = 'needs_review') AS review_rows,
+	       COUNT(r.id) FILTER (WHERE r.status = 'invalid')      AS invalid_rows,
+	       COUNT(r.id) FILTER (WHERE r.status = 'skipped')      AS skipped_rows,
+	       COUNT(r.id) FILTER (WHERE r.status = 'imported')     AS imported_rows
+	FROM achievement_import_batches b
+	LEFT JOIN users u ON b.created_by = u.id
+	LEFT JOIN achievement_import_rows r ON r.batch_id = b.id
+`
+
+func (r *achievementImportRepository) CreateBatch(ctx context.Context, batch *model.AchievementImportBatch, rows []*model.AchievementImportRow) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExecContext(ctx, `
+		INSERT INTO achievement_import_batches (id, file_name, status, total_rows, created_by, created_at)
+		VALUES (:id, :file_name, :status, :total_rows, :created_by, NOW())
+	`, batch); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO achievement_import_rows (
+			id, batch_id, line_number, nisn, student_name, class, competition_name, organizer,
+			category_name, level_name, rank, year, description, student_id, match_method, match_score,
+			category_id, level_id, duplicate_of, errors, warnings, confirmed, skipped, status, updated_at)
+		VALUES (
+			:id, :batch_id, :line_number, :nisn, :student_name, :class, :competition_name, :organizer,
+			:category_name, :level_name, :rank, :year, :description, :student_id, :match_method, :match_score,
+			:category_id, :level_id, :duplicate_of, :errors, :warnings, :confirmed, :skipped, :status, NOW())
+	`
+	for _, row := range rows {
+		if _, err := tx.NamedExecContext(ctx, query, row); err != nil {
+			return fmt.Errorf("baris %d: %w", row.LineNumber, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *achievementImportRepository) FindAllBatches(ctx context.Context, filter model.AchievementImportFilter) ([]*model.AchievementImportBatch, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	where := "1=1"
+	args := []interface{}{}
+	if filter.Status != "" {
+		where = "b.status = $1"
+		args = append(args, filter.Status)
+	}
+
+	var total int64
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM achievement_import_batches b WHERE %s", where)
+	if err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	query := fmt.Sprintf(`%s
+		WHERE %s
+		GROUP BY b.id, u.name
+		ORDER BY b.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, achievementImportBatchSelect, where, len(args)+1, len(args)+2)
+	args = append(args, filter.PerPage, offset)
+
+	var batches []*model.AchievementImportBatch
+	if err := conn(ctx, r.db).SelectContext(ctx, &batches, query, args...); err != nil {
+		return nil, 0, err
+	}
+	return batches, total, nil
+}
+
+func (r *achievementImportRepository) FindBatchByID(ctx context.Context, id uuid.UUID) (*model.AchievementImportBatch, error) {
+	var batch model.AchievementImportBatch
+	query := achievementImportBatchSelect + `
+		WHERE b.id = $1
+		GROUP BY b.id, u.name
+	`
+	err := conn(ctx, r.db).GetContext(ctx, &batch, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+const achievementImportRowSelect = `
+	SELECT r.*, s.full_name AS matched_student_name, s.nisn AS matched_student_nisn,
+	       s.class AS matched_student_class
+	FROM achievement_import_rows r
+	LEFT JOIN students s ON r.student_id = s.id
+`
+
+func (r *achievementImportRepository) FindRows(ctx context.Context, batchID uuid.UUID) ([]*model.AchievementImportRow, error) {
+	var rows []*model.AchievementImportRow
+	query := achievementImportRowSelect + `
+		WHERE r.batch_id = $1
+		ORDER BY r.line_number
+	`
+	if err := conn(ctx, r.db).SelectContext(ctx, &rows, query, batchID); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *achievementImportRepository) FindRowByID(ctx context.Context, batchID, rowID uuid.UUID) (*model.AchievementImportRow, error) {
+	var row model.AchievementImportRow
+	query := achievementImportRowSelect + `
+		WHERE r.batch_id = $1 AND r.id = $2
+	`
+	err := conn(ctx, r.db).GetContext(ctx, &row, query, batchID, rowID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (r *achievementImportRepository) UpdateRow(ctx context.Context, row *model.AchievementImportRow) error {
+	query := `
+		UPDATE achievement_import_rows SET
+			student_id = :student_id, match_method = :match_method, match_score = :match_score,
+			category_id = :category_id, level_id = :level_id, duplicate_of = :duplicate_of,
+			errors = :errors, warnings = :warnings, confirmed = :confirmed, skipped = :skipped,
+			status = :status, updated_at = NOW()
+		WHERE id = :id AND status <> 'imported'
+	`
+	_, err := conn(ctx, r.db).NamedExecContext(ctx, query, row)
+	return err
+}
+
+// Approve membuat prestasi dari baris yang siap dalam satu transaksi. Mengembalikan
+// false jika batch sudah tidak pending atau ada baris yang berubah status sejak dibaca.
+func (r *achievementImportRepository) Approve(ctx context.Context, batchID, reviewedBy uuid.UUID, items []model.ImportedAchievement) (bool, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE achievement_import_batches
+		SET status = 'approved', reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, batchID, reviewedBy)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	// Kunci baris batch agar UpdateRow yang berjalan bersamaan menunggu transaksi ini
+	if _, err := tx.ExecContext(ctx,
+		"SELECT id FROM achievement_import_rows WHERE batch_id = $1 FOR UPDATE", batchID); err != nil {
+		return false, err
+	}
+
+	// Batch tidak boleh disetujui selama masih ada baris yang belum beres, atau baris
+	// siap yang tidak ikut diimport (berubah jadi ready setelah items disusun)
+	rowIDs := make([]string, len(items))
+	for i, item := range items {
+		rowIDs[i] = item.RowID.String()
+	}
+	var unresolved int
+	if err := tx.GetContext(ctx, &unresolved, `
+		SELECT COUNT(*) FROM achievement_import_rows
+		WHERE batch_id = $1
+		  AND (status IN ('needs_review', 'invalid') OR (status = 'ready' AND id <> ALL($2::uuid[])))
+	`, batchID, rowIDs); err != nil {
+		return false, err
+	}
+	if unresolved > 0 {
+		return false, nil
+	}
+
+	for _, item := range items {
+		if err := insertAchievement(ctx, tx, item.Achievement); err != nil {
+			return false, err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE achievement_import_rows SET status = 'imported', achievement_id = $1, updated_at = NOW()
+			WHERE id = $2 AND batch_id = $3 AND status = 'ready'
+		`, item.Achievement.ID, item.RowID, batchID)
+		if err != nil {
+			return false, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return false, nil
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *achievementImportRepository) Discard(ctx context.Context, batchID, reviewedBy uuid.UUID) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE achievement_import_batches
+		SET status = 'discarded', reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, batchID, reviewedBy)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Pastikan interface terpenuhi
+var _ AchievementImportRepository = (*achievementImportRepository)(nil)
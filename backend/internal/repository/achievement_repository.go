@@ -7,9 +7,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 )
 
 type AchievementRepository interface {
@@ -17,18 +17,27 @@ type AchievementRepository interface {
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Achievement, error)
 	FindByIDWithAttachments(ctx context.Context, id uuid.UUID) (*model.AchievementWithAttachments, error)
 	FindByStudentID(ctx context.Context, studentID uuid.UUID) ([]*model.Achievement, error)
+	FindByStudentIDs(ctx context.Context, studentIDs []string) ([]*model.Achievement, error)
 	Create(ctx context.Context, achievement *model.Achievement) error
-	Update(ctx context.Context, achievement *model.Achievement) error
+	Update(ctx context.Context, achievement *model.Achievement, event *model.AchievementStatusEvent) error
+	UpdateParticipants(ctx context.Context, achievement *model.Achievement, event *model.AchievementStatusEvent) error
+	SetPrimaryStudent(ctx context.Context, id, studentID uuid.UUID) error
+	ChangeStatus(ctx context.Context, event *model.AchievementStatusEvent) error
+	FindStatusEvents(ctx context.Context, achievementID uuid.UUID) ([]*model.AchievementStatusEvent, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 
 	// Attachments
 	AddAttachment(ctx context.Context, att *model.AchievementAttachment) error
 	DeleteAttachment(ctx context.Context, id uuid.UUID) (*model.AchievementAttachment, error)
 	FindAttachmentByID(ctx context.Context, id uuid.UUID) (*model.AchievementAttachment, error)
+	FindAttachmentsByAchievementIDs(ctx context.Context, achievementIDs []string) (map[uuid.UUID][]model.AchievementAttachment, error)
 
 	// References
 	FindAllCategories(ctx context.Context) ([]*model.AchievementCategory, error)
 	FindAllLevels(ctx context.Context) ([]*model.CompetitionLevel, error)
+	FindAllRanks(ctx context.Context) ([]*model.Rank, error)
+	FindRankAliases(ctx context.Context) ([]*model.RankAlias, error)
+	FindUnmatchedRanks(ctx context.Context) ([]*model.UnmatchedRank, error)
 }
 
 type achievementRepository struct {
@@ -52,10 +61,18 @@ func (r *achievementRepository) FindAll(ctx context.Context, filter model.Achiev
 	argIdx := 1
 
 	if filter.StudentID != "" {
-		conditions = append(conditions, fmt.Sprintf("a.student_id = $%d", argIdx))
+		// Termasuk prestasi tim yang diikuti siswa
+		conditions = append(conditions, fmt.Sprintf(
+			"(a.student_id = $%d OR a.id IN (SELECT achievement_id FROM achievement_participants WHERE student_id = $%d))",
+			argIdx, argIdx))
 		args = append(args, filter.StudentID)
 		argIdx++
 	}
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("a.status = $%d", argIdx))
+		args = append(args, filter.Status)
+		argIdx++
+	}
 	if filter.CategoryID != nil {
 		conditions = append(conditions, fmt.Sprintf("a.category_id = $%d", argIdx))
 		args = append(args, *filter.CategoryID)
@@ -66,6 +83,16 @@ func (r *achievementRepository) FindAll(ctx context.Context, filter model.Achiev
 		args = append(args, *filter.LevelID)
 		argIdx++
 	}
+	if filter.RankID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.rank_id = $%d", argIdx))
+		args = append(args, *filter.RankID)
+		argIdx++
+	}
+	if filter.RankKind != "" {
+		conditions = append(conditions, fmt.Sprintf("a.rank_id IN (SELECT id FROM ranks WHERE kind = $%d)", argIdx))
+		args = append(args, filter.RankKind)
+		argIdx++
+	}
 	if filter.Year != nil {
 		conditions = append(conditions, fmt.Sprintf("a.year = $%d", argIdx))
 		args = append(args, *filter.Year)
@@ -82,17 +109,19 @@ func (r *achievementRepository) FindAll(ctx context.Context, filter model.Achiev
 
 	var total int64
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM achievements a WHERE %s", where)
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
 	offset := (filter.Page - 1) * filter.PerPage
 	query := fmt.Sprintf(`
 		SELECT a.*, ac.name as category_name, cl.name as level_name,
+		       rk.code as rank_code, rk.name as rank_name,
 		       s.full_name as student_name, s.nisn as student_nisn
 		FROM achievements a
 		LEFT JOIN achievement_categories ac ON a.category_id = ac.id
 		LEFT JOIN competition_levels cl ON a.level_id = cl.id
+		LEFT JOIN ranks rk ON a.rank_id = rk.id
 		LEFT JOIN students s ON a.student_id = s.id
 		WHERE %s
 		ORDER BY a.year DESC, a.created_at DESC
@@ -102,7 +131,10 @@ func (r *achievementRepository) FindAll(ctx context.Context, filter model.Achiev
 	args = append(args, filter.PerPage, offset)
 
 	var achievements []*model.Achievement
-	if err := r.db.SelectContext(ctx, &achievements, query, args...); err != nil {
+	if err := conn(ctx, r.db).SelectContext(ctx, &achievements, query, args...); err != nil {
+		return nil, 0, err
+	}
+	if err := r.attachParticipants(ctx, achievements); err != nil {
 		return nil, 0, err
 	}
 
@@ -113,20 +145,25 @@ func (r *achievementRepository) FindByID(ctx context.Context, id uuid.UUID) (*mo
 	var a model.Achievement
 	query := `
 		SELECT a.*, ac.name as category_name, cl.name as level_name,
+		       rk.code as rank_code, rk.name as rank_name,
 		       s.full_name as student_name, s.nisn as student_nisn
 		FROM achievements a
 		LEFT JOIN achievement_categories ac ON a.category_id = ac.id
 		LEFT JOIN competition_levels cl ON a.level_id = cl.id
+		LEFT JOIN ranks rk ON a.rank_id = rk.id
 		LEFT JOIN students s ON a.student_id = s.id
 		WHERE a.id = $1
 	`
-	err := r.db.GetContext(ctx, &a, query, id)
+	err := conn(ctx, r.db).GetContext(ctx, &a, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, err
 	}
+	if err := r.attachParticipants(ctx, []*model.Achievement{&a}); err != nil {
+		return nil, err
+	}
 	return &a, nil
 }
 
@@ -150,44 +187,260 @@ func (r *achievementRepository) FindByIDWithAttachments(ctx context.Context, id
 func (r *achievementRepository) FindByStudentID(ctx context.Context, studentID uuid.UUID) ([]*model.Achievement, error) {
 	var achievements []*model.Achievement
 	query := `
-		SELECT a.*, ac.name as category_name, cl.name as level_name
+		SELECT a.*, ac.name as category_name, cl.name as level_name,
+		       rk.code as rank_code, rk.name as rank_name
 		FROM achievements a
 		LEFT JOIN achievement_categories ac ON a.category_id = ac.id
 		LEFT JOIN competition_levels cl ON a.level_id = cl.id
+		LEFT JOIN ranks rk ON a.rank_id = rk.id
 		WHERE a.student_id = $1
+		   OR a.id IN (SELECT achievement_id FROM achievement_participants WHERE student_id = $1)
 		ORDER BY a.year DESC
 	`
-	if err := r.db.SelectContext(ctx, &achievements, query, studentID); err != nil {
+	if err := conn(ctx, r.db).SelectContext(ctx, &achievements, query, studentID); err != nil {
+		return nil, err
+	}
+	if err := r.attachParticipants(ctx, achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+func (r *achievementRepository) FindByStudentIDs(ctx context.Context, studentIDs []string) ([]*model.Achievement, error) {
+	var achievements []*model.Achievement
+	query := `
+		SELECT a.*, ac.name as category_name, cl.name as level_name,
+		       rk.code as rank_code, rk.name as rank_name
+		FROM achievements a
+		LEFT JOIN achievement_categories ac ON a.category_id = ac.id
+		LEFT JOIN competition_levels cl ON a.level_id = cl.id
+		LEFT JOIN ranks rk ON a.rank_id = rk.id
+		WHERE a.student_id = ANY($1::uuid[])
+		ORDER BY a.year DESC
+	`
+	if err := conn(ctx, r.db).SelectContext(ctx, &achievements, query, studentIDs); err != nil {
 		return nil, err
 	}
 	return achievements, nil
 }
 
 func (r *achievementRepository) Create(ctx context.Context, achievement *model.Achievement) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertAchievement(ctx, tx, achievement); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertAchievement menyimpan prestasi beserta anggota tim dan event pembuatan di riwayat
+// status. Dipakai juga saat menyetujui batch import agar berjalan di transaksinya.
+func insertAchievement(ctx context.Context, tx *repoTx, achievement *model.Achievement) error {
 	query := `
 		INSERT INTO achievements (id, student_id, competition_name, organizer, category_id,
-		                          rank, level_id, year, description, created_by, created_at, updated_at)
+		                          rank, rank_id, level_id, year, description, status, team_name,
+		                          created_by, created_at, updated_at)
 		VALUES (:id, :student_id, :competition_name, :organizer, :category_id,
-		        :rank, :level_id, :year, :description, :created_by, NOW(), NOW())
+		        :rank, :rank_id, :level_id, :year, :description, :status, :team_name,
+		        :created_by, NOW(), NOW())
 	`
-	_, err := r.db.NamedExecContext(ctx, query, achievement)
-	return err
+	if _, err := tx.NamedExecContext(ctx, query, achievement); err != nil {
+		return err
+	}
+
+	if err := insertParticipants(ctx, tx, achievement); err != nil {
+		return err
+	}
+
+	return insertAchievementStatusEvent(ctx, tx, &model.AchievementStatusEvent{
+		ID:            uuid.New(),
+		AchievementID: achievement.ID,
+		ToStatus:      achievement.Status,
+		ActorID:       achievement.CreatedBy,
+	})
 }
 
-func (r *achievementRepository) Update(ctx context.Context, achievement *model.Achievement) error {
+// Update menyimpan perubahan data prestasi. Jika event tidak nil, status ikut
+// berubah dalam transaksi yang sama (prestasi yang diubah harus diverifikasi ulang).
+func (r *achievementRepository) Update(ctx context.Context, achievement *model.Achievement, event *model.AchievementStatusEvent) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE achievements SET
 			competition_name = :competition_name, organizer = :organizer,
-			category_id = :category_id, rank = :rank, level_id = :level_id,
-			year = :year, description = :description, updated_at = NOW()
+			category_id = :category_id, rank = :rank, rank_id = :rank_id, level_id = :level_id,
+			year = :year, description = :description, team_name = :team_name, updated_at = NOW()
 		WHERE id = :id
 	`
-	_, err := r.db.NamedExecContext(ctx, query, achievement)
+	if _, err := tx.NamedExecContext(ctx, query, achievement); err != nil {
+		return err
+	}
+
+	if event != nil {
+		if err := changeAchievementStatus(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateParticipants mengganti seluruh anggota tim beserta siswa utama dan nama tim
+func (r *achievementRepository) UpdateParticipants(ctx context.Context, achievement *model.Achievement, event *model.AchievementStatusEvent) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExecContext(ctx, `
+		UPDATE achievements SET student_id = :student_id, team_name = :team_name, updated_at = NOW()
+		WHERE id = :id
+	`, achievement); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM achievement_participants WHERE achievement_id = $1", achievement.ID); err != nil {
+		return err
+	}
+	if err := insertParticipants(ctx, tx, achievement); err != nil {
+		return err
+	}
+
+	if event != nil {
+		if err := changeAchievementStatus(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertParticipants(ctx context.Context, tx *repoTx, achievement *model.Achievement) error {
+	for i := range achievement.Participants {
+		p := &achievement.Participants[i]
+		p.AchievementID = achievement.ID
+		if _, err := tx.NamedExecContext(ctx, `
+			INSERT INTO achievement_participants (achievement_id, student_id, role, created_at)
+			VALUES (:achievement_id, :student_id, :role, NOW())
+		`, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findParticipants mengambil anggota tim beberapa prestasi sekaligus, kapten lebih dulu
+func findParticipants(ctx context.Context, q sqlx.QueryerContext, achievementIDs []string) (map[uuid.UUID][]model.AchievementParticipant, error) {
+	var participants []model.AchievementParticipant
+	if err := sqlx.SelectContext(ctx, q, &participants, `
+		SELECT p.*, s.full_name AS student_name, s.nisn AS student_nisn, s.class AS student_class
+		FROM achievement_participants p
+		JOIN students s ON p.student_id = s.id
+		WHERE p.achievement_id = ANY($1::uuid[])
+		ORDER BY CASE p.role WHEN 'captain' THEN 0 WHEN 'member' THEN 1 ELSE 2 END, s.full_name
+	`, achievementIDs); err != nil {
+		return nil, err
+	}
+
+	byAchievement := make(map[uuid.UUID][]model.AchievementParticipant)
+	for _, p := range participants {
+		byAchievement[p.AchievementID] = append(byAchievement[p.AchievementID], p)
+	}
+	return byAchievement, nil
+}
+
+// attachParticipants mengisi anggota tim untuk prestasi tim di dalam daftar
+func (r *achievementRepository) attachParticipants(ctx context.Context, achievements []*model.Achievement) error {
+	var ids []string
+	for _, a := range achievements {
+		if a.TeamName != nil {
+			ids = append(ids, a.ID.String())
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	byAchievement, err := findParticipants(ctx, conn(ctx, r.db), ids)
+	if err != nil {
+		return err
+	}
+	for _, a := range achievements {
+		a.Participants = byAchievement[a.ID]
+	}
+	return nil
+}
+
+func (r *achievementRepository) ChangeStatus(ctx context.Context, event *model.AchievementStatusEvent) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := changeAchievementStatus(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *achievementRepository) FindStatusEvents(ctx context.Context, achievementID uuid.UUID) ([]*model.AchievementStatusEvent, error) {
+	var events []*model.AchievementStatusEvent
+	query := `
+		SELECT e.*, u.name as actor_name
+		FROM achievement_status_events e
+		LEFT JOIN users u ON e.actor_id = u.id
+		WHERE e.achievement_id = $1
+		ORDER BY e.created_at ASC
+	`
+	if err := conn(ctx, r.db).SelectContext(ctx, &events, query, achievementID); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func changeAchievementStatus(ctx context.Context, tx *repoTx, event *model.AchievementStatusEvent) error {
+	result, err := tx.ExecContext(ctx,
+		"UPDATE achievements SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3",
+		event.ToStatus, event.AchievementID, event.FromStatus)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New("status prestasi sudah berubah, muat ulang data")
+	}
+
+	return insertAchievementStatusEvent(ctx, tx, event)
+}
+
+func insertAchievementStatusEvent(ctx context.Context, tx *repoTx, event *model.AchievementStatusEvent) error {
+	_, err := tx.NamedExecContext(ctx, `
+		INSERT INTO achievement_status_events (id, achievement_id, from_status, to_status, comment, actor_id, created_at)
+		VALUES (:id, :achievement_id, :from_status, :to_status, :comment, :actor_id, NOW())
+	`, event)
+	return err
+}
+
+// SetPrimaryStudent mengganti siswa utama prestasi tim tanpa mengubah anggotanya
+func (r *achievementRepository) SetPrimaryStudent(ctx context.Context, id, studentID uuid.UUID) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE achievements SET student_id = $1, updated_at = NOW() WHERE id = $2", studentID, id)
 	return err
 }
 
 func (r *achievementRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM achievements WHERE id = $1", id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM achievements WHERE id = $1", id)
 	return err
 }
 
@@ -196,13 +449,13 @@ func (r *achievementRepository) AddAttachment(ctx context.Context, att *model.Ac
 		INSERT INTO achievement_attachments (id, achievement_id, file_url, file_name, file_type, label, uploaded_at)
 		VALUES (:id, :achievement_id, :file_url, :file_name, :file_type, :label, NOW())
 	`
-	_, err := r.db.NamedExecContext(ctx, query, att)
+	_, err := conn(ctx, r.db).NamedExecContext(ctx, query, att)
 	return err
 }
 
 func (r *achievementRepository) FindAttachmentByID(ctx context.Context, id uuid.UUID) (*model.AchievementAttachment, error) {
 	var att model.AchievementAttachment
-	err := r.db.GetContext(ctx, &att, "SELECT * FROM achievement_attachments WHERE id = $1", id)
+	err := conn(ctx, r.db).GetContext(ctx, &att, "SELECT * FROM achievement_attachments WHERE id = $1", id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -217,27 +470,66 @@ func (r *achievementRepository) DeleteAttachment(ctx context.Context, id uuid.UU
 	if err != nil || att == nil {
 		return nil, err
 	}
-	_, err = r.db.ExecContext(ctx, "DELETE FROM achievement_attachments WHERE id = $1", id)
+	_, err = conn(ctx, r.db).ExecContext(ctx, "DELETE FROM achievement_attachments WHERE id = $1", id)
 	return att, err
 }
 
 func (r *achievementRepository) findAttachmentsByAchievementID(ctx context.Context, achievementID uuid.UUID) ([]model.AchievementAttachment, error) {
 	var attachments []model.AchievementAttachment
-	err := r.db.SelectContext(ctx, &attachments,
+	err := conn(ctx, r.db).SelectContext(ctx, &attachments,
 		"SELECT * FROM achievement_attachments WHERE achievement_id = $1 ORDER BY uploaded_at ASC",
 		achievementID,
 	)
 	return attachments, err
 }
 
+func (r *achievementRepository) FindAttachmentsByAchievementIDs(ctx context.Context, achievementIDs []string) (map[uuid.UUID][]model.AchievementAttachment, error) {
+	byAchievement := make(map[uuid.UUID][]model.AchievementAttachment)
+	if len(achievementIDs) == 0 {
+		return byAchievement, nil
+	}
+
+	var attachments []model.AchievementAttachment
+	if err := conn(ctx, r.db).SelectContext(ctx, &attachments, `
+		SELECT * FROM achievement_attachments
+		WHERE achievement_id = ANY($1::uuid[])
+		ORDER BY uploaded_at ASC
+	`, achievementIDs); err != nil {
+		return nil, err
+	}
+
+	for _, att := range attachments {
+		byAchievement[att.AchievementID] = append(byAchievement[att.AchievementID], att)
+	}
+	return byAchievement, nil
+}
+
 func (r *achievementRepository) FindAllCategories(ctx context.Context) ([]*model.AchievementCategory, error) {
 	var categories []*model.AchievementCategory
-	err := r.db.SelectContext(ctx, &categories, "SELECT * FROM achievement_categories ORDER BY id")
+	err := conn(ctx, r.db).SelectContext(ctx, &categories, "SELECT * FROM achievement_categories ORDER BY id")
 	return categories, err
 }
 
 func (r *achievementRepository) FindAllLevels(ctx context.Context) ([]*model.CompetitionLevel, error) {
 	var levels []*model.CompetitionLevel
-	err := r.db.SelectContext(ctx, &levels, "SELECT * FROM competition_levels ORDER BY order_rank")
+	err := conn(ctx, r.db).SelectContext(ctx, &levels, "SELECT * FROM competition_levels ORDER BY order_rank")
 	return levels, err
-}
\ No newline at end of file
+}
+
+func (r *achievementRepository) FindAllRanks(ctx context.Context) ([]*model.Rank, error) {
+	var ranks []*model.Rank
+	err := conn(ctx, r.db).SelectContext(ctx, &ranks, "SELECT * FROM ranks ORDER BY order_rank, id")
+	return ranks, err
+}
+
+func (r *achievementRepository) FindRankAliases(ctx context.Context) ([]*model.RankAlias, error) {
+	var aliases []*model.RankAlias
+	err := conn(ctx, r.db).SelectContext(ctx, &aliases, "SELECT * FROM rank_aliases")
+	return aliases, err
+}
+
+func (r *achievementRepository) FindUnmatchedRanks(ctx context.Context) ([]*model.UnmatchedRank, error) {
+	var unmatched []*model.UnmatchedRank
+	err := conn(ctx, r.db).SelectContext(ctx, &unmatched, "SELECT * FROM achievement_rank_unmatched ORDER BY total DESC, rank")
+	return unmatched, err
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+// auditChainLockKey kunci advisory lock yang menserialkan penambahan entri audit
+const auditChainLockKey = 7263001
+
+type AuditRepository interface {
+	Append(ctx context.Context, entry *model.AuditLog) error
+	FindAll(ctx context.Context, filter model.AuditLogFilter) ([]*model.AuditLog, int64, error)
+	FindAfter(ctx context.Context, afterID int64, limit int) ([]*model.AuditLog, error)
+}
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Append menyambung entri ke ujung rantai. PrevHash dan Hash diisi di sini,
+// di bawah advisory lock agar dua entri tidak menunjuk ke prev_hash yang sama.
+func (r *auditRepository) Append(ctx context.Context, entry *model.AuditLog) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditChainLockKey); err != nil {
+		return err
+	}
+
+	var prevHash string
+	err = tx.GetContext(ctx, &prevHash, "SELECT hash FROM audit_logs ORDER BY id DESC LIMIT 1")
+	if errors.Is(err, sql.ErrNoRows) {
+		prevHash = model.AuditGenesisHash
+	} else if err != nil {
+		return err
+	}
+
+	entry.PrevHash = prevHash
+	entry.Hash, err = entry.ComputeHash()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowxContext(ctx, `
+		INSERT INTO audit_logs (occurred_at, actor_id, actor_name, actor_role, action, entity_type, entity_id,
+		                        before, after, ip_address, request_id, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`, entry.OccurredAt, entry.ActorID, entry.ActorName, entry.ActorRole, entry.Action, entry.EntityType, entry.EntityID,
+		entry.Before, entry.After, entry.IPAddress, entry.RequestID, entry.PrevHash, entry.Hash,
+	).Scan(&entry.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *auditRepository) FindAll(ctx context.Context, filter model.AuditLogFilter) ([]*model.AuditLog, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.Action != "" {
+		conditions = append(conditions, fmt.Sprintf("action = $%d", argIdx))
+		args = append(args, filter.Action)
+		argIdx++
+	}
+	if filter.EntityType != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", argIdx))
+		args = append(args, filter.EntityType)
+		argIdx++
+	}
+	if filter.EntityID != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", argIdx))
+		args = append(args, filter.EntityID)
+		argIdx++
+	}
+	if filter.ActorID != "" {
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", argIdx))
+		args = append(args, filter.ActorID)
+		argIdx++
+	}
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", argIdx))
+		args = append(args, *filter.From)
+		argIdx++
+	}
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("occurred_at < $%d", argIdx))
+		args = append(args, *filter.To)
+		argIdx++
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COUNT(*) FROM audit_logs WHERE %s", where), args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	query := fmt.Sprintf(`
+		SELECT * FROM audit_logs
+		WHERE %s
+		ORDER BY id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, argIdx, argIdx+1)
+
+	args = append(args, filter.PerPage, offset)
+
+	var logs []*model.AuditLog
+	if err := conn(ctx, r.db).SelectContext(ctx, &logs, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// FindAfter membaca rantai secara berurutan per batch untuk verifikasi
+func (r *auditRepository) FindAfter(ctx context.Context, afterID int64, limit int) ([]*model.AuditLog, error) {
+	var logs []*model.AuditLog
+	err := conn(ctx, r.db).SelectContext(ctx, &logs,
+		"SELECT * FROM audit_logs WHERE id > $1 ORDER BY id LIMIT $2", afterID, limit)
+	return logs, err
+}
@@ -3,14 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
-	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 )
 
 type CertificateRepository interface {
@@ -18,18 +19,37 @@ type CertificateRepository interface {
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Certificate, error)
 	FindByIDWithDetail(ctx context.Context, id uuid.UUID) (*model.CertificateDetail, error)
 	FindByQRToken(ctx context.Context, token string) (*model.Certificate, error)
-	Create(ctx context.Context, cert *model.Certificate, achievementIDs []uuid.UUID) error
-	UpdatePDFURL(ctx context.Context, id uuid.UUID, pdfURL string) error
-	Revoke(ctx context.Context, id uuid.UUID) error
-	CountByYear(ctx context.Context, year int) (int, error)
+	Create(ctx context.Context, cert *model.Certificate, achievementIDs []uuid.UUID, sign CertificateSignFunc) error
+	Reissue(ctx context.Context, cert *model.Certificate, achievementIDs []uuid.UUID, event *model.CertificateStatusEvent, sign CertificateSignFunc) error
+	UpdatePDF(ctx context.Context, id uuid.UUID, pdfURL, sha256 string) error
+	UpdatePDFStatus(ctx context.Context, id uuid.UUID, status string, pdfError *string) error
+	UpdateSignature(ctx context.Context, id uuid.UUID, payload, signature, keyID string) error
+	ChangeStatus(ctx context.Context, event *model.CertificateStatusEvent) error
+	FindStatusEvents(ctx context.Context, certificateID uuid.UUID) ([]*model.CertificateStatusEvent, error)
+	FindLatestStatusEvent(ctx context.Context, certificateID uuid.UUID, toStatus string) (*model.CertificateStatusEvent, error)
+	CreatePDFRegeneration(ctx context.Context, regen *model.CertificatePDFRegeneration) error
+	CompletePDFRegeneration(ctx context.Context, regenerationID uuid.UUID, pdfURL, sha256 string) error
+	FindPDFRegenerations(ctx context.Context, certificateID uuid.UUID) ([]*model.CertificatePDFRegeneration, error)
+}
+
+// CertificateNumbering menentukan scope counter dan format nomor surat
+type CertificateNumbering interface {
+	Scope(issuedAt time.Time) string
+	Format(seq int, issuedAt time.Time) string
 }
 
+// CertificateSignFunc menandatangani sertifikat yang sedang diterbitkan. Dipanggil di dalam
+// transaksi penerbitan setelah nomor surat dan snapshot tersedia, sehingga sertifikat
+// tidak pernah tersimpan tanpa tanda tangan; error membatalkan seluruh penerbitan.
+type CertificateSignFunc func(detail *model.CertificateDetail) (payload, signature, keyID string, err error)
+
 type certificateRepository struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	numbering CertificateNumbering
 }
 
-func NewCertificateRepository(db *sqlx.DB) CertificateRepository {
-	return &certificateRepository{db: db}
+func NewCertificateRepository(db *sqlx.DB, numbering CertificateNumbering) CertificateRepository {
+	return &certificateRepository{db: db, numbering: numbering}
 }
 
 func (r *certificateRepository) FindAll(ctx context.Context, filter model.CertificateFilter) ([]*model.Certificate, int64, error) {
@@ -58,7 +78,7 @@ func (r *certificateRepository) FindAll(ctx context.Context, filter model.Certif
 	where := strings.Join(conditions, " AND ")
 
 	var total int64
-	if err := r.db.QueryRowContext(ctx,
+	if err := conn(ctx, r.db).QueryRowContext(ctx,
 		fmt.Sprintf("SELECT COUNT(*) FROM certificates c WHERE %s", where), args...,
 	).Scan(&total); err != nil {
 		return nil, 0, err
@@ -67,10 +87,12 @@ func (r *certificateRepository) FindAll(ctx context.Context, filter model.Certif
 	offset := (filter.Page - 1) * filter.PerPage
 	query := fmt.Sprintf(`
 		SELECT c.*, s.full_name as student_name, s.nisn as student_nisn,
-		       u.name as issued_by_name
+		       u.name as issued_by_name, t.name as template_name, tv.version as template_version
 		FROM certificates c
 		LEFT JOIN students s ON c.student_id = s.id
 		LEFT JOIN users u ON c.issued_by = u.id
+		LEFT JOIN certificate_template_versions tv ON c.template_version_id = tv.id
+		LEFT JOIN certificate_templates t ON tv.template_id = t.id
 		WHERE %s
 		ORDER BY c.issued_at DESC
 		LIMIT $%d OFFSET $%d
@@ -79,7 +101,7 @@ func (r *certificateRepository) FindAll(ctx context.Context, filter model.Certif
 	args = append(args, filter.PerPage, offset)
 
 	var certs []*model.Certificate
-	if err := r.db.SelectContext(ctx, &certs, query, args...); err != nil {
+	if err := conn(ctx, r.db).SelectContext(ctx, &certs, query, args...); err != nil {
 		return nil, 0, err
 	}
 
@@ -90,13 +112,15 @@ func (r *certificateRepository) FindByID(ctx context.Context, id uuid.UUID) (*mo
 	var cert model.Certificate
 	query := `
 		SELECT c.*, s.full_name as student_name, s.nisn as student_nisn,
-		       u.name as issued_by_name
+		       u.name as issued_by_name, t.name as template_name, tv.version as template_version
 		FROM certificates c
 		LEFT JOIN students s ON c.student_id = s.id
 		LEFT JOIN users u ON c.issued_by = u.id
+		LEFT JOIN certificate_template_versions tv ON c.template_version_id = tv.id
+		LEFT JOIN certificate_templates t ON tv.template_id = t.id
 		WHERE c.id = $1
 	`
-	err := r.db.GetContext(ctx, &cert, query, id)
+	err := conn(ctx, r.db).GetContext(ctx, &cert, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -106,15 +130,36 @@ func (r *certificateRepository) FindByID(ctx context.Context, id uuid.UUID) (*mo
 	return &cert, nil
 }
 
+// FindByIDWithDetail mengembalikan isi sertifikat menurut snapshot penerbitan
 func (r *certificateRepository) FindByIDWithDetail(ctx context.Context, id uuid.UUID) (*model.CertificateDetail, error) {
 	cert, err := r.FindByID(ctx, id)
 	if err != nil || cert == nil {
 		return nil, err
 	}
 
+	if len(cert.Snapshot) == 0 {
+		return r.liveDetail(ctx, cert)
+	}
+
+	var snapshot model.CertificateSnapshot
+	if err := json.Unmarshal(cert.Snapshot, &snapshot); err != nil {
+		return nil, fmt.Errorf("snapshot sertifikat rusak: %w", err)
+	}
+	cert.StudentName = &snapshot.Student.FullName
+	cert.StudentNISN = &snapshot.Student.NISN
+
+	return &model.CertificateDetail{
+		Certificate:  *cert,
+		Student:      &snapshot.Student,
+		Achievements: snapshot.Achievements,
+	}, nil
+}
+
+// liveDetail membaca data live; hanya untuk sertifikat tanpa snapshot
+func (r *certificateRepository) liveDetail(ctx context.Context, cert *model.Certificate) (*model.CertificateDetail, error) {
 	// Ambil data student
 	var student model.Student
-	if err := r.db.GetContext(ctx, &student,
+	if err := conn(ctx, r.db).GetContext(ctx, &student,
 		"SELECT * FROM students WHERE id = $1", cert.StudentID); err != nil {
 		return nil, err
 	}
@@ -130,7 +175,7 @@ func (r *certificateRepository) FindByIDWithDetail(ctx context.Context, id uuid.
 		WHERE ca.certificate_id = $1
 		ORDER BY a.year DESC
 	`
-	if err := r.db.SelectContext(ctx, &achievements, achievementQuery, id); err != nil {
+	if err := conn(ctx, r.db).SelectContext(ctx, &achievements, achievementQuery, cert.ID); err != nil {
 		return nil, err
 	}
 
@@ -138,7 +183,7 @@ func (r *certificateRepository) FindByIDWithDetail(ctx context.Context, id uuid.
 	achievementsWithAtt := make([]model.AchievementWithAttachments, len(achievements))
 	for i, a := range achievements {
 		var attachments []model.AchievementAttachment
-		r.db.SelectContext(ctx, &attachments,
+		conn(ctx, r.db).SelectContext(ctx, &attachments,
 			"SELECT * FROM achievement_attachments WHERE achievement_id = $1", a.ID)
 		achievementsWithAtt[i] = model.AchievementWithAttachments{
 			Achievement: a,
@@ -161,7 +206,7 @@ func (r *certificateRepository) FindByQRToken(ctx context.Context, token string)
 		LEFT JOIN students s ON c.student_id = s.id
 		WHERE c.qr_token = $1
 	`
-	err := r.db.GetContext(ctx, &cert, query, token)
+	err := conn(ctx, r.db).GetContext(ctx, &cert, query, token)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -171,19 +216,90 @@ func (r *certificateRepository) FindByQRToken(ctx context.Context, token string)
 	return &cert, nil
 }
 
-func (r *certificateRepository) Create(ctx context.Context, cert *model.Certificate, achievementIDs []uuid.UUID) error {
-	tx, err := r.db.BeginTxx(ctx, nil)
+func (r *certificateRepository) Create(ctx context.Context, cert *model.Certificate, achievementIDs []uuid.UUID, sign CertificateSignFunc) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.insertCertificate(ctx, tx, cert, achievementIDs, sign); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reissue menyimpan sertifikat pengganti dan menandai sertifikat lama (cert.Supersedes)
+// sebagai superseded dalam satu transaksi
+func (r *certificateRepository) Reissue(ctx context.Context, cert *model.Certificate, achievementIDs []uuid.UUID, event *model.CertificateStatusEvent, sign CertificateSignFunc) error {
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := r.insertCertificate(ctx, tx, cert, achievementIDs, sign); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE certificates SET status = $1, superseded_by = $2 WHERE id = $3 AND status = $4",
+		event.ToStatus, cert.ID, event.CertificateID, event.FromStatus)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New("status sertifikat sudah berubah, muat ulang data")
+	}
+
+	if err := insertStatusEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *certificateRepository) insertCertificate(ctx context.Context, tx *repoTx, cert *model.Certificate, achievementIDs []uuid.UUID, sign CertificateSignFunc) error {
+	// Alokasikan nomor surat; baris sequence terkunci sampai transaksi selesai
+	seq, err := nextNumberSequence(ctx, tx, r.numbering.Scope(cert.IssuedAt))
+	if err != nil {
+		return err
+	}
+	cert.CertificateNumber = r.numbering.Format(seq, cert.IssuedAt)
+
+	// Bekukan data siswa & prestasi yang dinyatakan surat ini
+	snapshot, err := captureSnapshot(ctx, tx, cert.StudentID, achievementIDs)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	cert.Snapshot = model.JSONB(raw)
+
+	// Tanda tangani dari data yang sama dengan yang dibaca ulang dari snapshot
+	payload, signature, keyID, err := sign(&model.CertificateDetail{
+		Certificate:  *cert,
+		Student:      &snapshot.Student,
+		Achievements: snapshot.Achievements,
+	})
+	if err != nil {
+		return fmt.Errorf("gagal menandatangani sertifikat: %w", err)
+	}
+	cert.SignedPayload = &payload
+	cert.Signature = &signature
+	cert.SignatureKeyID = &keyID
+
 	// Insert certificate
 	query := `
 		INSERT INTO certificates (id, student_id, certificate_number, issued_at, issued_by,
-		                          valid_until, qr_token, status, notes, created_at)
+		                          valid_until, qr_token, status, notes, supersedes, pdf_status, snapshot,
+		                          signed_payload, signature, signature_key_id, template_version_id, created_at)
 		VALUES (:id, :student_id, :certificate_number, :issued_at, :issued_by,
-		        :valid_until, :qr_token, :status, :notes, NOW())
+		        :valid_until, :qr_token, :status, :notes, :supersedes, 'pending', :snapshot,
+		        :signed_payload, :signature, :signature_key_id, :template_version_id, NOW())
 	`
 	if _, err := tx.NamedExecContext(ctx, query, cert); err != nil {
 		return err
@@ -199,26 +315,289 @@ func (r *certificateRepository) Create(ctx context.Context, cert *model.Certific
 		}
 	}
 
-	return tx.Commit()
+	// Catat event penerbitan di riwayat status
+	if err := insertStatusEvent(ctx, tx, &model.CertificateStatusEvent{
+		ID:            uuid.New(),
+		CertificateID: cert.ID,
+		ToStatus:      cert.Status,
+		ActorID:       cert.IssuedBy,
+	}); err != nil {
+		return err
+	}
+
+	// Jadwalkan pembuatan PDF; ikut transaksi agar tidak ada sertifikat tanpa job
+	cert.PDFStatus = model.PDFStatusPending
+	_, err = insertJob(ctx, tx, model.JobTypeGenerateCertificatePDF,
+		model.CertificatePDFJobPayload{CertificateID: cert.ID})
+	return err
 }
 
-func (r *certificateRepository) UpdatePDFURL(ctx context.Context, id uuid.UUID, pdfURL string) error {
-	_, err := r.db.ExecContext(ctx,
-		"UPDATE certificates SET pdf_url = $1 WHERE id = $2", pdfURL, id)
+func (r *certificateRepository) UpdatePDF(ctx context.Context, id uuid.UUID, pdfURL, sha256 string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE certificates SET pdf_url = $1, pdf_sha256 = $2, pdf_status = 'ready', pdf_error = NULL
+		WHERE id = $3
+	`, pdfURL, sha256, id)
 	return err
 }
 
-func (r *certificateRepository) Revoke(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx,
-		"UPDATE certificates SET status = 'revoked', updated_at = $1 WHERE id = $2",
-		time.Now(), id)
+func (r *certificateRepository) UpdatePDFStatus(ctx context.Context, id uuid.UUID, status string, pdfError *string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE certificates SET pdf_status = $1, pdf_error = $2 WHERE id = $3", status, pdfError, id)
 	return err
 }
 
-func (r *certificateRepository) CountByYear(ctx context.Context, year int) (int, error) {
-	var count int
-	err := r.db.QueryRowContext(ctx,
-		"SELECT COUNT(*) FROM certificates WHERE EXTRACT(YEAR FROM issued_at) = $1", year,
-	).Scan(&count)
-	return count, err
-}
\ No newline at end of file
+func (r *certificateRepository) UpdateSignature(ctx context.Context, id uuid.UUID, payload, signature, keyID string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE certificates SET signed_payload = $1, signature = $2, signature_key_id = $3
+		WHERE id = $4
+	`, payload, signature, keyID, id)
+	return err
+}
+
+// ChangeStatus mengubah status sertifikat dan mencatat riwayatnya dalam satu transaksi.
+// Update hanya berhasil jika status saat ini masih sama dengan event.FromStatus.
+func (r *certificateRepository) ChangeStatus(ctx context.Context, event *model.CertificateStatusEvent) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE certificates SET status = $1 WHERE id = $2 AND status = $3",
+		event.ToStatus, event.CertificateID, event.FromStatus)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errors.New("status sertifikat sudah berubah, muat ulang data")
+	}
+
+	if err := insertStatusEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *certificateRepository) FindStatusEvents(ctx context.Context, certificateID uuid.UUID) ([]*model.CertificateStatusEvent, error) {
+	var events []*model.CertificateStatusEvent
+	query := `
+		SELECT e.*, u.name as actor_name
+		FROM certificate_status_events e
+		LEFT JOIN users u ON e.actor_id = u.id
+		WHERE e.certificate_id = $1
+		ORDER BY e.created_at ASC
+	`
+	if err := conn(ctx, r.db).SelectContext(ctx, &events, query, certificateID); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *certificateRepository) FindLatestStatusEvent(ctx context.Context, certificateID uuid.UUID, toStatus string) (*model.CertificateStatusEvent, error) {
+	var event model.CertificateStatusEvent
+	query := `
+		SELECT e.*, u.name as actor_name
+		FROM certificate_status_events e
+		LEFT JOIN users u ON e.actor_id = u.id
+		WHERE e.certificate_id = $1 AND e.to_status = $2
+		ORDER BY e.created_at DESC
+		LIMIT 1
+	`
+	err := conn(ctx, r.db).GetContext(ctx, &event, query, certificateID, toStatus)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func insertStatusEvent(ctx context.Context, tx *repoTx, event *model.CertificateStatusEvent) error {
+	_, err := tx.NamedExecContext(ctx, `
+		INSERT INTO certificate_status_events (id, certificate_id, from_status, to_status, reason, actor_id, created_at)
+		VALUES (:id, :certificate_id, :from_status, :to_status, :reason, :actor_id, NOW())
+	`, event)
+	return err
+}
+
+// captureSnapshot membaca siswa & prestasi di dalam transaksi penerbitan (dikunci FOR SHARE
+// agar tidak berubah sampai transaksi selesai) dan memastikan semua prestasi milik siswa tsb,
+// baik sebagai pemilik maupun anggota tim.
+func captureSnapshot(ctx context.Context, tx *repoTx, studentID uuid.UUID, achievementIDs []uuid.UUID) (*model.CertificateSnapshot, error) {
+	snapshot := model.CertificateSnapshot{CapturedAt: time.Now()}
+
+	if err := tx.GetContext(ctx, &snapshot.Student,
+		"SELECT * FROM students WHERE id = $1 FOR SHARE", studentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("siswa tidak ditemukan")
+		}
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(achievementIDs))
+	seen := make(map[uuid.UUID]bool, len(achievementIDs))
+	for _, id := range achievementIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id.String())
+		}
+	}
+
+	var achievements []model.Achievement
+	if err := tx.SelectContext(ctx, &achievements, `
+		SELECT a.*, ac.name as category_name, cl.name as level_name
+		FROM achievements a
+		LEFT JOIN achievement_categories ac ON a.category_id = ac.id
+		LEFT JOIN competition_levels cl ON a.level_id = cl.id
+		WHERE a.id = ANY($1::uuid[])
+		ORDER BY a.year DESC
+		FOR SHARE OF a
+	`, ids); err != nil {
+		return nil, err
+	}
+	if len(achievements) != len(ids) {
+		return nil, errors.New("sebagian prestasi tidak ditemukan")
+	}
+
+	var attachments []model.AchievementAttachment
+	if err := tx.SelectContext(ctx, &attachments, `
+		SELECT * FROM achievement_attachments
+		WHERE achievement_id = ANY($1::uuid[])
+		ORDER BY uploaded_at
+	`, ids); err != nil {
+		return nil, err
+	}
+	byAchievement := make(map[uuid.UUID][]model.AchievementAttachment)
+	for _, att := range attachments {
+		byAchievement[att.AchievementID] = append(byAchievement[att.AchievementID], att)
+	}
+
+	participants, err := findParticipants(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Achievements = make([]model.AchievementWithAttachments, len(achievements))
+	for i, a := range achievements {
+		a.Participants = participants[a.ID]
+		if a.StudentID != studentID && !isParticipant(a.Participants, studentID) {
+			return nil, fmt.Errorf("prestasi %s bukan milik siswa ini", a.CompetitionName)
+		}
+		if a.Status != model.AchievementStatusVerified {
+			return nil, fmt.Errorf("prestasi %s belum diverifikasi", a.CompetitionName)
+		}
+		snapshot.Achievements[i] = model.AchievementWithAttachments{
+			Achievement: a,
+			Attachments: byAchievement[a.ID],
+		}
+	}
+
+	return &snapshot, nil
+}
+
+func isParticipant(participants []model.AchievementParticipant, studentID uuid.UUID) bool {
+	for _, p := range participants {
+		if p.StudentID == studentID {
+			return true
+		}
+	}
+	return false
+}
+
+func nextNumberSequence(ctx context.Context, tx *repoTx, scope string) (int, error) {
+	var seq int
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO certificate_number_sequences (scope, last_value, updated_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (scope) DO UPDATE
+		SET last_value = certificate_number_sequences.last_value + 1, updated_at = NOW()
+		RETURNING last_value
+	`, scope).Scan(&seq)
+	return seq, err
+}
+
+// CreatePDFRegeneration mencatat permintaan regenerasi dan menjadwalkan job-nya.
+// PDF lama tetap tersimpan; URL dan hash-nya dicatat di baris regenerasi.
+func (r *certificateRepository) CreatePDFRegeneration(ctx context.Context, regen *model.CertificatePDFRegeneration) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Kolom di RETURNING bernilai sebelum update karena tidak diubah di SET
+	err = tx.QueryRowContext(ctx, `
+		UPDATE certificates SET pdf_status = 'pending', pdf_error = NULL
+		WHERE id = $1 AND pdf_status <> 'pending'
+		RETURNING pdf_url, pdf_sha256
+	`, regen.CertificateID).Scan(&regen.PreviousPDFURL, &regen.PreviousPDFSHA256)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("PDF sertifikat sedang dibuat, tunggu hingga selesai")
+		}
+		return err
+	}
+
+	job, err := insertJob(ctx, tx, model.JobTypeGenerateCertificatePDF, model.CertificatePDFJobPayload{
+		CertificateID:  regen.CertificateID,
+		RegenerationID: &regen.ID,
+	})
+	if err != nil {
+		return err
+	}
+	regen.JobID = &job.ID
+
+	if _, err := tx.NamedExecContext(ctx, `
+		INSERT INTO certificate_pdf_regenerations (id, certificate_id, requested_by, reason, job_id,
+		                                           previous_pdf_url, previous_pdf_sha256, created_at)
+		VALUES (:id, :certificate_id, :requested_by, :reason, :job_id,
+		        :previous_pdf_url, :previous_pdf_sha256, NOW())
+	`, regen); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CompletePDFRegeneration memasang PDF hasil regenerasi ke sertifikat
+func (r *certificateRepository) CompletePDFRegeneration(ctx context.Context, regenerationID uuid.UUID, pdfURL, sha256 string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var certificateID uuid.UUID
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE certificate_pdf_regenerations
+		SET new_pdf_url = $1, new_pdf_sha256 = $2, completed_at = NOW()
+		WHERE id = $3
+		RETURNING certificate_id
+	`, pdfURL, sha256, regenerationID).Scan(&certificateID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE certificates SET pdf_url = $1, pdf_sha256 = $2, pdf_status = 'ready', pdf_error = NULL
+		WHERE id = $3
+	`, pdfURL, sha256, certificateID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *certificateRepository) FindPDFRegenerations(ctx context.Context, certificateID uuid.UUID) ([]*model.CertificatePDFRegeneration, error) {
+	var regens []*model.CertificatePDFRegeneration
+	err := conn(ctx, r.db).SelectContext(ctx, &regens, `
+		SELECT g.*, u.name as requested_by_name
+		FROM certificate_pdf_regenerations g
+		LEFT JOIN users u ON g.requested_by = u.id
+		WHERE g.certificate_id = $1
+		ORDER BY g.created_at DESC
+	`, certificateID)
+	return regens, err
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+type CertificateTemplateRepository interface {
+	FindAll(ctx context.Context) ([]*model.CertificateTemplate, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.CertificateTemplate, error)
+	FindByName(ctx context.Context, name string) (*model.CertificateTemplate, error)
+	FindDefault(ctx context.Context) (*model.CertificateTemplate, error)
+	FindVersions(ctx context.Context, templateID uuid.UUID) ([]*model.CertificateTemplateVersion, error)
+	FindVersion(ctx context.Context, templateID uuid.UUID, version int) (*model.CertificateTemplateVersion, error)
+	FindVersionByID(ctx context.Context, id uuid.UUID) (*model.CertificateTemplateVersion, error)
+	Create(ctx context.Context, tmpl *model.CertificateTemplate, version *model.CertificateTemplateVersion) error
+	AddVersion(ctx context.Context, tmpl *model.CertificateTemplate, version *model.CertificateTemplateVersion) error
+	SetDefault(ctx context.Context, id uuid.UUID) error
+}
+
+type certificateTemplateRepository struct {
+	db *sqlx.DB
+}
+
+func NewCertificateTemplateRepository(db *sqlx.DB) CertificateTemplateRepository {
+	return &certificateTemplateRepository{db: db}
+}
+
+// Template beserta isi versi terbarunya
+const certificateTemplateSelect = `
+	SELECT t.*, v.id as current_version_id, v.definition
+	FROM certificate_templates t
+	JOIN certificate_template_versions v ON v.template_id = t.id AND v.version = t.current_version
+`
+
+const certificateTemplateVersionSelect = `
+	SELECT v.*, t.name as template_name, u.name as created_by_name
+	FROM certificate_template_versions v
+	JOIN certificate_templates t ON v.template_id = t.id
+	LEFT JOIN users u ON v.created_by = u.id
+`
+
+func (r *certificateTemplateRepository) FindAll(ctx context.Context) ([]*model.CertificateTemplate, error) {
+	var templates []*model.CertificateTemplate
+	err := conn(ctx, r.db).SelectContext(ctx, &templates,
+		certificateTemplateSelect+" ORDER BY t.is_default DESC, t.name")
+	return templates, err
+}
+
+func (r *certificateTemplateRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.CertificateTemplate, error) {
+	return r.findOne(ctx, certificateTemplateSelect+" WHERE t.id = $1", id)
+}
+
+func (r *certificateTemplateRepository) FindByName(ctx context.Context, name string) (*model.CertificateTemplate, error) {
+	return r.findOne(ctx, certificateTemplateSelect+" WHERE LOWER(t.name) = LOWER($1)", name)
+}
+
+func (r *certificateTemplateRepository) FindDefault(ctx context.Context) (*model.CertificateTemplate, error) {
+	return r.findOne(ctx, certificateTemplateSelect+" WHERE t.is_default")
+}
+
+func (r *certificateTemplateRepository) findOne(ctx context.Context, query string, args ...interface{}) (*model.CertificateTemplate, error) {
+	var tmpl model.CertificateTemplate
+	if err := conn(ctx, r.db).GetContext(ctx, &tmpl, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+func (r *certificateTemplateRepository) FindVersions(ctx context.Context, templateID uuid.UUID) ([]*model.CertificateTemplateVersion, error) {
+	var versions []*model.CertificateTemplateVersion
+	err := conn(ctx, r.db).SelectContext(ctx, &versions,
+		certificateTemplateVersionSelect+" WHERE v.template_id = $1 ORDER BY v.version DESC", templateID)
+	return versions, err
+}
+
+func (r *certificateTemplateRepository) FindVersion(ctx context.Context, templateID uuid.UUID, version int) (*model.CertificateTemplateVersion, error) {
+	return r.findVersion(ctx, certificateTemplateVersionSelect+" WHERE v.template_id = $1 AND v.version = $2",
+		templateID, version)
+}
+
+func (r *certificateTemplateRepository) FindVersionByID(ctx context.Context, id uuid.UUID) (*model.CertificateTemplateVersion, error) {
+	return r.findVersion(ctx, certificateTemplateVersionSelect+" WHERE v.id = $1", id)
+}
+
+func (r *certificateTemplateRepository) findVersion(ctx context.Context, query string, args ...interface{}) (*model.CertificateTemplateVersion, error) {
+	var version model.CertificateTemplateVersion
+	if err := conn(ctx, r.db).GetContext(ctx, &version, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+// Create menyimpan template baru beserta versi pertamanya
+func (r *certificateTemplateRepository) Create(ctx context.Context, tmpl *model.CertificateTemplate, version *model.CertificateTemplateVersion) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tmpl.CurrentVersion = 1
+	if _, err := tx.NamedExecContext(ctx, `
+		INSERT INTO certificate_templates (id, name, description, is_default, current_version, created_by, created_at, updated_at)
+		VALUES (:id, :name, :description, FALSE, :current_version, :created_by, NOW(), NOW())
+	`, tmpl); err != nil {
+		return err
+	}
+
+	version.TemplateID = tmpl.ID
+	version.Version = tmpl.CurrentVersion
+	if err := insertTemplateVersion(ctx, tx, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddVersion menyimpan versi baru dan menjadikannya versi terbaru template. Baris
+// template dikunci agar dua perubahan bersamaan tidak mendapat nomor versi yang sama.
+func (r *certificateTemplateRepository) AddVersion(ctx context.Context, tmpl *model.CertificateTemplate, version *model.CertificateTemplateVersion) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT current_version FROM certificate_templates WHERE id = $1 FOR UPDATE", tmpl.ID,
+	).Scan(&current); err != nil {
+		return err
+	}
+
+	tmpl.CurrentVersion = current + 1
+	if _, err := tx.NamedExecContext(ctx, `
+		UPDATE certificate_templates
+		SET name = :name, description = :description, current_version = :current_version, updated_at = NOW()
+		WHERE id = :id
+	`, tmpl); err != nil {
+		return err
+	}
+
+	version.TemplateID = tmpl.ID
+	version.Version = tmpl.CurrentVersion
+	if err := insertTemplateVersion(ctx, tx, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertTemplateVersion(ctx context.Context, tx *repoTx, version *model.CertificateTemplateVersion) error {
+	_, err := tx.NamedExecContext(ctx, `
+		INSERT INTO certificate_template_versions (id, template_id, version, definition, created_by, created_at)
+		VALUES (:id, :template_id, :version, :definition, :created_by, NOW())
+	`, version)
+	return err
+}
+
+// SetDefault menjadikan template ini default untuk surat baru
+func (r *certificateTemplateRepository) SetDefault(ctx context.Context, id uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE certificate_templates SET is_default = FALSE, updated_at = NOW() WHERE is_default AND id <> $1", id,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE certificate_templates SET is_default = TRUE, updated_at = NOW() WHERE id = $1", id,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Pastikan interface terpenuhi
+var _ CertificateTemplateRepository = (*certificateTemplateRepository)(nil)
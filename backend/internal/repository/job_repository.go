@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+// defaultJobMaxAttempts jumlah percobaan sebelum job masuk dead-letter
+const defaultJobMaxAttempts = 5
+
+type JobRepository interface {
+	FindAll(ctx context.Context, filter model.JobFilter) ([]*model.Job, int64, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Job, error)
+	Enqueue(ctx context.Context, jobType string, payload interface{}) (*model.Job, error)
+	ClaimNext(ctx context.Context, types []string, lockTimeout time.Duration) (*model.Job, error)
+	MarkDone(ctx context.Context, id uuid.UUID) error
+	MarkRetry(ctx context.Context, id uuid.UUID, runAt time.Time, lastError string) error
+	MarkDead(ctx context.Context, id uuid.UUID, lastError string) error
+	Retry(ctx context.Context, id uuid.UUID) (*model.Job, error)
+}
+
+type jobRepository struct {
+	db *sqlx.DB
+}
+
+func NewJobRepository(db *sqlx.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) FindAll(ctx context.Context, filter model.JobFilter) ([]*model.Job, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.Type != "" {
+		conditions = append(conditions, fmt.Sprintf("type = $%d", argIdx))
+		args = append(args, filter.Type)
+		argIdx++
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIdx))
+		args = append(args, filter.Status)
+		argIdx++
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COUNT(*) FROM jobs WHERE %s", where), args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	query := fmt.Sprintf(`
+		SELECT * FROM jobs
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, argIdx, argIdx+1)
+
+	args = append(args, filter.PerPage, offset)
+
+	var jobs []*model.Job
+	if err := conn(ctx, r.db).SelectContext(ctx, &jobs, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+func (r *jobRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	var job model.Job
+	err := conn(ctx, r.db).GetContext(ctx, &job, "SELECT * FROM jobs WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) Enqueue(ctx context.Context, jobType string, payload interface{}) (*model.Job, error) {
+	return insertJob(ctx, conn(ctx, r.db), jobType, payload)
+}
+
+// ClaimNext mengambil satu job yang siap dijalankan dan menandainya running.
+// Job running yang terkunci lebih lama dari lockTimeout (worker mati) ikut diambil ulang.
+func (r *jobRepository) ClaimNext(ctx context.Context, types []string, lockTimeout time.Duration) (*model.Job, error) {
+	var job model.Job
+	err := conn(ctx, r.db).GetContext(ctx, &job, `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE type = ANY($1)
+			  AND ((status = 'pending' AND run_at <= NOW())
+			    OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $2)))
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, types, lockTimeout.Seconds())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) MarkDone(ctx context.Context, id uuid.UUID) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE jobs SET status = 'done', locked_at = NULL, last_error = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *jobRepository) MarkRetry(ctx context.Context, id uuid.UUID, runAt time.Time, lastError string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE jobs SET status = 'pending', run_at = $1, locked_at = NULL, last_error = $2, updated_at = NOW()
+		WHERE id = $3
+	`, runAt, lastError, id)
+	return err
+}
+
+func (r *jobRepository) MarkDead(ctx context.Context, id uuid.UUID, lastError string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE jobs SET status = 'dead', locked_at = NULL, last_error = $1, updated_at = NOW()
+		WHERE id = $2
+	`, lastError, id)
+	return err
+}
+
+// Retry menjadwalkan ulang job dead dengan jatah percobaan baru. Untuk job PDF
+// sertifikat, status PDF yang gagal ikut dikembalikan ke pending.
+func (r *jobRepository) Retry(ctx context.Context, id uuid.UUID) (*model.Job, error) {
+	var job model.Job
+	err := conn(ctx, r.db).GetContext(ctx, &job, `
+		WITH retried AS (
+			UPDATE jobs
+			SET status = 'pending', attempts = 0, run_at = NOW(), locked_at = NULL, updated_at = NOW()
+			WHERE id = $1 AND status = 'dead'
+			RETURNING *
+		), pdf AS (
+			UPDATE certificates SET pdf_status = 'pending', pdf_error = NULL
+			WHERE pdf_status = 'failed' AND id = (
+				SELECT (payload->>'certificate_id')::uuid FROM retried WHERE type = $2
+			)
+		)
+		SELECT * FROM retried
+	`, id, model.JobTypeGenerateCertificatePDF)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// insertJob dipakai juga oleh repository lain agar job ikut dalam transaksinya
+func insertJob(ctx context.Context, db sqlx.QueryerContext, jobType string, payload interface{}) (*model.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var job model.Job
+	err = sqlx.GetContext(ctx, db, &job, `
+		INSERT INTO jobs (id, type, payload, status, max_attempts, run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 'pending', $4, NOW(), NOW(), NOW())
+		RETURNING *
+	`, uuid.New(), jobType, model.JSONB(raw), defaultJobMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginThrottleRepository interface {
+	Find(ctx context.Context, scope, subject string) (*model.LoginThrottle, error)
+	FindLocked(ctx context.Context) ([]*model.LoginThrottle, error)
+	RecordFailure(ctx context.Context, scope, subject string, window time.Duration) (*model.LoginThrottle, error)
+	Lock(ctx context.Context, scope, subject string, until time.Time) error
+	Reset(ctx context.Context, scope, subject string) (bool, error)
+}
+
+type loginThrottleRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginThrottleRepository(db *sqlx.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) Find(ctx context.Context, scope, subject string) (*model.LoginThrottle, error) {
+	var throttle model.LoginThrottle
+	err := conn(ctx, r.db).GetContext(ctx, &throttle,
+		"SELECT * FROM login_throttles WHERE scope = $1 AND subject = $2", scope, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// FindLocked akun dan IP yang sedang dikunci, yang paling lama terkunci lebih dulu
+func (r *loginThrottleRepository) FindLocked(ctx context.Context) ([]*model.LoginThrottle, error) {
+	var throttles []*model.LoginThrottle
+	err := conn(ctx, r.db).SelectContext(ctx, &throttles, `
+		SELECT * FROM login_throttles
+		WHERE locked_until > NOW()
+		ORDER BY locked_until DESC
+	`)
+	return throttles, err
+}
+
+// RecordFailure menambah hitungan gagal. Hitungan mulai dari awal jika kegagalan
+// terakhir sudah lewat dari window atau masa kunci sebelumnya sudah berakhir.
+func (r *loginThrottleRepository) RecordFailure(ctx context.Context, scope, subject string, window time.Duration) (*model.LoginThrottle, error) {
+	var throttle model.LoginThrottle
+	err := conn(ctx, r.db).GetContext(ctx, &throttle, `
+		INSERT INTO login_throttles (scope, subject, failures, last_failed_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failed_at < NOW() - $3 * INTERVAL '1 second'
+				  OR login_throttles.locked_until <= NOW()
+				THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			locked_until = CASE
+				WHEN login_throttles.locked_until <= NOW() THEN NULL
+				ELSE login_throttles.locked_until
+			END,
+			last_failed_at = NOW()
+		RETURNING *
+	`, scope, subject, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *loginThrottleRepository) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE login_throttles SET locked_until = $3 WHERE scope = $1 AND subject = $2",
+		scope, subject, until)
+	return err
+}
+
+// Reset menghapus catatan gagal (login berhasil atau dibuka admin); false jika tidak ada
+func (r *loginThrottleRepository) Reset(ctx context.Context, scope, subject string) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		"DELETE FROM login_throttles WHERE scope = $1 AND subject = $2", scope, subject)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Pastikan interface terpenuhi
+var _ LoginThrottleRepository = (*loginThrottleRepository)(nil)
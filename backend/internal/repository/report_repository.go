@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+type ReportRepository interface {
+	Summary(ctx context.Context, filter model.ReportFilter) (*model.ReportSummary, error)
+	AchievementsByYear(ctx context.Context, filter model.ReportFilter) ([]model.YearlyCount, error)
+	AchievementBreakdown(ctx context.Context, by string, filter model.ReportFilter) ([]model.BreakdownItem, error)
+	CertificatesByMonth(ctx context.Context, year int) ([]model.CertificateMonthly, error)
+	FindReportAchievements(ctx context.Context, filter model.ReportFilter) ([]*model.ReportAchievement, error)
+}
+
+type reportRepository struct {
+	db *sqlx.DB
+}
+
+func NewReportRepository(db *sqlx.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// reportAchievementFrom sumber data laporan prestasi: satu baris per pemilik prestasi,
+// sehingga prestasi tim dihitung di kelas/jenis kelamin setiap anggotanya. Jumlah
+// prestasi selalu memakai COUNT(DISTINCT a.id).
+var reportAchievementFrom = fmt.Sprintf(`
+	FROM achievements a
+	JOIN %s h ON h.achievement_id = a.id
+	JOIN students s ON h.student_id = s.id
+`, achievementHoldersSQL)
+
+// reportDimension kolom kunci, label dan urutan untuk satu dimensi rincian
+type reportDimension struct {
+	key     string
+	label   string
+	join    string
+	orderBy string
+}
+
+var reportDimensions = map[string]reportDimension{
+	model.ReportByLevel: {
+		key:     "COALESCE(l.id::text, '')",
+		label:   "COALESCE(l.name, 'Tanpa tingkat')",
+		join:    "LEFT JOIN competition_levels l ON a.level_id = l.id",
+		orderBy: "MIN(l.order_rank) NULLS LAST",
+	},
+	model.ReportByCategory: {
+		key:     "COALESCE(c.id::text, '')",
+		label:   "COALESCE(c.name, 'Tanpa kategori')",
+		join:    "LEFT JOIN achievement_categories c ON a.category_id = c.id",
+		orderBy: "total DESC, label",
+	},
+	model.ReportByRank: {
+		key:     "COALESCE(rk.code, '')",
+		label:   "COALESCE(rk.name, 'Belum terpetakan')",
+		join:    "LEFT JOIN ranks rk ON a.rank_id = rk.id",
+		orderBy: "MIN(rk.order_rank) NULLS LAST",
+	},
+	model.ReportByClass: {
+		key:     "COALESCE(s.class, '')",
+		label:   "COALESCE(NULLIF(s.class, ''), 'Tanpa kelas')",
+		orderBy: "key",
+	},
+	model.ReportByGender: {
+		key: "COALESCE(s.gender, '')",
+		label: `CASE s.gender WHEN 'L' THEN 'Laki-laki' WHEN 'P' THEN 'Perempuan'
+		        ELSE 'Tidak diketahui' END`,
+		orderBy: "key",
+	},
+}
+
+// achievementConditions menyusun klausa WHERE laporan prestasi. Filter status
+// dilewati jika withStatus false (mis. untuk rincian per status).
+func achievementConditions(filter model.ReportFilter, withStatus bool) (string, []interface{}) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	argIdx := 1
+
+	add := func(cond string, arg interface{}) {
+		conditions = append(conditions, fmt.Sprintf(cond, argIdx))
+		args = append(args, arg)
+		argIdx++
+	}
+
+	if withStatus && filter.Status != model.ReportStatusAll {
+		add("a.status = $%d", filter.Status)
+	}
+	if filter.FromYear != nil {
+		add("a.year >= $%d", *filter.FromYear)
+	}
+	if filter.ToYear != nil {
+		add("a.year <= $%d", *filter.ToYear)
+	}
+	if filter.CategoryID != nil {
+		add("a.category_id = $%d", *filter.CategoryID)
+	}
+	if filter.LevelID != nil {
+		add("a.level_id = $%d", *filter.LevelID)
+	}
+	if filter.Class != "" {
+		add("s.class = $%d", filter.Class)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+func (r *reportRepository) Summary(ctx context.Context, filter model.ReportFilter) (*model.ReportSummary, error) {
+	var summary model.ReportSummary
+
+	where, args := achievementConditions(filter, true)
+	query := fmt.Sprintf(`
+		SELECT COUNT(DISTINCT a.id) AS total_achievements,
+		       COUNT(DISTINCT h.student_id) AS students_with_achievements,
+		       COUNT(DISTINCT a.id) FILTER (WHERE a.team_name IS NOT NULL) AS team_achievements
+		%s
+		WHERE %s
+	`, reportAchievementFrom, where)
+	if err := conn(ctx, r.db).GetContext(ctx, &summary, query, args...); err != nil {
+		return nil, err
+	}
+
+	studentQuery := "SELECT COUNT(*) FROM students WHERE 1=1"
+	studentArgs := []interface{}{}
+	if filter.Class != "" {
+		studentQuery += " AND class = $1"
+		studentArgs = append(studentArgs, filter.Class)
+	}
+	if err := conn(ctx, r.db).GetContext(ctx, &summary.TotalStudents, studentQuery, studentArgs...); err != nil {
+		return nil, err
+	}
+
+	where, args = achievementConditions(filter, false)
+	var statuses []model.StatusCount
+	query = fmt.Sprintf(`
+		SELECT a.status, COUNT(DISTINCT a.id) AS total
+		%s
+		WHERE %s
+		GROUP BY a.status
+	`, reportAchievementFrom, where)
+	if err := conn(ctx, r.db).SelectContext(ctx, &statuses, query, args...); err != nil {
+		return nil, err
+	}
+	summary.AchievementsByStatus = make(map[string]int64, len(statuses))
+	for _, st := range statuses {
+		summary.AchievementsByStatus[st.Status] = st.Total
+	}
+
+	// Sertifikat difilter berdasarkan tahun terbit
+	certConditions := []string{"1=1"}
+	certArgs := []interface{}{}
+	if filter.FromYear != nil {
+		certArgs = append(certArgs, *filter.FromYear)
+		certConditions = append(certConditions, fmt.Sprintf("EXTRACT(YEAR FROM issued_at) >= $%d", len(certArgs)))
+	}
+	if filter.ToYear != nil {
+		certArgs = append(certArgs, *filter.ToYear)
+		certConditions = append(certConditions, fmt.Sprintf("EXTRACT(YEAR FROM issued_at) <= $%d", len(certArgs)))
+	}
+	query = fmt.Sprintf(`
+		SELECT COUNT(*) FILTER (WHERE status = 'active')     AS certificates_active,
+		       COUNT(*) FILTER (WHERE status = 'revoked')    AS certificates_revoked,
+		       COUNT(*) FILTER (WHERE status = 'superseded') AS certificates_superseded
+		FROM certificates
+		WHERE %s
+	`, strings.Join(certConditions, " AND "))
+	if err := conn(ctx, r.db).QueryRowxContext(ctx, query, certArgs...).Scan(
+		&summary.CertificatesActive, &summary.CertificatesRevoked, &summary.CertificatesSuperseded,
+	); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+func (r *reportRepository) AchievementsByYear(ctx context.Context, filter model.ReportFilter) ([]model.YearlyCount, error) {
+	where, args := achievementConditions(filter, true)
+	query := fmt.Sprintf(`
+		SELECT a.year, COUNT(DISTINCT a.id) AS total, COUNT(DISTINCT h.student_id) AS students
+		%s
+		WHERE %s
+		GROUP BY a.year
+		ORDER BY a.year
+	`, reportAchievementFrom, where)
+
+	var counts []model.YearlyCount
+	if err := conn(ctx, r.db).SelectContext(ctx, &counts, query, args...); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *reportRepository) AchievementBreakdown(ctx context.Context, by string, filter model.ReportFilter) ([]model.BreakdownItem, error) {
+	dim, ok := reportDimensions[by]
+	if !ok {
+		return nil, fmt.Errorf("dimensi laporan tidak dikenal: %s", by)
+	}
+
+	where, args := achievementConditions(filter, true)
+	query := fmt.Sprintf(`
+		SELECT %s AS key, %s AS label,
+		       COUNT(DISTINCT a.id) AS total, COUNT(DISTINCT h.student_id) AS students
+		%s
+		%s
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY %s
+	`, dim.key, dim.label, reportAchievementFrom, dim.join, where, dim.orderBy)
+
+	var items []model.BreakdownItem
+	if err := conn(ctx, r.db).SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CertificatesByMonth menghitung dari riwayat status sehingga sertifikat yang sudah
+// dicabut tetap terhitung terbit di bulan penerbitannya
+func (r *reportRepository) CertificatesByMonth(ctx context.Context, year int) ([]model.CertificateMonthly, error) {
+	query := `
+		SELECT to_char(date_trunc('month', created_at), 'YYYY-MM') AS month,
+		       COUNT(*) FILTER (WHERE from_status IS NULL)   AS issued,
+		       COUNT(*) FILTER (WHERE to_status = 'revoked') AS revoked
+		FROM certificate_status_events
+		WHERE EXTRACT(YEAR FROM created_at) = $1
+		GROUP BY 1
+		ORDER BY 1
+	`
+	var months []model.CertificateMonthly
+	if err := conn(ctx, r.db).SelectContext(ctx, &months, query, year); err != nil {
+		return nil, err
+	}
+	return months, nil
+}
+
+// FindReportAchievements daftar prestasi untuk laporan tahunan, satu baris per prestasi
+// (siswa utama), diurutkan dari tingkat tertinggi. Anggota prestasi tim diisi di Members.
+// Filter kelas diabaikan karena laporan tahunan mencakup seluruh sekolah.
+func (r *reportRepository) FindReportAchievements(ctx context.Context, filter model.ReportFilter) ([]*model.ReportAchievement, error) {
+	filter.Class = ""
+	where, args := achievementConditions(filter, true)
+	query := fmt.Sprintf(`
+		SELECT a.id, a.competition_name, a.organizer, COALESCE(a.rank, '') AS rank, a.year,
+		       a.team_name, l.name AS level_name, a.category_id, c.name AS category_name,
+		       s.full_name AS student_name, s.nisn AS student_nisn, COALESCE(s.class, '') AS student_class
+		FROM achievements a
+		JOIN students s ON a.student_id = s.id
+		LEFT JOIN competition_levels l ON a.level_id = l.id
+		LEFT JOIN achievement_categories c ON a.category_id = c.id
+		LEFT JOIN ranks rk ON a.rank_id = rk.id
+		WHERE %s
+		ORDER BY l.order_rank DESC NULLS LAST, rk.order_rank NULLS LAST, a.year, s.full_name, a.competition_name
+	`, where)
+
+	var achievements []*model.ReportAchievement
+	if err := conn(ctx, r.db).SelectContext(ctx, &achievements, query, args...); err != nil {
+		return nil, err
+	}
+
+	var teamIDs []string
+	for _, a := range achievements {
+		if a.TeamName != nil {
+			teamIDs = append(teamIDs, a.ID.String())
+		}
+	}
+	if len(teamIDs) == 0 {
+		return achievements, nil
+	}
+
+	participants, err := findParticipants(ctx, conn(ctx, r.db), teamIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range achievements {
+		for _, p := range participants[a.ID] {
+			if p.Role != model.ParticipantRoleCoach && p.StudentName != nil {
+				a.Members = append(a.Members, *p.StudentName)
+			}
+		}
+	}
+	return achievements, nil
+}
+
+// Pastikan interface terpenuhi
+var _ ReportRepository = (*reportRepository)(nil)
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+type ScoringRepository interface {
+	FindRules(ctx context.Context) ([]*model.ScoringRule, error)
+	UpdateRules(ctx context.Context, rules []model.UpdateScoringRule, updatedBy *uuid.UUID) error
+	FindScoringInputs(ctx context.Context, filter model.LeaderboardFilter) ([]*model.ScoringInput, error)
+}
+
+type scoringRepository struct {
+	db *sqlx.DB
+}
+
+func NewScoringRepository(db *sqlx.DB) ScoringRepository {
+	return &scoringRepository{db: db}
+}
+
+func (r *scoringRepository) FindRules(ctx context.Context) ([]*model.ScoringRule, error) {
+	var rules []*model.ScoringRule
+	query := `SELECT * FROM scoring_rules ORDER BY kind, key`
+	if err := conn(ctx, r.db).SelectContext(ctx, &rules, query); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// UpdateRules menyimpan beberapa aturan sekaligus; aturan yang belum ada ditambahkan
+func (r *scoringRepository) UpdateRules(ctx context.Context, rules []model.UpdateScoringRule, updatedBy *uuid.UUID) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rule := range rules {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO scoring_rules (kind, key, value, updated_by, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (kind, key) DO UPDATE SET
+				value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		`, rule.Kind, rule.Key, rule.Value, updatedBy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindScoringInputs mengambil prestasi terverifikasi beserta data siswa, tingkat dan
+// kategori yang dibutuhkan untuk menghitung poin. Prestasi tim menghasilkan satu baris
+// per anggota; pelatih tidak ikut mendapat poin.
+func (r *scoringRepository) FindScoringInputs(ctx context.Context, filter model.LeaderboardFilter) ([]*model.ScoringInput, error) {
+	conditions := []string{"a.status = 'verified'"}
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.StudentID != "" {
+		conditions = append(conditions, fmt.Sprintf("h.student_id = $%d", argIdx))
+		args = append(args, filter.StudentID)
+		argIdx++
+	}
+
+	if filter.Class != "" {
+		conditions = append(conditions, fmt.Sprintf("s.class = $%d", argIdx))
+		args = append(args, filter.Class)
+		argIdx++
+	}
+
+	if filter.YearGraduate != nil {
+		conditions = append(conditions, fmt.Sprintf("s.year_graduate = $%d", argIdx))
+		args = append(args, *filter.YearGraduate)
+		argIdx++
+	}
+
+	if filter.Year != nil {
+		conditions = append(conditions, fmt.Sprintf("a.year = $%d", argIdx))
+		args = append(args, *filter.Year)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT a.id AS achievement_id, h.student_id, s.full_name AS student_name,
+		       s.nisn AS student_nisn, COALESCE(s.class, '') AS student_class, s.year_graduate,
+		       a.competition_name, a.year, COALESCE(a.rank, '') AS rank, rk.code AS rank_code,
+		       l.name AS level_name, l.order_rank AS level_order,
+		       c.name AS category_name, c.type AS category_type
+		FROM achievements a
+		JOIN %s h ON h.achievement_id = a.id
+		JOIN students s ON h.student_id = s.id
+		LEFT JOIN competition_levels l ON a.level_id = l.id
+		LEFT JOIN ranks rk ON a.rank_id = rk.id
+		LEFT JOIN achievement_categories c ON a.category_id = c.id
+		WHERE %s
+		ORDER BY s.full_name, a.year DESC, a.competition_name
+	`, achievementHoldersSQL, strings.Join(conditions, " AND "))
+
+	var inputs []*model.ScoringInput
+	if err := conn(ctx, r.db).SelectContext(ctx, &inputs, query, args...); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// achievementHoldersSQL pasangan (achievement_id, student_id) pemilik prestasi: siswa utama
+// ditambah kapten/anggota tim. Pelatih tidak dihitung sebagai pemilik.
+const achievementHoldersSQL = `(
+	SELECT id AS achievement_id, student_id FROM achievements
+	UNION
+	SELECT achievement_id, student_id FROM achievement_participants WHERE role <> 'coach'
+)`
+
+// Pastikan interface terpenuhi
+var _ ScoringRepository = (*scoringRepository)(nil)
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+type SessionRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Session, error)
+	FindByUser(ctx context.Context, userID uuid.UUID) ([]*model.Session, error)
+	FindActive(ctx context.Context, id, userID uuid.UUID) (*model.Session, error)
+	Create(ctx context.Context, session *model.Session) error
+	Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID, reason string) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID, reason string) (int64, error)
+	RevokeOthers(ctx context.Context, userID, keepID uuid.UUID, reason string) (int64, error)
+}
+
+type sessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	return r.findOne(ctx, "SELECT * FROM sessions WHERE id = $1", id)
+}
+
+// FindByUser seluruh sesi user, terbaru lebih dulu
+func (r *sessionRepository) FindByUser(ctx context.Context, userID uuid.UUID) ([]*model.Session, error) {
+	var sessions []*model.Session
+	err := conn(ctx, r.db).SelectContext(ctx, &sessions,
+		"SELECT * FROM sessions WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	return sessions, err
+}
+
+// FindActive sesi yang belum dicabut, belum kedaluwarsa dan pemiliknya masih aktif
+func (r *sessionRepository) FindActive(ctx context.Context, id, userID uuid.UUID) (*model.Session, error) {
+	return r.findOne(ctx, `
+		SELECT s.*, u.must_change_password FROM sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.id = $1 AND s.user_id = $2
+		  AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		  AND u.is_active
+	`, id, userID)
+}
+
+func (r *sessionRepository) findOne(ctx context.Context, query string, args ...interface{}) (*model.Session, error) {
+	var session model.Session
+	if err := conn(ctx, r.db).GetContext(ctx, &session, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	_, err := conn(ctx, r.db).NamedExecContext(ctx, `
+		INSERT INTO sessions (id, user_id, refresh_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES (:id, :user_id, :refresh_token_hash, :user_agent, :ip_address, NOW(), NOW(), :expires_at)
+	`, session)
+	return err
+}
+
+// Rotate mengganti refresh token sesi hanya jika token lama masih yang berlaku.
+// false berarti token lama sudah pernah dipakai (atau sesi dicabut).
+func (r *sessionRepository) Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE sessions
+		SET refresh_token_hash = $3, expires_at = $4, last_used_at = NOW()
+		WHERE id = $1 AND refresh_token_hash = $2 AND revoked_at IS NULL
+	`, id, oldHash, newHash, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Revoke mencabut sesi; false jika sesi sudah dicabut sebelumnya
+func (r *sessionRepository) Revoke(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2 WHERE id = $1 AND revoked_at IS NULL",
+		id, reason)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RevokeAllForUser mencabut semua sesi user yang masih berlaku
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID, reason string) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	`, userID, reason)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RevokeOthers mencabut semua sesi user yang masih berlaku kecuali keepID
+func (r *sessionRepository) RevokeOthers(ctx context.Context, userID, keepID uuid.UUID, reason string) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND expires_at > NOW()
+	`, userID, keepID, reason)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Pastikan interface terpenuhi
+var _ SessionRepository = (*sessionRepository)(nil)
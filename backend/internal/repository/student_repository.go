@@ -18,10 +18,13 @@ type StudentRepository interface {
 	FindAll(ctx context.Context, filter model.StudentFilter) ([]*model.Student, int64, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Student, error)
 	FindByNISN(ctx context.Context, nisn string) (*model.Student, error)
+	FindByNISNs(ctx context.Context, nisns []string) ([]*model.Student, error)
+	FindAllForMatching(ctx context.Context) ([]*model.Student, error)
 	Create(ctx context.Context, student *model.Student) error
 	Update(ctx context.Context, student *model.Student) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	UpdatePhoto(ctx context.Context, id uuid.UUID, photoURL string) error
+	UpsertMany(ctx context.Context, students []*model.Student) ([]bool, error)
 }
 
 type studentRepository struct {
@@ -68,7 +71,7 @@ func (r *studentRepository) FindAll(ctx context.Context, filter model.StudentFil
 	// Count total
 	var total int64
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM students WHERE %s", where)
-	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+	if err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
@@ -86,7 +89,7 @@ func (r *studentRepository) FindAll(ctx context.Context, filter model.StudentFil
 	args = append(args, filter.PerPage, offset)
 
 	var students []*model.Student
-	if err := r.db.SelectContext(ctx, &students, query, args...); err != nil {
+	if err := conn(ctx, r.db).SelectContext(ctx, &students, query, args...); err != nil {
 		return nil, 0, err
 	}
 
@@ -100,7 +103,7 @@ func (r *studentRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.
 		       year_entry, year_graduate, photo_url, created_at, updated_at
 		FROM students WHERE id = $1
 	`
-	err := r.db.GetContext(ctx, &student, query, id)
+	err := conn(ctx, r.db).GetContext(ctx, &student, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -112,7 +115,7 @@ func (r *studentRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.
 
 func (r *studentRepository) FindByNISN(ctx context.Context, nisn string) (*model.Student, error) {
 	var student model.Student
-	err := r.db.GetContext(ctx, &student, "SELECT * FROM students WHERE nisn = $1", nisn)
+	err := conn(ctx, r.db).GetContext(ctx, &student, "SELECT * FROM students WHERE nisn = $1", nisn)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -122,6 +125,25 @@ func (r *studentRepository) FindByNISN(ctx context.Context, nisn string) (*model
 	return &student, nil
 }
 
+func (r *studentRepository) FindByNISNs(ctx context.Context, nisns []string) ([]*model.Student, error) {
+	var students []*model.Student
+	err := conn(ctx, r.db).SelectContext(ctx, &students, "SELECT * FROM students WHERE nisn = ANY($1::text[])", nisns)
+	return students, err
+}
+
+// FindAllForMatching semua siswa tanpa paging, untuk mencocokkan nama saat import
+func (r *studentRepository) FindAllForMatching(ctx context.Context) ([]*model.Student, error) {
+	var students []*model.Student
+	query := `
+		SELECT id, nisn, full_name, birth_place, birth_date, gender, class,
+		       year_entry, year_graduate, photo_url, created_at, updated_at
+		FROM students
+		ORDER BY full_name ASC
+	`
+	err := conn(ctx, r.db).SelectContext(ctx, &students, query)
+	return students, err
+}
+
 func (r *studentRepository) Create(ctx context.Context, student *model.Student) error {
 	query := `
 		INSERT INTO students (id, nisn, full_name, birth_place, birth_date, gender, class,
@@ -129,7 +151,7 @@ func (r *studentRepository) Create(ctx context.Context, student *model.Student)
 		VALUES (:id, :nisn, :full_name, :birth_place, :birth_date, :gender, :class,
 		        :year_entry, :year_graduate, :photo_url, NOW(), NOW())
 	`
-	_, err := r.db.NamedExecContext(ctx, query, student)
+	_, err := conn(ctx, r.db).NamedExecContext(ctx, query, student)
 	return err
 }
 
@@ -141,23 +163,61 @@ func (r *studentRepository) Update(ctx context.Context, student *model.Student)
 			year_graduate = :year_graduate, updated_at = NOW()
 		WHERE id = :id
 	`
-	_, err := r.db.NamedExecContext(ctx, query, student)
+	_, err := conn(ctx, r.db).NamedExecContext(ctx, query, student)
 	return err
 }
 
 func (r *studentRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM students WHERE id = $1", id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM students WHERE id = $1", id)
 	return err
 }
 
 func (r *studentRepository) UpdatePhoto(ctx context.Context, id uuid.UUID, photoURL string) error {
-	_, err := r.db.ExecContext(ctx,
+	_, err := conn(ctx, r.db).ExecContext(ctx,
 		"UPDATE students SET photo_url = $1, updated_at = $2 WHERE id = $3",
 		photoURL, time.Now(), id,
 	)
 	return err
 }
 
+// UpsertMany menyimpan hasil import dalam satu transaksi: siswa baru di-insert,
+// NISN yang sudah ada di-update. Jika satu baris gagal, semua dibatalkan. ID setiap
+// siswa diisi ID yang tersimpan; hasilnya true untuk siswa yang baru di-insert.
+func (r *studentRepository) UpsertMany(ctx context.Context, students []*model.Student) ([]bool, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO students (id, nisn, full_name, birth_place, birth_date, gender, class,
+		                      year_entry, year_graduate, photo_url, created_at, updated_at)
+		VALUES (:id, :nisn, :full_name, :birth_place, :birth_date, :gender, :class,
+		        :year_entry, :year_graduate, :photo_url, NOW(), NOW())
+		ON CONFLICT (nisn) DO UPDATE SET
+			full_name = EXCLUDED.full_name, birth_place = EXCLUDED.birth_place,
+			birth_date = EXCLUDED.birth_date, gender = EXCLUDED.gender, class = EXCLUDED.class,
+			year_entry = EXCLUDED.year_entry, year_graduate = EXCLUDED.year_graduate,
+			updated_at = NOW()
+		RETURNING id, (xmax = 0) AS inserted
+	`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	inserted := make([]bool, len(students))
+	for i, student := range students {
+		if err := stmt.QueryRowxContext(ctx, student).Scan(&student.ID, &inserted[i]); err != nil {
+			return nil, fmt.Errorf("NISN %s: %w", student.NISN, err)
+		}
+	}
+
+	return inserted, tx.Commit()
+}
+
 // Pastikan interface terpenuhi
 var _ StudentRepository = (*studentRepository)(nil)
 var _ = response.Success // suppress unused import
\ No newline at end of file
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Transactor menjalankan beberapa operasi repository dalam satu transaksi database.
+// Transaksi dibawa lewat context: method repository yang dipanggil dengan ctx dari fn
+// ikut transaksi tersebut, dan transaksi milik method itu sendiri menjadi savepoint.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	db *sqlx.DB
+}
+
+func NewTransactor(db *sqlx.DB) Transactor {
+	return &transactor{db: db}
+}
+
+type txKey struct{}
+
+type txState struct {
+	tx           *sqlx.Tx
+	savepoints   int
+	beforeCommit []func(ctx context.Context) error
+	afterCommit  []func()
+}
+
+// WithinTx meng-commit transaksi hanya jika fn dan semua hook BeforeCommit berhasil.
+// Pemanggilan bersarang ikut transaksi terluar.
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state := &txState{tx: tx}
+	txCtx := context.WithValue(ctx, txKey{}, state)
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+	// Hook boleh mendaftarkan hook baru, jadi jangan pakai range
+	for i := 0; i < len(state.beforeCommit); i++ {
+		if err := state.beforeCommit[i](txCtx); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, hook := range state.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// BeforeCommit menjalankan fn tepat sebelum transaksi di ctx di-commit; error dari fn
+// membatalkan transaksi. Tanpa transaksi, fn langsung dijalankan.
+func BeforeCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.beforeCommit = append(state.beforeCommit, fn)
+		return nil
+	}
+	return fn(ctx)
+}
+
+// AfterCommit menjalankan fn setelah transaksi di ctx berhasil di-commit, mis. untuk
+// invalidasi cache. Tanpa transaksi, fn langsung dijalankan.
+func AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// querier method yang sama pada sqlx.DB dan sqlx.Tx
+type querier interface {
+	sqlx.QueryerContext
+	sqlx.ExecerContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn transaksi dari ctx jika ada, selain itu pool koneksi
+func conn(ctx context.Context, db *sqlx.DB) querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
+
+// repoTx transaksi milik satu method repository. Di dalam WithinTx menjadi savepoint
+// sehingga Commit/Rollback hanya berlaku untuk bagian milik method tersebut.
+type repoTx struct {
+	*sqlx.Tx
+	savepoint string
+	done      bool
+}
+
+func beginTx(ctx context.Context, db *sqlx.DB) (*repoTx, error) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &repoTx{Tx: tx}, nil
+	}
+
+	state.savepoints++
+	name := fmt.Sprintf("repo_sp_%d", state.savepoints)
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+	return &repoTx{Tx: state.tx, savepoint: name}, nil
+}
+
+func (t *repoTx) Commit() error {
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
+
+func (t *repoTx) Rollback() error {
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Tx.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint)
+	return err
+}
@@ -4,17 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrLastActiveAdmin perubahan ditolak karena tidak akan tersisa admin aktif
+var ErrLastActiveAdmin = errors.New("minimal harus ada satu admin aktif")
+
 type UserRepository interface {
+	FindAll(ctx context.Context, filter model.UserFilter) ([]*model.User, int64, error)
 	FindByEmail(ctx context.Context, email string) (*model.User, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 	Create(ctx context.Context, user *model.User) error
 	Update(ctx context.Context, user *model.User) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string, mustChange bool) error
 }
 
 type userRepository struct {
@@ -25,15 +32,78 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+func (r *userRepository) FindAll(ctx context.Context, filter model.UserFilter) ([]*model.User, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR email ILIKE $%d)", argIdx, argIdx+1))
+		search := "%" + filter.Search + "%"
+		args = append(args, search, search)
+		argIdx += 2
+	}
+
+	if filter.Role != "" {
+		conditions = append(conditions, fmt.Sprintf("role = $%d", argIdx))
+		args = append(args, filter.Role)
+		argIdx++
+	}
+
+	if filter.IsActive != nil {
+		conditions = append(conditions, fmt.Sprintf("is_active = $%d", argIdx))
+		args = append(args, *filter.IsActive)
+		argIdx++
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users WHERE %s", where)
+	if err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	query := fmt.Sprintf(`
+		SELECT id, name, email, password, role, is_active, must_change_password,
+		       password_changed_at, totp_secret, totp_enabled_at, totp_last_step,
+		       created_at, updated_at
+		FROM users
+		WHERE %s
+		ORDER BY name ASC
+		LIMIT $%d OFFSET $%d
+	`, where, argIdx, argIdx+1)
+
+	args = append(args, filter.PerPage, offset)
+
+	var users []*model.User
+	if err := conn(ctx, r.db).SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// FindByEmail juga mengembalikan user nonaktif; pemanggil yang memeriksa IsActive
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
 	query := `
-		SELECT id, name, email, password, role, is_active, created_at, updated_at
+		SELECT id, name, email, password, role, is_active, must_change_password,
+		       password_changed_at, totp_secret, totp_enabled_at, totp_last_step,
+		       created_at, updated_at
 		FROM users
-		WHERE email = $1 AND is_active = TRUE
+		WHERE email = $1
 		LIMIT 1
 	`
-	err := r.db.GetContext(ctx, &user, query, email)
+	err := conn(ctx, r.db).GetContext(ctx, &user, query, email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil // not found, bukan error
@@ -46,12 +116,14 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.
 func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	var user model.User
 	query := `
-		SELECT id, name, email, password, role, is_active, created_at, updated_at
+		SELECT id, name, email, password, role, is_active, must_change_password,
+		       password_changed_at, totp_secret, totp_enabled_at, totp_last_step,
+		       created_at, updated_at
 		FROM users
 		WHERE id = $1
 		LIMIT 1
 	`
-	err := r.db.GetContext(ctx, &user, query, id)
+	err := conn(ctx, r.db).GetContext(ctx, &user, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -63,19 +135,57 @@ func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Use
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	query := `
-		INSERT INTO users (id, name, email, password, role, is_active, created_at, updated_at)
-		VALUES (:id, :name, :email, :password, :role, :is_active, NOW(), NOW())
+		INSERT INTO users (id, name, email, password, role, is_active, must_change_password, created_at, updated_at)
+		VALUES (:id, :name, :email, :password, :role, :is_active, :must_change_password, NOW(), NOW())
 	`
-	_, err := r.db.NamedExecContext(ctx, query, user)
+	_, err := conn(ctx, r.db).NamedExecContext(ctx, query, user)
 	return err
 }
 
+// Update menyimpan perubahan user. Baris admin aktif dikunci lebih dulu agar dua
+// perubahan bersamaan tidak bisa menghabiskan admin aktif (ErrLastActiveAdmin).
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var adminIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &adminIDs,
+		"SELECT id FROM users WHERE role = $1 AND is_active FOR UPDATE", model.RoleAdmin,
+	); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE users
 		SET name = :name, email = :email, role = :role, is_active = :is_active, updated_at = NOW()
 		WHERE id = :id
 	`
-	_, err := r.db.NamedExecContext(ctx, query, user)
+	if _, err := tx.NamedExecContext(ctx, query, user); err != nil {
+		return err
+	}
+
+	var admins int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM users WHERE role = $1 AND is_active", model.RoleAdmin,
+	).Scan(&admins); err != nil {
+		return err
+	}
+	if admins == 0 && len(adminIDs) > 0 {
+		return ErrLastActiveAdmin
+	}
+
+	return tx.Commit()
+}
+
+// UpdatePassword mengganti password; mustChange true untuk password yang ditentukan admin
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string, mustChange bool) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE users
+		SET password = $2, must_change_password = $3, password_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id, passwordHash, mustChange)
 	return err
 }
\ No newline at end of file
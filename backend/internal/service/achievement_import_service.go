@@ -0,0 +1,739 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAchievementImportRows = 2000
+	// fuzzyMatchThreshold kemiripan nama minimum agar siswa diusulkan sebagai kecocokan
+	fuzzyMatchThreshold = 0.8
+)
+
+var (
+	ErrImportBatchNotFound   = errors.New("batch import tidak ditemukan")
+	ErrImportRowNotFound     = errors.New("baris import tidak ditemukan")
+	ErrImportBatchNotPending = errors.New("batch import sudah disetujui atau dibatalkan")
+	ErrImportBatchUnresolved = errors.New("masih ada baris yang perlu ditinjau atau diperbaiki")
+	ErrImportBatchNoReadyRow = errors.New("tidak ada baris yang siap diimport")
+	ErrImportBatchChanged    = errors.New("batch import berubah saat disetujui, muat ulang dan coba lagi")
+	ErrImportRowImported     = errors.New("baris sudah diimport dan tidak dapat diubah")
+)
+
+// achievementImportColumns alias judul kolom spreadsheet hasil lomba
+var achievementImportColumns = map[string][]string{
+	"nisn":             {"NISN"},
+	"student_name":     {"Nama", "Nama Siswa", "Nama Peserta", "Nama Peserta Didik", "student_name"},
+	"class":            {"Kelas", "Rombel", "class"},
+	"competition_name": {"Nama Lomba", "Lomba", "Kompetisi", "Cabang Lomba", "competition_name"},
+	"organizer":        {"Penyelenggara", "organizer"},
+	"category":         {"Kategori", "category"},
+	"level":            {"Tingkat", "Level", "level"},
+	"rank":             {"Juara", "Peringkat", "rank"},
+	"year":             {"Tahun", "year"},
+	"description":      {"Keterangan", "Deskripsi", "description"},
+}
+
+type AchievementImportService interface {
+	Stage(ctx context.Context, filename string, data []byte, createdBy string) (*model.AchievementImportBatchDetail, error)
+	GetAll(ctx context.Context, filter model.AchievementImportFilter) ([]*model.AchievementImportBatch, *response.Pagination, error)
+	GetByID(ctx context.Context, id string) (*model.AchievementImportBatchDetail, error)
+	UpdateRow(ctx context.Context, batchID, rowID string, req model.UpdateAchievementImportRowRequest) (*model.AchievementImportRow, error)
+	Approve(ctx context.Context, id string, reviewedBy string) (*model.AchievementImportBatchDetail, error)
+	Discard(ctx context.Context, id string, reviewedBy string) (*model.AchievementImportBatch, error)
+}
+
+type achievementImportService struct {
+	repo        repository.AchievementImportRepository
+	achRepo     repository.AchievementRepository
+	studentRepo repository.StudentRepository
+	tx          repository.Transactor
+	audit       AuditService
+}
+
+func NewAchievementImportService(
+	repo repository.AchievementImportRepository,
+	achRepo repository.AchievementRepository,
+	studentRepo repository.StudentRepository,
+	tx repository.Transactor,
+	audit AuditService,
+) AchievementImportService {
+	return &achievementImportService{repo: repo, achRepo: achRepo, studentRepo: studentRepo, tx: tx, audit: audit}
+}
+
+// importLookup data referensi untuk mencocokkan dan memvalidasi baris staging
+type importLookup struct {
+	students       []*model.Student
+	studentsByID   map[uuid.UUID]*model.Student
+	studentsByNISN map[string]*model.Student
+	categories     map[string]int // nama ternormalisasi -> id
+	levels         map[string]int
+	existing       map[string]uuid.UUID // kunci duplikat -> prestasi yang sudah ada
+}
+
+// Stage membaca file dan menyimpan hasilnya sebagai batch staging untuk ditinjau.
+// Belum ada prestasi yang dibuat sampai batch disetujui.
+func (s *achievementImportService) Stage(ctx context.Context, filename string, data []byte, createdBy string) (*model.AchievementImportBatchDetail, error) {
+	sheet, err := utils.ReadSpreadsheet(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	headerIdx, columns, ok := utils.FindHeaderRow(sheet, achievementImportColumns, []string{"competition_name", "rank"})
+	if !ok {
+		return nil, ErrImportHeaderNotFound
+	}
+	_, hasNISN := columns["nisn"]
+	_, hasName := columns["student_name"]
+	if !hasNISN && !hasName {
+		return nil, ErrImportHeaderNotFound
+	}
+
+	lookup, err := s.loadLookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &model.AchievementImportBatch{
+		ID:       uuid.New(),
+		FileName: filename,
+		Status:   model.ImportBatchPending,
+	}
+	if uid, err := uuid.Parse(createdBy); err == nil {
+		batch.CreatedBy = &uid
+	}
+
+	var rows []*model.AchievementImportRow
+	for i := headerIdx + 1; i < len(sheet); i++ {
+		cell := func(field string) string {
+			idx, ok := columns[field]
+			if !ok || idx >= len(sheet[i]) {
+				return ""
+			}
+			return utils.SanitizeString(sheet[i][idx])
+		}
+
+		if cell("competition_name") == "" && cell("nisn") == "" && cell("student_name") == "" {
+			continue
+		}
+
+		row := &model.AchievementImportRow{
+			ID:              uuid.New(),
+			BatchID:         batch.ID,
+			LineNumber:      i + 1,
+			NISN:            normalizeImportNISN(cell("nisn")),
+			StudentName:     cell("student_name"),
+			Class:           cell("class"),
+			CompetitionName: cell("competition_name"),
+			Organizer:       cell("organizer"),
+			CategoryName:    cell("category"),
+			LevelName:       cell("level"),
+			Rank:            cell("rank"),
+			Description:     cell("description"),
+			Errors:          model.ImportIssues{},
+		}
+		if v := cell("year"); v != "" {
+			if year, err := strconv.Atoi(v); err == nil && year >= 1900 && year <= 2100 {
+				row.Year = &year
+			} else {
+				row.Errors["year"] = "Tahun tidak valid"
+			}
+		}
+
+		matchImportStudent(row, lookup)
+		row.CategoryID = resolveReference(row.CategoryName, lookup.categories)
+		row.LevelID = resolveReference(row.LevelName, lookup.levels)
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(rows) > maxAchievementImportRows {
+		return nil, ErrImportTooManyRows
+	}
+	batch.TotalRows = len(rows)
+
+	if err := s.loadExisting(ctx, lookup, rows); err != nil {
+		return nil, err
+	}
+	evaluateImportRows(rows, lookup)
+
+	if err := s.repo.CreateBatch(ctx, batch, rows); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, batch.ID.String())
+}
+
+func (s *achievementImportService) GetAll(ctx context.Context, filter model.AchievementImportFilter) ([]*model.AchievementImportBatch, *response.Pagination, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	batches, total, err := s.repo.FindAllBatches(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int(total) / filter.PerPage
+	if int(total)%filter.PerPage > 0 {
+		totalPages++
+	}
+
+	return batches, &response.Pagination{
+		Page: filter.Page, PerPage: filter.PerPage,
+		TotalItems: total, TotalPages: totalPages,
+	}, nil
+}
+
+func (s *achievementImportService) GetByID(ctx context.Context, id string) (*model.AchievementImportBatchDetail, error) {
+	batch, err := s.getBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.FindRows(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AchievementImportBatchDetail{AchievementImportBatch: *batch, Rows: rows}, nil
+}
+
+// UpdateRow menerapkan koreksi operator lalu mengevaluasi ulang seluruh batch,
+// karena mengganti siswa pada satu baris bisa mengubah status duplikat baris lain.
+func (s *achievementImportService) UpdateRow(ctx context.Context, batchID, rowID string, req model.UpdateAchievementImportRowRequest) (*model.AchievementImportRow, error) {
+	batch, err := s.getBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != model.ImportBatchPending {
+		return nil, ErrImportBatchNotPending
+	}
+
+	rowUID, err := uuid.Parse(rowID)
+	if err != nil {
+		return nil, errors.New("ID baris tidak valid")
+	}
+
+	rows, err := s.repo.FindRows(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *model.AchievementImportRow
+	for _, row := range rows {
+		if row.ID == rowUID {
+			target = row
+		}
+	}
+	if target == nil {
+		return nil, ErrImportRowNotFound
+	}
+	if target.Status == model.ImportRowImported {
+		return nil, ErrImportRowImported
+	}
+
+	lookup, err := s.loadLookup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StudentID != nil {
+		studentUID, err := uuid.Parse(*req.StudentID)
+		if err != nil || lookup.studentsByID[studentUID] == nil {
+			return nil, ErrStudentNotFound
+		}
+		if target.StudentID == nil || *target.StudentID != studentUID {
+			method := model.StudentMatchManual
+			target.StudentID = &studentUID
+			target.MatchMethod = &method
+			target.MatchScore = nil
+			target.Confirmed = false
+		}
+	}
+	if req.CategoryID != nil {
+		if !containsID(lookup.categories, *req.CategoryID) {
+			return nil, errors.New("kategori tidak ditemukan")
+		}
+		target.CategoryID = req.CategoryID
+	}
+	if req.LevelID != nil {
+		if !containsID(lookup.levels, *req.LevelID) {
+			return nil, errors.New("tingkat lomba tidak ditemukan")
+		}
+		target.LevelID = req.LevelID
+	}
+	if req.Confirmed != nil {
+		target.Confirmed = *req.Confirmed
+	}
+	if req.Skipped != nil {
+		target.Skipped = *req.Skipped
+	}
+
+	if err := s.loadExisting(ctx, lookup, rows); err != nil {
+		return nil, err
+	}
+
+	type rowState struct {
+		status, duplicate string
+		errors, warnings  model.ImportIssues
+	}
+	state := func(r *model.AchievementImportRow) rowState {
+		dup := ""
+		if r.DuplicateOf != nil {
+			dup = r.DuplicateOf.String()
+		}
+		return rowState{r.Status, dup, r.Errors, r.Warnings}
+	}
+	before := make(map[uuid.UUID]rowState, len(rows))
+	for _, row := range rows {
+		before[row.ID] = state(row)
+	}
+
+	evaluateImportRows(rows, lookup)
+
+	for _, row := range rows {
+		if row.ID != target.ID && reflect.DeepEqual(before[row.ID], state(row)) {
+			continue
+		}
+		if err := s.repo.UpdateRow(ctx, row); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.FindRowByID(ctx, batch.ID, target.ID)
+}
+
+// Approve membuat prestasi untuk semua baris berstatus ready dalam satu transaksi
+func (s *achievementImportService) Approve(ctx context.Context, id string, reviewedBy string) (*model.AchievementImportBatchDetail, error) {
+	batch, err := s.getBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != model.ImportBatchPending {
+		return nil, ErrImportBatchNotPending
+	}
+
+	rows, err := s.repo.FindRows(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recheckReadyRows(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	ranks, err := loadRankResolver(ctx, s.achRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []model.ImportedAchievement
+	for _, row := range rows {
+		switch row.Status {
+		case model.ImportRowNeedsReview, model.ImportRowInvalid:
+			return nil, ErrImportBatchUnresolved
+		case model.ImportRowReady:
+			achievement := &model.Achievement{
+				ID:              uuid.New(),
+				StudentID:       *row.StudentID,
+				CompetitionName: row.CompetitionName,
+				Organizer:       row.Organizer,
+				CategoryID:      row.CategoryID,
+				LevelID:         row.LevelID,
+				Year:            *row.Year,
+				Description:     row.Description,
+				Status:          model.AchievementStatusSubmitted, // sudah ditinjau operator, tinggal diverifikasi
+				CreatedBy:       batch.CreatedBy,
+			}
+			if err := ranks.apply(achievement, nil, row.Rank); err != nil {
+				return nil, err
+			}
+			items = append(items, model.ImportedAchievement{RowID: row.ID, Achievement: achievement})
+		}
+	}
+	if len(items) == 0 {
+		return nil, ErrImportBatchNoReadyRow
+	}
+
+	reviewerUID, _ := uuid.Parse(reviewedBy)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		approved, err := s.repo.Approve(ctx, batch.ID, reviewerUID, items)
+		if err != nil {
+			return err
+		}
+		if !approved {
+			return ErrImportBatchChanged
+		}
+
+		for _, item := range items {
+			if err := s.audit.Record(ctx, model.AuditEntry{
+				Action: AuditAchievementImport, EntityType: AuditEntityAchievement,
+				EntityID: item.Achievement.ID.String(), After: item.Achievement,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, batch.ID.String())
+}
+
+// recheckReadyRows menilai ulang baris terhadap data terbaru, karena prestasi atau siswa
+// bisa berubah sejak batch ditinjau. Baris siap yang kini perlu ditinjau (mis. menjadi
+// duplikat prestasi yang baru dibuat) disimpan agar tampil ke operator, lalu
+// persetujuan ditolak.
+func (s *achievementImportService) recheckReadyRows(ctx context.Context, rows []*model.AchievementImportRow) error {
+	lookup, err := s.loadLookup(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.loadExisting(ctx, lookup, rows); err != nil {
+		return err
+	}
+
+	// Dinilai pada salinan: baris lain tetap diperlakukan sesuai status tersimpannya
+	current := make([]*model.AchievementImportRow, len(rows))
+	for i, row := range rows {
+		r := *row
+		current[i] = &r
+	}
+	evaluateImportRows(current, lookup)
+
+	changed := false
+	for i, row := range current {
+		if rows[i].Status != model.ImportRowReady || row.Status == model.ImportRowReady {
+			continue
+		}
+		if err := s.repo.UpdateRow(ctx, row); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if changed {
+		return ErrImportBatchChanged
+	}
+	return nil
+}
+
+func (s *achievementImportService) Discard(ctx context.Context, id string, reviewedBy string) (*model.AchievementImportBatch, error) {
+	batch, err := s.getBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewerUID, _ := uuid.Parse(reviewedBy)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		discarded, err := s.repo.Discard(ctx, batch.ID, reviewerUID)
+		if err != nil {
+			return err
+		}
+		if !discarded {
+			return ErrImportBatchNotPending
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditImportDiscard, EntityType: AuditEntityImportBatch,
+			EntityID: batch.ID.String(), Before: batch,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindBatchByID(ctx, batch.ID)
+}
+
+func (s *achievementImportService) getBatch(ctx context.Context, id string) (*model.AchievementImportBatch, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("ID tidak valid")
+	}
+
+	batch, err := s.repo.FindBatchByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, ErrImportBatchNotFound
+	}
+	return batch, nil
+}
+
+func (s *achievementImportService) loadLookup(ctx context.Context) (*importLookup, error) {
+	students, err := s.studentRepo.FindAllForMatching(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.achRepo.FindAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.achRepo.FindAllLevels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &importLookup{
+		students:       students,
+		studentsByID:   make(map[uuid.UUID]*model.Student, len(students)),
+		studentsByNISN: make(map[string]*model.Student, len(students)),
+		categories:     make(map[string]int, len(categories)),
+		levels:         make(map[string]int, len(levels)),
+	}
+	for _, st := range students {
+		lookup.studentsByID[st.ID] = st
+		lookup.studentsByNISN[st.NISN] = st
+	}
+	for _, c := range categories {
+		lookup.categories[utils.NormalizeName(c.Name)] = c.ID
+	}
+	for _, l := range levels {
+		lookup.levels[utils.NormalizeName(l.Name)] = l.ID
+	}
+	return lookup, nil
+}
+
+// loadExisting memuat prestasi siswa yang tercocokkan untuk deteksi duplikat
+func (s *achievementImportService) loadExisting(ctx context.Context, lookup *importLookup, rows []*model.AchievementImportRow) error {
+	var studentIDs []string
+	seen := make(map[uuid.UUID]bool)
+	for _, row := range rows {
+		if row.StudentID != nil && !seen[*row.StudentID] {
+			seen[*row.StudentID] = true
+			studentIDs = append(studentIDs, row.StudentID.String())
+		}
+	}
+
+	lookup.existing = make(map[string]uuid.UUID)
+	if len(studentIDs) == 0 {
+		return nil
+	}
+
+	achievements, err := s.achRepo.FindByStudentIDs(ctx, studentIDs)
+	if err != nil {
+		return err
+	}
+
+	// Baris yang sudah diimport dari batch ini tidak dihitung sebagai duplikat dirinya sendiri
+	imported := make(map[uuid.UUID]bool)
+	for _, row := range rows {
+		if row.AchievementID != nil {
+			imported[*row.AchievementID] = true
+		}
+	}
+	for _, a := range achievements {
+		if !imported[a.ID] {
+			lookup.existing[achievementDuplicateKey(a.StudentID, a.Year, a.CompetitionName, a.Rank)] = a.ID
+		}
+	}
+	return nil
+}
+
+// matchStudent mencocokkan siswa berdasarkan NISN, atau nama + kelas bila NISN kosong
+func matchImportStudent(row *model.AchievementImportRow, lookup *importLookup) {
+	setMatch := func(st *model.Student, method string, score float64) {
+		row.StudentID = &st.ID
+		row.MatchMethod = &method
+		row.MatchScore = &score
+	}
+
+	if row.NISN != "" {
+		if st := lookup.studentsByNISN[row.NISN]; st != nil {
+			setMatch(st, model.StudentMatchNISN, 1)
+			return
+		}
+		row.Errors["student_id"] = fmt.Sprintf("NISN %s tidak terdaftar", row.NISN)
+		return
+	}
+	if row.StudentName == "" {
+		row.Errors["student_id"] = "NISN atau nama siswa wajib diisi"
+		return
+	}
+
+	name := utils.NormalizeName(row.StudentName)
+	class := utils.NormalizeHeader(row.Class)
+
+	// Utamakan siswa di kelas yang sama; jika kelas kosong atau penulisannya
+	// berbeda dari data siswa, bandingkan dengan semua siswa
+	candidates := lookup.students
+	classMatched := false
+	if class != "" {
+		var inClass []*model.Student
+		for _, st := range lookup.students {
+			if utils.NormalizeHeader(st.Class) == class {
+				inClass = append(inClass, st)
+			}
+		}
+		if len(inClass) > 0 {
+			candidates = inClass
+			classMatched = true
+		}
+	}
+
+	var best *model.Student
+	bestScore, secondScore := 0.0, 0.0
+	for _, st := range candidates {
+		score := utils.Similarity(name, utils.NormalizeName(st.FullName))
+		if score > bestScore {
+			best, bestScore, secondScore = st, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+
+	switch {
+	case best == nil || bestScore < fuzzyMatchThreshold:
+		row.Errors["student_id"] = fmt.Sprintf("Siswa dengan nama %q tidak ditemukan", row.StudentName)
+	case secondScore == bestScore:
+		row.Errors["student_id"] = fmt.Sprintf("Lebih dari satu siswa cocok dengan nama %q, pilih siswa secara manual", row.StudentName)
+	case bestScore == 1 && classMatched:
+		setMatch(best, model.StudentMatchNameClass, 1)
+	default:
+		setMatch(best, model.StudentMatchFuzzy, bestScore)
+	}
+}
+
+// evaluateImportRows menghitung ulang kesalahan, peringatan dan status setiap baris.
+// Kesalahan hasil pencocokan siswa dan parsing tahun dipertahankan selama
+// belum diperbaiki operator.
+func evaluateImportRows(rows []*model.AchievementImportRow, lookup *importLookup) {
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		if row.Status == model.ImportRowImported {
+			continue
+		}
+
+		errs := model.ImportIssues{}
+		warnings := model.ImportIssues{}
+
+		req := model.CreateAchievementRequest{
+			CompetitionName: row.CompetitionName,
+			Organizer:       row.Organizer,
+			Rank:            row.Rank,
+			Description:     row.Description,
+		}
+		if row.Year != nil {
+			req.Year = *row.Year
+		}
+
+		var student *model.Student
+		if row.StudentID != nil {
+			student = lookup.studentsByID[*row.StudentID]
+		}
+		if student == nil {
+			msg := row.Errors["student_id"]
+			if msg == "" {
+				msg = "Siswa belum dipilih"
+			}
+			errs["student_id"] = msg
+		} else {
+			req.StudentID = student.ID.String()
+			if row.MatchMethod != nil && *row.MatchMethod == model.StudentMatchFuzzy {
+				score := 0.0
+				if row.MatchScore != nil {
+					score = *row.MatchScore
+				}
+				warnings["student_id"] = fmt.Sprintf("Dicocokkan dari kemiripan nama (%.0f%%) dengan %s (%s, kelas %s)",
+					score*100, student.FullName, student.NISN, student.Class)
+			}
+		}
+
+		if row.Year == nil && row.Errors["year"] != "" {
+			errs["year"] = row.Errors["year"]
+		}
+		for field, msg := range ValidateCreateAchievement(&req) {
+			if _, exists := errs[field]; !exists {
+				errs[field] = msg
+			}
+		}
+
+		if row.CategoryName != "" && row.CategoryID == nil {
+			errs["category_id"] = fmt.Sprintf("Kategori %q tidak dikenal", row.CategoryName)
+		}
+		if row.LevelName != "" && row.LevelID == nil {
+			errs["level_id"] = fmt.Sprintf("Tingkat %q tidak dikenal", row.LevelName)
+		}
+
+		row.DuplicateOf = nil
+		if student != nil && row.Year != nil && !row.Skipped {
+			key := achievementDuplicateKey(student.ID, *row.Year, row.CompetitionName, row.Rank)
+			if existingID, ok := lookup.existing[key]; ok {
+				row.DuplicateOf = &existingID
+				warnings["duplicate"] = "Prestasi yang sama sudah tercatat untuk siswa ini"
+			} else if line, ok := seen[key]; ok {
+				warnings["duplicate"] = fmt.Sprintf("Sama dengan baris %d di file ini", line)
+			} else {
+				seen[key] = row.LineNumber
+			}
+		}
+
+		row.Errors = errs
+		row.Warnings = warnings
+		switch {
+		case row.Skipped:
+			row.Status = model.ImportRowSkipped
+		case len(errs) > 0:
+			row.Status = model.ImportRowInvalid
+		case len(warnings) > 0 && !row.Confirmed:
+			row.Status = model.ImportRowNeedsReview
+		default:
+			row.Status = model.ImportRowReady
+		}
+	}
+}
+
+func achievementDuplicateKey(studentID uuid.UUID, year int, competitionName, rank string) string {
+	return fmt.Sprintf("%s|%d|%s|%s", studentID, year, utils.NormalizeName(competitionName), utils.NormalizeName(rank))
+}
+
+// resolveReference mencari id kategori/tingkat dari nama: sama persis setelah
+// dinormalisasi, atau satu-satunya nama referensi yang memuat teks tersebut
+// ("Kabupaten" -> "Kabupaten/Kota")
+func resolveReference(name string, refs map[string]int) *int {
+	n := utils.NormalizeName(name)
+	if n == "" {
+		return nil
+	}
+	if id, ok := refs[n]; ok {
+		return &id
+	}
+
+	var found *int
+	for refName, id := range refs {
+		if strings.Contains(refName, n) {
+			if found != nil {
+				return nil
+			}
+			found = &id
+		}
+	}
+	return found
+}
+
+func containsID(refs map[string]int, id int) bool {
+	for _, v := range refs {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/google/uuid"
+)
+
+type fakeImportRepo struct {
+	repository.AchievementImportRepository
+	updated []*model.AchievementImportRow
+}
+
+func (r *fakeImportRepo) UpdateRow(_ context.Context, row *model.AchievementImportRow) error {
+	r.updated = append(r.updated, row)
+	return nil
+}
+
+func TestRecheckReadyRowsRejectsNewDuplicate(t *testing.T) {
+	student := &model.Student{ID: uuid.New(), NISN: "0012345678", FullName: "Budi Santoso"}
+	year := 2026
+	readyRow := func(confirmed bool) *model.AchievementImportRow {
+		return &model.AchievementImportRow{
+			ID: uuid.New(), LineNumber: 2, StudentID: &student.ID, CompetitionName: "Olimpiade Sains",
+			Organizer: "Dinas Pendidikan", Rank: "Juara 1", Year: &year, Confirmed: confirmed,
+			Status: model.ImportRowReady, Errors: model.ImportIssues{}, Warnings: model.ImportIssues{},
+		}
+	}
+	// Prestasi yang sama dibuat lewat form setelah batch ditinjau
+	existing := &model.Achievement{ID: uuid.New(), StudentID: student.ID, CompetitionName: "olimpiade sains", Rank: "Juara 1", Year: year}
+
+	tests := []struct {
+		name        string
+		achievement []*model.Achievement
+		confirmed   bool
+		wantErr     error
+	}{
+		{name: "tidak ada prestasi baru", wantErr: nil},
+		{name: "duplikat baru belum dikonfirmasi", achievement: []*model.Achievement{existing}, wantErr: ErrImportBatchChanged},
+		{name: "duplikat sudah dikonfirmasi operator", achievement: []*model.Achievement{existing}, confirmed: true, wantErr: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeImportRepo{}
+			svc := &achievementImportService{
+				repo:        repo,
+				achRepo:     &fakeAchievementRepo{achievements: tt.achievement},
+				studentRepo: &fakeStudentRepo{students: map[uuid.UUID]*model.Student{student.ID: student}},
+			}
+			row := readyRow(tt.confirmed)
+
+			err := svc.recheckReadyRows(context.Background(), []*model.AchievementImportRow{row})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("recheckReadyRows() error = %v, seharusnya %v", err, tt.wantErr)
+			}
+			if row.Status != model.ImportRowReady || row.DuplicateOf != nil {
+				t.Error("baris asli ikut diubah, seharusnya hanya salinannya")
+			}
+			if tt.wantErr == nil {
+				if len(repo.updated) != 0 {
+					t.Errorf("baris disimpan padahal tidak berubah: %+v", repo.updated)
+				}
+				return
+			}
+			if len(repo.updated) != 1 {
+				t.Fatalf("baris tersimpan = %d, seharusnya 1", len(repo.updated))
+			}
+			got := repo.updated[0]
+			if got.Status != model.ImportRowNeedsReview || got.DuplicateOf == nil || *got.DuplicateOf != existing.ID {
+				t.Errorf("status = %s, duplicate_of = %v", got.Status, got.DuplicateOf)
+			}
+		})
+	}
+}
+
+func TestResolveReference(t *testing.T) {
+	levels := map[string]int{"nasional": 1, "provinsi": 2, "kabupaten kota": 3, "kecamatan": 4, "internasional": 5}
+	tests := []struct {
+		name string
+		want int // 0 = tidak ditemukan
+	}{
+		{"Nasional", 1},
+		{" PROVINSI ", 2},
+		{"Kabupaten/Kota", 3},
+		{"Kabupaten", 3}, // satu-satunya nama yang memuat teks
+		{"nasional ", 1}, // sama persis diutamakan walau "internasional" juga memuat teks
+		{"kota", 3},
+		{"a", 0}, // memuat lebih dari satu nama, ambigu
+		{"Sekolah", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		got := resolveReference(tt.name, levels)
+		switch {
+		case tt.want == 0 && got != nil:
+			t.Errorf("resolveReference(%q) = %d, seharusnya tidak ditemukan", tt.name, *got)
+		case tt.want != 0 && (got == nil || *got != tt.want):
+			t.Errorf("resolveReference(%q) = %v, seharusnya %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testImportLookup(students ...*model.Student) *importLookup {
+	lookup := &importLookup{
+		students:       students,
+		studentsByID:   make(map[uuid.UUID]*model.Student),
+		studentsByNISN: make(map[string]*model.Student),
+		existing:       make(map[string]uuid.UUID),
+	}
+	for _, st := range students {
+		lookup.studentsByID[st.ID] = st
+		lookup.studentsByNISN[st.NISN] = st
+	}
+	return lookup
+}
+
+func TestMatchImportStudent(t *testing.T) {
+	budi := &model.Student{ID: uuid.New(), NISN: "0011111111", FullName: "Budi Santoso", Class: "XII IPA 1"}
+	budiB := &model.Student{ID: uuid.New(), NISN: "0022222222", FullName: "Budi Santoso", Class: "XII IPS 2"}
+	siti := &model.Student{ID: uuid.New(), NISN: "0033333333", FullName: "Siti Nurhaliza", Class: "XI IPA 1"}
+	lookup := testImportLookup(budi, budiB, siti)
+
+	tests := []struct {
+		name       string
+		row        model.AchievementImportRow
+		want       *model.Student
+		wantMethod string
+		wantError  bool
+	}{
+		{name: "NISN terdaftar", row: model.AchievementImportRow{NISN: "0033333333", StudentName: "nama lain"}, want: siti, wantMethod: model.StudentMatchNISN},
+		{name: "NISN tidak terdaftar tidak jatuh ke nama", row: model.AchievementImportRow{NISN: "0099999999", StudentName: "Siti Nurhaliza"}, wantError: true},
+		{name: "tanpa NISN dan nama", row: model.AchievementImportRow{}, wantError: true},
+		{name: "nama sama persis di kelas yang sama", row: model.AchievementImportRow{StudentName: "budi santoso", Class: "XII IPS 2"}, want: budiB, wantMethod: model.StudentMatchNameClass},
+		{name: "nama kembar tanpa kelas ambigu", row: model.AchievementImportRow{StudentName: "Budi Santoso"}, wantError: true},
+		{name: "kelas tidak dikenal, cari di semua siswa", row: model.AchievementImportRow{StudentName: "Siti Nurhaliza", Class: "X IPA 9"}, want: siti, wantMethod: model.StudentMatchFuzzy},
+		{name: "kelas dikenal membatasi kandidat", row: model.AchievementImportRow{StudentName: "Siti Nurhaliza", Class: "XII IPA 1"}, wantError: true},
+		{name: "typo kecil dengan kelas", row: model.AchievementImportRow{StudentName: "Siti Nurhalisa", Class: "XI IPA 1"}, want: siti, wantMethod: model.StudentMatchFuzzy},
+		{name: "nama terlalu berbeda", row: model.AchievementImportRow{StudentName: "Andi Wijaya"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		row := tt.row
+		row.Errors = model.ImportIssues{}
+		matchImportStudent(&row, lookup)
+
+		if tt.wantError {
+			if row.StudentID != nil || row.Errors["student_id"] == "" {
+				t.Errorf("%s: student_id=%v errors=%v, seharusnya tidak cocok", tt.name, row.StudentID, row.Errors)
+			}
+			continue
+		}
+		if row.StudentID == nil || *row.StudentID != tt.want.ID || row.MatchMethod == nil || *row.MatchMethod != tt.wantMethod {
+			t.Errorf("%s: student_id=%v method=%v, seharusnya %s lewat %s", tt.name, row.StudentID, row.MatchMethod, tt.want.FullName, tt.wantMethod)
+		}
+	}
+}
+
+func TestEvaluateImportRows(t *testing.T) {
+	student := &model.Student{ID: uuid.New(), NISN: "0011111111", FullName: "Budi Santoso", Class: "XII"}
+	lookup := testImportLookup(student)
+	existingID := uuid.New()
+	lookup.existing[achievementDuplicateKey(student.ID, 2025, "Lomba Lama", "Juara 1")] = existingID
+
+	year := func(y int) *int { return &y }
+	fuzzy := model.StudentMatchFuzzy
+	score := 0.9
+	row := func(edit func(r *model.AchievementImportRow)) *model.AchievementImportRow {
+		r := &model.AchievementImportRow{
+			ID: uuid.New(), StudentID: &student.ID, CompetitionName: "Olimpiade Sains",
+			Organizer: "Dinas", Rank: "Juara 1", Year: year(2026),
+			Errors: model.ImportIssues{}, Warnings: model.ImportIssues{},
+		}
+		if edit != nil {
+			edit(r)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name          string
+		rows          []*model.AchievementImportRow
+		wantStatus    []string
+		wantDuplicate bool // baris terakhir ditandai duplikat prestasi tersimpan
+	}{
+		{name: "baris lengkap siap", rows: []*model.AchievementImportRow{row(nil)}, wantStatus: []string{model.ImportRowReady}},
+		{
+			name:       "siswa belum dipilih",
+			rows:       []*model.AchievementImportRow{row(func(r *model.AchievementImportRow) { r.StudentID = nil })},
+			wantStatus: []string{model.ImportRowInvalid},
+		},
+		{
+			name:       "kesalahan parsing tahun dipertahankan",
+			rows:       []*model.AchievementImportRow{row(func(r *model.AchievementImportRow) { r.Year = nil; r.Errors["year"] = "Tahun tidak valid" })},
+			wantStatus: []string{model.ImportRowInvalid},
+		},
+		{
+			name:       "kategori tidak dikenal",
+			rows:       []*model.AchievementImportRow{row(func(r *model.AchievementImportRow) { r.CategoryName = "Olahraga" })},
+			wantStatus: []string{model.ImportRowInvalid},
+		},
+		{
+			name: "kecocokan fuzzy perlu ditinjau, lalu siap setelah dikonfirmasi",
+			rows: []*model.AchievementImportRow{
+				row(func(r *model.AchievementImportRow) { r.MatchMethod, r.MatchScore = &fuzzy, &score }),
+				row(func(r *model.AchievementImportRow) {
+					r.CompetitionName = "Lomba Lain"
+					r.MatchMethod, r.MatchScore, r.Confirmed = &fuzzy, &score, true
+				}),
+			},
+			wantStatus: []string{model.ImportRowNeedsReview, model.ImportRowReady},
+		},
+		{
+			name: "duplikat di dalam file",
+			rows: []*model.AchievementImportRow{
+				row(nil),
+				row(func(r *model.AchievementImportRow) { r.CompetitionName = "olimpiade  SAINS" }),
+			},
+			wantStatus: []string{model.ImportRowReady, model.ImportRowNeedsReview},
+		},
+		{
+			name:          "duplikat prestasi tersimpan",
+			rows:          []*model.AchievementImportRow{row(func(r *model.AchievementImportRow) { r.CompetitionName, r.Year = "Lomba Lama", year(2025) })},
+			wantStatus:    []string{model.ImportRowNeedsReview},
+			wantDuplicate: true,
+		},
+		{
+			name: "baris dilewati tidak dicek duplikat",
+			rows: []*model.AchievementImportRow{row(func(r *model.AchievementImportRow) {
+				r.CompetitionName, r.Year, r.Skipped = "Lomba Lama", year(2025), true
+			})},
+			wantStatus: []string{model.ImportRowSkipped},
+		},
+		{
+			name:       "baris yang sudah diimport tidak dinilai ulang",
+			rows:       []*model.AchievementImportRow{row(func(r *model.AchievementImportRow) { r.StudentID, r.Status = nil, model.ImportRowImported })},
+			wantStatus: []string{model.ImportRowImported},
+		},
+	}
+
+	for _, tt := range tests {
+		evaluateImportRows(tt.rows, lookup)
+		for i, r := range tt.rows {
+			if r.Status != tt.wantStatus[i] {
+				t.Errorf("%s: baris %d status = %s (errors=%v warnings=%v), seharusnya %s",
+					tt.name, i, r.Status, r.Errors, r.Warnings, tt.wantStatus[i])
+			}
+		}
+		last := tt.rows[len(tt.rows)-1]
+		if gotDup := last.DuplicateOf != nil && *last.DuplicateOf == existingID; gotDup != tt.wantDuplicate {
+			t.Errorf("%s: duplicate_of = %v", tt.name, last.DuplicateOf)
+		}
+	}
+}
@@ -3,40 +3,147 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
-	"github.com/google/uuid"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/google/uuid"
 )
 
-var ErrAchievementNotFound = errors.New("prestasi tidak ditemukan")
+var (
+	ErrAchievementNotFound       = errors.New("prestasi tidak ditemukan")
+	ErrAchievementNotVerified    = errors.New("prestasi belum diverifikasi")
+	ErrAchievementTransition     = errors.New("perubahan status prestasi tidak diizinkan")
+	ErrAchievementCommentMissing = errors.New("komentar wajib diisi saat menolak prestasi")
+	ErrRankNotFound              = errors.New("peringkat tidak ditemukan")
+	ErrParticipantPrimary        = errors.New("siswa utama harus termasuk anggota tim dan bukan pelatih")
+)
+
+// achievementTransitions perubahan status yang diizinkan
+var achievementTransitions = map[string][]string{
+	model.AchievementStatusDraft:     {model.AchievementStatusSubmitted},
+	model.AchievementStatusSubmitted: {model.AchievementStatusVerified, model.AchievementStatusRejected},
+	model.AchievementStatusVerified:  {model.AchievementStatusRejected},
+	model.AchievementStatusRejected:  {model.AchievementStatusSubmitted},
+}
 
 type AchievementService interface {
 	GetAll(ctx context.Context, filter model.AchievementFilter) ([]*model.Achievement, *response.Pagination, error)
 	GetByID(ctx context.Context, id string) (*model.AchievementWithAttachments, error)
 	Create(ctx context.Context, req model.CreateAchievementRequest, createdBy string) (*model.Achievement, error)
-	Update(ctx context.Context, id string, req model.UpdateAchievementRequest) (*model.Achievement, error)
+	Update(ctx context.Context, id string, req model.UpdateAchievementRequest, actorID string) (*model.Achievement, error)
+	UpdateParticipants(ctx context.Context, id string, req model.UpdateParticipantsRequest, actorID string) (*model.Achievement, error)
 	Delete(ctx context.Context, id string) error
 	UploadAttachment(ctx context.Context, achievementID string, data []byte, contentType, label string) (*model.AchievementAttachment, error)
 	DeleteAttachment(ctx context.Context, attachmentID string) error
 	GetCategories(ctx context.Context) ([]*model.AchievementCategory, error)
 	GetLevels(ctx context.Context) ([]*model.CompetitionLevel, error)
+	GetRanks(ctx context.Context) ([]*model.Rank, error)
+	GetUnmatchedRanks(ctx context.Context) ([]*model.UnmatchedRank, error)
+	Submit(ctx context.Context, id string, actorID string) (*model.Achievement, error)
+	Verify(ctx context.Context, id string, req model.ReviewAchievementRequest, actorID string) (*model.Achievement, error)
+	Reject(ctx context.Context, id string, req model.ReviewAchievementRequest, actorID string) (*model.Achievement, error)
+	GetStatusHistory(ctx context.Context, id string) ([]*model.AchievementStatusEvent, error)
 }
 
 type achievementService struct {
 	repo        repository.AchievementRepository
 	studentRepo repository.StudentRepository
 	storage     *utils.StorageService
+	tx          repository.Transactor
+	audit       AuditService
 }
 
 func NewAchievementService(
 	repo repository.AchievementRepository,
 	studentRepo repository.StudentRepository,
 	storage *utils.StorageService,
+	tx repository.Transactor,
+	audit AuditService,
 ) AchievementService {
-	return &achievementService{repo: repo, studentRepo: studentRepo, storage: storage}
+	return &achievementService{repo: repo, studentRepo: studentRepo, storage: storage, tx: tx, audit: audit}
+}
+
+// ValidateCreateAchievement membersihkan dan memvalidasi data prestasi baru.
+// Dipakai endpoint create maupun import prestasi.
+func ValidateCreateAchievement(req *model.CreateAchievementRequest) utils.ValidationErrors {
+	errs := utils.ValidationErrors{}
+	req.CompetitionName = utils.SanitizeString(req.CompetitionName)
+	req.Organizer = utils.SanitizeString(req.Organizer)
+
+	if len(req.Participants) > 0 {
+		req.TeamName = utils.SanitizeString(req.TeamName)
+		for field, msg := range ValidateParticipants(req.TeamName, req.Participants) {
+			errs[field] = msg
+		}
+	} else if req.StudentID == "" {
+		errs["student_id"] = "Student ID wajib diisi"
+	}
+	if req.CompetitionName == "" {
+		errs["competition_name"] = "Nama lomba wajib diisi"
+	}
+	if req.Organizer == "" {
+		errs["organizer"] = "Penyelenggara wajib diisi"
+	}
+	if req.Rank == "" && req.RankID == nil {
+		errs["rank"] = "Juara/peringkat wajib diisi"
+	}
+	if req.Year == 0 {
+		errs["year"] = "Tahun wajib diisi"
+	}
+
+	return errs
+}
+
+// ValidateParticipants memvalidasi anggota prestasi tim. Peran kosong dianggap member.
+func ValidateParticipants(teamName string, participants []model.ParticipantRequest) utils.ValidationErrors {
+	errs := utils.ValidationErrors{}
+	if len(participants) == 0 {
+		return errs
+	}
+	if teamName == "" {
+		errs["team_name"] = "Nama tim wajib diisi untuk prestasi tim"
+	}
+
+	seen := make(map[string]bool)
+	captains, players := 0, 0
+	for i := range participants {
+		p := &participants[i]
+		field := fmt.Sprintf("participants[%d]", i)
+
+		p.StudentID = utils.SanitizeString(p.StudentID)
+		if _, err := uuid.Parse(p.StudentID); err != nil {
+			errs[field+".student_id"] = "Student ID tidak valid"
+		} else if seen[p.StudentID] {
+			errs[field+".student_id"] = "Siswa tercantum lebih dari sekali"
+		}
+		seen[p.StudentID] = true
+
+		p.Role = strings.ToLower(utils.SanitizeString(p.Role))
+		switch p.Role {
+		case "":
+			p.Role = model.ParticipantRoleMember
+			players++
+		case model.ParticipantRoleCaptain:
+			captains++
+			players++
+		case model.ParticipantRoleMember:
+			players++
+		case model.ParticipantRoleCoach:
+		default:
+			errs[field+".role"] = "Peran harus captain, member atau coach"
+		}
+	}
+
+	if captains > 1 {
+		errs["participants"] = "Kapten tim hanya boleh satu"
+	} else if players == 0 {
+		errs["participants"] = "Tim minimal memiliki satu kapten atau anggota"
+	}
+	return errs
 }
 
 func (s *achievementService) GetAll(ctx context.Context, filter model.AchievementFilter) ([]*model.Achievement, *response.Pagination, error) {
@@ -81,15 +188,40 @@ func (s *achievementService) GetByID(ctx context.Context, id string) (*model.Ach
 }
 
 func (s *achievementService) Create(ctx context.Context, req model.CreateAchievementRequest, createdBy string) (*model.Achievement, error) {
-	studentUID, err := uuid.Parse(req.StudentID)
-	if err != nil {
-		return nil, errors.New("student_id tidak valid")
+	var studentUID uuid.UUID
+	var participants []model.AchievementParticipant
+	if len(req.Participants) > 0 {
+		var err error
+		if participants, err = s.resolveParticipants(ctx, req.Participants); err != nil {
+			return nil, err
+		}
+
+		var preferred uuid.UUID
+		if req.StudentID != "" {
+			if preferred, err = uuid.Parse(req.StudentID); err != nil {
+				return nil, errors.New("student_id tidak valid")
+			}
+		}
+		studentUID = teamPrimary(participants, preferred)
+		if preferred != uuid.Nil && studentUID != preferred {
+			return nil, ErrParticipantPrimary
+		}
+	} else {
+		var err error
+		if studentUID, err = uuid.Parse(req.StudentID); err != nil {
+			return nil, errors.New("student_id tidak valid")
+		}
+
+		// Pastikan student ada
+		student, err := s.studentRepo.FindByID(ctx, studentUID)
+		if err != nil || student == nil {
+			return nil, ErrStudentNotFound
+		}
 	}
 
-	// Pastikan student ada
-	student, err := s.studentRepo.FindByID(ctx, studentUID)
-	if err != nil || student == nil {
-		return nil, ErrStudentNotFound
+	ranks, err := loadRankResolver(ctx, s.repo)
+	if err != nil {
+		return nil, err
 	}
 
 	createdByUID, _ := uuid.Parse(createdBy)
@@ -100,21 +232,36 @@ func (s *achievementService) Create(ctx context.Context, req model.CreateAchieve
 		CompetitionName: req.CompetitionName,
 		Organizer:       req.Organizer,
 		CategoryID:      req.CategoryID,
-		Rank:            req.Rank,
 		LevelID:         req.LevelID,
 		Year:            req.Year,
 		Description:     req.Description,
+		Status:          model.AchievementStatusDraft,
 		CreatedBy:       &createdByUID,
+		Participants:    participants,
 	}
-
-	if err := s.repo.Create(ctx, achievement); err != nil {
+	if len(participants) > 0 {
+		achievement.TeamName = &req.TeamName
+	}
+	if err := ranks.apply(achievement, req.RankID, req.Rank); err != nil {
 		return nil, err
 	}
 
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, achievement); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAchievementCreate, EntityType: AuditEntityAchievement,
+			EntityID: achievement.ID.String(), After: achievement,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 	return achievement, nil
 }
 
-func (s *achievementService) Update(ctx context.Context, id string, req model.UpdateAchievementRequest) (*model.Achievement, error) {
+func (s *achievementService) Update(ctx context.Context, id string, req model.UpdateAchievementRequest, actorID string) (*model.Achievement, error) {
 	uid, err := uuid.Parse(id)
 	if err != nil {
 		return nil, errors.New("ID tidak valid")
@@ -124,22 +271,171 @@ func (s *achievementService) Update(ctx context.Context, id string, req model.Up
 	if err != nil || achievement == nil {
 		return nil, ErrAchievementNotFound
 	}
+	before := *achievement
+
+	ranks, err := loadRankResolver(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := ranks.apply(achievement, req.RankID, req.Rank); err != nil {
+		return nil, err
+	}
 
 	achievement.CompetitionName = req.CompetitionName
 	achievement.Organizer = req.Organizer
 	achievement.CategoryID = req.CategoryID
-	achievement.Rank = req.Rank
 	achievement.LevelID = req.LevelID
 	achievement.Year = req.Year
 	achievement.Description = req.Description
+	if teamName := utils.SanitizeString(req.TeamName); achievement.TeamName != nil && teamName != "" {
+		achievement.TeamName = &teamName
+	}
 
-	if err := s.repo.Update(ctx, achievement); err != nil {
+	// Data yang sudah diajukan/diverifikasi berubah: kembali ke draft agar diverifikasi ulang.
+	// Prestasi tim hanya satu baris, jadi perubahan berlaku untuk seluruh anggota.
+	event := resetForReverification(achievement, "Data prestasi diubah, perlu diverifikasi ulang", actorID)
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, achievement, event); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAchievementUpdate, EntityType: AuditEntityAchievement,
+			EntityID: achievement.ID.String(), Before: before, After: achievement,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return achievement, nil
 }
 
+// UpdateParticipants mengganti anggota tim. Daftar kosong menjadikan prestasi perorangan
+// milik siswa utama.
+func (s *achievementService) UpdateParticipants(ctx context.Context, id string, req model.UpdateParticipantsRequest, actorID string) (*model.Achievement, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("ID tidak valid")
+	}
+
+	achievement, err := s.repo.FindByID(ctx, uid)
+	if err != nil || achievement == nil {
+		return nil, ErrAchievementNotFound
+	}
+	if len(req.Participants) == 0 && achievement.TeamName == nil {
+		return achievement, nil // sudah perorangan, tidak ada yang berubah
+	}
+	before := *achievement
+
+	if len(req.Participants) == 0 {
+		achievement.TeamName = nil
+		achievement.Participants = nil
+	} else {
+		participants, err := s.resolveParticipants(ctx, req.Participants)
+		if err != nil {
+			return nil, err
+		}
+		teamName := req.TeamName
+		achievement.TeamName = &teamName
+		achievement.Participants = participants
+		achievement.StudentID = teamPrimary(participants, achievement.StudentID)
+	}
+
+	event := resetForReverification(achievement, "Anggota tim diubah, perlu diverifikasi ulang", actorID)
+	var updated *model.Achievement
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateParticipants(ctx, achievement, event); err != nil {
+			return err
+		}
+
+		var err error
+		if updated, err = s.repo.FindByID(ctx, uid); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAchievementTeam, EntityType: AuditEntityAchievement,
+			EntityID: uid.String(), Before: before, After: updated,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// resolveParticipants memastikan seluruh anggota tim terdaftar sebagai siswa
+func (s *achievementService) resolveParticipants(ctx context.Context, reqs []model.ParticipantRequest) ([]model.AchievementParticipant, error) {
+	participants := make([]model.AchievementParticipant, 0, len(reqs))
+	for _, p := range reqs {
+		studentUID, err := uuid.Parse(p.StudentID)
+		if err != nil {
+			return nil, errors.New("student_id anggota tim tidak valid")
+		}
+		student, err := s.studentRepo.FindByID(ctx, studentUID)
+		if err != nil {
+			return nil, err
+		}
+		if student == nil {
+			return nil, fmt.Errorf("%w: %s", ErrStudentNotFound, p.StudentID)
+		}
+		participants = append(participants, model.AchievementParticipant{
+			StudentID:   studentUID,
+			Role:        p.Role,
+			StudentName: &student.FullName,
+			StudentNISN: &student.NISN,
+		})
+	}
+	return participants, nil
+}
+
+// teamPrimary menentukan siswa utama prestasi tim (achievements.student_id): preferred jika
+// termasuk anggota dan bukan pelatih, selain itu kapten, selain itu anggota pertama
+func teamPrimary(participants []model.AchievementParticipant, preferred uuid.UUID) uuid.UUID {
+	var captain, member uuid.UUID
+	for _, p := range participants {
+		switch p.Role {
+		case model.ParticipantRoleCoach:
+			continue
+		case model.ParticipantRoleCaptain:
+			captain = p.StudentID
+		default:
+			if member == uuid.Nil {
+				member = p.StudentID
+			}
+		}
+		if p.StudentID == preferred {
+			return preferred
+		}
+	}
+	if captain != uuid.Nil {
+		return captain
+	}
+	return member
+}
+
+// teamSuccessor siswa utama pengganti saat leaving keluar dari tim; uuid.Nil jika
+// tidak ada anggota lain selain pelatih
+func teamSuccessor(participants []model.AchievementParticipant, leaving uuid.UUID) uuid.UUID {
+	rest := make([]model.AchievementParticipant, 0, len(participants))
+	for _, p := range participants {
+		if p.StudentID != leaving {
+			rest = append(rest, p)
+		}
+	}
+	return teamPrimary(rest, uuid.Nil)
+}
+
+// resetForReverification mengembalikan prestasi yang sudah diajukan/diverifikasi ke draft
+// setelah datanya diubah. Mengembalikan nil jika status tidak perlu berubah.
+func resetForReverification(achievement *model.Achievement, comment, actorID string) *model.AchievementStatusEvent {
+	if achievement.Status != model.AchievementStatusSubmitted && achievement.Status != model.AchievementStatusVerified {
+		return nil
+	}
+	event := newAchievementStatusEvent(achievement, model.AchievementStatusDraft, comment, actorID)
+	achievement.Status = model.AchievementStatusDraft
+	return event
+}
+
 func (s *achievementService) Delete(ctx context.Context, id string) error {
 	uid, err := uuid.Parse(id)
 	if err != nil {
@@ -151,12 +447,24 @@ func (s *achievementService) Delete(ctx context.Context, id string) error {
 		return ErrAchievementNotFound
 	}
 
-	// Hapus semua file attachment dari MinIO
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, uid); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAchievementDelete, EntityType: AuditEntityAchievement,
+			EntityID: uid.String(), Before: achievement,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Hapus semua file attachment dari MinIO setelah data terhapus
 	for _, att := range achievement.Attachments {
 		s.storage.DeleteFile(ctx, att.FileURL)
 	}
-
-	return s.repo.Delete(ctx, uid)
+	return nil
 }
 
 func (s *achievementService) UploadAttachment(ctx context.Context, achievementID string, data []byte, contentType, label string) (*model.AchievementAttachment, error) {
@@ -188,11 +496,19 @@ func (s *achievementService) UploadAttachment(ctx context.Context, achievementID
 		Label:         label,
 	}
 
-	if err := s.repo.AddAttachment(ctx, att); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddAttachment(ctx, att); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAttachmentUpload, EntityType: AuditEntityAttachment,
+			EntityID: att.ID.String(), After: att,
+		})
+	})
+	if err != nil {
 		s.storage.DeleteFile(ctx, result.FileURL) // rollback file jika DB gagal
 		return nil, err
 	}
-
 	return att, nil
 }
 
@@ -202,15 +518,25 @@ func (s *achievementService) DeleteAttachment(ctx context.Context, attachmentID
 		return errors.New("ID tidak valid")
 	}
 
-	att, err := s.repo.DeleteAttachment(ctx, uid)
+	var att *model.AchievementAttachment
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if att, err = s.repo.DeleteAttachment(ctx, uid); err != nil {
+			return err
+		}
+		if att == nil {
+			return errors.New("attachment tidak ditemukan")
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAttachmentDelete, EntityType: AuditEntityAttachment,
+			EntityID: att.ID.String(), Before: att,
+		})
+	})
 	if err != nil {
 		return err
 	}
-	if att == nil {
-		return errors.New("attachment tidak ditemukan")
-	}
 
-	// Hapus file dari MinIO
+	// Hapus file dari MinIO setelah data terhapus
 	s.storage.DeleteFile(ctx, att.FileURL)
 	return nil
 }
@@ -221,4 +547,106 @@ func (s *achievementService) GetCategories(ctx context.Context) ([]*model.Achiev
 
 func (s *achievementService) GetLevels(ctx context.Context) ([]*model.CompetitionLevel, error) {
 	return s.repo.FindAllLevels(ctx)
-}
\ No newline at end of file
+}
+
+func (s *achievementService) GetRanks(ctx context.Context) ([]*model.Rank, error) {
+	return s.repo.FindAllRanks(ctx)
+}
+
+// GetUnmatchedRanks laporan teks juara lama yang belum terpetakan ke tabel ranks
+func (s *achievementService) GetUnmatchedRanks(ctx context.Context) ([]*model.UnmatchedRank, error) {
+	return s.repo.FindUnmatchedRanks(ctx)
+}
+
+// Submit mengajukan prestasi draft (atau yang ditolak dan sudah diperbaiki) untuk diverifikasi
+func (s *achievementService) Submit(ctx context.Context, id string, actorID string) (*model.Achievement, error) {
+	return s.changeStatus(ctx, id, model.AchievementStatusSubmitted, "", actorID, AuditAchievementSubmit)
+}
+
+func (s *achievementService) Verify(ctx context.Context, id string, req model.ReviewAchievementRequest, actorID string) (*model.Achievement, error) {
+	return s.changeStatus(ctx, id, model.AchievementStatusVerified, req.Comment, actorID, AuditAchievementVerify)
+}
+
+func (s *achievementService) Reject(ctx context.Context, id string, req model.ReviewAchievementRequest, actorID string) (*model.Achievement, error) {
+	if utils.SanitizeString(req.Comment) == "" {
+		return nil, ErrAchievementCommentMissing
+	}
+	return s.changeStatus(ctx, id, model.AchievementStatusRejected, req.Comment, actorID, AuditAchievementReject)
+}
+
+func (s *achievementService) GetStatusHistory(ctx context.Context, id string) ([]*model.AchievementStatusEvent, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("ID tidak valid")
+	}
+
+	achievement, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if achievement == nil {
+		return nil, ErrAchievementNotFound
+	}
+
+	return s.repo.FindStatusEvents(ctx, uid)
+}
+
+func (s *achievementService) changeStatus(ctx context.Context, id, toStatus, comment, actorID, auditAction string) (*model.Achievement, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("ID tidak valid")
+	}
+
+	achievement, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if achievement == nil {
+		return nil, ErrAchievementNotFound
+	}
+
+	allowed := false
+	for _, next := range achievementTransitions[achievement.Status] {
+		if next == toStatus {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrAchievementTransition, achievement.Status, toStatus)
+	}
+
+	before := *achievement
+	event := newAchievementStatusEvent(achievement, toStatus, utils.SanitizeString(comment), actorID)
+	achievement.Status = toStatus
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ChangeStatus(ctx, event); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: auditAction, EntityType: AuditEntityAchievement,
+			EntityID: achievement.ID.String(), Before: before, After: achievement,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return achievement, nil
+}
+
+func newAchievementStatusEvent(achievement *model.Achievement, toStatus, comment, actorID string) *model.AchievementStatusEvent {
+	fromStatus := achievement.Status
+	event := &model.AchievementStatusEvent{
+		ID:            uuid.New(),
+		AchievementID: achievement.ID,
+		FromStatus:    &fromStatus,
+		ToStatus:      toStatus,
+	}
+	if comment != "" {
+		event.Comment = &comment
+	}
+	if actorUID, err := uuid.Parse(actorID); err == nil {
+		event.ActorID = &actorUID
+	}
+	return event
+}
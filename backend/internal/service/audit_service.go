@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/google/uuid"
+)
+
+// Aksi yang dicatat di ledger audit
+const (
+	AuditStudentCreate      = "student.create"
+	AuditStudentUpdate      = "student.update"
+	AuditStudentDelete      = "student.delete"
+	AuditStudentPhoto       = "student.upload_photo"
+	AuditStudentImport      = "student.import"
+	AuditAchievementCreate  = "achievement.create"
+	AuditAchievementUpdate  = "achievement.update"
+	AuditAchievementDelete  = "achievement.delete"
+	AuditAchievementSubmit  = "achievement.submit"
+	AuditAchievementVerify  = "achievement.verify"
+	AuditAchievementReject  = "achievement.reject"
+	AuditAchievementTeam    = "achievement.update_participants"
+	AuditAttachmentUpload   = "achievement.attachment_upload"
+	AuditAttachmentDelete   = "achievement.attachment_delete"
+	AuditAchievementImport  = "achievement.import"
+	AuditImportDiscard      = "achievement_import.discard"
+	AuditCertificateIssue   = "certificate.issue"
+	AuditCertificateReissue = "certificate.reissue"
+	AuditCertificateRevoke  = "certificate.revoke"
+	AuditCertificateRestore = "certificate.reinstate"
+	AuditCertificatePDF     = "certificate.regenerate_pdf"
+	AuditScoringRulesUpdate = "scoring.update_rules"
+	AuditTemplateCreate     = "certificate_template.create"
+	AuditTemplateUpdate     = "certificate_template.update"
+	AuditTemplateDefault    = "certificate_template.set_default"
+	AuditUserRegister       = "user.register"
+	AuditUserUpdate         = "user.update"
+	AuditUserActivate       = "user.activate"
+	AuditUserDeactivate     = "user.deactivate"
+	AuditUserResetPassword  = "user.reset_password"
+	AuditUserResetMFA       = "user.reset_mfa"
+	AuditAuthLogin          = "auth.login"
+	AuditAuthLogout         = "auth.logout"
+	AuditAuthLogoutAll      = "auth.logout_all"
+	AuditAuthTokenReuse     = "auth.refresh_token_reuse"
+	AuditAuthPasswordChange = "auth.change_password"
+	AuditAuthMFAEnable      = "auth.mfa_enable"
+	AuditAuthMFADisable     = "auth.mfa_disable"
+	AuditAuthMFARecovery    = "auth.mfa_regenerate_recovery_codes"
+	AuditAuthMFARecoveryUse = "auth.mfa_recovery_code_used"
+	AuditAuthLockout        = "auth.lockout"
+	AuditAuthUnlock         = "auth.unlock"
+	AuditSessionRevoke      = "session.revoke"
+	AuditSessionRevokeAll   = "session.revoke_all"
+)
+
+// Tipe entitas di ledger audit
+const (
+	AuditEntityStudent             = "student"
+	AuditEntityAchievement         = "achievement"
+	AuditEntityAttachment          = "achievement_attachment"
+	AuditEntityImportBatch         = "achievement_import_batch"
+	AuditEntityCertificate         = "certificate"
+	AuditEntityScoringRule         = "scoring_rule"
+	AuditEntityCertificateTemplate = "certificate_template"
+	AuditEntityUser                = "user"
+	AuditEntitySession             = "session"
+	AuditEntityLoginThrottle       = "login_throttle"
+)
+
+// auditVerifyBatchSize jumlah entri yang dibaca per query saat verifikasi rantai
+const auditVerifyBatchSize = 500
+
+// AuditListener dipanggil setiap kali entri audit dicatat, mis. untuk invalidasi cache
+type AuditListener func(entry model.AuditEntry)
+
+type AuditService interface {
+	Record(ctx context.Context, entry model.AuditEntry) error
+	Subscribe(listener AuditListener)
+	GetAll(ctx context.Context, filter model.AuditLogFilter) ([]*model.AuditLog, *response.Pagination, error)
+	VerifyChain(ctx context.Context) (*model.AuditVerifyResult, error)
+}
+
+type auditService struct {
+	repo      repository.AuditRepository
+	mu        sync.RWMutex
+	listeners []AuditListener
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// Record menambahkan entri audit untuk sebuah mutasi. Dipanggil di dalam
+// Transactor.WithinTx yang sama dengan mutasinya: entri disambung ke rantai tepat
+// sebelum commit, sehingga mutasi dan entrinya tersimpan atau batal bersama.
+// Listener dipanggil setelah commit.
+func (s *auditService) Record(ctx context.Context, entry model.AuditEntry) error {
+	auditLog, err := s.newAuditLog(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("audit %s tidak valid: %w", entry.Action, err)
+	}
+
+	if err := repository.BeforeCommit(ctx, func(ctx context.Context) error {
+		if err := s.repo.Append(ctx, auditLog); err != nil {
+			return fmt.Errorf("gagal mencatat audit %s: %w", entry.Action, err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	repository.AfterCommit(ctx, func() {
+		s.mu.RLock()
+		listeners := s.listeners
+		s.mu.RUnlock()
+		for _, listener := range listeners {
+			listener(entry)
+		}
+	})
+	return nil
+}
+
+func (s *auditService) Subscribe(listener AuditListener) {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, listener)
+	s.mu.Unlock()
+}
+
+func (s *auditService) newAuditLog(ctx context.Context, entry model.AuditEntry) (*model.AuditLog, error) {
+	before, err := auditJSON(entry.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := auditJSON(entry.After)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog := &model.AuditLog{
+		// Presisi timestamptz Postgres adalah mikrodetik
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   optionalString(entry.EntityID),
+		Before:     before,
+		After:      after,
+		IPAddress:  optionalString(middleware.GetClientIPFromContext(ctx)),
+		RequestID:  optionalString(middleware.GetRequestIDFromContext(ctx)),
+	}
+
+	// Aktor eksplisit (mis. login) diutamakan, selain itu dari token request
+	if entry.ActorID != nil {
+		auditLog.ActorID = entry.ActorID
+		auditLog.ActorName = optionalString(entry.ActorName)
+		auditLog.ActorRole = optionalString(entry.ActorRole)
+	} else if actorID, err := uuid.Parse(middleware.GetUserIDFromContext(ctx)); err == nil {
+		auditLog.ActorID = &actorID
+		auditLog.ActorName = optionalString(middleware.GetNameFromContext(ctx))
+		auditLog.ActorRole = optionalString(middleware.GetRoleFromContext(ctx))
+	}
+
+	return auditLog, nil
+}
+
+func (s *auditService) GetAll(ctx context.Context, filter model.AuditLogFilter) ([]*model.AuditLog, *response.Pagination, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	logs, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int(total) / filter.PerPage
+	if int(total)%filter.PerPage > 0 {
+		totalPages++
+	}
+
+	return logs, &response.Pagination{
+		Page: filter.Page, PerPage: filter.PerPage,
+		TotalItems: total, TotalPages: totalPages,
+	}, nil
+}
+
+// VerifyChain menelusuri seluruh rantai dari entri pertama: prev_hash harus sama
+// dengan hash entri sebelumnya dan hash harus cocok dengan isi entri.
+func (s *auditService) VerifyChain(ctx context.Context) (*model.AuditVerifyResult, error) {
+	result := &model.AuditVerifyResult{
+		LastHash: model.AuditGenesisHash,
+		Breaks:   []model.AuditChainBreak{},
+	}
+
+	var lastID int64
+	for {
+		batch, err := s.repo.FindAfter(ctx, lastID, auditVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, entry := range batch {
+			if entry.PrevHash != result.LastHash {
+				result.Breaks = append(result.Breaks, model.AuditChainBreak{
+					ID:       entry.ID,
+					Reason:   "prev_hash tidak sama dengan hash entri sebelumnya (entri dihapus atau disisipkan)",
+					Expected: result.LastHash,
+					Actual:   entry.PrevHash,
+				})
+			}
+
+			computed, err := entry.ComputeHash()
+			if err != nil {
+				result.Breaks = append(result.Breaks, model.AuditChainBreak{
+					ID:     entry.ID,
+					Reason: "isi entri tidak dapat dibaca: " + err.Error(),
+				})
+			} else if computed != entry.Hash {
+				result.Breaks = append(result.Breaks, model.AuditChainBreak{
+					ID:       entry.ID,
+					Reason:   "hash tidak cocok dengan isi entri (entri diubah)",
+					Expected: computed,
+					Actual:   entry.Hash,
+				})
+			}
+
+			// Lanjut dari hash yang tersimpan agar setiap kerusakan dilaporkan sekali
+			result.LastHash = entry.Hash
+			result.Checked++
+			lastID = entry.ID
+		}
+	}
+
+	result.Valid = len(result.Breaks) == 0
+	result.CheckedAt = time.Now()
+	return result, nil
+}
+
+// auditJSON menyimpan before/after dalam bentuk kanonik
+func auditJSON(v interface{}) (model.JSONB, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := model.CanonicalJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	return model.JSONB(canonical), nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
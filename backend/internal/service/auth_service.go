@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/middleware"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
@@ -14,13 +19,36 @@ import (
 
 // Request & Response DTOs
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	UserAgent string `json:"-"` // diisi handler dari header User-Agent
 }
 
+// LoginResponse berisi token, atau hanya MFA jika login masih butuh langkah kedua
 type LoginResponse struct {
-	User  model.UserResponse `json:"user"`
-	Token utils.TokenPair    `json:"token"`
+	User          *model.UserResponse `json:"user,omitempty"`
+	Token         *utils.TokenPair    `json:"token,omitempty"`
+	MFA           *MFAChallenge       `json:"mfa,omitempty"`
+	RecoveryCodes []string            `json:"recovery_codes,omitempty"` // hanya setelah enrolment saat login
+}
+
+// MFAChallenge token sementara untuk langkah kedua login
+type MFAChallenge struct {
+	Token              string `json:"mfa_token"`
+	ExpiresAt          int64  `json:"expires_at"` // unix timestamp
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// MFAVerifyRequest langkah kedua login: kode authenticator atau recovery code
+type MFAVerifyRequest struct {
+	MFAToken  string `json:"mfa_token"`
+	Code      string `json:"code"`
+	UserAgent string `json:"-"` // diisi handler dari header User-Agent
+}
+
+// MFAEnrollRequest meminta QR code enrolment saat login
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token"`
 }
 
 type RegisterRequest struct {
@@ -39,6 +67,44 @@ var (
 	ErrInvalidCredentials = errors.New("email atau password salah")
 	ErrAccountDisabled    = errors.New("akun tidak aktif, hubungi administrator")
 	ErrEmailAlreadyExists = errors.New("email sudah terdaftar")
+
+	ErrInvalidRefreshToken = errors.New("refresh token tidak valid atau sudah expired")
+	ErrRefreshTokenReused  = errors.New("refresh token sudah pernah dipakai, sesi dicabut demi keamanan. Silakan login kembali")
+	ErrUserNotFound        = errors.New("user tidak ditemukan")
+	ErrSessionNotFound     = errors.New("sesi tidak ditemukan")
+	ErrWrongPassword       = errors.New("password saat ini salah")
+	ErrSamePassword        = errors.New("password baru harus berbeda dari password saat ini")
+
+	ErrInvalidMFAToken       = errors.New("verifikasi dua langkah kedaluwarsa atau tidak valid, silakan login kembali")
+	ErrMFAEnrollmentRequired = errors.New("verifikasi dua langkah wajib diaktifkan terlebih dahulu")
+
+	ErrTooManyLoginAttempts = errors.New("terlalu banyak percobaan login gagal")
+	ErrLockoutNotFound      = errors.New("tidak ada percobaan login gagal yang tercatat")
+)
+
+// LoginThrottledError login ditolak sementara karena terlalu banyak percobaan gagal.
+// errors.Is(err, ErrTooManyLoginAttempts) bernilai true.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // dikunci, bukan sekadar jeda progresif
+}
+
+func (e *LoginThrottledError) Error() string {
+	seconds := int(e.RetryAfter.Round(time.Second).Seconds())
+	if e.Locked {
+		return fmt.Sprintf("terlalu banyak percobaan login gagal, login dikunci sementara. Coba lagi dalam %d menit", (seconds+59)/60)
+	}
+	return fmt.Sprintf("terlalu banyak percobaan login gagal, coba lagi dalam %d detik", max(seconds, 1))
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrTooManyLoginAttempts
+}
+
+// Percobaan gagal yang masih boleh langsung diulang sebelum jeda progresif dimulai
+const (
+	accountFreeFailures = 3
+	ipFreeFailures      = 10 // satu IP sekolah bisa dipakai banyak user sekaligus
 )
 
 type AuthService interface {
@@ -46,62 +112,243 @@ type AuthService interface {
 	Register(ctx context.Context, req RegisterRequest) (*model.UserResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*utils.TokenPair, error)
 	Me(ctx context.Context, userID string) (*model.UserResponse, error)
+	ChangePassword(ctx context.Context, userID, sessionID string, req model.ChangePasswordRequest) (*model.UserResponse, error)
+
+	// Langkah kedua login
+	VerifyMFA(ctx context.Context, req MFAVerifyRequest) (*LoginResponse, error)
+	EnrollMFA(ctx context.Context, mfaToken string) (*model.TwoFactorSetup, error)
+	ConfirmMFAEnrollment(ctx context.Context, req MFAVerifyRequest) (*LoginResponse, error)
+
+	// Sesi login
+	CheckSession(ctx context.Context, userID, sessionID string) (middleware.SessionStatus, error)
+	Logout(ctx context.Context, sessionID string) error
+	LogoutAll(ctx context.Context, userID string) error
+	GetSessions(ctx context.Context, userID, currentSessionID string) ([]*model.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) (int64, error)
+
+	// Perlindungan brute-force (admin)
+	GetLockouts(ctx context.Context) ([]*model.LoginThrottle, error)
+	UnlockUser(ctx context.Context, userID string) error
+	UnlockIP(ctx context.Context, ip string) error
 }
 
 type authService struct {
-	userRepo repository.UserRepository
-	cfg      *config.Config
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	throttleRepo repository.LoginThrottleRepository
+	twoFactor    TwoFactorService
+	cfg          *config.Config
+	tx           repository.Transactor
+	audit        AuditService
+
+	// Hash pembanding untuk email yang tidak terdaftar, agar waktu respons login
+	// tidak membocorkan email mana yang ada
+	dummyHash []byte
 }
 
-func NewAuthService(userRepo repository.UserRepository, cfg *config.Config) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	throttleRepo repository.LoginThrottleRepository,
+	twoFactor TwoFactorService,
+	cfg *config.Config,
+	tx repository.Transactor,
+	audit AuditService,
+) AuthService {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte(uuid.NewString()), bcrypt.DefaultCost)
 	return &authService{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		throttleRepo: throttleRepo,
+		twoFactor:    twoFactor,
+		cfg:          cfg,
+		tx:           tx,
+		audit:        audit,
+		dummyHash:    dummyHash,
 	}
 }
 
 func (s *authService) Login(ctx context.Context, req LoginRequest) (*LoginResponse, error) {
+	ip := middleware.GetClientIPFromContext(ctx)
+	if err := s.checkThrottle(ctx, req.Email, ip); err != nil {
+		return nil, err
+	}
+
 	// Cari user by email
 	user, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
+
+	// Validasi password; email tidak terdaftar tetap melewati bcrypt
+	hash := s.dummyHash
+	if user != nil {
+		hash = []byte(user.Password)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(req.Password)); err != nil || user == nil {
+		if err := s.recordLoginFailure(ctx, req.Email, ip); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
 
-	// Cek status aktif
+	// Status aktif baru diungkap setelah password benar
 	if !user.IsActive {
 		return nil, ErrAccountDisabled
 	}
 
-	// Validasi password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, ErrInvalidCredentials
+	// Role yang wajib 2FA (atau user yang mengaktifkannya) lanjut ke langkah kedua
+	if user.TwoFactorEnabled() || user.Role.RequiresTwoFactor() {
+		return s.mfaChallenge(user)
 	}
 
-	// Generate token
-	claims := model.JWTClaims{
+	return s.startSession(ctx, user, req.UserAgent, "")
+}
+
+// VerifyMFA menyelesaikan login dengan kode authenticator atau recovery code
+func (s *authService) VerifyMFA(ctx context.Context, req MFAVerifyRequest) (*LoginResponse, error) {
+	user, err := s.challengeUser(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, ErrMFAEnrollmentRequired
+	}
+
+	ip := middleware.GetClientIPFromContext(ctx)
+	if err := s.checkThrottle(ctx, user.Email, ip); err != nil {
+		return nil, err
+	}
+	method, err := s.twoFactor.Verify(ctx, user.ID.String(), req.Code)
+	if err != nil {
+		return nil, s.secondFactorFailed(ctx, user, ip, err)
+	}
+	return s.startSession(ctx, user, req.UserAgent, method)
+}
+
+// EnrollMFA memulai enrolment bagi user yang wajib 2FA tetapi belum mengaktifkannya
+func (s *authService) EnrollMFA(ctx context.Context, mfaToken string) (*model.TwoFactorSetup, error) {
+	user, err := s.challengeUser(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.twoFactor.Setup(ctx, user.ID.String())
+}
+
+// ConfirmMFAEnrollment mengaktifkan 2FA dengan kode pertama lalu menyelesaikan login.
+// Recovery code dikembalikan sekali di response.
+func (s *authService) ConfirmMFAEnrollment(ctx context.Context, req MFAVerifyRequest) (*LoginResponse, error) {
+	user, err := s.challengeUser(ctx, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := middleware.GetClientIPFromContext(ctx)
+	if err := s.checkThrottle(ctx, user.Email, ip); err != nil {
+		return nil, err
+	}
+	codes, err := s.twoFactor.Enable(ctx, user.ID.String(), req.Code)
+	if err != nil {
+		return nil, s.secondFactorFailed(ctx, user, ip, err)
+	}
+
+	// Muat ulang agar two_factor_enabled di response sudah true
+	if user, err = s.findUser(ctx, user.ID.String()); err != nil {
+		return nil, err
+	}
+	resp, err := s.startSession(ctx, user, req.UserAgent, TwoFactorMethodTOTP)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = codes.RecoveryCodes
+	return resp, nil
+}
+
+// mfaChallenge token langkah kedua; belum ada sesi yang dibuat
+func (s *authService) mfaChallenge(user *model.User) (*LoginResponse, error) {
+	token, expiresAt, err := utils.GenerateMFAToken(model.JWTClaims{
 		UserID: user.ID.String(),
 		Email:  user.Email,
 		Role:   string(user.Role),
 		Name:   user.Name,
+	}, s.cfg.JWT.Secret, s.cfg.MFA.ChallengeTTL)
+	if err != nil {
+		return nil, err
 	}
 
-	tokenPair, err := utils.GenerateTokenPair(
-		claims,
-		s.cfg.JWT.Secret,
-		s.cfg.JWT.ExpireHours,
-		s.cfg.JWT.RefreshExpHours,
-	)
+	return &LoginResponse{MFA: &MFAChallenge{
+		Token:              token,
+		ExpiresAt:          expiresAt,
+		EnrollmentRequired: !user.TwoFactorEnabled(),
+	}}, nil
+}
+
+// challengeUser user pemilik token challenge yang masih aktif
+func (s *authService) challengeUser(ctx context.Context, mfaToken string) (*model.User, error) {
+	claims, err := utils.ValidateToken(mfaToken, s.cfg.JWT.Secret)
+	if err != nil || claims.Type != utils.TokenTypeMFA {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.findUser(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+	return user, nil
+}
+
+// startSession membuat sesi baru beserta token pertamanya. mfaMethod kosong
+// untuk login tanpa langkah kedua.
+func (s *authService) startSession(ctx context.Context, user *model.User, userAgent, mfaMethod string) (*LoginResponse, error) {
+	session := &model.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		UserAgent: userAgent,
+		IPAddress: middleware.GetClientIPFromContext(ctx),
+	}
+	tokenPair, err := s.generateTokens(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshTokenHash = hashRefreshToken(tokenPair.RefreshToken)
+	session.ExpiresAt = time.Unix(tokenPair.RefreshExpiresAt, 0)
+
+	after := map[string]string{"session_id": session.ID.String()}
+	if mfaMethod != "" {
+		after["mfa"] = mfaMethod
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.Create(ctx, session); err != nil {
+			return err
+		}
+
+		// Login lengkap berhasil: hitungan gagal akun diulang. Hitungan IP tidak, agar
+		// satu akun valid tidak bisa dipakai untuk menghapus jejak tebakan ke akun lain.
+		if _, err := s.throttleRepo.Reset(ctx, model.LoginThrottleAccount, user.Email); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthLogin, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+			ActorID: &user.ID, ActorName: user.Name, ActorRole: string(user.Role),
+			After: after,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	userResp := user.ToResponse()
 	return &LoginResponse{
-		User:  userResp,
-		Token: *tokenPair,
+		User:  &userResp,
+		Token: tokenPair,
 	}, nil
 }
 
@@ -126,57 +373,131 @@ func (s *authService) Register(ctx context.Context, req RegisterRequest) (*model
 		req.Role = model.RoleOperator
 	}
 
+	// Password dibuat admin, user wajib menggantinya saat login pertama
 	user := &model.User{
-		ID:       uuid.New(),
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: string(hashedPassword),
-		Role:     req.Role,
-		IsActive: true,
+		ID:                 uuid.New(),
+		Name:               req.Name,
+		Email:              req.Email,
+		Password:           string(hashedPassword),
+		Role:               req.Role,
+		IsActive:           true,
+		MustChangePassword: true,
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	resp := user.ToResponse()
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditUserRegister, EntityType: AuditEntityUser,
+			EntityID: user.ID.String(), After: resp,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	resp := user.ToResponse()
 	return &resp, nil
 }
 
+// RefreshToken menukar refresh token dengan pasangan token baru. Refresh token lama
+// langsung tidak berlaku; jika dipakai lagi, sesinya dicabut karena token dianggap dicuri.
 func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*utils.TokenPair, error) {
 	// Validasi refresh token
 	claims, err := utils.ValidateToken(refreshToken, s.cfg.JWT.Secret)
-	if err != nil {
-		return nil, errors.New("refresh token tidak valid atau sudah expired")
+	if err != nil || claims.Type != utils.TokenTypeRefresh {
+		return nil, ErrInvalidRefreshToken
 	}
 
-	// Pastikan user masih ada dan aktif
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		return nil, errors.New("token tidak valid")
+		return nil, ErrInvalidRefreshToken
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userID || !session.IsActive(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	oldHash := hashRefreshToken(refreshToken)
+	if session.RefreshTokenHash != oldHash {
+		return nil, s.revokeReusedSession(ctx, session)
 	}
 
+	// Pastikan user masih ada dan aktif
 	user, err := s.userRepo.FindByID(ctx, userID)
-	if err != nil || user == nil || !user.IsActive {
-		return nil, errors.New("akun tidak ditemukan atau tidak aktif")
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrAccountDisabled
 	}
 
-	// Generate token baru
-	newClaims := model.JWTClaims{
-		UserID: user.ID.String(),
-		Email:  user.Email,
-		Role:   string(user.Role),
-		Name:   user.Name,
+	tokenPair, err := s.generateTokens(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Token lama bisa saja dipakai bersamaan oleh pihak lain; hanya satu yang menang
+	rotated, err := s.sessionRepo.Rotate(ctx, session.ID, oldHash,
+		hashRefreshToken(tokenPair.RefreshToken), time.Unix(tokenPair.RefreshExpiresAt, 0))
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, s.revokeReusedSession(ctx, session)
+	}
+
+	return tokenPair, nil
+}
+
+func (s *authService) revokeReusedSession(ctx context.Context, session *model.Session) error {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		revoked, err := s.sessionRepo.Revoke(ctx, session.ID, model.SessionRevokedTokenReuse)
+		if err != nil || !revoked {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthTokenReuse, EntityType: AuditEntitySession, EntityID: session.ID.String(),
+			After: map[string]string{"user_id": session.UserID.String(), "user_agent": session.UserAgent},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *authService) generateTokens(user *model.User, sessionID uuid.UUID) (*utils.TokenPair, error) {
+	claims := model.JWTClaims{
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		Role:      string(user.Role),
+		Name:      user.Name,
+		SessionID: sessionID.String(),
 	}
 
 	return utils.GenerateTokenPair(
-		newClaims,
+		claims,
 		s.cfg.JWT.Secret,
 		s.cfg.JWT.ExpireHours,
 		s.cfg.JWT.RefreshExpHours,
 	)
 }
 
+// hashRefreshToken refresh token hanya disimpan sebagai hash
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (s *authService) Me(ctx context.Context, userID string) (*model.UserResponse, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
@@ -188,9 +509,334 @@ func (s *authService) Me(ctx context.Context, userID string) (*model.UserRespons
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("user tidak ditemukan")
+		return nil, ErrUserNotFound
 	}
 
 	resp := user.ToResponse()
 	return &resp, nil
-}
\ No newline at end of file
+}
+
+// ChangePassword mengganti password user sendiri. Sesi lain dicabut, sesi saat ini tetap berlaku.
+func (s *authService) ChangePassword(ctx context.Context, userID, sessionID string, req model.ChangePasswordRequest) (*model.UserResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return nil, ErrWrongPassword
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return nil, ErrSamePassword
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword), false); err != nil {
+			return err
+		}
+
+		var revoked int64
+		if sid, err := uuid.Parse(sessionID); err == nil {
+			if revoked, err = s.sessionRepo.RevokeOthers(ctx, user.ID, sid, model.SessionRevokedPwdChange); err != nil {
+				return err
+			}
+		}
+
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthPasswordChange, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+			Before: map[string]bool{"must_change_password": user.MustChangePassword},
+			After:  map[string]int64{"revoked_sessions": revoked},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Me(ctx, userID)
+}
+
+// CheckSession dipakai middleware Authenticate untuk setiap request
+func (s *authService) CheckSession(ctx context.Context, userID, sessionID string) (middleware.SessionStatus, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return middleware.SessionStatus{}, nil
+	}
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return middleware.SessionStatus{}, nil
+	}
+
+	session, err := s.sessionRepo.FindActive(ctx, sid, uid)
+	if err != nil || session == nil {
+		return middleware.SessionStatus{}, err
+	}
+	return middleware.SessionStatus{Active: true, MustChangePassword: session.MustChangePassword}, nil
+}
+
+// Logout mencabut sesi token yang sedang dipakai
+func (s *authService) Logout(ctx context.Context, sessionID string) error {
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.sessionRepo.Revoke(ctx, sid, model.SessionRevokedLogout); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthLogout, EntityType: AuditEntitySession, EntityID: sessionID,
+		})
+	})
+}
+
+// LogoutAll mencabut semua sesi milik user sendiri, termasuk sesi saat ini
+func (s *authService) LogoutAll(ctx context.Context, userID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		count, err := s.sessionRepo.RevokeAllForUser(ctx, uid, model.SessionRevokedLogoutAll)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthLogoutAll, EntityType: AuditEntityUser, EntityID: userID,
+			After: map[string]int64{"revoked_sessions": count},
+		})
+	})
+}
+
+// GetSessions seluruh sesi user; currentSessionID ditandai agar mudah dikenali
+func (s *authService) GetSessions(ctx context.Context, userID, currentSessionID string) ([]*model.Session, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID.String() == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession mencabut satu sesi user (admin)
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	session, err := s.sessionRepo.FindByID(ctx, sid)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != user.ID {
+		return ErrSessionNotFound
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		revoked, err := s.sessionRepo.Revoke(ctx, session.ID, model.SessionRevokedAdmin)
+		if err != nil || !revoked {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditSessionRevoke, EntityType: AuditEntitySession, EntityID: session.ID.String(),
+			Before: session, After: map[string]string{"revoked_reason": model.SessionRevokedAdmin},
+		})
+	})
+}
+
+// RevokeAllSessions mencabut semua sesi user (admin), mis. saat akun diduga dibobol
+func (s *authService) RevokeAllSessions(ctx context.Context, userID string) (int64, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if count, err = s.sessionRepo.RevokeAllForUser(ctx, user.ID, model.SessionRevokedAdmin); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditSessionRevokeAll, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+			After: map[string]int64{"revoked_sessions": count},
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetLockouts akun dan IP yang sedang dikunci
+func (s *authService) GetLockouts(ctx context.Context) ([]*model.LoginThrottle, error) {
+	return s.throttleRepo.FindLocked(ctx)
+}
+
+// UnlockUser membuka kunci login user dan menghapus hitungan gagalnya (admin)
+func (s *authService) UnlockUser(ctx context.Context, userID string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.unlock(ctx, model.LoginThrottleAccount, user.Email)
+	if errors.Is(err, ErrLockoutNotFound) {
+		return nil // tidak ada yang perlu dibuka
+	}
+	return err
+}
+
+// UnlockIP membuka kunci login sebuah IP (admin)
+func (s *authService) UnlockIP(ctx context.Context, ip string) error {
+	return s.unlock(ctx, model.LoginThrottleIP, ip)
+}
+
+func (s *authService) unlock(ctx context.Context, scope, subject string) error {
+	throttle, err := s.throttleRepo.Find(ctx, scope, subject)
+	if err != nil {
+		return err
+	}
+	if throttle == nil {
+		return ErrLockoutNotFound
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.throttleRepo.Reset(ctx, scope, subject); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthUnlock, EntityType: AuditEntityLoginThrottle,
+			EntityID: scope + ":" + subject, Before: throttle,
+		})
+	})
+}
+
+// loginThrottleSubject satu hal yang dibatasi: akun (email) atau IP
+type loginThrottleSubject struct {
+	scope        string
+	subject      string
+	maxFailures  int
+	freeFailures int
+}
+
+func (s *authService) throttleSubjects(email, ip string) []loginThrottleSubject {
+	subjects := []loginThrottleSubject{
+		{model.LoginThrottleAccount, email, s.cfg.Login.AccountMaxFailures, accountFreeFailures},
+	}
+	if ip != "" {
+		subjects = append(subjects, loginThrottleSubject{model.LoginThrottleIP, ip, s.cfg.Login.IPMaxFailures, ipFreeFailures})
+	}
+	return subjects
+}
+
+// checkThrottle menolak percobaan login selama akun atau IP terkunci atau masih
+// dalam jeda progresif setelah kegagalan sebelumnya
+func (s *authService) checkThrottle(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	for _, sub := range s.throttleSubjects(email, ip) {
+		throttle, err := s.throttleRepo.Find(ctx, sub.scope, sub.subject)
+		if err != nil {
+			return err
+		}
+		if throttle == nil {
+			continue
+		}
+		if wait := s.retryAfter(throttle, sub.freeFailures, now); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait, Locked: throttle.IsLocked(now)}
+		}
+	}
+	return nil
+}
+
+// retryAfter sisa waktu tunggu. Setelah freeFailures, jeda berlipat dua setiap
+// kegagalan (1 detik, 2 detik, 4 detik, ...) hingga MaxDelay.
+func (s *authService) retryAfter(throttle *model.LoginThrottle, freeFailures int, now time.Time) time.Duration {
+	if throttle.IsLocked(now) {
+		return throttle.LockedUntil.Sub(now)
+	}
+	if throttle.Failures <= freeFailures || now.Sub(throttle.LastFailedAt) > s.cfg.Login.FailureWindow {
+		return 0
+	}
+
+	delay := s.cfg.Login.MaxDelay
+	if n := throttle.Failures - freeFailures - 1; n < 16 {
+		delay = min(time.Second<<n, delay)
+	}
+	return throttle.LastFailedAt.Add(delay).Sub(now)
+}
+
+// recordLoginFailure mencatat kegagalan untuk akun dan IP, lalu mengunci yang
+// mencapai batas. Batas 0 berarti tanpa penguncian.
+func (s *authService) recordLoginFailure(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	for _, sub := range s.throttleSubjects(email, ip) {
+		throttle, err := s.throttleRepo.RecordFailure(ctx, sub.scope, sub.subject, s.cfg.Login.FailureWindow)
+		if err != nil {
+			return err
+		}
+		if sub.maxFailures <= 0 || throttle.Failures < sub.maxFailures || throttle.IsLocked(now) {
+			continue
+		}
+
+		until := now.Add(s.cfg.Login.LockoutDuration)
+		err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.throttleRepo.Lock(ctx, sub.scope, sub.subject, until); err != nil {
+				return err
+			}
+			return s.audit.Record(ctx, model.AuditEntry{
+				Action: AuditAuthLockout, EntityType: AuditEntityLoginThrottle,
+				EntityID: sub.scope + ":" + sub.subject,
+				After: map[string]interface{}{"failures": throttle.Failures, "locked_until": until},
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secondFactorFailed kode 2FA yang salah dihitung sebagai login gagal
+func (s *authService) secondFactorFailed(ctx context.Context, user *model.User, ip string, err error) error {
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if recordErr := s.recordLoginFailure(ctx, user.Email, ip); recordErr != nil {
+			return recordErr
+		}
+	}
+	return err
+}
+
+func (s *authService) findUser(ctx context.Context, userID string) (*model.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := s.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Pastikan interface terpenuhi
+var _ AuthService = (*authService)(nil)
\ No newline at end of file
@@ -2,48 +2,91 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
 	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/google/uuid"
 )
 
 var (
-	ErrCertificateNotFound = errors.New("sertifikat tidak ditemukan")
-	ErrCertificateRevoked  = errors.New("sertifikat telah dicabut")
+	ErrCertificateNotFound   = errors.New("sertifikat tidak ditemukan")
+	ErrCertificateRevoked    = errors.New("sertifikat telah dicabut")
+	ErrCertificateNotRevoked = errors.New("hanya sertifikat yang dicabut yang dapat dipulihkan")
+	ErrCertificateNotActive  = errors.New("hanya sertifikat aktif yang dapat diterbitkan ulang")
+	ErrCertificateSuperseded = errors.New("sertifikat sudah digantikan, ubah status sertifikat penggantinya")
+	ErrCertificatePDFPending = errors.New("PDF sertifikat masih dalam proses pembuatan")
+	ErrCertificatePDFFailed  = errors.New("PDF sertifikat gagal dibuat, hubungi admin")
 )
 
+// CertificatePDFFile PDF tersimpan yang dialirkan ke client; Body wajib di-Close
+type CertificatePDFFile struct {
+	Body              io.ReadCloser
+	Size              int64
+	SHA256            string
+	CertificateNumber string
+}
+
 type CertificateService interface {
 	GetAll(ctx context.Context, filter model.CertificateFilter) ([]*model.Certificate, *response.Pagination, error)
 	GetByID(ctx context.Context, id string) (*model.CertificateDetail, error)
 	Create(ctx context.Context, req model.CreateCertificateRequest, issuedBy string) (*model.CertificateDetail, error)
-	Revoke(ctx context.Context, id string) error
+	Reissue(ctx context.Context, id string, req model.ReissueCertificateRequest, issuedBy string) (*model.CertificateDetail, error)
+	Revoke(ctx context.Context, id string, req model.ChangeCertificateStatusRequest, actorID string) error
+	Reinstate(ctx context.Context, id string, req model.ChangeCertificateStatusRequest, actorID string) error
+	GetStatusHistory(ctx context.Context, id string) ([]*model.CertificateStatusEvent, error)
 	Verify(ctx context.Context, token string) (*model.VerifyResponse, error)
-	DownloadPDF(ctx context.Context, id string) ([]byte, string, error)
+	DownloadPDF(ctx context.Context, id string) (*CertificatePDFFile, error)
+	RegeneratePDF(ctx context.Context, id string, req model.RegenerateCertificatePDFRequest, actorID string) (*model.CertificatePDFRegeneration, error)
+	GetPDFRegenerations(ctx context.Context, id string) ([]*model.CertificatePDFRegeneration, error)
+	GetDrift(ctx context.Context, id string) (*model.CertificateDrift, error)
+	SigningKey(ctx context.Context) (*model.SigningKeyResponse, error)
+
+	// Handler job background (didaftarkan ke worker pool)
+	GeneratePDFJob(ctx context.Context, job *model.Job) error
+	PDFJobDead(ctx context.Context, job *model.Job, jobErr error)
 }
 
 type certificateService struct {
 	repo        repository.CertificateRepository
 	studentRepo repository.StudentRepository
 	achRepo     repository.AchievementRepository
+	templates   repository.CertificateTemplateRepository
 	storage     *utils.StorageService
+	signer      *utils.CertificateSigner
+	pdfSigner   *utils.PDFSigner // nil jika tanda tangan PDF belum dikonfigurasi
+	tx          repository.Transactor
+	audit       AuditService
 }
 
 func NewCertificateService(
 	repo repository.CertificateRepository,
 	studentRepo repository.StudentRepository,
 	achRepo repository.AchievementRepository,
+	templates repository.CertificateTemplateRepository,
 	storage *utils.StorageService,
+	signer *utils.CertificateSigner,
+	pdfSigner *utils.PDFSigner,
+	tx repository.Transactor,
+	audit AuditService,
 ) CertificateService {
 	return &certificateService{
 		repo: repo, studentRepo: studentRepo,
-		achRepo: achRepo, storage: storage,
+		achRepo: achRepo, templates: templates, storage: storage,
+		signer: signer, pdfSigner: pdfSigner,
+		tx: tx, audit: audit,
 	}
 }
 
@@ -94,101 +137,376 @@ func (s *certificateService) Create(ctx context.Context, req model.CreateCertifi
 		return nil, errors.New("student_id tidak valid")
 	}
 
-	// Validasi achievement IDs
-	if len(req.AchievementIDs) == 0 {
-		return nil, errors.New("minimal 1 prestasi harus dipilih")
+	cert, achievementUIDs, err := s.newCertificate(studentUID, req.AchievementIDs, req.ValidUntil, req.Notes, issuedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureVerified(ctx, achievementUIDs); err != nil {
+		return nil, err
+	}
+	if cert.TemplateVersionID, err = s.templateVersionFor(ctx, req.TemplateID, nil); err != nil {
+		return nil, err
 	}
 
-	achievementUIDs := make([]uuid.UUID, 0, len(req.AchievementIDs))
-	for _, idStr := range req.AchievementIDs {
-		uid, err := uuid.Parse(idStr)
-		if err != nil {
-			return nil, fmt.Errorf("achievement_id tidak valid: %s", idStr)
+	// Simpan ke DB
+	var detail *model.CertificateDetail
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, cert, achievementUIDs, s.signIssuance); err != nil {
+			return err
 		}
-		achievementUIDs = append(achievementUIDs, uid)
+
+		var err error
+		if detail, err = s.issuedDetail(ctx, cert.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditCertificateIssue, EntityType: AuditEntityCertificate,
+			EntityID: cert.ID.String(), After: detail.Certificate,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func (s *certificateService) Reissue(ctx context.Context, id string, req model.ReissueCertificateRequest, issuedBy string) (*model.CertificateDetail, error) {
+	old, err := s.findForStatusChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if old.Status != model.CertificateStatusActive {
+		return nil, ErrCertificateNotActive
 	}
 
-	// Generate nomor surat
-	year := time.Now().Year()
-	count, err := s.repo.CountByYear(ctx, year)
+	cert, achievementUIDs, err := s.newCertificate(old.StudentID, req.AchievementIDs, req.ValidUntil, req.Notes, issuedBy)
 	if err != nil {
 		return nil, err
 	}
-	certNumber := utils.GenerateCertificateNumber(count + 1)
+	if err := s.ensureVerified(ctx, achievementUIDs); err != nil {
+		return nil, err
+	}
+	cert.Supersedes = &old.ID
+	if cert.TemplateVersionID, err = s.templateVersionFor(ctx, req.TemplateID, old.TemplateVersionID); err != nil {
+		return nil, err
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Diterbitkan ulang"
+	}
+
+	fromStatus := old.Status
+	event := &model.CertificateStatusEvent{
+		ID:            uuid.New(),
+		CertificateID: old.ID,
+		FromStatus:    &fromStatus,
+		ToStatus:      model.CertificateStatusSuperseded,
+		Reason:        &reason,
+		ActorID:       cert.IssuedBy,
+	}
+
+	var detail *model.CertificateDetail
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Reissue(ctx, cert, achievementUIDs, event, s.signIssuance); err != nil {
+			return err
+		}
+
+		var err error
+		if detail, err = s.issuedDetail(ctx, cert.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditCertificateReissue, EntityType: AuditEntityCertificate,
+			EntityID: old.ID.String(), Before: old, After: detail.Certificate,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// newCertificate memvalidasi input dan menyiapkan sertifikat baru (QR token, masa berlaku)
+func (s *certificateService) newCertificate(studentUID uuid.UUID, achievementIDs []string, validUntil, notes, issuedBy string) (*model.Certificate, []uuid.UUID, error) {
+	// Validasi achievement IDs
+	if len(achievementIDs) == 0 {
+		return nil, nil, errors.New("minimal 1 prestasi harus dipilih")
+	}
+
+	achievementUIDs := make([]uuid.UUID, 0, len(achievementIDs))
+	for _, idStr := range achievementIDs {
+		uid, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("achievement_id tidak valid: %s", idStr)
+		}
+		achievementUIDs = append(achievementUIDs, uid)
+	}
 
 	// Generate QR token
 	qrToken, err := utils.GenerateQRToken()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	issuedByUID, _ := uuid.Parse(issuedBy)
 
+	// Nomor surat dialokasikan repository di dalam transaksi penyimpanan
 	cert := &model.Certificate{
-		ID:                uuid.New(),
-		StudentID:         studentUID,
-		CertificateNumber: certNumber,
-		IssuedAt:          time.Now(),
-		IssuedBy:          &issuedByUID,
-		QRToken:           qrToken,
-		Status:            "active",
-		Notes:             req.Notes,
-	}
-
-	if req.ValidUntil != "" {
-		t, err := time.Parse("2006-01-02", req.ValidUntil)
+		ID:        uuid.New(),
+		StudentID: studentUID,
+		IssuedAt:  time.Now(),
+		IssuedBy:  &issuedByUID,
+		QRToken:   qrToken,
+		Status:    model.CertificateStatusActive,
+		Notes:     notes,
+	}
+
+	if validUntil != "" {
+		t, err := time.Parse("2006-01-02", validUntil)
 		if err != nil {
-			return nil, errors.New("format valid_until tidak valid, gunakan YYYY-MM-DD")
+			return nil, nil, errors.New("format valid_until tidak valid, gunakan YYYY-MM-DD")
 		}
 		cert.ValidUntil = &t
 	}
 
-	// Simpan ke DB
-	if err := s.repo.Create(ctx, cert, achievementUIDs); err != nil {
-		return nil, err
-	}
+	return cert, achievementUIDs, nil
+}
 
-	// Ambil detail lengkap
-	detail, err := s.repo.FindByIDWithDetail(ctx, cert.ID)
+// templateVersionFor memilih versi template untuk surat baru: versi terbaru dari template
+// yang diminta, dari template surat lama (penerbitan ulang), atau dari template default
+func (s *certificateService) templateVersionFor(ctx context.Context, templateID string, previousVersionID *uuid.UUID) (*uuid.UUID, error) {
+	var tmpl *model.CertificateTemplate
+	var err error
+	switch {
+	case templateID != "":
+		uid, parseErr := uuid.Parse(templateID)
+		if parseErr != nil {
+			return nil, ErrTemplateNotFound
+		}
+		tmpl, err = s.templates.FindByID(ctx, uid)
+		if err == nil && tmpl == nil {
+			return nil, ErrTemplateNotFound
+		}
+	case previousVersionID != nil:
+		var previous *model.CertificateTemplateVersion
+		previous, err = s.templates.FindVersionByID(ctx, *previousVersionID)
+		if err == nil && previous != nil {
+			tmpl, err = s.templates.FindByID(ctx, previous.TemplateID)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate dan upload PDF di background
-	go s.generateAndUploadPDF(context.Background(), detail)
+	if tmpl == nil {
+		if tmpl, err = s.templates.FindDefault(ctx); err != nil {
+			return nil, err
+		}
+	}
+	// Tanpa template sama sekali surat memakai tata letak bawaan
+	if tmpl == nil {
+		return nil, nil
+	}
+	return &tmpl.CurrentVersionID, nil
+}
+
+// ensureVerified menolak prestasi yang belum diverifikasi. Repository memeriksa ulang
+// di dalam transaksi penerbitan, pengecekan ini untuk pesan error yang jelas.
+func (s *certificateService) ensureVerified(ctx context.Context, achievementIDs []uuid.UUID) error {
+	for _, id := range achievementIDs {
+		achievement, err := s.achRepo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if achievement == nil {
+			return fmt.Errorf("%w: %s", ErrAchievementNotFound, id)
+		}
+		if achievement.Status != model.AchievementStatusVerified {
+			return fmt.Errorf("%w: %s (status %s)", ErrAchievementNotVerified, achievement.CompetitionName, achievement.Status)
+		}
+	}
+	return nil
+}
+
+// signIssuance menandatangani sertifikat dengan kunci sekolah; dipanggil repository
+// di dalam transaksi penerbitan
+func (s *certificateService) signIssuance(detail *model.CertificateDetail) (string, string, string, error) {
+	signed, err := s.signer.Sign(s.signaturePayload(detail))
+	if err != nil {
+		return "", "", "", err
+	}
+	return signed.Payload, signed.Signature, signed.KeyID, nil
+}
 
+// issuedDetail membaca sertifikat yang baru diterbitkan (sudah ditandatangani).
+// PDF dibuat oleh worker dari job yang dijadwalkan repository saat penyimpanan.
+func (s *certificateService) issuedDetail(ctx context.Context, id uuid.UUID) (*model.CertificateDetail, error) {
+	detail, err := s.repo.FindByIDWithDetail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if detail == nil {
+		return nil, ErrCertificateNotFound
+	}
 	return detail, nil
 }
 
-func (s *certificateService) generateAndUploadPDF(ctx context.Context, detail *model.CertificateDetail) {
-	pdfBytes, certNumber, err := s.buildPDF(detail)
+// GeneratePDFJob membuat PDF sertifikat lalu menguploadnya ke storage
+func (s *certificateService) GeneratePDFJob(ctx context.Context, job *model.Job) error {
+	var payload model.CertificatePDFJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("payload job tidak valid: %w", err)
+	}
+
+	detail, err := s.repo.FindByIDWithDetail(ctx, payload.CertificateID)
 	if err != nil {
+		return err
+	}
+	if detail == nil {
+		return ErrCertificateNotFound
+	}
+
+	// PDF yang sudah diterbitkan tidak boleh tertimpa kecuali lewat regenerasi admin
+	if payload.RegenerationID == nil && detail.PDFURL != nil {
+		return nil
+	}
+
+	// Sertifikat lama yang terbit sebelum ada tanda tangan digital
+	if detail.Signature == nil {
+		if err := s.sign(ctx, detail); err != nil {
+			return err
+		}
+	}
+
+	pdfURL, sum, err := s.generateAndUploadPDF(ctx, detail)
+	if err != nil {
+		// Simpan error terakhir agar operator tahu penyebabnya selama job dicoba ulang
+		msg := err.Error()
+		if updateErr := s.repo.UpdatePDFStatus(ctx, detail.Certificate.ID, model.PDFStatusPending, &msg); updateErr != nil {
+			log.Printf("gagal menyimpan status PDF sertifikat %s: %v", detail.Certificate.ID, updateErr)
+		}
+		return err
+	}
+
+	if payload.RegenerationID != nil {
+		return s.repo.CompletePDFRegeneration(ctx, *payload.RegenerationID, pdfURL, sum)
+	}
+	return s.repo.UpdatePDF(ctx, detail.Certificate.ID, pdfURL, sum)
+}
+
+// PDFJobDead menandai PDF sertifikat gagal setelah semua percobaan habis
+func (s *certificateService) PDFJobDead(ctx context.Context, job *model.Job, jobErr error) {
+	var payload model.CertificatePDFJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
 		return
 	}
 
+	msg := jobErr.Error()
+	if err := s.repo.UpdatePDFStatus(ctx, payload.CertificateID, model.PDFStatusFailed, &msg); err != nil {
+		log.Printf("gagal menyimpan status PDF sertifikat %s: %v", payload.CertificateID, err)
+	}
+}
+
+// generateAndUploadPDF membuat PDF, menguploadnya, dan mengembalikan URL serta SHA-256-nya
+func (s *certificateService) generateAndUploadPDF(ctx context.Context, detail *model.CertificateDetail) (string, string, error) {
+	pdfBytes, certNumber, err := s.buildPDF(ctx, detail)
+	if err != nil {
+		return "", "", fmt.Errorf("gagal membuat PDF: %w", err)
+	}
+
 	pdfURL, err := s.storage.UploadPDF(ctx, "certificates", pdfBytes, certNumber)
 	if err != nil {
-		return
+		return "", "", fmt.Errorf("gagal upload PDF: %w", err)
 	}
 
-	s.repo.UpdatePDFURL(ctx, detail.Certificate.ID, pdfURL)
+	sum := sha256.Sum256(pdfBytes)
+	return pdfURL, hex.EncodeToString(sum[:]), nil
 }
 
-func (s *certificateService) DownloadPDF(ctx context.Context, id string) ([]byte, string, error) {
-	detail, err := s.GetByID(ctx, id)
+// DownloadPDF membuka PDF yang dibekukan saat penerbitan
+func (s *certificateService) DownloadPDF(ctx context.Context, id string) (*CertificatePDFFile, error) {
+	cert, err := s.findForStatusChange(ctx, id)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	if cert.PDFURL == nil {
+		if cert.PDFStatus == model.PDFStatusFailed {
+			return nil, ErrCertificatePDFFailed
+		}
+		return nil, ErrCertificatePDFPending
 	}
 
-	pdfBytes, certNumber, err := s.buildPDF(detail)
+	file, err := s.storage.GetFile(ctx, *cert.PDFURL)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	return pdfBytes, certNumber, nil
+	pdf := &CertificatePDFFile{
+		Body:              file.Body,
+		Size:              file.Size,
+		CertificateNumber: cert.CertificateNumber,
+	}
+	if cert.PDFSHA256 != nil {
+		pdf.SHA256 = *cert.PDFSHA256
+	}
+	return pdf, nil
+}
+
+// RegeneratePDF menjadwalkan pembuatan ulang PDF; dipicu eksplisit oleh admin
+func (s *certificateService) RegeneratePDF(ctx context.Context, id string, req model.RegenerateCertificatePDFRequest, actorID string) (*model.CertificatePDFRegeneration, error) {
+	cert, err := s.findForStatusChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	regen := &model.CertificatePDFRegeneration{
+		ID:            uuid.New(),
+		CertificateID: cert.ID,
+		Reason:        req.Reason,
+	}
+	if actorUID, err := uuid.Parse(actorID); err == nil {
+		regen.RequestedBy = &actorUID
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreatePDFRegeneration(ctx, regen); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditCertificatePDF, EntityType: AuditEntityCertificate,
+			EntityID: cert.ID.String(), After: regen,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return regen, nil
+}
+
+func (s *certificateService) GetPDFRegenerations(ctx context.Context, id string) ([]*model.CertificatePDFRegeneration, error) {
+	cert, err := s.findForStatusChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindPDFRegenerations(ctx, cert.ID)
 }
 
-func (s *certificateService) buildPDF(detail *model.CertificateDetail) ([]byte, string, error) {
+func (s *certificateService) buildPDF(ctx context.Context, detail *model.CertificateDetail) ([]byte, string, error) {
+	// Surat dibuat dengan versi template yang dipakai saat diterbitkan
+	var version *model.CertificateTemplateVersion
+	if detail.TemplateVersionID != nil {
+		var err error
+		if version, err = s.templates.FindVersionByID(ctx, *detail.TemplateVersionID); err != nil {
+			return nil, "", err
+		}
+	}
+	definition, err := certificateDefinition(version)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Build achievements untuk PDF
 	pdfAchievements := make([]utils.PDFAchievement, len(detail.Achievements))
 	for i, a := range detail.Achievements {
@@ -202,7 +520,7 @@ func (s *certificateService) buildPDF(detail *model.CertificateDetail) ([]byte,
 		}
 		pdfAchievements[i] = utils.PDFAchievement{
 			No:              i + 1,
-			CompetitionName: a.CompetitionName,
+			CompetitionName: teamCompetitionName(&a.Achievement, detail.Student.ID),
 			Organizer:       a.Organizer,
 			Category:        category,
 			Rank:            a.Rank,
@@ -214,39 +532,20 @@ func (s *certificateService) buildPDF(detail *model.CertificateDetail) ([]byte,
 	// Build student data untuk PDF
 	birthDate := ""
 	if detail.Student.BirthDate != nil {
-		birthDate = detail.Student.BirthDate.Format("02 January 2006")
-	}
-
-	// Generate QR code
-	appURL := os.Getenv("APP_URL")
-	if appURL == "" {
-		appURL = "http://localhost:8080"
+		birthDate = utils.FormatCertificateDate(definition.Language, *detail.Student.BirthDate)
 	}
-	verifyURL := fmt.Sprintf("%s/api/v1/verify/%s", appURL, detail.Certificate.QRToken)
 
-	qrPNG, _ := utils.GenerateQRCodePNG(verifyURL, 150)
+	// Generate QR code (berisi payload + tanda tangan untuk verifikasi offline)
+	qrPNG, _ := utils.GenerateQRCodePNG(s.verifyURL(detail), 150)
 
-	// Data sekolah dari env
-	schoolName := os.Getenv("SCHOOL_NAME")
-	if schoolName == "" {
-		schoolName = "SMA Negeri 1"
-	}
-	schoolAddress := os.Getenv("SCHOOL_ADDRESS")
-	if schoolAddress == "" {
-		schoolAddress = "Jl. Pendidikan No. 1"
-	}
-	headmasterName := os.Getenv("HEADMASTER_NAME")
-	if headmasterName == "" {
-		headmasterName = "Kepala Sekolah"
-	}
-	headmasterNIP := os.Getenv("HEADMASTER_NIP")
+	school := loadSchoolInfo()
 
 	pdfData := utils.CertificatePDFData{
 		CertificateNumber: detail.Certificate.CertificateNumber,
 		IssuedAt:          detail.Certificate.IssuedAt,
 		ValidUntil:        detail.Certificate.ValidUntil,
-		SchoolName:        schoolName,
-		SchoolAddress:     schoolAddress,
+		SchoolName:        school.Name,
+		SchoolAddress:     school.Address,
 		Student: utils.PDFStudent{
 			FullName:   detail.Student.FullName,
 			NISN:       detail.Student.NISN,
@@ -256,32 +555,113 @@ func (s *certificateService) buildPDF(detail *model.CertificateDetail) ([]byte,
 		},
 		Achievements:   pdfAchievements,
 		QRCodePNG:      qrPNG,
-		HeadmasterName: headmasterName,
-		HeadmasterNIP:  headmasterNIP,
+		HeadmasterName: school.HeadmasterName,
+		HeadmasterNIP:  school.HeadmasterNIP,
+	}
+	if detail.Signature != nil && detail.SignedPayload != nil {
+		pdfData.SignedPayload = *detail.SignedPayload
+		pdfData.Signature = *detail.Signature
+	}
+	if detail.SignatureKeyID != nil {
+		pdfData.SignatureKeyID = *detail.SignatureKeyID
+	}
+
+	pdfBytes, err := utils.GenerateCertificatePDF(pdfData, certificateLayout(ctx, s.storage, definition))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Sematkan tanda tangan PDF (PAdES) agar dikenali Adobe Reader dkk.
+	if s.pdfSigner != nil {
+		pdfBytes, err = s.pdfSigner.Sign(pdfBytes, time.Now())
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
-	pdfBytes, err := utils.GenerateCertificatePDF(pdfData)
-	return pdfBytes, detail.Certificate.CertificateNumber, err
+	return pdfBytes, detail.Certificate.CertificateNumber, nil
 }
 
-func (s *certificateService) Revoke(ctx context.Context, id string) error {
+func (s *certificateService) Revoke(ctx context.Context, id string, req model.ChangeCertificateStatusRequest, actorID string) error {
+	cert, err := s.findForStatusChange(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cert.Status == model.CertificateStatusRevoked {
+		return errors.New("sertifikat sudah dicabut sebelumnya")
+	}
+	// Sertifikat yang digantikan tidak boleh dicabut lalu dipulihkan menjadi aktif,
+	// karena penggantinya juga aktif sehingga ada dua dokumen sah untuk prestasi yang sama
+	if cert.Status == model.CertificateStatusSuperseded {
+		return ErrCertificateSuperseded
+	}
+
+	return s.changeStatus(ctx, cert, model.CertificateStatusRevoked, req.Reason, actorID, AuditCertificateRevoke)
+}
+
+func (s *certificateService) Reinstate(ctx context.Context, id string, req model.ChangeCertificateStatusRequest, actorID string) error {
+	cert, err := s.findForStatusChange(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cert.Status != model.CertificateStatusRevoked {
+		return ErrCertificateNotRevoked
+	}
+	if cert.SupersededBy != nil {
+		return ErrCertificateSuperseded
+	}
+
+	return s.changeStatus(ctx, cert, model.CertificateStatusActive, req.Reason, actorID, AuditCertificateRestore)
+}
+
+func (s *certificateService) GetStatusHistory(ctx context.Context, id string) ([]*model.CertificateStatusEvent, error) {
+	cert, err := s.findForStatusChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindStatusEvents(ctx, cert.ID)
+}
+
+func (s *certificateService) findForStatusChange(ctx context.Context, id string) (*model.Certificate, error) {
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		return errors.New("ID tidak valid")
+		return nil, errors.New("ID tidak valid")
 	}
 
 	cert, err := s.repo.FindByID(ctx, uid)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if cert == nil {
-		return ErrCertificateNotFound
+		return nil, ErrCertificateNotFound
 	}
-	if cert.Status == "revoked" {
-		return errors.New("sertifikat sudah dicabut sebelumnya")
+	return cert, nil
+}
+
+func (s *certificateService) changeStatus(ctx context.Context, cert *model.Certificate, toStatus, reason, actorID, auditAction string) error {
+	fromStatus := cert.Status
+	event := &model.CertificateStatusEvent{
+		ID:            uuid.New(),
+		CertificateID: cert.ID,
+		FromStatus:    &fromStatus,
+		ToStatus:      toStatus,
+		Reason:        &reason,
+	}
+	if actorUID, err := uuid.Parse(actorID); err == nil {
+		event.ActorID = &actorUID
 	}
 
-	return s.repo.Revoke(ctx, uid)
+	after := *cert
+	after.Status = toStatus
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ChangeStatus(ctx, event); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: auditAction, EntityType: AuditEntityCertificate,
+			EntityID: cert.ID.String(), Before: cert, After: after,
+		})
+	})
 }
 
 func (s *certificateService) Verify(ctx context.Context, token string) (*model.VerifyResponse, error) {
@@ -297,15 +677,54 @@ func (s *certificateService) Verify(ctx context.Context, token string) (*model.V
 		}, nil
 	}
 
-	if cert.Status == "revoked" {
-		return &model.VerifyResponse{
+	if cert.Status == model.CertificateStatusRevoked {
+		resp := &model.VerifyResponse{
 			IsValid:     false,
 			Certificate: cert,
 			Message:     "Sertifikat ini telah dicabut dan tidak berlaku.",
-		}, nil
+		}
+
+		event, err := s.repo.FindLatestStatusEvent(ctx, cert.ID, model.CertificateStatusRevoked)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			reason := ""
+			if event.Reason != nil {
+				reason = *event.Reason
+			}
+			resp.Revocation = &model.RevocationInfo{RevokedAt: event.CreatedAt, Reason: reason}
+			resp.Message = fmt.Sprintf("Sertifikat ini telah dicabut pada %s dan tidak berlaku. Alasan: %s",
+				utils.FormatIndonesianDate(event.CreatedAt), reason)
+		}
+		return resp, nil
+	}
+
+	if cert.Status == model.CertificateStatusSuperseded {
+		resp := &model.VerifyResponse{
+			IsValid:     false,
+			Certificate: cert,
+			Message:     "Sertifikat ini telah digantikan oleh surat yang lebih baru.",
+		}
+
+		current, err := s.currentSuccessor(ctx, cert)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			resp.SupersededBy = &model.SupersessionInfo{
+				CertificateID:     current.ID,
+				CertificateNumber: current.CertificateNumber,
+				Status:            current.Status,
+				VerifyURL:         publicVerifyURL(current.QRToken),
+			}
+			resp.Message = fmt.Sprintf("Sertifikat ini telah digantikan oleh No. %s. Gunakan dokumen terbaru.",
+				current.CertificateNumber)
+		}
+		return resp, nil
 	}
 
-	// Ambil detail lengkap
+	// Isi sertifikat diambil dari snapshot penerbitan, bukan data live
 	uid := cert.ID
 	detail, err := s.repo.FindByIDWithDetail(ctx, uid)
 	if err != nil {
@@ -317,11 +736,293 @@ func (s *certificateService) Verify(ctx context.Context, token string) (*model.V
 		achievements[i] = a.Achievement
 	}
 
+	signatureStatus := s.checkSignature(detail)
+	if signatureStatus == model.SignatureStatusTampered {
+		return &model.VerifyResponse{
+			IsValid:         false,
+			SignatureStatus: signatureStatus,
+			Certificate:     &detail.Certificate,
+			Student:         detail.Student,
+			Achievements:    achievements,
+			Message:         "Tanda tangan digital tidak cocok dengan data sertifikat. Dokumen ini kemungkinan telah dimanipulasi.",
+		}, nil
+	}
+
 	return &model.VerifyResponse{
-		IsValid:      true,
-		Certificate:  cert,
-		Student:      detail.Student,
-		Achievements: achievements,
-		Message:      "Sertifikat valid dan sah dikeluarkan oleh sekolah.",
+		IsValid:         true,
+		SignatureStatus: signatureStatus,
+		Certificate:     &detail.Certificate,
+		Student:         detail.Student,
+		Achievements:    achievements,
+		Message:         "Sertifikat valid dan sah dikeluarkan oleh sekolah.",
+	}, nil
+}
+
+// GetDrift membandingkan snapshot penerbitan dengan data siswa & prestasi saat ini
+func (s *certificateService) GetDrift(ctx context.Context, id string) (*model.CertificateDrift, error) {
+	cert, err := s.findForStatusChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(cert.Snapshot) == 0 {
+		return nil, errors.New("sertifikat ini belum memiliki snapshot")
+	}
+
+	var snapshot model.CertificateSnapshot
+	if err := json.Unmarshal(cert.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	drift := &model.CertificateDrift{
+		CertificateID:     cert.ID,
+		CertificateNumber: cert.CertificateNumber,
+		SnapshotAt:        snapshot.CapturedAt,
+		Backfilled:        snapshot.Backfilled,
+		Student:           []model.FieldDiff{},
+		Achievements:      []model.AchievementDrift{},
+	}
+
+	student, err := s.studentRepo.FindByID(ctx, cert.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if student == nil {
+		drift.StudentDeleted = true
+	} else {
+		drift.Student = diffStudent(&snapshot.Student, student)
+	}
+
+	for _, frozen := range snapshot.Achievements {
+		live, err := s.achRepo.FindByIDWithAttachments(ctx, frozen.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		item := model.AchievementDrift{
+			AchievementID:   frozen.ID,
+			CompetitionName: frozen.CompetitionName,
+		}
+		if live == nil {
+			item.Status = model.DriftStatusDeleted
+		} else if fields := diffAchievement(&frozen, live); len(fields) > 0 {
+			item.Status = model.DriftStatusChanged
+			item.Fields = fields
+		} else {
+			continue
+		}
+		drift.Achievements = append(drift.Achievements, item)
+	}
+
+	drift.HasDrift = drift.StudentDeleted || len(drift.Student) > 0 || len(drift.Achievements) > 0
+	return drift, nil
+}
+
+func diffStudent(frozen, live *model.Student) []model.FieldDiff {
+	diffs := []model.FieldDiff{}
+	diffs = appendDiff(diffs, "nisn", frozen.NISN, live.NISN)
+	diffs = appendDiff(diffs, "full_name", frozen.FullName, live.FullName)
+	diffs = appendDiff(diffs, "birth_place", frozen.BirthPlace, live.BirthPlace)
+	diffs = appendDiff(diffs, "birth_date", formatOptionalDate(frozen.BirthDate), formatOptionalDate(live.BirthDate))
+	diffs = appendDiff(diffs, "gender", frozen.Gender, live.Gender)
+	diffs = appendDiff(diffs, "class", frozen.Class, live.Class)
+	return diffs
+}
+
+func diffAchievement(frozen, live *model.AchievementWithAttachments) []model.FieldDiff {
+	var diffs []model.FieldDiff
+	diffs = appendDiff(diffs, "competition_name", frozen.CompetitionName, live.CompetitionName)
+	diffs = appendDiff(diffs, "organizer", frozen.Organizer, live.Organizer)
+	diffs = appendDiff(diffs, "category_name", derefString(frozen.CategoryName), derefString(live.CategoryName))
+	diffs = appendDiff(diffs, "rank", frozen.Rank, live.Rank)
+	diffs = appendDiff(diffs, "level_name", derefString(frozen.LevelName), derefString(live.LevelName))
+	diffs = appendDiff(diffs, "year", frozen.Year, live.Year)
+	diffs = appendDiff(diffs, "description", frozen.Description, live.Description)
+	if frozen.Status != "" { // snapshot sebelum alur verifikasi tidak menyimpan status
+		diffs = appendDiff(diffs, "status", frozen.Status, live.Status)
+	}
+	if frozen.TeamName != nil || live.TeamName != nil {
+		diffs = appendDiff(diffs, "team_name", derefString(frozen.TeamName), derefString(live.TeamName))
+		diffs = appendDiff(diffs, "participants", participantList(frozen.Participants), participantList(live.Participants))
+	}
+
+	frozenFiles := make([]string, len(frozen.Attachments))
+	for i, att := range frozen.Attachments {
+		frozenFiles[i] = att.FileName
+	}
+	liveFiles := make([]string, len(live.Attachments))
+	for i, att := range live.Attachments {
+		liveFiles[i] = att.FileName
+	}
+	diffs = appendDiff(diffs, "attachments", strings.Join(frozenFiles, ", "), strings.Join(liveFiles, ", "))
+	return diffs
+}
+
+// teamCompetitionName menambahkan nama tim dan peran siswa pada prestasi tim,
+// mis. "Lomba Robotik (Tim Garuda, Kapten)"
+func teamCompetitionName(a *model.Achievement, studentID uuid.UUID) string {
+	if a.TeamName == nil {
+		return a.CompetitionName
+	}
+	for _, p := range a.Participants {
+		if p.StudentID == studentID {
+			return fmt.Sprintf("%s (Tim %s, %s)", a.CompetitionName, *a.TeamName, participantRoleLabel(p.Role))
+		}
+	}
+	return fmt.Sprintf("%s (Tim %s)", a.CompetitionName, *a.TeamName)
+}
+
+func participantRoleLabel(role string) string {
+	switch role {
+	case model.ParticipantRoleCaptain:
+		return "Kapten"
+	case model.ParticipantRoleCoach:
+		return "Pelatih"
+	}
+	return "Anggota"
+}
+
+// participantList daftar anggota tim untuk perbandingan drift, mis. "Budi (captain), Sari (member)"
+func participantList(participants []model.AchievementParticipant) string {
+	items := make([]string, len(participants))
+	for i, p := range participants {
+		items[i] = fmt.Sprintf("%s (%s)", derefString(p.StudentName), p.Role)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
+
+func appendDiff[T comparable](diffs []model.FieldDiff, field string, frozen, live T) []model.FieldDiff {
+	if frozen == live {
+		return diffs
+	}
+	return append(diffs, model.FieldDiff{Field: field, Snapshot: frozen, Live: live})
+}
+
+func formatOptionalDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (s *certificateService) SigningKey(ctx context.Context) (*model.SigningKeyResponse, error) {
+	pemKey, err := s.signer.PublicKeyPEM()
+	if err != nil {
+		return nil, err
+	}
+	return &model.SigningKeyResponse{
+		KeyID:        s.signer.KeyID(),
+		Algorithm:    "Ed25519",
+		PublicKey:    s.signer.PublicKeyBase64(),
+		PEM:          pemKey,
+		PreviousKeys: s.signer.PreviousKeysBase64(),
 	}, nil
-}
\ No newline at end of file
+}
+
+// sign menandatangani sertifikat dan menyimpan hasilnya ke DB
+func (s *certificateService) sign(ctx context.Context, detail *model.CertificateDetail) error {
+	signed, err := s.signer.Sign(s.signaturePayload(detail))
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateSignature(ctx, detail.Certificate.ID, signed.Payload, signed.Signature, signed.KeyID); err != nil {
+		return err
+	}
+
+	detail.SignedPayload = &signed.Payload
+	detail.Signature = &signed.Signature
+	detail.SignatureKeyID = &signed.KeyID
+	return nil
+}
+
+// checkSignature memvalidasi tanda tangan dan membandingkan payload dengan data saat ini
+func (s *certificateService) checkSignature(detail *model.CertificateDetail) string {
+	if detail.Signature == nil || detail.SignedPayload == nil {
+		return model.SignatureStatusUnsigned
+	}
+
+	signed, err := s.signer.Verify(derefString(detail.SignatureKeyID), *detail.SignedPayload, *detail.Signature)
+	if err != nil {
+		return model.SignatureStatusTampered
+	}
+
+	current := s.signaturePayload(detail)
+	current.Version = signed.Version
+	current.KeyID = signed.KeyID
+	if *signed != current {
+		return model.SignatureStatusTampered
+	}
+
+	return model.SignatureStatusValid
+}
+
+func (s *certificateService) signaturePayload(detail *model.CertificateDetail) utils.CertificateSignaturePayload {
+	lines := make([]string, len(detail.Achievements))
+	for i, a := range detail.Achievements {
+		level := ""
+		if a.LevelName != nil {
+			level = *a.LevelName
+		}
+		lines[i] = fmt.Sprintf("%s|%s|%s|%d", a.CompetitionName, a.Rank, level, a.Year)
+	}
+
+	payload := utils.CertificateSignaturePayload{
+		CertificateNumber: detail.Certificate.CertificateNumber,
+		QRToken:           detail.Certificate.QRToken,
+		StudentName:       detail.Student.FullName,
+		StudentNISN:       detail.Student.NISN,
+		IssuedAt:          detail.Certificate.IssuedAt.Format("2006-01-02"),
+		AchievementsHash:  utils.HashAchievementLines(lines),
+	}
+	if detail.Certificate.ValidUntil != nil {
+		payload.ValidUntil = detail.Certificate.ValidUntil.Format("2006-01-02")
+	}
+	return payload
+}
+
+// currentSuccessor menelusuri rantai superseded_by sampai dokumen terakhir
+func (s *certificateService) currentSuccessor(ctx context.Context, cert *model.Certificate) (*model.Certificate, error) {
+	var current *model.Certificate
+	next := cert.SupersededBy
+	for i := 0; next != nil && i < maxSupersessionDepth; i++ {
+		c, err := s.repo.FindByID(ctx, *next)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			break
+		}
+		current = c
+		next = c.SupersededBy
+	}
+	return current, nil
+}
+
+// maxSupersessionDepth batas aman penelusuran rantai penerbitan ulang
+const maxSupersessionDepth = 50
+
+func publicVerifyURL(qrToken string) string {
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:8080"
+	}
+	return fmt.Sprintf("%s/api/v1/verify/%s", appURL, qrToken)
+}
+
+// verifyURL URL verifikasi online; query p & s memuat payload bertanda tangan untuk verifikasi offline
+func (s *certificateService) verifyURL(detail *model.CertificateDetail) string {
+	verifyURL := publicVerifyURL(detail.Certificate.QRToken)
+
+	if detail.Signature != nil && detail.SignedPayload != nil {
+		verifyURL += fmt.Sprintf("?p=%s&s=%s", *detail.SignedPayload, *detail.Signature)
+	}
+	return verifyURL
+}
@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+)
+
+var (
+	ErrTemplateNotFound        = errors.New("template surat tidak ditemukan")
+	ErrTemplateVersionNotFound = errors.New("versi template tidak ditemukan")
+	ErrTemplateNameExists      = errors.New("nama template sudah digunakan")
+	ErrTemplateImageType       = errors.New("kop surat harus berupa gambar JPG atau PNG")
+)
+
+// Batas isi template
+const (
+	templateMaxTextLength = 2000
+	templateMaxColumns    = 7
+	templateMaxSignatures = 3
+	templateHeaderPixels  = 1600 // lebar maksimal kop surat bergambar
+)
+
+type CertificateTemplateService interface {
+	GetAll(ctx context.Context) ([]*model.CertificateTemplate, error)
+	GetByID(ctx context.Context, id string) (*model.CertificateTemplateDetail, error)
+	GetVersion(ctx context.Context, id string, version int) (*model.CertificateTemplateVersion, error)
+	Create(ctx context.Context, req model.SaveCertificateTemplateRequest, actorID string) (*model.CertificateTemplateDetail, error)
+	Update(ctx context.Context, id string, req model.SaveCertificateTemplateRequest, actorID string) (*model.CertificateTemplateDetail, error)
+	SetDefault(ctx context.Context, id string) (*model.CertificateTemplateDetail, error)
+	UploadHeaderImage(ctx context.Context, data []byte, contentType string) (*model.CertificateTemplateImage, error)
+	Preview(ctx context.Context, def model.CertificateTemplateDefinition) (*model.ReportFile, error)
+	PreviewVersion(ctx context.Context, id string, version *int) (*model.ReportFile, error)
+}
+
+type certificateTemplateService struct {
+	repo    repository.CertificateTemplateRepository
+	storage *utils.StorageService
+	tx      repository.Transactor
+	audit   AuditService
+}
+
+func NewCertificateTemplateService(repo repository.CertificateTemplateRepository, storage *utils.StorageService, tx repository.Transactor, audit AuditService) CertificateTemplateService {
+	return &certificateTemplateService{repo: repo, storage: storage, tx: tx, audit: audit}
+}
+
+// ValidateCertificateTemplate membersihkan dan memvalidasi template yang akan disimpan
+func ValidateCertificateTemplate(req *model.SaveCertificateTemplateRequest) utils.ValidationErrors {
+	errs := ValidateCertificateTemplateDefinition(&req.Definition)
+	req.Name = utils.SanitizeString(req.Name)
+	req.Description = utils.SanitizeString(req.Description)
+
+	if req.Name == "" {
+		errs["name"] = "Nama template wajib diisi"
+	} else if len([]rune(req.Name)) > 100 {
+		errs["name"] = "Nama template maksimal 100 karakter"
+	}
+	return errs
+}
+
+// ValidateCertificateTemplateDefinition memvalidasi isi template. Nilai kosong diisi
+// bawaan: bahasa "id", warna #003366, perataan kolom "L" dan judul kolom sesuai bahasa.
+// Placeholder di setiap teks dicoba dijalankan agar kesalahan ketahuan sebelum disimpan.
+func ValidateCertificateTemplateDefinition(def *model.CertificateTemplateDefinition) utils.ValidationErrors {
+	errs := utils.ValidationErrors{}
+	defaults := model.DefaultCertificateTemplateDefinition()
+
+	def.Language = strings.ToLower(utils.SanitizeString(def.Language))
+	if def.Language == "" {
+		def.Language = defaults.Language
+	}
+	if !utils.IsCertificateLanguage(def.Language) {
+		errs["definition.language"] = "Bahasa template harus id atau en"
+	}
+
+	def.Title = utils.SanitizeString(def.Title)
+	if def.Title == "" {
+		errs["definition.title"] = "Judul surat wajib diisi"
+	} else if len([]rune(def.Title)) > 150 {
+		errs["definition.title"] = "Judul surat maksimal 150 karakter"
+	}
+
+	def.HeaderImageURL = utils.SanitizeString(def.HeaderImageURL)
+	def.PrimaryColor = strings.ToLower(utils.SanitizeString(def.PrimaryColor))
+	if def.PrimaryColor == "" {
+		def.PrimaryColor = defaults.PrimaryColor
+	}
+	if _, err := utils.ParseHexColor(def.PrimaryColor); err != nil {
+		errs["definition.primary_color"] = "Warna harus berformat #RRGGBB"
+	}
+
+	texts := map[string]*string{
+		"definition.opening_text": &def.OpeningText,
+		"definition.body_text":    &def.BodyText,
+		"definition.closing_text": &def.ClosingText,
+	}
+	for field, text := range texts {
+		*text = strings.TrimSpace(*text)
+		checkTemplateText(errs, field, *text)
+	}
+
+	if len(def.Columns) == 0 || len(def.Columns) > templateMaxColumns {
+		errs["definition.columns"] = fmt.Sprintf("Tabel prestasi harus memiliki 1-%d kolom", templateMaxColumns)
+	}
+	seen := make(map[string]bool)
+	for i := range def.Columns {
+		col := &def.Columns[i]
+		field := fmt.Sprintf("definition.columns[%d]", i)
+		col.Field = strings.ToLower(utils.SanitizeString(col.Field))
+		col.Label = utils.SanitizeString(col.Label)
+		col.Align = strings.ToUpper(utils.SanitizeString(col.Align))
+		if col.Align == "" {
+			col.Align = "L"
+		}
+
+		if !slices.Contains(utils.CertificateColumnFields, col.Field) {
+			errs[field+".field"] = "Kolom harus salah satu dari " + strings.Join(utils.CertificateColumnFields, ", ")
+		} else if seen[col.Field] {
+			errs[field+".field"] = "Kolom duplikat"
+		}
+		seen[col.Field] = true
+		if col.Width <= 0 || col.Width > 100 {
+			errs[field+".width"] = "Lebar kolom harus antara 1 dan 100"
+		}
+		if col.Align != "L" && col.Align != "C" && col.Align != "R" {
+			errs[field+".align"] = "Perataan kolom harus L, C atau R"
+		}
+	}
+
+	if len(def.Signatures) == 0 || len(def.Signatures) > templateMaxSignatures {
+		errs["definition.signatures"] = fmt.Sprintf("Template harus memiliki 1-%d blok tanda tangan", templateMaxSignatures)
+	}
+	for i := range def.Signatures {
+		sig := &def.Signatures[i]
+		field := fmt.Sprintf("definition.signatures[%d]", i)
+		sig.Title = utils.SanitizeString(sig.Title)
+		sig.Name = utils.SanitizeString(sig.Name)
+		sig.NIP = utils.SanitizeString(sig.NIP)
+		if sig.Name == "" {
+			errs[field+".name"] = "Nama penanda tangan wajib diisi"
+		}
+		checkTemplateText(errs, field+".title", sig.Title)
+		checkTemplateText(errs, field+".name", sig.Name)
+		checkTemplateText(errs, field+".nip", sig.NIP)
+	}
+
+	return errs
+}
+
+func checkTemplateText(errs utils.ValidationErrors, field, text string) {
+	if len([]rune(text)) > templateMaxTextLength {
+		errs[field] = fmt.Sprintf("Teks maksimal %d karakter", templateMaxTextLength)
+		return
+	}
+	if err := utils.CheckCertificateText(text); err != nil {
+		errs[field] = err.Error()
+	}
+}
+
+func (s *certificateTemplateService) GetAll(ctx context.Context) ([]*model.CertificateTemplate, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *certificateTemplateService) GetByID(ctx context.Context, id string) (*model.CertificateTemplateDetail, error) {
+	tmpl, err := s.findTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, tmpl)
+}
+
+func (s *certificateTemplateService) GetVersion(ctx context.Context, id string, version int) (*model.CertificateTemplateVersion, error) {
+	tmpl, err := s.findTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.repo.FindVersion(ctx, tmpl.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrTemplateVersionNotFound
+	}
+	return v, nil
+}
+
+func (s *certificateTemplateService) Create(ctx context.Context, req model.SaveCertificateTemplateRequest, actorID string) (*model.CertificateTemplateDetail, error) {
+	if err := s.ensureNameAvailable(ctx, req.Name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	tmpl := &model.CertificateTemplate{
+		ID:          uuid.New(),
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	version, err := newTemplateVersion(req.Definition, actorID)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.CreatedBy = version.CreatedBy
+
+	var detail *model.CertificateTemplateDetail
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, tmpl, version); err != nil {
+			return err
+		}
+
+		var err error
+		if detail, err = s.GetByID(ctx, tmpl.ID.String()); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditTemplateCreate, EntityType: AuditEntityCertificateTemplate,
+			EntityID: tmpl.ID.String(), After: detail.CertificateTemplate,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// Update menyimpan perubahan sebagai versi baru; versi lama tetap dipakai surat
+// yang sudah diterbitkan dengannya
+func (s *certificateTemplateService) Update(ctx context.Context, id string, req model.SaveCertificateTemplateRequest, actorID string) (*model.CertificateTemplateDetail, error) {
+	before, err := s.findTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureNameAvailable(ctx, req.Name, before.ID); err != nil {
+		return nil, err
+	}
+
+	tmpl := *before
+	tmpl.Name = req.Name
+	tmpl.Description = req.Description
+	version, err := newTemplateVersion(req.Definition, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var detail *model.CertificateTemplateDetail
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddVersion(ctx, &tmpl, version); err != nil {
+			return err
+		}
+
+		var err error
+		if detail, err = s.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditTemplateUpdate, EntityType: AuditEntityCertificateTemplate,
+			EntityID: before.ID.String(), Before: before, After: detail.CertificateTemplate,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// SetDefault menjadikan template ini pilihan awal untuk surat baru
+func (s *certificateTemplateService) SetDefault(ctx context.Context, id string) (*model.CertificateTemplateDetail, error) {
+	tmpl, err := s.findTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		previous, err := s.repo.FindDefault(ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.SetDefault(ctx, tmpl.ID); err != nil {
+			return err
+		}
+
+		entry := model.AuditEntry{
+			Action: AuditTemplateDefault, EntityType: AuditEntityCertificateTemplate,
+			EntityID: tmpl.ID.String(), After: map[string]string{"default_template": tmpl.Name},
+		}
+		if previous != nil {
+			entry.Before = map[string]string{"default_template": previous.Name}
+		}
+		return s.audit.Record(ctx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+// UploadHeaderImage menyimpan gambar kop surat. Gambar tidak pernah dihapus agar versi
+// template lama yang merujuknya tetap bisa dipakai.
+func (s *certificateTemplateService) UploadHeaderImage(ctx context.Context, data []byte, contentType string) (*model.CertificateTemplateImage, error) {
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return nil, ErrTemplateImageType
+	}
+	if _, err := utils.MakeThumbnail(data, templateHeaderPixels); err != nil {
+		return nil, err
+	}
+
+	result, err := s.storage.UploadFile(ctx, "certificate-templates", data, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CertificateTemplateImage{HeaderImageURL: result.FileURL}, nil
+}
+
+// Preview membuat PDF contoh dari template yang belum disimpan
+func (s *certificateTemplateService) Preview(ctx context.Context, def model.CertificateTemplateDefinition) (*model.ReportFile, error) {
+	return s.render(ctx, def)
+}
+
+// PreviewVersion membuat PDF contoh dari versi template tersimpan (default versi terbaru)
+func (s *certificateTemplateService) PreviewVersion(ctx context.Context, id string, version *int) (*model.ReportFile, error) {
+	tmpl, err := s.findTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := tmpl.Definition
+	if version != nil {
+		v, err := s.repo.FindVersion(ctx, tmpl.ID, *version)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, ErrTemplateVersionNotFound
+		}
+		definition = v.Definition
+	}
+
+	var def model.CertificateTemplateDefinition
+	if err := json.Unmarshal(definition, &def); err != nil {
+		return nil, fmt.Errorf("isi template rusak: %w", err)
+	}
+	return s.render(ctx, def)
+}
+
+func (s *certificateTemplateService) render(ctx context.Context, def model.CertificateTemplateDefinition) (*model.ReportFile, error) {
+	pdf, err := utils.GenerateCertificatePDF(sampleCertificateData(def.Language), certificateLayout(ctx, s.storage, def))
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReportFile{
+		FileName:    "pratinjau-template.pdf",
+		ContentType: "application/pdf",
+		Data:        pdf,
+	}, nil
+}
+
+func (s *certificateTemplateService) findTemplate(ctx context.Context, id string) (*model.CertificateTemplate, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	tmpl, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return tmpl, nil
+}
+
+func (s *certificateTemplateService) detail(ctx context.Context, tmpl *model.CertificateTemplate) (*model.CertificateTemplateDetail, error) {
+	versions, err := s.repo.FindVersions(ctx, tmpl.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CertificateTemplateDetail{CertificateTemplate: *tmpl, Versions: versions}, nil
+}
+
+func (s *certificateTemplateService) ensureNameAvailable(ctx context.Context, name string, id uuid.UUID) error {
+	existing, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != id {
+		return ErrTemplateNameExists
+	}
+	return nil
+}
+
+func newTemplateVersion(def model.CertificateTemplateDefinition, actorID string) (*model.CertificateTemplateVersion, error) {
+	raw, err := json.Marshal(def)
+	if err != nil {
+		return nil, err
+	}
+	version := &model.CertificateTemplateVersion{ID: uuid.New(), Definition: model.JSONB(raw)}
+	if uid, err := uuid.Parse(actorID); err == nil {
+		version.CreatedBy = &uid
+	}
+	return version, nil
+}
+
+// certificateLayout mengubah isi template menjadi tata letak PDF. Kop surat bergambar
+// yang gagal dibaca diganti kop teks agar surat tetap bisa dibuat.
+func certificateLayout(ctx context.Context, storage *utils.StorageService, def model.CertificateTemplateDefinition) utils.CertificateLayout {
+	color, err := utils.ParseHexColor(def.PrimaryColor)
+	if err != nil {
+		color, _ = utils.ParseHexColor(model.DefaultCertificateTemplateDefinition().PrimaryColor)
+	}
+
+	layout := utils.CertificateLayout{
+		Language:    def.Language,
+		Title:       def.Title,
+		Color:       color,
+		OpeningText: def.OpeningText,
+		BodyText:    def.BodyText,
+		ClosingText: def.ClosingText,
+	}
+	for _, col := range def.Columns {
+		layout.Columns = append(layout.Columns, utils.CertificateColumn{
+			Field: col.Field, Label: col.Label, Width: col.Width, Align: col.Align,
+		})
+	}
+	for _, sig := range def.Signatures {
+		layout.Signatures = append(layout.Signatures, utils.CertificateSignature{
+			Title: sig.Title, Name: sig.Name, NIP: sig.NIP,
+		})
+	}
+	if def.HeaderImageURL != "" {
+		layout.HeaderImage = loadStoredImage(ctx, storage, def.HeaderImageURL, templateHeaderPixels)
+	}
+	return layout
+}
+
+// certificateDefinition isi versi template sertifikat; sertifikat tanpa versi template
+// memakai tata letak bawaan
+func certificateDefinition(version *model.CertificateTemplateVersion) (model.CertificateTemplateDefinition, error) {
+	if version == nil {
+		return model.DefaultCertificateTemplateDefinition(), nil
+	}
+	var def model.CertificateTemplateDefinition
+	if err := json.Unmarshal(version.Definition, &def); err != nil {
+		return def, fmt.Errorf("isi template rusak: %w", err)
+	}
+	return def, nil
+}
+
+// sampleCertificateData data contoh untuk pratinjau template
+func sampleCertificateData(language string) utils.CertificatePDFData {
+	school := loadSchoolInfo()
+	now := time.Now()
+	qrPNG, _ := utils.GenerateQRCodePNG(publicVerifyURL("pratinjau"), 150)
+
+	return utils.CertificatePDFData{
+		CertificateNumber: fmt.Sprintf("421.2/SKP/%d/0001", now.Year()),
+		IssuedAt:          now,
+		SchoolName:        school.Name,
+		SchoolAddress:     school.Address,
+		Student: utils.PDFStudent{
+			FullName:   "Budi Santoso",
+			NISN:       "0051234567",
+			BirthPlace: "Bandung",
+			BirthDate:  utils.FormatCertificateDate(language, time.Date(now.Year()-17, time.August, 17, 0, 0, 0, 0, time.Local)),
+			Class:      "XII IPA 1",
+		},
+		Achievements: []utils.PDFAchievement{
+			{No: 1, CompetitionName: "Olimpiade Sains Nasional Matematika", Organizer: "Pusat Prestasi Nasional",
+				Category: "Akademik", Rank: "Juara 1", Level: "Nasional", Year: now.Year()},
+			{No: 2, CompetitionName: "Lomba Robotik (Tim Garuda, Kapten)", Organizer: "Dinas Pendidikan Provinsi",
+				Category: "Non Akademik", Rank: "Juara 2", Level: "Provinsi", Year: now.Year() - 1},
+			{No: 3, CompetitionName: "Festival Lomba Seni Siswa Nasional", Organizer: "Dinas Pendidikan Kabupaten",
+				Category: "Non Akademik", Rank: "Harapan 1", Level: "Kabupaten/Kota", Year: now.Year() - 1},
+		},
+		QRCodePNG:      qrPNG,
+		HeadmasterName: school.HeadmasterName,
+		HeadmasterNIP:  school.HeadmasterNIP,
+	}
+}
+
+// Pastikan interface terpenuhi
+var _ CertificateTemplateService = (*certificateTemplateService)(nil)
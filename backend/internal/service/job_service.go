@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+)
+
+var (
+	ErrJobNotFound = errors.New("job tidak ditemukan")
+	ErrJobNotDead  = errors.New("hanya job yang gagal permanen yang dapat dicoba ulang")
+)
+
+type JobService interface {
+	GetAll(ctx context.Context, filter model.JobFilter) ([]*model.Job, *response.Pagination, error)
+	Retry(ctx context.Context, id string) (*model.Job, error)
+}
+
+type jobService struct {
+	repo repository.JobRepository
+}
+
+func NewJobService(repo repository.JobRepository) JobService {
+	return &jobService{repo: repo}
+}
+
+func (s *jobService) GetAll(ctx context.Context, filter model.JobFilter) ([]*model.Job, *response.Pagination, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	jobs, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := int(total) / filter.PerPage
+	if int(total)%filter.PerPage > 0 {
+		totalPages++
+	}
+
+	return jobs, &response.Pagination{
+		Page: filter.Page, PerPage: filter.PerPage,
+		TotalItems: total, TotalPages: totalPages,
+	}, nil
+}
+
+func (s *jobService) Retry(ctx context.Context, id string) (*model.Job, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("ID tidak valid")
+	}
+
+	job, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	if job.Status != model.JobStatusDead {
+		return nil, ErrJobNotDead
+	}
+
+	retried, err := s.repo.Retry(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if retried == nil {
+		// Status berubah di antara pengecekan dan update
+		return nil, ErrJobNotDead
+	}
+	return retried, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+)
+
+// Batas gambar di portofolio agar ukuran PDF tetap wajar
+const (
+	portfolioPhotoPixels     = 600
+	portfolioThumbnailPixels = 400
+	portfolioMaxThumbnails   = 6 // per prestasi
+)
+
+type PortfolioService interface {
+	Generate(ctx context.Context, studentID string) (*model.ReportFile, error)
+}
+
+type portfolioService struct {
+	studentRepo     repository.StudentRepository
+	achievementRepo repository.AchievementRepository
+	storage         *utils.StorageService
+}
+
+func NewPortfolioService(studentRepo repository.StudentRepository, achievementRepo repository.AchievementRepository, storage *utils.StorageService) PortfolioService {
+	return &portfolioService{studentRepo: studentRepo, achievementRepo: achievementRepo, storage: storage}
+}
+
+// Generate membuat portofolio PDF berisi seluruh prestasi verified siswa (termasuk
+// prestasi tim) urut per tahun, foto siswa dan thumbnail lampiran gambar. Foto atau
+// lampiran yang gagal dibaca dilewati tanpa menggagalkan dokumen.
+func (s *portfolioService) Generate(ctx context.Context, studentID string) (*model.ReportFile, error) {
+	uid, err := uuid.Parse(studentID)
+	if err != nil {
+		return nil, ErrStudentNotFound
+	}
+
+	student, err := s.studentRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if student == nil {
+		return nil, ErrStudentNotFound
+	}
+
+	all, err := s.achievementRepo.FindByStudentID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	var achievements []*model.Achievement
+	var ids []string
+	for _, a := range all {
+		if a.Status == model.AchievementStatusVerified {
+			achievements = append(achievements, a)
+			ids = append(ids, a.ID.String())
+		}
+	}
+	slices.SortStableFunc(achievements, func(a, b *model.Achievement) int {
+		if a.Year != b.Year {
+			return a.Year - b.Year
+		}
+		return strings.Compare(a.CompetitionName, b.CompetitionName)
+	})
+
+	attachments, err := s.achievementRepo.FindAttachmentsByAchievementIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	school := loadSchoolInfo()
+	birthDate := ""
+	if student.BirthDate != nil {
+		birthDate = utils.FormatIndonesianDate(*student.BirthDate)
+	}
+	data := utils.PortfolioPDFData{
+		SchoolName:     school.Name,
+		SchoolAddress:  school.Address,
+		GeneratedAt:    time.Now(),
+		HeadmasterName: school.HeadmasterName,
+		HeadmasterNIP:  school.HeadmasterNIP,
+		Student: utils.PDFStudent{
+			FullName:   student.FullName,
+			NISN:       student.NISN,
+			BirthPlace: student.BirthPlace,
+			BirthDate:  birthDate,
+			Class:      student.Class,
+		},
+		YearEntry:    optionalYear(student.YearEntry),
+		YearGraduate: optionalYear(student.YearGraduate),
+	}
+	if student.PhotoURL != nil {
+		data.PhotoJPEG = loadStoredImage(ctx, s.storage, *student.PhotoURL, portfolioPhotoPixels)
+	}
+
+	for i, a := range achievements {
+		item := utils.PortfolioAchievement{
+			No:              i + 1,
+			CompetitionName: a.CompetitionName,
+			Organizer:       a.Organizer,
+			Category:        derefString(a.CategoryName),
+			Level:           derefString(a.LevelName),
+			Rank:            a.Rank,
+			Year:            a.Year,
+			Team:            portfolioTeamLabel(a, uid),
+			Description:     strings.TrimSpace(a.Description),
+		}
+
+		for _, att := range attachments[a.ID] {
+			if len(item.Thumbnails) == portfolioMaxThumbnails {
+				break
+			}
+			if !strings.HasPrefix(att.FileType, "image/") {
+				continue
+			}
+			if thumb := loadStoredImage(ctx, s.storage, att.FileURL, portfolioThumbnailPixels); thumb != nil {
+				label := att.Label
+				if label == "" {
+					label = att.FileName
+				}
+				item.Thumbnails = append(item.Thumbnails, utils.PDFThumbnail{Label: label, JPEG: thumb})
+			}
+		}
+		data.Achievements = append(data.Achievements, item)
+	}
+
+	pdf, err := utils.GeneratePortfolioPDF(data)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReportFile{
+		FileName:    fmt.Sprintf("portofolio-%s.pdf", student.NISN),
+		ContentType: "application/pdf",
+		Data:        pdf,
+	}, nil
+}
+
+// loadStoredImage membaca gambar dari storage dan mengecilkannya. Mengembalikan nil jika
+// file tidak ada atau bukan gambar yang bisa dibaca.
+func loadStoredImage(ctx context.Context, storage *utils.StorageService, fileURL string, maxSide int) []byte {
+	file, err := storage.GetFile(ctx, fileURL)
+	if err != nil {
+		log.Printf("gambar %s gagal dibaca: %v", fileURL, err)
+		return nil
+	}
+	defer file.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file.Body, utils.MaxFileSize+1))
+	if err != nil || len(data) > utils.MaxFileSize {
+		log.Printf("gambar %s gagal dibaca: %v", fileURL, err)
+		return nil
+	}
+
+	thumb, err := utils.MakeThumbnail(data, maxSide)
+	if err != nil {
+		log.Printf("gambar %s dilewati: %v", fileURL, err)
+		return nil
+	}
+	return thumb
+}
+
+// portfolioTeamLabel mis. "Tim Robotik (Kapten)", kosong untuk prestasi perorangan
+func portfolioTeamLabel(a *model.Achievement, studentID uuid.UUID) string {
+	if a.TeamName == nil {
+		return ""
+	}
+	for _, p := range a.Participants {
+		if p.StudentID == studentID {
+			return fmt.Sprintf("Tim %s (%s)", *a.TeamName, participantRoleLabel(p.Role))
+		}
+	}
+	return "Tim " + *a.TeamName
+}
+
+func optionalYear(year *int) string {
+	if year == nil {
+		return "-"
+	}
+	return strconv.Itoa(*year)
+}
+
+// Pastikan interface terpenuhi
+var _ PortfolioService = (*portfolioService)(nil)
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+)
+
+// Kode peringkat (ranks.code), dipakai juga sebagai key aturan poin jenis rank
+const (
+	RankJuara1         = "juara_1"
+	RankJuara2         = "juara_2"
+	RankJuara3         = "juara_3"
+	RankMedaliEmas     = "medali_emas"
+	RankMedaliPerak    = "medali_perak"
+	RankMedaliPerunggu = "medali_perunggu"
+	RankHarapan1       = "harapan_1"
+	RankHarapan2       = "harapan_2"
+	RankHarapan3       = "harapan_3"
+	RankFinalis        = "finalis"
+	RankPeserta        = "peserta"
+)
+
+var rankCodes = []string{
+	RankJuara1, RankJuara2, RankJuara3,
+	RankMedaliEmas, RankMedaliPerak, RankMedaliPerunggu,
+	RankHarapan1, RankHarapan2, RankHarapan3,
+	RankFinalis, RankPeserta,
+}
+
+// rankResolver mencocokkan teks juara dengan tabel ranks: lebih dulu lewat alias,
+// lalu lewat kata kunci (NormalizeRank) untuk teks yang memuat kata lain
+type rankResolver struct {
+	byID    map[int]*model.Rank
+	byCode  map[string]*model.Rank
+	aliases map[string]int
+}
+
+func loadRankResolver(ctx context.Context, repo repository.AchievementRepository) (*rankResolver, error) {
+	ranks, err := repo.FindAllRanks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := repo.FindRankAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rr := &rankResolver{
+		byID:    make(map[int]*model.Rank, len(ranks)),
+		byCode:  make(map[string]*model.Rank, len(ranks)),
+		aliases: make(map[string]int, len(aliases)),
+	}
+	for _, rank := range ranks {
+		rr.byID[rank.ID] = rank
+		rr.byCode[rank.Code] = rank
+	}
+	for _, alias := range aliases {
+		rr.aliases[alias.Alias] = alias.RankID
+	}
+	return rr, nil
+}
+
+// match mengembalikan nil jika teks tidak dikenali
+func (rr *rankResolver) match(text string) *model.Rank {
+	if id, ok := rr.aliases[utils.NormalizeName(text)]; ok {
+		return rr.byID[id]
+	}
+	return rr.byCode[NormalizeRank(text)]
+}
+
+// apply mengisi teks juara dan rank_id prestasi. rank_id dari request diutamakan
+// (teks kosong diisi nama peringkat); tanpa rank_id, teks dicocokkan otomatis.
+func (rr *rankResolver) apply(a *model.Achievement, rankID *int, text string) error {
+	a.Rank = text
+	a.RankID, a.RankCode, a.RankName = nil, nil, nil
+
+	var rank *model.Rank
+	if rankID != nil {
+		if rank = rr.byID[*rankID]; rank == nil {
+			return ErrRankNotFound
+		}
+		if a.Rank == "" {
+			a.Rank = rank.Name
+		}
+	} else {
+		rank = rr.match(text)
+	}
+
+	if rank != nil {
+		a.RankID, a.RankCode, a.RankName = &rank.ID, &rank.Code, &rank.Name
+	}
+	return nil
+}
+
+// rankOrdinals kata yang menyatakan urutan 1-3, termasuk angka romawi
+var rankOrdinals = map[string]int{
+	"1": 1, "i": 1, "satu": 1, "pertama": 1, "1st": 1, "first": 1,
+	"2": 2, "ii": 2, "dua": 2, "kedua": 2, "2nd": 2, "second": 2,
+	"3": 3, "iii": 3, "tiga": 3, "ketiga": 3, "3rd": 3, "third": 3,
+}
+
+// NormalizeRank mengubah teks peringkat bebas ("Juara 1 Putra", "juara I", "Harapan II",
+// "Medali Emas", "Finalis") menjadi kode peringkat berdasarkan kata kuncinya.
+// Mengembalikan string kosong jika peringkat tidak dikenali.
+func NormalizeRank(rank string) string {
+	words := strings.FieldsFunc(strings.ToLower(rank), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	has := func(candidates ...string) bool {
+		for _, w := range words {
+			if slices.Contains(candidates, w) {
+				return true
+			}
+		}
+		return false
+	}
+	// ordinal mencari urutan pada kata pertama yang dikenali
+	ordinal := func() int {
+		for _, w := range words {
+			if n, ok := rankOrdinals[w]; ok {
+				return n
+			}
+		}
+		return 0
+	}
+
+	switch {
+	case has("emas", "gold"):
+		return RankMedaliEmas
+	case has("perak", "silver"):
+		return RankMedaliPerak
+	case has("perunggu", "bronze"):
+		return RankMedaliPerunggu
+	case has("harapan"):
+		if n := ordinal(); n > 0 {
+			return fmt.Sprintf("harapan_%d", n)
+		}
+		return ""
+	case has("finalis", "finalist", "final"):
+		return RankFinalis
+	case has("peserta", "participant", "partisipan"):
+		return RankPeserta
+	}
+
+	// "Juara 1", "Peringkat II", "1st place", atau hanya "1"
+	if has("juara", "peringkat", "rank", "winner", "place") || len(words) == 1 {
+		if n := ordinal(); n > 0 {
+			return fmt.Sprintf("juara_%d", n)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+)
+
+// reportMaxYearSpan batas rentang tahun deret waktu agar respons tetap kecil
+const reportMaxYearSpan = 50
+
+var (
+	ErrReportDimension = errors.New("dimensi laporan harus salah satu dari level, category, rank, class, gender")
+	ErrReportStatus    = errors.New("status harus salah satu dari draft, submitted, verified, rejected, all")
+	ErrReportYearRange = fmt.Errorf("rentang tahun tidak valid (maksimal %d tahun)", reportMaxYearSpan)
+	ErrReportFormat    = errors.New("format laporan harus pdf atau xlsx")
+)
+
+// reportInvalidatingEntities entitas audit yang perubahannya mengosongkan cache laporan
+var reportInvalidatingEntities = []string{
+	AuditEntityStudent, AuditEntityAchievement, AuditEntityCertificate,
+}
+
+type ReportService interface {
+	GetSummary(ctx context.Context, filter model.ReportFilter) (*model.ReportSummary, error)
+	GetAchievementsByYear(ctx context.Context, filter model.ReportFilter) ([]model.YearlyCount, error)
+	GetAchievementBreakdown(ctx context.Context, by string, filter model.ReportFilter) ([]model.BreakdownItem, error)
+	GetCertificatesByMonth(ctx context.Context, year int) ([]model.CertificateMonthly, error)
+	ExportAnnual(ctx context.Context, filter model.ReportFilter, format string) (*model.ReportFile, error)
+}
+
+type reportService struct {
+	repo  repository.ReportRepository
+	cache *utils.TTLCache
+}
+
+// NewReportService membuat service laporan. Hasil agregasi di-cache selama cacheTTL
+// dan dikosongkan setiap kali audit mencatat perubahan siswa, prestasi atau sertifikat.
+func NewReportService(repo repository.ReportRepository, audit AuditService, cacheTTL time.Duration) ReportService {
+	s := &reportService{repo: repo, cache: utils.NewTTLCache(cacheTTL)}
+	audit.Subscribe(func(entry model.AuditEntry) {
+		if slices.Contains(reportInvalidatingEntities, entry.EntityType) {
+			s.cache.Clear()
+		}
+	})
+	return s
+}
+
+func (s *reportService) GetSummary(ctx context.Context, filter model.ReportFilter) (*model.ReportSummary, error) {
+	if err := normalizeReportFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	v, err := s.cache.GetOrLoad(reportCacheKey("summary", filter), func() (any, error) {
+		return s.repo.Summary(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*model.ReportSummary), nil
+}
+
+// GetAchievementsByYear mengembalikan deret tahunan tanpa celah: tahun tanpa prestasi
+// di dalam rentang tetap muncul dengan jumlah 0
+func (s *reportService) GetAchievementsByYear(ctx context.Context, filter model.ReportFilter) ([]model.YearlyCount, error) {
+	if err := normalizeReportFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	v, err := s.cache.GetOrLoad(reportCacheKey("yearly", filter), func() (any, error) {
+		counts, err := s.repo.AchievementsByYear(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return fillYearlyCounts(counts, filter.FromYear, filter.ToYear), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]model.YearlyCount), nil
+}
+
+func (s *reportService) GetAchievementBreakdown(ctx context.Context, by string, filter model.ReportFilter) ([]model.BreakdownItem, error) {
+	by = strings.ToLower(strings.TrimSpace(by))
+	switch by {
+	case model.ReportByLevel, model.ReportByCategory, model.ReportByRank, model.ReportByClass, model.ReportByGender:
+	default:
+		return nil, ErrReportDimension
+	}
+	if err := normalizeReportFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	v, err := s.cache.GetOrLoad(reportCacheKey("breakdown:"+by, filter), func() (any, error) {
+		items, err := s.repo.AchievementBreakdown(ctx, by, filter)
+		if err != nil {
+			return nil, err
+		}
+		if items == nil {
+			items = []model.BreakdownItem{}
+		}
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]model.BreakdownItem), nil
+}
+
+// GetCertificatesByMonth mengembalikan 12 bulan untuk tahun yang diminta, termasuk
+// bulan tanpa penerbitan maupun pencabutan
+func (s *reportService) GetCertificatesByMonth(ctx context.Context, year int) ([]model.CertificateMonthly, error) {
+	if year < 1900 || year > 2100 {
+		return nil, ErrReportYearRange
+	}
+
+	v, err := s.cache.GetOrLoad("certificates:"+strconv.Itoa(year), func() (any, error) {
+		months, err := s.repo.CertificatesByMonth(ctx, year)
+		if err != nil {
+			return nil, err
+		}
+
+		byMonth := make(map[string]model.CertificateMonthly, len(months))
+		for _, m := range months {
+			byMonth[m.Month] = m
+		}
+		filled := make([]model.CertificateMonthly, 0, 12)
+		for month := 1; month <= 12; month++ {
+			key := fmt.Sprintf("%04d-%02d", year, month)
+			item, ok := byMonth[key]
+			if !ok {
+				item = model.CertificateMonthly{Month: key}
+			}
+			filled = append(filled, item)
+		}
+		return filled, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]model.CertificateMonthly), nil
+}
+
+// ExportAnnual membuat rekap prestasi verified untuk Dinas Pendidikan sebagai PDF
+// (ringkasan dan daftar per tingkat) atau XLSX (satu sheet per kategori). Tanpa
+// filter tahun, rekap mencakup tahun berjalan.
+func (s *reportService) ExportAnnual(ctx context.Context, filter model.ReportFilter, format string) (*model.ReportFile, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = model.ReportFormatPDF
+	}
+	if format != model.ReportFormatPDF && format != model.ReportFormatXLSX {
+		return nil, ErrReportFormat
+	}
+
+	switch {
+	case filter.FromYear == nil && filter.ToYear == nil:
+		year := time.Now().Year()
+		filter.FromYear, filter.ToYear = &year, &year
+	case filter.FromYear == nil:
+		filter.FromYear = filter.ToYear
+	case filter.ToYear == nil:
+		filter.ToYear = filter.FromYear
+	}
+	filter.Status = model.AchievementStatusVerified
+	filter.Class = ""
+	if err := normalizeReportFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	achievements, err := s.repo.FindReportAchievements(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	period := fmt.Sprintf("Tahun %d", *filter.FromYear)
+	fileName := fmt.Sprintf("rekap-prestasi-%d", *filter.FromYear)
+	if *filter.ToYear != *filter.FromYear {
+		period = fmt.Sprintf("Tahun %d - %d", *filter.FromYear, *filter.ToYear)
+		fileName = fmt.Sprintf("rekap-prestasi-%d-%d", *filter.FromYear, *filter.ToYear)
+	}
+
+	if format == model.ReportFormatXLSX {
+		data, err := utils.WriteXLSX(annualReportSheets(achievements))
+		if err != nil {
+			return nil, err
+		}
+		return &model.ReportFile{FileName: fileName + ".xlsx", ContentType: utils.XLSXContentType, Data: data}, nil
+	}
+
+	summary, err := s.GetSummary(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	school := loadSchoolInfo()
+	pdfData := utils.AnnualReportPDFData{
+		SchoolName:        school.Name,
+		SchoolAddress:     school.Address,
+		Period:            period,
+		GeneratedAt:       time.Now(),
+		HeadmasterName:    school.HeadmasterName,
+		HeadmasterNIP:     school.HeadmasterNIP,
+		TotalAchievements: summary.TotalAchievements,
+		TotalStudents:     summary.StudentsWithAchievements,
+	}
+
+	summaryTables := []struct{ by, title, label string }{
+		{model.ReportByLevel, "Rekap per Tingkat", "Tingkat"},
+		{model.ReportByCategory, "Rekap per Kategori", "Kategori"},
+		{model.ReportByGender, "Rekap per Jenis Kelamin", "Jenis Kelamin"},
+	}
+	for _, t := range summaryTables {
+		items, err := s.GetAchievementBreakdown(ctx, t.by, filter)
+		if err != nil {
+			return nil, err
+		}
+		table := utils.PDFSummaryTable{Title: t.title, Label: t.label}
+		for _, item := range items {
+			table.Rows = append(table.Rows, utils.PDFSummaryRow{
+				Label: item.Label, Achievements: item.Total, Students: item.Students,
+			})
+		}
+		pdfData.SummaryTables = append(pdfData.SummaryTables, table)
+	}
+
+	// Prestasi sudah terurut per tingkat dari repository
+	for _, a := range achievements {
+		level := "Tanpa Tingkat"
+		if a.LevelName != nil {
+			level = *a.LevelName
+		}
+		if n := len(pdfData.Levels); n == 0 || pdfData.Levels[n-1].Level != level {
+			pdfData.Levels = append(pdfData.Levels, utils.PDFLevelListing{Level: level})
+		}
+		listing := &pdfData.Levels[len(pdfData.Levels)-1]
+
+		category := ""
+		if a.CategoryName != nil {
+			category = *a.CategoryName
+		}
+		class := a.StudentClass
+		if a.TeamName != nil {
+			class = "-"
+		}
+		listing.Achievements = append(listing.Achievements, utils.PDFReportAchievement{
+			No:              len(listing.Achievements) + 1,
+			StudentName:     reportHolderName(a),
+			Class:           class,
+			CompetitionName: a.CompetitionName,
+			Organizer:       a.Organizer,
+			Category:        category,
+			Rank:            a.Rank,
+			Year:            a.Year,
+		})
+	}
+
+	data, err := utils.GenerateAnnualReportPDF(pdfData)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReportFile{FileName: fileName + ".pdf", ContentType: "application/pdf", Data: data}, nil
+}
+
+// annualReportSheets menyusun satu sheet per kategori, urut sesuai ID kategori.
+// Prestasi tanpa kategori masuk sheet terakhir.
+func annualReportSheets(achievements []*model.ReportAchievement) []utils.XLSXSheet {
+	header := []string{
+		"No", "NISN", "Nama Siswa", "Kelas", "Nama Tim", "Anggota Tim", "Nama Lomba",
+		"Penyelenggara", "Tingkat", "Juara", "Tahun",
+	}
+
+	const uncategorized = -1
+	sheets := make(map[int]*utils.XLSXSheet)
+	var order []int
+	for _, a := range achievements {
+		key, name := uncategorized, "Tanpa Kategori"
+		if a.CategoryID != nil {
+			key = *a.CategoryID
+			if a.CategoryName != nil {
+				name = *a.CategoryName
+			}
+		}
+		sheet, ok := sheets[key]
+		if !ok {
+			sheet = &utils.XLSXSheet{Name: name, Header: header}
+			sheets[key] = sheet
+			order = append(order, key)
+		}
+
+		var teamName, level interface{}
+		if a.TeamName != nil {
+			teamName = *a.TeamName
+		}
+		if a.LevelName != nil {
+			level = *a.LevelName
+		}
+		sheet.Rows = append(sheet.Rows, []interface{}{
+			len(sheet.Rows) + 1, a.StudentNISN, a.StudentName, a.StudentClass, teamName,
+			strings.Join(a.Members, ", "), a.CompetitionName, a.Organizer, level, a.Rank, a.Year,
+		})
+	}
+
+	if len(order) == 0 {
+		return []utils.XLSXSheet{{Name: "Prestasi", Header: header}}
+	}
+
+	slices.SortFunc(order, func(a, b int) int {
+		switch {
+		case a == uncategorized:
+			return 1
+		case b == uncategorized:
+			return -1
+		}
+		return a - b
+	})
+	result := make([]utils.XLSXSheet, 0, len(order))
+	for _, key := range order {
+		result = append(result, *sheets[key])
+	}
+	return result
+}
+
+// reportHolderName nama siswa untuk daftar laporan; prestasi tim ditulis sebagai
+// nama tim diikuti anggotanya
+func reportHolderName(a *model.ReportAchievement) string {
+	if a.TeamName == nil {
+		return a.StudentName
+	}
+	if len(a.Members) == 0 {
+		return "Tim " + *a.TeamName
+	}
+	return fmt.Sprintf("Tim %s: %s", *a.TeamName, strings.Join(a.Members, ", "))
+}
+
+// normalizeReportFilter mengisi status default (verified) dan memvalidasi rentang tahun
+func normalizeReportFilter(filter *model.ReportFilter) error {
+	filter.Status = strings.ToLower(strings.TrimSpace(filter.Status))
+	switch filter.Status {
+	case "":
+		filter.Status = model.AchievementStatusVerified
+	case model.AchievementStatusDraft, model.AchievementStatusSubmitted,
+		model.AchievementStatusVerified, model.AchievementStatusRejected, model.ReportStatusAll:
+	default:
+		return ErrReportStatus
+	}
+
+	if filter.FromYear != nil && filter.ToYear != nil {
+		if *filter.FromYear > *filter.ToYear || *filter.ToYear-*filter.FromYear > reportMaxYearSpan {
+			return ErrReportYearRange
+		}
+	}
+	filter.Class = utils.SanitizeString(filter.Class)
+	return nil
+}
+
+// fillYearlyCounts menambahkan tahun kosong di antara tahun pertama dan terakhir.
+// Batas rentang memakai filter jika diisi, selain itu tahun data paling awal/akhir.
+func fillYearlyCounts(counts []model.YearlyCount, fromYear, toYear *int) []model.YearlyCount {
+	if len(counts) == 0 && (fromYear == nil || toYear == nil) {
+		return []model.YearlyCount{}
+	}
+
+	byYear := make(map[int]model.YearlyCount, len(counts))
+	first, last := 0, 0
+	for i, c := range counts {
+		byYear[c.Year] = c
+		if i == 0 || c.Year < first {
+			first = c.Year
+		}
+		if i == 0 || c.Year > last {
+			last = c.Year
+		}
+	}
+	if fromYear != nil {
+		first = *fromYear
+	}
+	if toYear != nil {
+		last = *toYear
+	}
+	if last-first > reportMaxYearSpan {
+		return counts
+	}
+
+	filled := make([]model.YearlyCount, 0, last-first+1)
+	for year := first; year <= last; year++ {
+		item, ok := byYear[year]
+		if !ok {
+			item = model.YearlyCount{Year: year}
+		}
+		filled = append(filled, item)
+	}
+	return filled
+}
+
+// reportCacheKey menyusun key cache dari nama laporan dan nilai filter
+func reportCacheKey(name string, f model.ReportFilter) string {
+	optional := func(v *int) string {
+		if v == nil {
+			return "-"
+		}
+		return strconv.Itoa(*v)
+	}
+	return strings.Join([]string{
+		name, f.Status, optional(f.FromYear), optional(f.ToYear),
+		optional(f.CategoryID), optional(f.LevelID), f.Class,
+	}, "|")
+}
+
+// Pastikan interface terpenuhi
+var _ ReportService = (*reportService)(nil)
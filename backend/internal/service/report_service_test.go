@@ -0,0 +1,55 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+func TestFillYearlyCounts(t *testing.T) {
+	year := func(y int) *int { return &y }
+	tests := []struct {
+		name     string
+		counts   []model.YearlyCount
+		from, to *int
+		want     []model.YearlyCount
+	}{
+		{name: "kosong tanpa rentang", want: []model.YearlyCount{}},
+		{
+			name: "kosong dengan rentang diisi nol",
+			from: year(2024), to: year(2026),
+			want: []model.YearlyCount{{Year: 2024}, {Year: 2025}, {Year: 2026}},
+		},
+		{
+			name:   "tahun yang bolong diisi nol",
+			counts: []model.YearlyCount{{Year: 2026, Total: 4, Students: 3}, {Year: 2023, Total: 2, Students: 2}},
+			want: []model.YearlyCount{
+				{Year: 2023, Total: 2, Students: 2}, {Year: 2024}, {Year: 2025}, {Year: 2026, Total: 4, Students: 3},
+			},
+		},
+		{
+			name:   "rentang filter melebihi data",
+			counts: []model.YearlyCount{{Year: 2025, Total: 1, Students: 1}},
+			from:   year(2024), to: year(2026),
+			want: []model.YearlyCount{{Year: 2024}, {Year: 2025, Total: 1, Students: 1}, {Year: 2026}},
+		},
+		{
+			name:   "hanya from, akhir dari data",
+			counts: []model.YearlyCount{{Year: 2025, Total: 1, Students: 1}},
+			from:   year(2024),
+			want:   []model.YearlyCount{{Year: 2024}, {Year: 2025, Total: 1, Students: 1}},
+		},
+		{
+			name:   "rentang terlalu panjang dikembalikan apa adanya",
+			counts: []model.YearlyCount{{Year: 1900, Total: 1}, {Year: 2026, Total: 1}},
+			want:   []model.YearlyCount{{Year: 1900, Total: 1}, {Year: 2026, Total: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := fillYearlyCounts(tt.counts, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: fillYearlyCounts() = %+v, seharusnya %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import "os"
+
+// schoolInfo identitas sekolah untuk kop dan tanda tangan dokumen PDF
+type schoolInfo struct {
+	Name           string
+	Address        string
+	HeadmasterName string
+	HeadmasterNIP  string
+}
+
+// loadSchoolInfo membaca identitas sekolah dari env
+func loadSchoolInfo() schoolInfo {
+	info := schoolInfo{
+		Name:           os.Getenv("SCHOOL_NAME"),
+		Address:        os.Getenv("SCHOOL_ADDRESS"),
+		HeadmasterName: os.Getenv("HEADMASTER_NAME"),
+		HeadmasterNIP:  os.Getenv("HEADMASTER_NIP"),
+	}
+	if info.Name == "" {
+		info.Name = "SMA Negeri 1"
+	}
+	if info.Address == "" {
+		info.Address = "Jl. Pendidikan No. 1"
+	}
+	if info.HeadmasterName == "" {
+		info.HeadmasterName = "Kepala Sekolah"
+	}
+	return info
+}
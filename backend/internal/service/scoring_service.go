@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+)
+
+type ScoringService interface {
+	GetRules(ctx context.Context) ([]*model.ScoringRule, error)
+	UpdateRules(ctx context.Context, req model.UpdateScoringRulesRequest, updatedBy string) ([]*model.ScoringRule, error)
+	GetStudentScore(ctx context.Context, studentID string, year *int) (*model.StudentScore, error)
+	GetLeaderboard(ctx context.Context, filter model.LeaderboardFilter) ([]*model.LeaderboardEntry, *response.Pagination, error)
+}
+
+type scoringService struct {
+	repo        repository.ScoringRepository
+	studentRepo repository.StudentRepository
+	tx          repository.Transactor
+	audit       AuditService
+}
+
+func NewScoringService(repo repository.ScoringRepository, studentRepo repository.StudentRepository, tx repository.Transactor, audit AuditService) ScoringService {
+	return &scoringService{repo: repo, studentRepo: studentRepo, tx: tx, audit: audit}
+}
+
+// ValidateScoringRules memvalidasi perubahan aturan poin. Key level harus berupa
+// order_rank tingkat lomba dan key rank harus salah satu kode peringkat yang dikenali.
+func ValidateScoringRules(req *model.UpdateScoringRulesRequest) utils.ValidationErrors {
+	errs := utils.ValidationErrors{}
+	if len(req.Rules) == 0 {
+		errs["rules"] = "Minimal satu aturan harus diisi"
+		return errs
+	}
+
+	seen := make(map[string]bool)
+	for i := range req.Rules {
+		rule := &req.Rules[i]
+		rule.Kind = strings.ToLower(utils.SanitizeString(rule.Kind))
+		rule.Key = strings.ToLower(utils.SanitizeString(rule.Key))
+		field := fmt.Sprintf("rules[%d]", i)
+
+		switch rule.Kind {
+		case model.ScoringKindLevel:
+			if n, err := strconv.Atoi(rule.Key); err != nil || n <= 0 {
+				errs[field+".key"] = "Key aturan tingkat harus berupa urutan tingkat (angka)"
+			}
+		case model.ScoringKindRank:
+			if !slices.Contains(rankCodes, rule.Key) {
+				errs[field+".key"] = "Kode peringkat harus salah satu dari " + strings.Join(rankCodes, ", ")
+			}
+		case model.ScoringKindCategory:
+			if rule.Key == "" {
+				errs[field+".key"] = "Key aturan kategori wajib diisi"
+			}
+		default:
+			errs[field+".kind"] = "Jenis aturan harus level, rank atau category"
+		}
+
+		if rule.Value < 0 || math.IsNaN(rule.Value) || math.IsInf(rule.Value, 0) {
+			errs[field+".value"] = "Nilai aturan tidak boleh negatif"
+		}
+
+		id := rule.Kind + "|" + rule.Key
+		if seen[id] {
+			errs[field] = "Aturan duplikat dalam request"
+		}
+		seen[id] = true
+	}
+
+	return errs
+}
+
+func (s *scoringService) GetRules(ctx context.Context) ([]*model.ScoringRule, error) {
+	return s.repo.FindRules(ctx)
+}
+
+func (s *scoringService) UpdateRules(ctx context.Context, req model.UpdateScoringRulesRequest, updatedBy string) ([]*model.ScoringRule, error) {
+	before, err := s.repo.FindRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var updater *uuid.UUID
+	if uid, err := uuid.Parse(updatedBy); err == nil {
+		updater = &uid
+	}
+
+	var rules []*model.ScoringRule
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateRules(ctx, req.Rules, updater); err != nil {
+			return err
+		}
+
+		var err error
+		if rules, err = s.repo.FindRules(ctx); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditScoringRulesUpdate, EntityType: AuditEntityScoringRule,
+			Before: before, After: rules,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetStudentScore menghitung poin seluruh prestasi terverifikasi seorang siswa,
+// lengkap dengan rincian per prestasi
+func (s *scoringService) GetStudentScore(ctx context.Context, studentID string, year *int) (*model.StudentScore, error) {
+	uid, err := uuid.Parse(studentID)
+	if err != nil {
+		return nil, ErrStudentNotFound
+	}
+
+	student, err := s.studentRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if student == nil {
+		return nil, ErrStudentNotFound
+	}
+
+	rules, err := s.loadRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, err := s.repo.FindScoringInputs(ctx, model.LeaderboardFilter{StudentID: uid.String(), Year: year})
+	if err != nil {
+		return nil, err
+	}
+
+	score := &model.StudentScore{
+		StudentID:    student.ID,
+		NISN:         student.NISN,
+		FullName:     student.FullName,
+		Class:        student.Class,
+		YearGraduate: student.YearGraduate,
+		Breakdown:    []model.AchievementScore{},
+	}
+	for _, in := range inputs {
+		item := rules.score(in)
+		score.Breakdown = append(score.Breakdown, item)
+		score.TotalPoints += item.Points
+	}
+	score.TotalPoints = roundPoints(score.TotalPoints)
+	score.AchievementCount = len(score.Breakdown)
+
+	return score, nil
+}
+
+// GetLeaderboard mengurutkan siswa berdasarkan total poin. Siswa dengan poin sama
+// mendapat posisi yang sama (1, 2, 2, 4). Siswa tanpa prestasi terverifikasi tidak ikut.
+func (s *scoringService) GetLeaderboard(ctx context.Context, filter model.LeaderboardFilter) ([]*model.LeaderboardEntry, *response.Pagination, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	rules, err := s.loadRules(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inputs, err := s.repo.FindScoringInputs(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byStudent := make(map[uuid.UUID]*model.LeaderboardEntry)
+	var entries []*model.LeaderboardEntry
+	for _, in := range inputs {
+		entry, ok := byStudent[in.StudentID]
+		if !ok {
+			entry = &model.LeaderboardEntry{
+				StudentID:    in.StudentID,
+				NISN:         in.StudentNISN,
+				FullName:     in.StudentName,
+				Class:        in.StudentClass,
+				YearGraduate: in.YearGraduate,
+			}
+			byStudent[in.StudentID] = entry
+			entries = append(entries, entry)
+		}
+		entry.TotalPoints += rules.score(in).Points
+		entry.AchievementCount++
+	}
+
+	rankLeaderboard(entries)
+
+	total := len(entries)
+	totalPages := total / filter.PerPage
+	if total%filter.PerPage > 0 {
+		totalPages++
+	}
+
+	start := min((filter.Page-1)*filter.PerPage, total)
+	end := min(start+filter.PerPage, total)
+	page := entries[start:end]
+	if page == nil {
+		page = []*model.LeaderboardEntry{}
+	}
+
+	pagination := &response.Pagination{
+		Page:       filter.Page,
+		PerPage:    filter.PerPage,
+		TotalItems: int64(total),
+		TotalPages: totalPages,
+	}
+
+	return page, pagination, nil
+}
+
+// rankLeaderboard mengurutkan entri dan mengisi posisinya. Poin dibulatkan lebih dulu
+// agar selisih pecahan yang tidak tampil tidak memisahkan posisi, dan siswa dengan
+// poin sama tetap urut nama.
+func rankLeaderboard(entries []*model.LeaderboardEntry) {
+	for _, entry := range entries {
+		entry.TotalPoints = roundPoints(entry.TotalPoints)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].TotalPoints != entries[j].TotalPoints {
+			return entries[i].TotalPoints > entries[j].TotalPoints
+		}
+		return entries[i].FullName < entries[j].FullName
+	})
+	for i, entry := range entries {
+		entry.Position = i + 1
+		if i > 0 && entry.TotalPoints == entries[i-1].TotalPoints {
+			entry.Position = entries[i-1].Position
+		}
+	}
+}
+
+// scoringRules aturan poin yang sudah dikelompokkan per jenis
+type scoringRules map[string]map[string]float64
+
+func (s *scoringService) loadRules(ctx context.Context) (scoringRules, error) {
+	rules, err := s.repo.FindRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := scoringRules{
+		model.ScoringKindLevel:    {},
+		model.ScoringKindRank:     {},
+		model.ScoringKindCategory: {},
+	}
+	for _, rule := range rules {
+		if grouped[rule.Kind] == nil {
+			continue
+		}
+		grouped[rule.Kind][rule.Key] = rule.Value
+	}
+	return grouped, nil
+}
+
+// score menghitung poin satu prestasi: poin tingkat x pengali peringkat x bobot kategori.
+// Kategori tanpa aturan berbobot 1; tingkat atau peringkat yang tidak dikenali bernilai 0.
+func (rules scoringRules) score(in *model.ScoringInput) model.AchievementScore {
+	item := model.AchievementScore{
+		AchievementID:   in.AchievementID,
+		CompetitionName: in.CompetitionName,
+		Year:            in.Year,
+		LevelName:       in.LevelName,
+		Rank:            in.Rank,
+		CategoryName:    in.CategoryName,
+		CategoryWeight:  1,
+	}
+
+	// Prestasi lama yang belum terpetakan ke tabel ranks dinilai dari teksnya
+	if in.RankCode != nil {
+		item.RankCode = *in.RankCode
+	} else {
+		item.RankCode = NormalizeRank(in.Rank)
+	}
+
+	switch {
+	case in.LevelOrder == nil:
+		item.Notes = append(item.Notes, "Tingkat lomba belum diisi")
+	default:
+		v, ok := rules[model.ScoringKindLevel][strconv.Itoa(*in.LevelOrder)]
+		if !ok {
+			item.Notes = append(item.Notes, fmt.Sprintf("Belum ada aturan poin untuk tingkat %s", *in.LevelName))
+		}
+		item.LevelPoints = v
+	}
+
+	switch {
+	case item.RankCode == "":
+		item.Notes = append(item.Notes, fmt.Sprintf("Peringkat %q tidak dikenali", in.Rank))
+	default:
+		v, ok := rules[model.ScoringKindRank][item.RankCode]
+		if !ok {
+			item.Notes = append(item.Notes, fmt.Sprintf("Belum ada aturan poin untuk peringkat %s", item.RankCode))
+		}
+		item.RankMultiplier = v
+	}
+
+	if in.CategoryType != nil {
+		if v, ok := rules[model.ScoringKindCategory][*in.CategoryType]; ok {
+			item.CategoryWeight = v
+		}
+	}
+
+	item.Points = roundPoints(item.LevelPoints * item.RankMultiplier * item.CategoryWeight)
+	return item
+}
+
+func roundPoints(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Pastikan interface terpenuhi
+var _ ScoringService = (*scoringService)(nil)
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+)
+
+func TestRankLeaderboard(t *testing.T) {
+	tests := []struct {
+		name          string
+		points        map[string]float64
+		wantOrder     []string
+		wantPositions []int
+	}{
+		{
+			name:          "poin sama berbagi posisi (1, 2, 2, 4)",
+			points:        map[string]float64{"Andi": 120, "Budi": 80, "Citra": 80, "Dewi": 40},
+			wantOrder:     []string{"Andi", "Budi", "Citra", "Dewi"},
+			wantPositions: []int{1, 2, 2, 4},
+		},
+		{
+			name:          "poin sama diurutkan nama",
+			points:        map[string]float64{"Zaki": 50, "Ayu": 50, "Maya": 50},
+			wantOrder:     []string{"Ayu", "Maya", "Zaki"},
+			wantPositions: []int{1, 1, 1},
+		},
+		{
+			name:          "selisih pecahan di bawah pembulatan dianggap sama",
+			points:        map[string]float64{"Budi": 0.1 + 0.2, "Andi": 0.3, "Citra": 0.29},
+			wantOrder:     []string{"Andi", "Budi", "Citra"},
+			wantPositions: []int{1, 1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		var entries []*model.LeaderboardEntry
+		for name, points := range tt.points {
+			entries = append(entries, &model.LeaderboardEntry{FullName: name, TotalPoints: points})
+		}
+		rankLeaderboard(entries)
+
+		for i, entry := range entries {
+			if entry.FullName != tt.wantOrder[i] || entry.Position != tt.wantPositions[i] {
+				t.Errorf("%s: urutan %d = %s posisi %d, seharusnya %s posisi %d",
+					tt.name, i, entry.FullName, entry.Position, tt.wantOrder[i], tt.wantPositions[i])
+			}
+		}
+	}
+}
+
+func TestScoringRulesScore(t *testing.T) {
+	rules := scoringRules{
+		model.ScoringKindLevel:    {"1": 100, "2": 50},
+		model.ScoringKindRank:     {RankJuara1: 1, RankJuara2: 0.75, RankHarapan1: 0.4},
+		model.ScoringKindCategory: {"akademik": 1.5},
+	}
+	level := func(order int) *int { return &order }
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		in         model.ScoringInput
+		wantPoints float64
+		wantCode   string
+		wantNotes  int
+	}{
+		{
+			name:       "tingkat x peringkat x kategori",
+			in:         model.ScoringInput{Rank: "Juara 2", LevelOrder: level(1), LevelName: str("Nasional"), CategoryType: str("akademik")},
+			wantPoints: 112.5, wantCode: RankJuara2,
+		},
+		{
+			name:       "kategori tanpa aturan berbobot 1",
+			in:         model.ScoringInput{Rank: "Juara 1", LevelOrder: level(2), LevelName: str("Provinsi"), CategoryType: str("seni")},
+			wantPoints: 50, wantCode: RankJuara1,
+		},
+		{
+			name:       "rank_code tersimpan diutamakan",
+			in:         model.ScoringInput{Rank: "Juara Harapan I", RankCode: str(RankHarapan1), LevelOrder: level(2), LevelName: str("Provinsi")},
+			wantPoints: 20, wantCode: RankHarapan1,
+		},
+		{
+			name:       "peringkat tidak dikenali bernilai 0",
+			in:         model.ScoringInput{Rank: "Penghargaan khusus", LevelOrder: level(1), LevelName: str("Nasional")},
+			wantPoints: 0, wantCode: "", wantNotes: 1,
+		},
+		{
+			name:       "tingkat kosong dan tanpa aturan peringkat",
+			in:         model.ScoringInput{Rank: "Finalis"},
+			wantPoints: 0, wantCode: RankFinalis, wantNotes: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		got := rules.score(&tt.in)
+		if got.Points != tt.wantPoints || got.RankCode != tt.wantCode || len(got.Notes) != tt.wantNotes {
+			t.Errorf("%s: points=%v code=%q notes=%v, seharusnya points=%v code=%q %d catatan",
+				tt.name, got.Points, got.RankCode, got.Notes, tt.wantPoints, tt.wantCode, tt.wantNotes)
+		}
+	}
+}
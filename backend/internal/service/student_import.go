@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+)
+
+const maxStudentImportRows = 5000
+
+var (
+	ErrImportHeaderNotFound = errors.New("baris judul kolom tidak ditemukan, pastikan file memiliki kolom NISN dan Nama")
+	ErrImportEmpty          = errors.New("file tidak berisi data siswa")
+	ErrImportTooManyRows    = fmt.Errorf("jumlah baris melebihi batas %d", maxStudentImportRows)
+	ErrImportInvalidRows    = errors.New("import dibatalkan, masih ada baris yang tidak valid")
+)
+
+// studentImportColumns alias judul kolom yang dikenali, termasuk layout export Dapodik
+var studentImportColumns = map[string][]string{
+	"nisn":          {"NISN"},
+	"full_name":     {"Nama", "Nama Lengkap", "Nama Siswa", "Nama Peserta Didik", "full_name"},
+	"birth_place":   {"Tempat Lahir", "birth_place"},
+	"birth_date":    {"Tanggal Lahir", "Tgl Lahir", "birth_date"},
+	"gender":        {"JK", "L/P", "Jenis Kelamin", "gender"},
+	"class":         {"Rombel Saat Ini", "Rombel", "Kelas", "class"},
+	"year_entry":    {"Tahun Masuk", "year_entry"},
+	"year_graduate": {"Tahun Lulus", "year_graduate"},
+}
+
+// Import membaca file CSV/XLSX dan memvalidasi setiap baris dengan aturan yang sama
+// seperti Create. Tanpa commit hanya menghasilkan laporan (dry-run). Dengan commit,
+// semua baris disimpan dalam satu transaksi (upsert berdasarkan NISN) dan ditolak
+// seluruhnya jika ada satu baris pun yang tidak valid.
+func (s *studentService) Import(ctx context.Context, filename string, data []byte, commit bool) (*model.StudentImportReport, error) {
+	rows, err := utils.ReadSpreadsheet(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	headerIdx, columns, ok := utils.FindHeaderRow(rows, studentImportColumns, []string{"nisn", "full_name"})
+	if !ok {
+		return nil, ErrImportHeaderNotFound
+	}
+
+	type parsedRow struct {
+		report *model.StudentImportRow
+		req    model.CreateStudentRequest
+	}
+
+	var parsed []parsedRow
+	for i := headerIdx + 1; i < len(rows); i++ {
+		cell := func(field string) string {
+			idx, ok := columns[field]
+			if !ok || idx >= len(rows[i]) {
+				return ""
+			}
+			return utils.SanitizeString(rows[i][idx])
+		}
+
+		// Lewati baris kosong dan baris sub-judul (Dapodik memakai header dua baris)
+		if cell("nisn") == "" && cell("full_name") == "" {
+			continue
+		}
+
+		req, errs := parseStudentImportRow(cell)
+		for field, msg := range ValidateCreateStudent(&req) {
+			if _, exists := errs[field]; !exists {
+				errs[field] = msg
+			}
+		}
+
+		parsed = append(parsed, parsedRow{
+			report: &model.StudentImportRow{
+				Row: i + 1, NISN: req.NISN, FullName: req.FullName, Errors: errs,
+			},
+			req: req,
+		})
+	}
+
+	if len(parsed) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(parsed) > maxStudentImportRows {
+		return nil, ErrImportTooManyRows
+	}
+
+	// NISN duplikat di dalam file yang sama
+	seen := make(map[string]int)
+	nisns := make([]string, 0, len(parsed))
+	for _, p := range parsed {
+		if _, invalid := p.report.Errors["nisn"]; invalid {
+			continue
+		}
+		if first, dup := seen[p.req.NISN]; dup {
+			p.report.Errors["nisn"] = fmt.Sprintf("NISN duplikat dengan baris %d", first)
+			continue
+		}
+		seen[p.req.NISN] = p.report.Row
+		nisns = append(nisns, p.req.NISN)
+	}
+
+	existingByNISN, err := s.findStudentsByNISN(ctx, nisns)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.StudentImportReport{TotalRows: len(parsed)}
+	var reqs []model.CreateStudentRequest
+	var reqRows []int // indeks report.Rows untuk setiap reqs
+	for _, p := range parsed {
+		report.Rows = append(report.Rows, *p.report)
+		row := &report.Rows[len(report.Rows)-1]
+
+		if len(row.Errors) > 0 {
+			row.Action = model.StudentImportError
+			report.Failed++
+			continue
+		}
+
+		current := existingByNISN[p.req.NISN]
+		if current == nil {
+			row.Action = model.StudentImportCreate
+			report.Created++
+		} else {
+			row.Action = model.StudentImportUpdate
+			report.Updated++
+		}
+		reqs = append(reqs, p.req)
+		reqRows = append(reqRows, len(report.Rows)-1)
+	}
+
+	if !commit {
+		return report, nil
+	}
+	if report.Failed > 0 {
+		return report, ErrImportInvalidRows
+	}
+
+	// Data siswa dibaca ulang di dalam transaksi: siswa yang ditambahkan setelah
+	// pratinjau di-update dengan datanya sendiri, dan audit memakai ID hasil upsert
+	var inserted []bool
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.findStudentsByNISN(ctx, nisns)
+		if err != nil {
+			return err
+		}
+		students := make([]*model.Student, len(reqs))
+		for i, req := range reqs {
+			students[i] = mergeStudentImport(current[req.NISN], req)
+		}
+
+		if inserted, err = s.repo.UpsertMany(ctx, students); err != nil {
+			return err
+		}
+		for i, student := range students {
+			entry := model.AuditEntry{
+				Action: AuditStudentImport, EntityType: AuditEntityStudent,
+				EntityID: student.ID.String(), After: student,
+			}
+			if before := current[student.NISN]; before != nil && !inserted[i] {
+				entry.Before = before
+			}
+			if err := s.audit.Record(ctx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Created, report.Updated = 0, 0
+	for i, idx := range reqRows {
+		if inserted[i] {
+			report.Rows[idx].Action = model.StudentImportCreate
+			report.Created++
+		} else {
+			report.Rows[idx].Action = model.StudentImportUpdate
+			report.Updated++
+		}
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// findStudentsByNISN siswa yang sudah terdaftar, dikelompokkan per NISN
+func (s *studentService) findStudentsByNISN(ctx context.Context, nisns []string) (map[string]*model.Student, error) {
+	students, err := s.repo.FindByNISNs(ctx, nisns)
+	if err != nil {
+		return nil, err
+	}
+	byNISN := make(map[string]*model.Student, len(students))
+	for _, st := range students {
+		byNISN[st.NISN] = st
+	}
+	return byNISN, nil
+}
+
+// parseStudentImportRow mengubah isi sel menjadi request create. Kesalahan yang
+// hanya bisa dideteksi saat parsing (tahun bukan angka) dikembalikan sebagai errs.
+func parseStudentImportRow(cell func(field string) string) (model.CreateStudentRequest, map[string]string) {
+	errs := map[string]string{}
+	req := model.CreateStudentRequest{
+		NISN:       normalizeImportNISN(cell("nisn")),
+		FullName:   cell("full_name"),
+		BirthPlace: cell("birth_place"),
+		BirthDate:  cell("birth_date"),
+		Gender:     normalizeImportGender(cell("gender")),
+		Class:      cell("class"),
+	}
+
+	if req.BirthDate != "" {
+		if t, err := utils.ParseSpreadsheetDate(req.BirthDate); err == nil {
+			req.BirthDate = t.Format("2006-01-02")
+		}
+	}
+
+	for field, dst := range map[string]**int{"year_entry": &req.YearEntry, "year_graduate": &req.YearGraduate} {
+		v := cell(field)
+		if v == "" {
+			continue
+		}
+		year, err := strconv.Atoi(v)
+		if err != nil || year < 1900 || year > 2100 {
+			errs[field] = "Tahun tidak valid"
+			continue
+		}
+		*dst = &year
+	}
+
+	return req, errs
+}
+
+// normalizeImportNISN membuang tanda kutip pemaksa teks dan mengembalikan nol di depan
+// yang hilang saat NISN tersimpan sebagai angka di Excel
+func normalizeImportNISN(s string) string {
+	s = strings.TrimPrefix(s, "'")
+	if len(s) >= 8 && len(s) < 10 {
+		if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+			s = strings.Repeat("0", 10-len(s)) + s
+		}
+	}
+	return s
+}
+
+func normalizeImportGender(s string) string {
+	switch strings.ToUpper(s) {
+	case "L", "LAKI-LAKI", "LAKI LAKI":
+		return "L"
+	case "P", "PEREMPUAN":
+		return "P"
+	}
+	return s
+}
+
+// mergeStudentImport menyusun data siswa hasil import. Untuk siswa yang sudah ada,
+// sel kosong di file tidak menghapus data yang tersimpan.
+func mergeStudentImport(current *model.Student, req model.CreateStudentRequest) *model.Student {
+	var birthDate *time.Time
+	if req.BirthDate != "" {
+		t, _ := time.Parse("2006-01-02", req.BirthDate)
+		birthDate = &t
+	}
+
+	if current == nil {
+		return &model.Student{
+			ID:           uuid.New(),
+			NISN:         req.NISN,
+			FullName:     req.FullName,
+			BirthPlace:   req.BirthPlace,
+			BirthDate:    birthDate,
+			Gender:       req.Gender,
+			Class:        req.Class,
+			YearEntry:    req.YearEntry,
+			YearGraduate: req.YearGraduate,
+		}
+	}
+
+	student := *current
+	student.FullName = req.FullName
+	if req.BirthPlace != "" {
+		student.BirthPlace = req.BirthPlace
+	}
+	if birthDate != nil {
+		student.BirthDate = birthDate
+	}
+	if req.Gender != "" {
+		student.Gender = req.Gender
+	}
+	if req.Class != "" {
+		student.Class = req.Class
+	}
+	if req.YearEntry != nil {
+		student.YearEntry = req.YearEntry
+	}
+	if req.YearGraduate != nil {
+		student.YearGraduate = req.YearGraduate
+	}
+	return &student
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestStudentImportAuditsStoredID(t *testing.T) {
+	concurrent := &model.Student{ID: uuid.New(), NISN: "0012345678", FullName: "Budi", BirthPlace: "Bandung", Class: "X-1"}
+	repo := &fakeStudentRepo{students: map[uuid.UUID]*model.Student{}, concurrent: concurrent}
+	audit := &recordingAudit{}
+	svc := NewStudentService(repo, nil, nil, passthroughTx{}, audit)
+
+	data := []byte("NISN,Nama,Kelas\n0012345678,Budi Santoso,X-2\n0098765432,Siti Aminah,X-1\n")
+	report, err := svc.Import(context.Background(), "siswa.csv", data, true)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if report.Created != 1 || report.Updated != 1 || report.Rows[0].Action != model.StudentImportUpdate {
+		t.Errorf("created=%d updated=%d action=%s, seharusnya 1 baru dan 1 update", report.Created, report.Updated, report.Rows[0].Action)
+	}
+
+	// Siswa yang muncul setelah pratinjau tetap di-update dengan datanya sendiri
+	merged := repo.upserted[0]
+	if merged.ID != concurrent.ID || merged.BirthPlace != "Bandung" || merged.Class != "X-2" {
+		t.Errorf("hasil merge = %+v", merged)
+	}
+
+	if len(audit.entries) != 2 {
+		t.Fatalf("audit = %d entri, seharusnya 2", len(audit.entries))
+	}
+	if got := audit.entries[0]; got.EntityID != concurrent.ID.String() || got.Before != concurrent {
+		t.Errorf("audit update: entity=%s before=%v, seharusnya ID tersimpan %s", got.EntityID, got.Before, concurrent.ID)
+	}
+	if got := audit.entries[1]; got.Before != nil {
+		t.Errorf("audit siswa baru seharusnya tanpa before: %v", got.Before)
+	}
+}
+
+func TestNormalizeImportNISN(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0012345678", "0012345678"},
+		{"'0012345678", "0012345678"}, // apostrof penanda teks di Excel
+		{"12345678", "0012345678"},    // nol di depan hilang karena dibaca angka
+		{"123456789", "0123456789"},
+		{"1234567", "1234567"},     // terlalu pendek, dibiarkan agar validasi menolaknya
+		{"12345678A", "12345678A"}, // bukan angka
+		{"00123456789", "00123456789"},
+	}
+	for _, tt := range tests {
+		if got := normalizeImportNISN(tt.in); got != tt.want {
+			t.Errorf("normalizeImportNISN(%q) = %q, seharusnya %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeImportGender(t *testing.T) {
+	tests := map[string]string{"l": "L", "Laki-laki": "L", "LAKI LAKI": "L", "perempuan": "P", "P": "P", "X": "X", "": ""}
+	for in, want := range tests {
+		if got := normalizeImportGender(in); got != want {
+			t.Errorf("normalizeImportGender(%q) = %q, seharusnya %q", in, got, want)
+		}
+	}
+}
+
+func TestMergeStudentImport(t *testing.T) {
+	year := func(y int) *int { return &y }
+	birth := time.Date(2009, 8, 17, 0, 0, 0, 0, time.UTC)
+	photo := "students/photos/budi.jpg"
+	current := &model.Student{
+		ID: uuid.New(), NISN: "0012345678", FullName: "Budi", BirthPlace: "Bandung", BirthDate: &birth,
+		Gender: "L", Class: "XI IPA 1", YearEntry: year(2023), PhotoURL: &photo,
+	}
+
+	tests := []struct {
+		name    string
+		current *model.Student
+		req     model.CreateStudentRequest
+		check   func(t *testing.T, got *model.Student)
+	}{
+		{
+			name:    "siswa baru",
+			current: nil,
+			req:     model.CreateStudentRequest{NISN: "0098765432", FullName: "Siti", BirthDate: "2010-01-02", Gender: "P"},
+			check: func(t *testing.T, got *model.Student) {
+				if got.ID == uuid.Nil || got.NISN != "0098765432" || got.Gender != "P" {
+					t.Errorf("siswa baru = %+v", got)
+				}
+				if got.BirthDate == nil || got.BirthDate.Format("2006-01-02") != "2010-01-02" {
+					t.Errorf("tanggal lahir = %v", got.BirthDate)
+				}
+			},
+		},
+		{
+			name:    "kolom kosong tidak menghapus data lama",
+			current: current,
+			req:     model.CreateStudentRequest{NISN: current.NISN, FullName: "Budi Santoso"},
+			check: func(t *testing.T, got *model.Student) {
+				if got.ID != current.ID || got.FullName != "Budi Santoso" || got.BirthPlace != "Bandung" ||
+					got.BirthDate != &birth || got.Class != "XI IPA 1" || got.YearEntry == nil || got.PhotoURL != &photo {
+					t.Errorf("hasil merge = %+v", got)
+				}
+			},
+		},
+		{
+			name:    "kolom terisi menimpa data lama",
+			current: current,
+			req:     model.CreateStudentRequest{NISN: current.NISN, FullName: "Budi", Class: "XII IPA 1", YearGraduate: year(2026)},
+			check: func(t *testing.T, got *model.Student) {
+				if got.Class != "XII IPA 1" || got.YearGraduate == nil || *got.YearGraduate != 2026 || *got.YearEntry != 2023 {
+					t.Errorf("hasil merge = %+v", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, mergeStudentImport(tt.current, tt.req))
+		})
+	}
+	if current.FullName != "Budi" || current.YearGraduate != nil {
+		t.Error("data siswa tersimpan ikut berubah")
+	}
+}
@@ -15,6 +15,10 @@ import (
 var (
 	ErrStudentNotFound  = errors.New("siswa tidak ditemukan")
 	ErrNISNAlreadyExist = errors.New("NISN sudah terdaftar")
+
+	// achievements.student_id ikut terhapus (ON DELETE CASCADE), jadi siswa utama
+	// prestasi tim tanpa anggota lain tidak boleh dihapus
+	ErrStudentSoleTeamMember = errors.New("siswa adalah satu-satunya anggota prestasi tim, hapus prestasinya lebih dulu")
 )
 
 type StudentService interface {
@@ -24,15 +28,53 @@ type StudentService interface {
 	Update(ctx context.Context, id string, req model.UpdateStudentRequest) (*model.Student, error)
 	Delete(ctx context.Context, id string) error
 	UploadPhoto(ctx context.Context, id string, data []byte, contentType string) (*model.Student, error)
+	Import(ctx context.Context, filename string, data []byte, commit bool) (*model.StudentImportReport, error)
 }
 
 type studentService struct {
-	repo    repository.StudentRepository
-	storage *utils.StorageService
+	repo            repository.StudentRepository
+	achievementRepo repository.AchievementRepository
+	storage         *utils.StorageService
+	tx              repository.Transactor
+	audit           AuditService
+}
+
+func NewStudentService(repo repository.StudentRepository, achievementRepo repository.AchievementRepository, storage *utils.StorageService, tx repository.Transactor, audit AuditService) StudentService {
+	return &studentService{repo: repo, achievementRepo: achievementRepo, storage: storage, tx: tx, audit: audit}
 }
 
-func NewStudentService(repo repository.StudentRepository, storage *utils.StorageService) StudentService {
-	return &studentService{repo: repo, storage: storage}
+// ValidateCreateStudent membersihkan dan memvalidasi data siswa baru.
+// Dipakai endpoint create maupun import agar aturan validasinya sama.
+func ValidateCreateStudent(req *model.CreateStudentRequest) utils.ValidationErrors {
+	errs := utils.ValidationErrors{}
+	req.NISN = utils.SanitizeString(req.NISN)
+	req.FullName = utils.SanitizeString(req.FullName)
+	req.BirthDate = utils.SanitizeString(req.BirthDate)
+
+	if req.NISN == "" {
+		errs["nisn"] = "NISN wajib diisi"
+	} else if len(req.NISN) != 10 {
+		errs["nisn"] = "NISN harus 10 digit"
+	}
+	if req.FullName == "" {
+		errs["full_name"] = "Nama lengkap wajib diisi"
+	}
+	if req.Gender != "" && req.Gender != "L" && req.Gender != "P" {
+		errs["gender"] = "Gender harus L atau P"
+	}
+	if req.BirthDate != "" {
+		if _, err := time.Parse("2006-01-02", req.BirthDate); err != nil {
+			errs["birth_date"] = "Format tanggal lahir tidak valid, gunakan YYYY-MM-DD"
+		}
+	}
+	if len(req.BirthPlace) > 100 {
+		errs["birth_place"] = "Tempat lahir maksimal 100 karakter"
+	}
+	if len(req.Class) > 20 {
+		errs["class"] = "Kelas maksimal 20 karakter"
+	}
+
+	return errs
 }
 
 func (s *studentService) GetAll(ctx context.Context, filter model.StudentFilter) ([]*model.Student, *response.Pagination, error) {
@@ -110,10 +152,18 @@ func (s *studentService) Create(ctx context.Context, req model.CreateStudentRequ
 		student.BirthDate = &t
 	}
 
-	if err := s.repo.Create(ctx, student); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, student); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditStudentCreate, EntityType: AuditEntityStudent,
+			EntityID: student.ID.String(), After: student,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return student, nil
 }
 
@@ -122,6 +172,7 @@ func (s *studentService) Update(ctx context.Context, id string, req model.Update
 	if err != nil {
 		return nil, err
 	}
+	before := *student
 
 	student.FullName = req.FullName
 	student.BirthPlace = req.BirthPlace
@@ -138,21 +189,69 @@ func (s *studentService) Update(ctx context.Context, id string, req model.Update
 		student.BirthDate = &t
 	}
 
-	if err := s.repo.Update(ctx, student); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, student); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditStudentUpdate, EntityType: AuditEntityStudent,
+			EntityID: student.ID.String(), Before: before, After: student,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return student, nil
 }
 
 func (s *studentService) Delete(ctx context.Context, id string) error {
-	_, err := s.GetByID(ctx, id)
+	student, err := s.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	uid, _ := uuid.Parse(id)
-	return s.repo.Delete(ctx, uid)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.handOverTeamAchievements(ctx, student.ID); err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, student.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditStudentDelete, EntityType: AuditEntityStudent,
+			EntityID: student.ID.String(), Before: student,
+		})
+	})
+}
+
+// handOverTeamAchievements memindahkan siswa utama prestasi tim milik studentID ke
+// anggota lain, agar prestasi bersama tidak ikut terhapus bersama siswanya
+func (s *studentService) handOverTeamAchievements(ctx context.Context, studentID uuid.UUID) error {
+	achievements, err := s.achievementRepo.FindByStudentID(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	for _, a := range achievements {
+		if a.TeamName == nil || a.StudentID != studentID {
+			continue
+		}
+		successor := teamSuccessor(a.Participants, studentID)
+		if successor == uuid.Nil {
+			return ErrStudentSoleTeamMember
+		}
+		if err := s.achievementRepo.SetPrimaryStudent(ctx, a.ID, successor); err != nil {
+			return err
+		}
+		err := s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAchievementTeam, EntityType: AuditEntityAchievement, EntityID: a.ID.String(),
+			Before: map[string]string{"student_id": studentID.String()},
+			After:  map[string]string{"student_id": successor.String()},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *studentService) UploadPhoto(ctx context.Context, id string, data []byte, contentType string) (*model.Student, error) {
@@ -160,22 +259,31 @@ func (s *studentService) UploadPhoto(ctx context.Context, id string, data []byte
 	if err != nil {
 		return nil, err
 	}
-
-	// Hapus foto lama jika ada
-	if student.PhotoURL != nil {
-		s.storage.DeleteFile(ctx, *student.PhotoURL)
-	}
+	before := *student
 
 	result, err := s.storage.UploadFile(ctx, "students/photos", data, contentType)
 	if err != nil {
 		return nil, err
 	}
 
-	uid, _ := uuid.Parse(id)
-	if err := s.repo.UpdatePhoto(ctx, uid, result.FileURL); err != nil {
+	student.PhotoURL = &result.FileURL
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdatePhoto(ctx, student.ID, result.FileURL); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditStudentPhoto, EntityType: AuditEntityStudent,
+			EntityID: student.ID.String(), Before: before, After: student,
+		})
+	})
+	if err != nil {
+		s.storage.DeleteFile(ctx, result.FileURL) // rollback file jika DB gagal
 		return nil, err
 	}
 
-	student.PhotoURL = &result.FileURL
+	// Foto lama baru dihapus setelah foto baru tersimpan
+	if before.PhotoURL != nil {
+		s.storage.DeleteFile(ctx, *before.PhotoURL)
+	}
 	return student, nil
 }
\ No newline at end of file
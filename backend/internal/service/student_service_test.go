@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/google/uuid"
+)
+
+// passthroughTx menjalankan fn tanpa transaksi sungguhan
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// recordingAudit mencatat entri audit di memori
+type recordingAudit struct {
+	AuditService
+	entries []model.AuditEntry
+}
+
+func (a *recordingAudit) Record(_ context.Context, entry model.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+type fakeStudentRepo struct {
+	repository.StudentRepository
+	students map[uuid.UUID]*model.Student
+	deleted  []uuid.UUID
+
+	concurrent  *model.Student
+	nisnLookups int
+	upserted    []*model.Student
+}
+
+func (r *fakeStudentRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Student, error) {
+	return r.students[id], nil
+}
+
+func (r *fakeStudentRepo) FindAllForMatching(context.Context) ([]*model.Student, error) {
+	var out []*model.Student
+	for _, st := range r.students {
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (r *fakeStudentRepo) Delete(_ context.Context, id uuid.UUID) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+// FindByNISNs mengembalikan siswa di students, ditambah concurrent mulai panggilan
+// kedua untuk meniru siswa yang ditambahkan di antara pratinjau dan commit
+func (r *fakeStudentRepo) FindByNISNs(_ context.Context, nisns []string) ([]*model.Student, error) {
+	r.nisnLookups++
+	var out []*model.Student
+	for _, st := range r.students {
+		for _, nisn := range nisns {
+			if st.NISN == nisn {
+				out = append(out, st)
+			}
+		}
+	}
+	if r.concurrent != nil && r.nisnLookups > 1 {
+		out = append(out, r.concurrent)
+	}
+	return out, nil
+}
+
+// UpsertMany meniru ON CONFLICT (nisn): siswa yang NISN-nya sudah ada memakai ID tersimpan
+func (r *fakeStudentRepo) UpsertMany(_ context.Context, students []*model.Student) ([]bool, error) {
+	inserted := make([]bool, len(students))
+	for i, st := range students {
+		if r.concurrent != nil && r.concurrent.NISN == st.NISN {
+			st.ID = r.concurrent.ID
+			continue
+		}
+		inserted[i] = true
+	}
+	r.upserted = students
+	return inserted, nil
+}
+
+type fakeAchievementRepo struct {
+	repository.AchievementRepository
+	achievements []*model.Achievement
+}
+
+func (r *fakeAchievementRepo) FindByStudentID(_ context.Context, studentID uuid.UUID) ([]*model.Achievement, error) {
+	var out []*model.Achievement
+	for _, a := range r.achievements {
+		if a.StudentID == studentID {
+			out = append(out, a)
+			continue
+		}
+		for _, p := range a.Participants {
+			if p.StudentID == studentID {
+				out = append(out, a)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeAchievementRepo) FindByStudentIDs(_ context.Context, studentIDs []string) ([]*model.Achievement, error) {
+	var out []*model.Achievement
+	for _, a := range r.achievements {
+		for _, id := range studentIDs {
+			if a.StudentID.String() == id {
+				out = append(out, a)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeAchievementRepo) FindAllCategories(context.Context) ([]*model.AchievementCategory, error) {
+	return nil, nil
+}
+
+func (r *fakeAchievementRepo) FindAllLevels(context.Context) ([]*model.CompetitionLevel, error) {
+	return nil, nil
+}
+
+func (r *fakeAchievementRepo) SetPrimaryStudent(_ context.Context, id, studentID uuid.UUID) error {
+	for _, a := range r.achievements {
+		if a.ID == id {
+			a.StudentID = studentID
+		}
+	}
+	return nil
+}
+
+func TestStudentDeleteHandsOverTeamAchievement(t *testing.T) {
+	captain, member, coach, solo := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	team := "Tim Robotik"
+	tests := []struct {
+		name         string
+		participants []model.AchievementParticipant
+		wantErr      error
+		wantPrimary  uuid.UUID
+	}{
+		{
+			name: "kapten dihapus, anggota jadi siswa utama",
+			participants: []model.AchievementParticipant{
+				{StudentID: captain, Role: model.ParticipantRoleCaptain},
+				{StudentID: coach, Role: model.ParticipantRoleCoach},
+				{StudentID: member, Role: model.ParticipantRoleMember},
+			},
+			wantPrimary: member,
+		},
+		{
+			name: "hanya tersisa pelatih",
+			participants: []model.AchievementParticipant{
+				{StudentID: captain, Role: model.ParticipantRoleCaptain},
+				{StudentID: coach, Role: model.ParticipantRoleCoach},
+			},
+			wantErr:     ErrStudentSoleTeamMember,
+			wantPrimary: captain,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			achievement := &model.Achievement{ID: uuid.New(), StudentID: captain, TeamName: &team, Participants: tt.participants}
+			individual := &model.Achievement{ID: uuid.New(), StudentID: solo}
+			students := &fakeStudentRepo{students: map[uuid.UUID]*model.Student{captain: {ID: captain}}}
+			achievements := &fakeAchievementRepo{achievements: []*model.Achievement{achievement, individual}}
+			audit := &recordingAudit{}
+			svc := NewStudentService(students, achievements, nil, passthroughTx{}, audit)
+
+			err := svc.Delete(context.Background(), captain.String())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete() error = %v, seharusnya %v", err, tt.wantErr)
+			}
+			if achievement.StudentID != tt.wantPrimary {
+				t.Errorf("siswa utama = %s, seharusnya %s", achievement.StudentID, tt.wantPrimary)
+			}
+			if individual.StudentID != solo {
+				t.Error("prestasi perorangan siswa lain ikut berubah")
+			}
+			if tt.wantErr != nil {
+				if len(students.deleted) != 0 {
+					t.Error("siswa tetap dihapus padahal prestasi tim tidak bisa dipindahkan")
+				}
+				return
+			}
+			if len(students.deleted) != 1 || students.deleted[0] != captain {
+				t.Errorf("siswa yang dihapus = %v", students.deleted)
+			}
+			if len(audit.entries) != 2 || audit.entries[0].Action != AuditAchievementTeam {
+				t.Errorf("audit = %+v, seharusnya pergantian siswa utama lalu penghapusan siswa", audit.entries)
+			}
+		})
+	}
+}
+
+func TestTeamSuccessor(t *testing.T) {
+	captain, member1, member2, coach := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	tests := []struct {
+		name         string
+		participants []model.AchievementParticipant
+		leaving      uuid.UUID
+		want         uuid.UUID
+	}{
+		{
+			name: "anggota keluar, kapten tetap",
+			participants: []model.AchievementParticipant{
+				{StudentID: captain, Role: model.ParticipantRoleCaptain},
+				{StudentID: member1, Role: model.ParticipantRoleMember},
+			},
+			leaving: member1,
+			want:    captain,
+		},
+		{
+			name: "kapten keluar, anggota pertama",
+			participants: []model.AchievementParticipant{
+				{StudentID: captain, Role: model.ParticipantRoleCaptain},
+				{StudentID: member1, Role: model.ParticipantRoleMember},
+				{StudentID: member2, Role: model.ParticipantRoleMember},
+			},
+			leaving: captain,
+			want:    member1,
+		},
+		{
+			name: "pelatih tidak pernah jadi siswa utama",
+			participants: []model.AchievementParticipant{
+				{StudentID: coach, Role: model.ParticipantRoleCoach},
+				{StudentID: member1, Role: model.ParticipantRoleMember},
+			},
+			leaving: member1,
+			want:    uuid.Nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := teamSuccessor(tt.participants, tt.leaving); got != tt.want {
+			t.Errorf("%s: teamSuccessor() = %s, seharusnya %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("verifikasi dua langkah sudah aktif")
+	ErrTwoFactorNotEnabled     = errors.New("verifikasi dua langkah belum aktif")
+	ErrTwoFactorSetupMissing   = errors.New("enrolment belum dimulai, minta QR code terlebih dahulu")
+	ErrInvalidTwoFactorCode    = errors.New("kode verifikasi salah atau sudah dipakai")
+	ErrTwoFactorRequired       = errors.New("verifikasi dua langkah wajib untuk role ini dan tidak dapat dimatikan")
+)
+
+// Cara verifikasi langkah kedua, dicatat di audit login
+const (
+	TwoFactorMethodTOTP     = "totp"
+	TwoFactorMethodRecovery = "recovery_code"
+)
+
+const (
+	recoveryCodeCount = 10
+	totpQRCodeSize    = 256
+)
+
+type TwoFactorService interface {
+	Status(ctx context.Context, userID string) (*model.TwoFactorStatus, error)
+	Setup(ctx context.Context, userID string) (*model.TwoFactorSetup, error)
+	Enable(ctx context.Context, userID, code string) (*model.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID string, req model.DisableTwoFactorRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*model.RecoveryCodesResponse, error)
+	Reset(ctx context.Context, userID string) error
+
+	// Verify memeriksa kode TOTP atau recovery code saat login; mengembalikan caranya
+	Verify(ctx context.Context, userID, code string) (string, error)
+}
+
+type twoFactorService struct {
+	repo        repository.TwoFactorRepository
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	cfg         *config.MFAConfig
+	tx          repository.Transactor
+	audit       AuditService
+}
+
+func NewTwoFactorService(
+	repo repository.TwoFactorRepository,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	cfg *config.MFAConfig,
+	tx repository.Transactor,
+	audit AuditService,
+) TwoFactorService {
+	return &twoFactorService{
+		repo:        repo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		cfg:         cfg,
+		tx:          tx,
+		audit:       audit,
+	}
+}
+
+func (s *twoFactorService) Status(ctx context.Context, userID string) (*model.TwoFactorStatus, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.TwoFactorStatus{
+		Enabled:  user.TwoFactorEnabled(),
+		Required: user.Role.RequiresTwoFactor(),
+	}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = s.repo.CountRecoveryCodes(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Setup membuat secret baru untuk dipindai aplikasi authenticator. 2FA belum aktif
+// sampai kode pertama dikonfirmasi lewat Enable; Setup ulang mengganti secret.
+func (s *twoFactorService) Setup(ctx context.Context, userID string) (*model.TwoFactorSetup, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPendingSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	uri := utils.TOTPProvisioningURI(s.cfg.Issuer, user.Email, secret)
+	png, err := utils.GenerateQRCodePNG(uri, totpQRCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURL: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Enable mengonfirmasi enrolment dengan kode pertama dan membuat recovery code
+func (s *twoFactorService) Enable(ctx context.Context, userID, code string) (*model.RecoveryCodesResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrTwoFactorSetupMissing
+	}
+
+	step, ok := utils.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Enable(ctx, user.ID, step, hashes); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthMFAEnable, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable mematikan 2FA atas permintaan user sendiri; tidak berlaku untuk role yang wajib 2FA
+func (s *twoFactorService) Disable(ctx context.Context, userID string, req model.DisableTwoFactorRequest) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if user.Role.RequiresTwoFactor() {
+		return ErrTwoFactorRequired
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return ErrWrongPassword
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.verify(ctx, user, req.Code); err != nil {
+			return err
+		}
+		if err := s.repo.Disable(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthMFADisable, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+		})
+	})
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code; yang lama langsung tidak berlaku
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*model.RecoveryCodesResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.verify(ctx, user, code); err != nil {
+			return err
+		}
+		if err := s.repo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditAuthMFARecovery, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Reset menghapus 2FA user yang kehilangan perangkat dan recovery code-nya (admin).
+// Semua sesi dicabut; role yang wajib 2FA melakukan enrolment ulang saat login.
+func (s *twoFactorService) Reset(ctx context.Context, userID string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPSecret == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Disable(ctx, user.ID); err != nil {
+			return err
+		}
+		revoked, err := s.sessionRepo.RevokeAllForUser(ctx, user.ID, model.SessionRevokedMFAReset)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditUserResetMFA, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+			Before: map[string]bool{"two_factor_enabled": user.TwoFactorEnabled()},
+			After:  map[string]int64{"revoked_sessions": revoked},
+		})
+	})
+}
+
+func (s *twoFactorService) Verify(ctx context.Context, userID, code string) (string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if !user.TwoFactorEnabled() {
+		return "", ErrTwoFactorNotEnabled
+	}
+
+	var method string
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		method, err = s.verify(ctx, user, code)
+		return err
+	})
+	return method, err
+}
+
+// verify menerima kode TOTP (sekali pakai per langkah waktu) atau recovery code.
+// Pemakaian recovery code dicatat di audit, jadi dipanggil di dalam transaksi.
+func (s *twoFactorService) verify(ctx context.Context, user *model.User, code string) (string, error) {
+	if step, ok := utils.ValidateTOTP(*user.TOTPSecret, code, time.Now()); ok {
+		used, err := s.repo.UseStep(ctx, user.ID, step)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return "", ErrInvalidTwoFactorCode
+		}
+		return TwoFactorMethodTOTP, nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return "", err
+	}
+	if !used {
+		return "", ErrInvalidTwoFactorCode
+	}
+
+	left, err := s.repo.CountRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.audit.Record(ctx, model.AuditEntry{
+		Action: AuditAuthMFARecoveryUse, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+		After: map[string]int{"recovery_codes_left": left},
+	}); err != nil {
+		return "", err
+	}
+	return TwoFactorMethodRecovery, nil
+}
+
+func (s *twoFactorService) findUser(ctx context.Context, userID string) (*model.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := s.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// newRecoveryCodes recovery code untuk ditampilkan sekali beserta hash-nya untuk disimpan
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode recovery code acak ±50 bit, cukup di-hash SHA-256 seperti refresh token
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(utils.NormalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Pastikan interface terpenuhi
+var _ TwoFactorService = (*twoFactorService)(nil)
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/response"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/utils"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrLastAdmin            = errors.New("tidak dapat mengubah admin aktif terakhir, tambahkan admin lain terlebih dahulu")
+	ErrCannotDeactivateSelf = errors.New("tidak dapat menonaktifkan akun sendiri")
+	ErrUserAlreadyActive    = errors.New("user sudah aktif")
+	ErrUserAlreadyInactive  = errors.New("user sudah tidak aktif")
+)
+
+// temporaryPasswordLength panjang password sementara hasil reset
+const temporaryPasswordLength = 12
+
+type UserService interface {
+	GetAll(ctx context.Context, filter model.UserFilter) ([]model.UserResponse, *response.Pagination, error)
+	GetByID(ctx context.Context, id string) (*model.UserResponse, error)
+	Update(ctx context.Context, id string, req model.UpdateUserRequest) (*model.UserResponse, error)
+	Activate(ctx context.Context, id string) (*model.UserResponse, error)
+	Deactivate(ctx context.Context, id, actorID string) (*model.UserResponse, error)
+	ResetPassword(ctx context.Context, id string, req model.ResetPasswordRequest) (*model.ResetPasswordResponse, error)
+}
+
+type userService struct {
+	repo        repository.UserRepository
+	sessionRepo repository.SessionRepository
+	tx          repository.Transactor
+	audit       AuditService
+}
+
+func NewUserService(repo repository.UserRepository, sessionRepo repository.SessionRepository, tx repository.Transactor, audit AuditService) UserService {
+	return &userService{repo: repo, sessionRepo: sessionRepo, tx: tx, audit: audit}
+}
+
+func (s *userService) GetAll(ctx context.Context, filter model.UserFilter) ([]model.UserResponse, *response.Pagination, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PerPage <= 0 {
+		filter.PerPage = 10
+	}
+
+	users, total, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]model.UserResponse, len(users))
+	for i, user := range users {
+		result[i] = user.ToResponse()
+	}
+
+	totalPages := int(total) / filter.PerPage
+	if int(total)%filter.PerPage > 0 {
+		totalPages++
+	}
+
+	return result, &response.Pagination{
+		Page: filter.Page, PerPage: filter.PerPage,
+		TotalItems: total, TotalPages: totalPages,
+	}, nil
+}
+
+func (s *userService) GetByID(ctx context.Context, id string) (*model.UserResponse, error) {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := user.ToResponse()
+	return &resp, nil
+}
+
+// Update mengubah nama dan role. Admin aktif terakhir tidak bisa diturunkan.
+// Role ikut tersimpan di access token, jadi perubahan role mencabut semua sesi user
+// agar hak akses lama tidak berlaku sampai token kedaluwarsa.
+func (s *userService) Update(ctx context.Context, id string, req model.UpdateUserRequest) (*model.UserResponse, error) {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := user.ToResponse()
+	roleChanged := user.Role != req.Role
+	user.Name = req.Name
+	user.Role = req.Role
+
+	var resp *model.UserResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if resp, err = s.save(ctx, user, before, AuditUserUpdate); err != nil {
+			return err
+		}
+		if roleChanged {
+			_, err = s.sessionRepo.RevokeAllForUser(ctx, user.ID, model.SessionRevokedRoleChange)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *userService) Activate(ctx context.Context, id string) (*model.UserResponse, error) {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsActive {
+		return nil, ErrUserAlreadyActive
+	}
+
+	before := user.ToResponse()
+	user.IsActive = true
+
+	return s.save(ctx, user, before, AuditUserActivate)
+}
+
+// Deactivate menonaktifkan user dan mencabut semua sesinya
+func (s *userService) Deactivate(ctx context.Context, id, actorID string) (*model.UserResponse, error) {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID.String() == actorID {
+		return nil, ErrCannotDeactivateSelf
+	}
+	if !user.IsActive {
+		return nil, ErrUserAlreadyInactive
+	}
+
+	before := user.ToResponse()
+	user.IsActive = false
+
+	var resp *model.UserResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if resp, err = s.save(ctx, user, before, AuditUserDeactivate); err != nil {
+			return err
+		}
+		_, err = s.sessionRepo.RevokeAllForUser(ctx, user.ID, model.SessionRevokedDeactivate)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ResetPassword mengganti password user dan mencabut semua sesinya. Tanpa password
+// di request, password sementara acak dibuat dan dikembalikan sekali. User wajib
+// menggantinya saat login berikutnya.
+func (s *userService) ResetPassword(ctx context.Context, id string, req model.ResetPasswordRequest) (*model.ResetPasswordResponse, error) {
+	user, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MustChangePassword = true
+	result := &model.ResetPasswordResponse{User: user.ToResponse()}
+	password := req.Password
+	if password == "" {
+		if password, err = utils.GenerateTemporaryPassword(temporaryPasswordLength); err != nil {
+			return nil, err
+		}
+		result.TemporaryPassword = password
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdatePassword(ctx, user.ID, string(hashedPassword), true); err != nil {
+			return err
+		}
+		if _, err := s.sessionRepo.RevokeAllForUser(ctx, user.ID, model.SessionRevokedPassword); err != nil {
+			return err
+		}
+
+		// Password tidak pernah masuk audit
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: AuditUserResetPassword, EntityType: AuditEntityUser, EntityID: user.ID.String(),
+			After: map[string]bool{"generated": result.TemporaryPassword != ""},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// save menyimpan perubahan user beserta entri audit-nya dalam satu transaksi
+func (s *userService) save(ctx context.Context, user *model.User, before model.UserResponse, action string) (*model.UserResponse, error) {
+	var resp model.UserResponse
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, user); err != nil {
+			if errors.Is(err, repository.ErrLastActiveAdmin) {
+				return ErrLastAdmin
+			}
+			return err
+		}
+
+		updated, err := s.repo.FindByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		resp = updated.ToResponse()
+
+		return s.audit.Record(ctx, model.AuditEntry{
+			Action: action, EntityType: AuditEntityUser,
+			EntityID: user.ID.String(), Before: before, After: resp,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s *userService) find(ctx context.Context, id string) (*model.User, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := s.repo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Pastikan interface terpenuhi
+var _ UserService = (*userService)(nil)
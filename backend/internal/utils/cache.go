@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// TTLCache cache sederhana di memori dengan masa berlaku per entri. Clear menaikkan
+// generasi cache sehingga hasil load yang dimulai sebelum Clear tidak ikut disimpan.
+type TTLCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	items      map[string]cacheItem
+	generation uint64
+}
+
+type cacheItem struct {
+	value     any
+	expiresAt time.Time
+}
+
+func NewTTLCache(ttl time.Duration) *TTLCache {
+	return &TTLCache{ttl: ttl, items: make(map[string]cacheItem)}
+}
+
+// GetOrLoad mengembalikan nilai dari cache, atau memanggil load dan menyimpan hasilnya
+// jika belum ada atau sudah kedaluwarsa. Error dari load tidak disimpan.
+func (c *TTLCache) GetOrLoad(key string, load func() (any, error)) (any, error) {
+	c.mu.Lock()
+	if item, ok := c.items[key]; ok {
+		if time.Now().Before(item.expiresAt) {
+			c.mu.Unlock()
+			return item.value, nil
+		}
+		delete(c.items, key)
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.items[key] = cacheItem{value: value, expiresAt: time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Clear menghapus seluruh entri
+func (c *TTLCache) Clear() {
+	c.mu.Lock()
+	c.items = make(map[string]cacheItem)
+	c.generation++
+	c.mu.Unlock()
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTTLCacheGetOrLoad(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	loads := 0
+	load := func() (any, error) {
+		loads++
+		return loads, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if v, err := cache.GetOrLoad("ringkasan", load); err != nil || v != 1 {
+			t.Fatalf("GetOrLoad() = %v, %v, seharusnya nilai tersimpan 1", v, err)
+		}
+	}
+	if v, _ := cache.GetOrLoad("lain", load); v != 2 {
+		t.Errorf("key berbeda = %v, seharusnya dimuat sendiri", v)
+	}
+
+	cache.Clear()
+	if v, _ := cache.GetOrLoad("ringkasan", load); v != 3 {
+		t.Errorf("setelah Clear = %v, seharusnya dimuat ulang", v)
+	}
+}
+
+func TestTTLCacheExpiry(t *testing.T) {
+	cache := NewTTLCache(10 * time.Millisecond)
+	loads := 0
+	load := func() (any, error) {
+		loads++
+		return loads, nil
+	}
+
+	cache.GetOrLoad("k", load)
+	time.Sleep(20 * time.Millisecond)
+	if v, _ := cache.GetOrLoad("k", load); v != 2 {
+		t.Errorf("entri kedaluwarsa = %v, seharusnya dimuat ulang", v)
+	}
+}
+
+func TestTTLCacheErrorNotStored(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	errLoad := errors.New("database mati")
+	if _, err := cache.GetOrLoad("k", func() (any, error) { return nil, errLoad }); !errors.Is(err, errLoad) {
+		t.Fatalf("error load = %v", err)
+	}
+	if v, _ := cache.GetOrLoad("k", func() (any, error) { return "ok", nil }); v != "ok" {
+		t.Errorf("setelah error = %v, seharusnya dimuat ulang", v)
+	}
+}
+
+// Load yang dimulai sebelum Clear tidak boleh menyimpan data lama ke generasi baru
+func TestTTLCacheClearDuringLoad(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan any)
+	go func() {
+		v, _ := cache.GetOrLoad("k", func() (any, error) {
+			close(started)
+			<-release
+			return "lama", nil
+		})
+		done <- v
+	}()
+
+	<-started
+	cache.Clear()
+	close(release)
+	if v := <-done; v != "lama" {
+		t.Fatalf("pemanggil load = %v, seharusnya tetap menerima hasilnya", v)
+	}
+	if v, _ := cache.GetOrLoad("k", func() (any, error) { return "baru", nil }); v != "baru" {
+		t.Errorf("GetOrLoad() setelah Clear = %v, seharusnya hasil load lama tidak disimpan", v)
+	}
+}
@@ -0,0 +1,367 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// CertificateLayout tata letak dan teks surat keterangan, diambil dari versi template
+type CertificateLayout struct {
+	Language    string // id | en
+	Title       string
+	HeaderImage []byte // JPEG hasil MakeThumbnail, opsional; menggantikan kop teks
+	Color       PDFColor
+	OpeningText string // Go template, lihat CertificateTextData
+	BodyText    string
+	ClosingText string
+	Columns     []CertificateColumn
+	Signatures  []CertificateSignature
+}
+
+type CertificateColumn struct {
+	Field string // no | competition_name | organizer | category | rank | level | year
+	Label string
+	Width float64 // bobot relatif
+	Align string
+}
+
+// CertificateSignature blok tanda tangan; Title, Name dan NIP boleh berisi placeholder
+type CertificateSignature struct {
+	Title string
+	Name  string
+	NIP   string
+}
+
+// CertificateTextData data untuk placeholder teks template, mis. {{.Student.FullName}},
+// {{.Certificate.Number}}, {{.School.HeadmasterName}} atau {{.AchievementCount}}
+type CertificateTextData struct {
+	Certificate      PDFCertificateInfo
+	Student          PDFStudent
+	School           PDFSchool
+	AchievementCount int
+}
+
+type PDFCertificateInfo struct {
+	Number     string
+	IssuedAt   string // sudah diformat sesuai bahasa template
+	ValidUntil string
+}
+
+type PDFSchool struct {
+	Name           string
+	Address        string
+	HeadmasterName string
+	HeadmasterNIP  string
+}
+
+// certificateLabels teks bawaan surat per bahasa
+type certificateLabels struct {
+	number       string
+	fullName     string
+	nisn         string
+	birth        string
+	class        string
+	issued       string
+	scanToVerify string
+	footer       string
+	page         string
+	columns      map[string]string
+}
+
+var certificateLabelsByLanguage = map[string]certificateLabels{
+	"id": {
+		number:       "Nomor: %s",
+		fullName:     "Nama Lengkap",
+		nisn:         "NISN",
+		birth:        "Tempat, Tanggal Lahir",
+		class:        "Kelas",
+		issued:       "Diterbitkan, %s",
+		scanToVerify: "Scan untuk verifikasi:",
+		footer:       "Dokumen ini diterbitkan secara digital pada %s | Verifikasi keaslian dokumen dengan scan QR Code",
+		page:         "Halaman %d dari {nb}",
+		columns: map[string]string{
+			"no": "No", "competition_name": "Nama Lomba", "organizer": "Penyelenggara",
+			"category": "Kategori", "rank": "Juara", "level": "Tingkat", "year": "Tahun",
+		},
+	},
+	"en": {
+		number:       "Number: %s",
+		fullName:     "Full Name",
+		nisn:         "NISN",
+		birth:        "Place, Date of Birth",
+		class:        "Class",
+		issued:       "Issued, %s",
+		scanToVerify: "Scan to verify:",
+		footer:       "This document was issued digitally on %s | Verify its authenticity by scanning the QR code",
+		page:         "Page %d of {nb}",
+		columns: map[string]string{
+			"no": "No", "competition_name": "Competition", "organizer": "Organizer",
+			"category": "Category", "rank": "Rank", "level": "Level", "year": "Year",
+		},
+	},
+}
+
+// CertificateColumnFields kolom tabel prestasi yang dikenali
+var CertificateColumnFields = []string{"no", "competition_name", "organizer", "category", "rank", "level", "year"}
+
+// IsCertificateLanguage true jika bahasa template didukung
+func IsCertificateLanguage(language string) bool {
+	_, ok := certificateLabelsByLanguage[language]
+	return ok
+}
+
+func labelsFor(language string) certificateLabels {
+	if labels, ok := certificateLabelsByLanguage[language]; ok {
+		return labels
+	}
+	return certificateLabelsByLanguage["id"]
+}
+
+// FormatCertificateDate format tanggal sesuai bahasa template,
+// mis. "17 Agustus 2026" atau "17 August 2026"
+func FormatCertificateDate(language string, t time.Time) string {
+	if language == "en" {
+		return t.Format("2 January 2006")
+	}
+	return FormatIndonesianDate(t)
+}
+
+// CheckCertificateText memastikan teks template bisa di-parse dan hanya memakai
+// placeholder yang tersedia di CertificateTextData. Seluruh pohon template diperiksa,
+// termasuk cabang {{if}} yang tidak berjalan untuk data tertentu.
+func CheckCertificateText(text string) error {
+	if !strings.Contains(text, "{{") {
+		return nil
+	}
+	tmpl, err := template.New("certificate").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("template tidak valid: %w", err)
+	}
+	root := reflect.TypeOf(CertificateTextData{})
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		if err := checkTemplateNode(t.Tree.Root, root, root); err != nil {
+			return fmt.Errorf("placeholder tidak valid: %w", err)
+		}
+	}
+
+	// Kesalahan argumen fungsi bawaan (index, slice, printf) baru muncul saat dijalankan
+	if err := tmpl.Execute(io.Discard, certificateTextSample); err != nil {
+		return fmt.Errorf("placeholder tidak valid: %w", err)
+	}
+	return nil
+}
+
+// certificateTextSample data lengkap untuk menjalankan template saat validasi
+var certificateTextSample = CertificateTextData{
+	Certificate: PDFCertificateInfo{Number: "421.2/SKP/2026/0001", IssuedAt: "17 Agustus 2026", ValidUntil: "17 Agustus 2027"},
+	Student: PDFStudent{
+		FullName: "Budi Santoso", NISN: "0051234567", BirthPlace: "Bandung",
+		BirthDate: "17 Agustus 2009", Class: "XII IPA 1",
+	},
+	School:           PDFSchool{Name: "SMA Negeri 1", Address: "Jl. Pendidikan No. 1", HeadmasterName: "Dra. Siti Aminah", HeadmasterNIP: "196501011990032001"},
+	AchievementCount: 3,
+}
+
+// checkTemplateNode memeriksa field yang dipakai node terhadap tipe dot. dot nil
+// berarti tipenya tidak diketahui (mis. di dalam {{range}}), sehingga tidak diperiksa.
+func checkTemplateNode(node parse.Node, dot, root reflect.Type) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTemplateNode(child, dot, root); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		_, err := checkTemplatePipe(n.Pipe, dot, root)
+		return err
+	case *parse.IfNode:
+		return checkTemplateBranch(&n.BranchNode, dot, dot, root)
+	case *parse.WithNode:
+		inner, err := checkTemplatePipe(n.Pipe, dot, root)
+		if err != nil {
+			return err
+		}
+		return checkTemplateBranch(&n.BranchNode, inner, dot, root)
+	case *parse.RangeNode:
+		return checkTemplateBranch(&n.BranchNode, nil, dot, root)
+	case *parse.TemplateNode:
+		_, err := checkTemplatePipe(n.Pipe, dot, root)
+		return err
+	}
+	return nil
+}
+
+func checkTemplateBranch(n *parse.BranchNode, inner, outer, root reflect.Type) error {
+	if _, err := checkTemplatePipe(n.Pipe, outer, root); err != nil {
+		return err
+	}
+	if err := checkTemplateNode(n.List, inner, root); err != nil {
+		return err
+	}
+	return checkTemplateNode(n.ElseList, outer, root)
+}
+
+// checkTemplatePipe memeriksa argumen pipeline dan mengembalikan tipe hasilnya bila
+// pipeline hanya berupa satu field
+func checkTemplatePipe(pipe *parse.PipeNode, dot, root reflect.Type) (reflect.Type, error) {
+	if pipe == nil {
+		return nil, nil
+	}
+	var result reflect.Type
+	for _, cmd := range pipe.Cmds {
+		result = nil
+		for _, arg := range cmd.Args {
+			var t reflect.Type
+			var err error
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				t, err = templateFieldType(dot, a.Ident)
+			case *parse.VariableNode:
+				if a.Ident[0] == "$" {
+					t, err = templateFieldType(root, a.Ident[1:])
+				}
+			case *parse.DotNode:
+				t = dot
+			case *parse.PipeNode:
+				t, err = checkTemplatePipe(a, dot, root)
+			case *parse.ChainNode:
+				if p, ok := a.Node.(*parse.PipeNode); ok {
+					_, err = checkTemplatePipe(p, dot, root)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(cmd.Args) == 1 {
+				result = t
+			}
+		}
+	}
+	if len(pipe.Decl) > 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// templateFieldType tipe field bersarang names pada t, atau error jika ada yang tidak dikenal
+func templateFieldType(t reflect.Type, names []string) (reflect.Type, error) {
+	path := ""
+	for _, name := range names {
+		path += "." + name
+		if t == nil {
+			return nil, nil
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s tidak dikenal", path)
+		}
+		f, ok := t.FieldByName(name)
+		if !ok || !f.IsExported() {
+			return nil, fmt.Errorf("%s tidak dikenal", path)
+		}
+		t = f.Type
+	}
+	return t, nil
+}
+
+func renderCertificateText(text string, data CertificateTextData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("certificate").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("template tidak valid: %w", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("placeholder tidak valid: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// certificateTexts teks template yang sudah diisi data surat
+type certificateTexts struct {
+	opening, body, closing string
+	signatures             []CertificateSignature
+}
+
+func renderCertificateTexts(layout CertificateLayout, data CertificatePDFData) (*certificateTexts, error) {
+	textData := CertificateTextData{
+		Certificate: PDFCertificateInfo{
+			Number:   data.CertificateNumber,
+			IssuedAt: FormatCertificateDate(layout.Language, data.IssuedAt),
+		},
+		Student: data.Student,
+		School: PDFSchool{
+			Name:           data.SchoolName,
+			Address:        data.SchoolAddress,
+			HeadmasterName: data.HeadmasterName,
+			HeadmasterNIP:  data.HeadmasterNIP,
+		},
+		AchievementCount: len(data.Achievements),
+	}
+	if data.ValidUntil != nil {
+		textData.Certificate.ValidUntil = FormatCertificateDate(layout.Language, *data.ValidUntil)
+	}
+
+	var texts certificateTexts
+	var err error
+	render := func(text string) string {
+		if err != nil {
+			return ""
+		}
+		var out string
+		out, err = renderCertificateText(text, textData)
+		return out
+	}
+
+	texts.opening = render(layout.OpeningText)
+	texts.body = render(layout.BodyText)
+	texts.closing = render(layout.ClosingText)
+	for _, sig := range layout.Signatures {
+		texts.signatures = append(texts.signatures, CertificateSignature{
+			Title: render(sig.Title),
+			Name:  render(sig.Name),
+			NIP:   render(sig.NIP),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &texts, nil
+}
+
+// certificateCell isi satu kolom tabel untuk sebuah prestasi
+func certificateCell(field string, a PDFAchievement) string {
+	switch field {
+	case "no":
+		return fmt.Sprintf("%d", a.No)
+	case "competition_name":
+		return a.CompetitionName
+	case "organizer":
+		return a.Organizer
+	case "category":
+		return a.Category
+	case "rank":
+		return a.Rank
+	case "level":
+		return a.Level
+	case "year":
+		return fmt.Sprintf("%d", a.Year)
+	}
+	return ""
+}
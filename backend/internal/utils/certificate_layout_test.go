@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestCheckCertificateText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "teks biasa", text: "Demikian surat keterangan ini dibuat."},
+		{name: "placeholder valid", text: "Nama: {{.Student.FullName}} ({{.Student.NISN}}), {{.AchievementCount}} prestasi"},
+		{name: "variabel root", text: "{{$.School.HeadmasterName}}"},
+		{name: "with mengganti dot", text: "{{with .Student}}{{.FullName}}, kelas {{.Class}}{{end}}"},
+		{name: "else memakai dot luar", text: "{{with .Certificate.ValidUntil}}s.d. {{.}}{{else}}{{.Certificate.IssuedAt}}{{end}}"},
+		{name: "fungsi bawaan", text: `{{printf "%s/%d" .Certificate.Number .AchievementCount}}`},
+		{name: "sintaks rusak", text: "{{.Student.FullName", wantErr: true},
+		{name: "field tidak dikenal", text: "{{.Bogus}}", wantErr: true},
+		{name: "field bersarang tidak dikenal", text: "{{.Student.Address}}", wantErr: true},
+		{name: "field di cabang if yang tidak berjalan", text: "{{if .Student.FullName}}{{.Bogus}}{{end}}", wantErr: true},
+		{name: "field di cabang else", text: "{{if .AchievementCount}}ada{{else}}{{.School.Phone}}{{end}}", wantErr: true},
+		{name: "field salah di dalam with", text: "{{with .School}}{{.FullName}}{{end}}", wantErr: true},
+		{name: "field pada string", text: "{{.Student.FullName.First}}", wantErr: true},
+		{name: "argumen fungsi salah", text: "{{index .Student 0}}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := CheckCertificateText(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckCertificateText(%q) error = %v, wantErr %v", tt.name, tt.text, err, tt.wantErr)
+		}
+	}
+}
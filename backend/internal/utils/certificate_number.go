@@ -2,12 +2,112 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+)
+
+// Kebijakan reset counter nomor surat
+const (
+	NumberResetYearly  = "yearly"
+	NumberResetMonthly = "monthly"
+	NumberResetNever   = "never"
 )
 
-// GenerateCertificateNumber membuat nomor surat format: 421.2/SKP/{YEAR}/{INCREMENT}
-// increment didapat dari DB (total sertifikat tahun ini + 1)
-func GenerateCertificateNumber(increment int) string {
-	year := time.Now().Year()
-	return fmt.Sprintf("421.2/SKP/%d/%04d", year, increment)
-}
\ No newline at end of file
+var numberPlaceholderRegex = regexp.MustCompile(`\{(code|year|month|month_roman|seq)(?::(\d+))?\}`)
+
+// CertificateNumberFormat membuat nomor surat dari template, contoh:
+// "{code}/SKP/{month_roman}/{year}/{seq:4}" -> "421.2/SKP/X/2026/0007"
+//
+// Placeholder: {code} kode klasifikasi, {year}, {month}, {month_roman},
+// {seq} counter (opsional lebar padding nol, mis. {seq:4}).
+type CertificateNumberFormat struct {
+	template string
+	code     string
+	reset    string
+}
+
+func NewCertificateNumberFormat(cfg *config.CertificateNumberConfig) (*CertificateNumberFormat, error) {
+	if !strings.Contains(cfg.Template, "{seq") {
+		return nil, fmt.Errorf("CERT_NUMBER_TEMPLATE harus mengandung placeholder {seq}")
+	}
+
+	// Counter yang di-reset harus dibarengi periode di nomor surat; tanpa itu
+	// nomor periode berikutnya mengulang nomor lama dan ditolak UNIQUE constraint
+	placeholders := map[string]bool{}
+	for _, m := range numberPlaceholderRegex.FindAllStringSubmatch(cfg.Template, -1) {
+		placeholders[m[1]] = true
+	}
+	hasYear := placeholders["year"]
+	hasMonth := placeholders["month"] || placeholders["month_roman"]
+
+	switch cfg.ResetPolicy {
+	case NumberResetYearly:
+		if !hasYear {
+			return nil, fmt.Errorf("CERT_NUMBER_TEMPLATE harus mengandung {year} untuk CERT_NUMBER_RESET=yearly")
+		}
+	case NumberResetMonthly:
+		if !hasYear || !hasMonth {
+			return nil, fmt.Errorf("CERT_NUMBER_TEMPLATE harus mengandung {year} dan {month} atau {month_roman} untuk CERT_NUMBER_RESET=monthly")
+		}
+	case NumberResetNever:
+	default:
+		return nil, fmt.Errorf("CERT_NUMBER_RESET tidak valid: %q (yearly, monthly, never)", cfg.ResetPolicy)
+	}
+
+	return &CertificateNumberFormat{
+		template: cfg.Template,
+		code:     cfg.ClassificationCode,
+		reset:    cfg.ResetPolicy,
+	}, nil
+}
+
+// Scope kunci sequence sesuai kebijakan reset; counter dimulai dari 1 untuk setiap scope baru
+func (f *CertificateNumberFormat) Scope(t time.Time) string {
+	switch f.reset {
+	case NumberResetMonthly:
+		return fmt.Sprintf("month:%04d-%02d", t.Year(), t.Month())
+	case NumberResetNever:
+		return "all"
+	default:
+		return fmt.Sprintf("year:%04d", t.Year())
+	}
+}
+
+func (f *CertificateNumberFormat) Format(seq int, t time.Time) string {
+	return numberPlaceholderRegex.ReplaceAllStringFunc(f.template, func(m string) string {
+		parts := numberPlaceholderRegex.FindStringSubmatch(m)
+		width, _ := strconv.Atoi(parts[2])
+
+		switch parts[1] {
+		case "code":
+			return f.code
+		case "year":
+			return strconv.Itoa(t.Year())
+		case "month":
+			return fmt.Sprintf("%02d", t.Month())
+		case "month_roman":
+			return ToRoman(int(t.Month()))
+		default:
+			return fmt.Sprintf("%0*d", width, seq)
+		}
+	})
+}
+
+// ToRoman mengubah angka (1-3999) menjadi angka Romawi, dipakai untuk bulan pada nomor surat
+func ToRoman(n int) string {
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+
+	var b strings.Builder
+	for i, v := range values {
+		for n >= v {
+			b.WriteString(symbols[i])
+			n -= v
+		}
+	}
+	return b.String()
+}
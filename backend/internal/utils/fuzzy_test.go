@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"M. Rizki  Ananda", "m rizki ananda"},
+		{"  Siti Nur'aini ", "siti nur aini"},
+		{"KABUPATEN/KOTA", "kabupaten kota"},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.in); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, seharusnya %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"budi", "budi", 0},
+		{"dewi", "dwi", 1},
+		{"ñandú", "nandu", 2}, // dihitung per rune, bukan per byte
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, seharusnya %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"budi santoso", "budi santoso", 1},
+		{"budi santoso", "budi santosa", 1 - 1.0/12},
+		{"abc", "xyz", 0},
+		{"dwi", "dewi", 0.75},
+	}
+	for _, tt := range tests {
+		got := Similarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, seharusnya %v", tt.a, tt.b, got, tt.want)
+		}
+		if back := Similarity(tt.b, tt.a); back != got {
+			t.Errorf("Similarity tidak simetris untuk %q dan %q: %v vs %v", tt.a, tt.b, got, back)
+		}
+	}
+}
@@ -6,20 +6,30 @@ import (
 
 	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Jenis token
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeMFA     = "mfa" // challenge login dua langkah, bukan token akses
 )
 
 type TokenPair struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	ExpiresAt    int64  `json:"expires_at"` // unix timestamp
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresAt        int64  `json:"expires_at"`         // unix timestamp
+	RefreshExpiresAt int64  `json:"refresh_expires_at"` // unix timestamp
 }
 
 type tokenClaims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
-	Name   string `json:"name"`
-	Type   string `json:"type"` // "access" | "refresh"
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Name      string `json:"name"`
+	SessionID string `json:"sid"`
+	Type      string `json:"type"` // "access" | "refresh" | "mfa"
 	jwt.RegisteredClaims
 }
 
@@ -29,32 +39,47 @@ func GenerateTokenPair(claims model.JWTClaims, secret string, expireHours, refre
 	refreshExp := now.Add(time.Duration(refreshExpHours) * time.Hour)
 
 	// Access token
-	accessToken, err := generateToken(claims, secret, accessExp, "access")
+	accessToken, err := generateToken(claims, secret, accessExp, TokenTypeAccess)
 	if err != nil {
 		return nil, err
 	}
 
 	// Refresh token
-	refreshToken, err := generateToken(claims, secret, refreshExp, "refresh")
+	refreshToken, err := generateToken(claims, secret, refreshExp, TokenTypeRefresh)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    accessExp.Unix(),
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        accessExp.Unix(),
+		RefreshExpiresAt: refreshExp.Unix(),
 	}, nil
 }
 
+// GenerateMFAToken token challenge berumur pendek yang diterbitkan setelah password
+// benar, ditukar dengan token pair setelah kode 2FA diverifikasi
+func GenerateMFAToken(claims model.JWTClaims, secret string, ttl time.Duration) (string, int64, error) {
+	exp := time.Now().Add(ttl)
+	token, err := generateToken(claims, secret, exp, TokenTypeMFA)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, exp.Unix(), nil
+}
+
 func generateToken(claims model.JWTClaims, secret string, exp time.Time, tokenType string) (string, error) {
 	c := tokenClaims{
-		UserID: claims.UserID,
-		Email:  claims.Email,
-		Role:   claims.Role,
-		Name:   claims.Name,
-		Type:   tokenType,
+		UserID:    claims.UserID,
+		Email:     claims.Email,
+		Role:      claims.Role,
+		Name:      claims.Name,
+		SessionID: claims.SessionID,
+		Type:      tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
+			// ID unik agar setiap refresh token hasil rotasi berbeda
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(exp),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -82,9 +107,11 @@ func ValidateToken(tokenString, secret string) (*model.JWTClaims, error) {
 	}
 
 	return &model.JWTClaims{
-		UserID: claims.UserID,
-		Email:  claims.Email,
-		Role:   claims.Role,
-		Name:   claims.Name,
+		UserID:    claims.UserID,
+		Email:     claims.Email,
+		Role:      claims.Role,
+		Name:      claims.Name,
+		SessionID: claims.SessionID,
+		Type:      claims.Type,
 	}, nil
 }
\ No newline at end of file
@@ -19,6 +19,11 @@ type CertificatePDFData struct {
 	QRCodePNG         []byte // QR code sebagai bytes PNG
 	HeadmasterName    string
 	HeadmasterNIP     string
+
+	// Tanda tangan digital Ed25519 (lihat signature.go), disimpan di metadata PDF
+	SignedPayload  string
+	Signature      string
+	SignatureKeyID string
 }
 
 type PDFStudent struct {
@@ -39,179 +44,180 @@ type PDFAchievement struct {
 	Year            int
 }
 
-func GenerateCertificatePDF(data CertificatePDFData) ([]byte, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
+// Ukuran blok penutup (mm): QR code di kiri, tanda tangan di sisi kanan
+const (
+	certificateQRBlockWidth   = 45.0
+	certificateQRBlockHeight  = 41.0
+	certificateSignatureWidth = 65.0
+	certificateSignatureSpace = 18.0 // ruang tanda tangan basah
+)
+
+// GenerateCertificatePDF membuat surat keterangan prestasi sesuai tata letak template.
+// Tabel prestasi pindah halaman otomatis dengan judul kolom diulang, teks panjang
+// dibungkus, dan blok QR code serta tanda tangan tidak pernah terpotong antarhalaman.
+func GenerateCertificatePDF(data CertificatePDFData, layout CertificateLayout) ([]byte, error) {
+	texts, err := renderCertificateTexts(layout, data)
+	if err != nil {
+		return nil, err
+	}
+	labels := labelsFor(layout.Language)
+	color := layout.Color
+
+	pdf := newPDF()
 	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(fmt.Sprintf("%s %s", layout.Title, data.CertificateNumber), true)
+	pdf.SetAuthor(data.SchoolName, true)
+	if data.Signature != "" {
+		pdf.SetSubject(fmt.Sprintf("Ed25519 signed certificate (kid=%s)", data.SignatureKeyID), true)
+		pdf.SetKeywords(fmt.Sprintf("kid=%s payload=%s signature=%s",
+			data.SignatureKeyID, data.SignedPayload, data.Signature), true)
+	}
+	certificateFooter(pdf, data.IssuedAt, labels)
 	pdf.AddPage()
 
+	left, _, right, _ := pdf.GetMargins()
+	pageWidth, _ := pdf.GetPageSize()
+	contentWidth := pageWidth - left - right
+
 	// ─────────────────────────────────────────
 	// HEADER - Kop Surat
 	// ─────────────────────────────────────────
-	pdf.SetFont("Arial", "B", 14)
-	pdf.SetTextColor(0, 51, 102)
-	pdf.CellFormat(0, 8, data.SchoolName, "", 1, "C", false, 0, "")
-
-	pdf.SetFont("Arial", "", 10)
-	pdf.SetTextColor(0, 0, 0)
-	pdf.CellFormat(0, 5, data.SchoolAddress, "", 1, "C", false, 0, "")
-
-	// Garis pembatas
-	pdf.SetDrawColor(0, 51, 102)
-	pdf.SetLineWidth(0.8)
-	pdf.Line(20, pdf.GetY()+3, 190, pdf.GetY()+3)
-	pdf.Ln(8)
+	if len(layout.HeaderImage) == 0 || !drawCertificateHeaderImage(pdf, layout.HeaderImage, contentWidth) {
+		pdfLetterhead(pdf, data.SchoolName, data.SchoolAddress, color)
+	}
 
 	// ─────────────────────────────────────────
 	// JUDUL SURAT
 	// ─────────────────────────────────────────
-	pdf.SetFont("Arial", "B", 13)
+	pdf.SetFont(pdfFontFamily, "B", 13)
 	pdf.SetTextColor(0, 0, 0)
-	pdf.CellFormat(0, 8, "SURAT KETERANGAN PRESTASI", "", 1, "C", false, 0, "")
+	pdf.MultiCell(0, 8, layout.Title, "", "C", false)
 
-	pdf.SetFont("Arial", "", 10)
-	pdf.CellFormat(0, 5, fmt.Sprintf("Nomor: %s", data.CertificateNumber), "", 1, "C", false, 0, "")
+	pdf.SetFont(pdfFontFamily, "", 10)
+	pdf.CellFormat(0, 5, fmt.Sprintf(labels.number, data.CertificateNumber), "", 1, "C", false, 0, "")
 	pdf.Ln(5)
 
 	// ─────────────────────────────────────────
 	// PEMBUKA
 	// ─────────────────────────────────────────
-	pdf.SetFont("Arial", "", 10)
-	pdf.MultiCell(0, 6,
-		"Yang bertanda tangan di bawah ini, Kepala Sekolah menerangkan bahwa siswa berikut:",
-		"", "L", false)
-	pdf.Ln(3)
+	if texts.opening != "" {
+		pdf.MultiCell(0, 6, texts.opening, "", "L", false)
+		pdf.Ln(3)
+	}
 
 	// ─────────────────────────────────────────
 	// DATA SISWA
 	// ─────────────────────────────────────────
 	colLabel := 50.0
-	colValue := 120.0
+	colValue := contentWidth - colLabel - 5
 
 	dataRows := [][]string{
-		{"Nama Lengkap", data.Student.FullName},
-		{"NISN", data.Student.NISN},
-		{"Tempat, Tanggal Lahir", fmt.Sprintf("%s, %s", data.Student.BirthPlace, data.Student.BirthDate)},
-		{"Kelas", data.Student.Class},
+		{labels.fullName, data.Student.FullName},
+		{labels.nisn, data.Student.NISN},
+		{labels.birth, fmt.Sprintf("%s, %s", data.Student.BirthPlace, data.Student.BirthDate)},
+		{labels.class, data.Student.Class},
 	}
 
-	pdf.SetFont("Arial", "", 10)
 	for _, row := range dataRows {
-		pdf.CellFormat(colLabel, 6, row[0], "", 0, "L", false, 0, "")
-		pdf.CellFormat(5, 6, ":", "", 0, "C", false, 0, "")
-		pdf.CellFormat(colValue, 6, row[1], "", 1, "L", false, 0, "")
+		// Nilai panjang (mis. nama) dibungkus, label tetap di baris pertama
+		for l, line := range splitPDFText(pdf, row[1], colValue) {
+			label, sep := "", ""
+			if l == 0 {
+				label, sep = row[0], ":"
+			}
+			pdf.CellFormat(colLabel, 6, label, "", 0, "L", false, 0, "")
+			pdf.CellFormat(5, 6, sep, "", 0, "C", false, 0, "")
+			pdf.CellFormat(colValue, 6, line, "", 1, "L", false, 0, "")
+		}
 	}
 	pdf.Ln(4)
 
 	// ─────────────────────────────────────────
 	// KALIMAT TENGAH
 	// ─────────────────────────────────────────
-	pdf.MultiCell(0, 6,
-		"Telah meraih prestasi sebagai berikut:",
-		"", "L", false)
-	pdf.Ln(3)
+	if texts.body != "" {
+		pdf.MultiCell(0, 6, texts.body, "", "L", false)
+		pdf.Ln(3)
+	}
 
 	// ─────────────────────────────────────────
 	// TABEL PRESTASI
 	// ─────────────────────────────────────────
-	// Header tabel
-	pdf.SetFont("Arial", "B", 9)
-	pdf.SetFillColor(0, 51, 102)
-	pdf.SetTextColor(255, 255, 255)
-
-	headers := []string{"No", "Nama Lomba", "Penyelenggara", "Kategori", "Juara", "Tingkat", "Tahun"}
-	widths := []float64{8, 45, 35, 22, 18, 22, 15}
-
-	for i, h := range headers {
-		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	var totalWeight float64
+	for _, col := range layout.Columns {
+		totalWeight += col.Width
+	}
+	headers := make([]string, len(layout.Columns))
+	widths := make([]float64, len(layout.Columns))
+	aligns := make([]string, len(layout.Columns))
+	for i, col := range layout.Columns {
+		headers[i] = col.Label
+		if headers[i] == "" {
+			headers[i] = labels.columns[col.Field]
+		}
+		widths[i] = col.Width / totalWeight * contentWidth
+		aligns[i] = col.Align
 	}
-	pdf.Ln(-1)
-
-	// Isi tabel
-	pdf.SetFont("Arial", "", 8)
-	pdf.SetTextColor(0, 0, 0)
 
+	table := newPDFTable(pdf, headers, widths, aligns)
+	table.color = color
+	ensurePDFSpace(pdf, 20) // judul kolom tidak ditinggal sendirian di dasar halaman
+	table.header()
 	for i, a := range data.Achievements {
-		fillColor := i%2 == 0
-		if fillColor {
-			pdf.SetFillColor(240, 245, 255)
-		} else {
-			pdf.SetFillColor(255, 255, 255)
+		cells := make([]string, len(layout.Columns))
+		for c, col := range layout.Columns {
+			cells[c] = certificateCell(col.Field, a)
 		}
-
-		pdf.CellFormat(widths[0], 6, fmt.Sprintf("%d", a.No), "1", 0, "C", fillColor, 0, "")
-		pdf.CellFormat(widths[1], 6, truncate(a.CompetitionName, 30), "1", 0, "L", fillColor, 0, "")
-		pdf.CellFormat(widths[2], 6, truncate(a.Organizer, 22), "1", 0, "L", fillColor, 0, "")
-		pdf.CellFormat(widths[3], 6, truncate(a.Category, 14), "1", 0, "C", fillColor, 0, "")
-		pdf.CellFormat(widths[4], 6, a.Rank, "1", 0, "C", fillColor, 0, "")
-		pdf.CellFormat(widths[5], 6, truncate(a.Level, 14), "1", 0, "C", fillColor, 0, "")
-		pdf.CellFormat(widths[6], 6, fmt.Sprintf("%d", a.Year), "1", 0, "C", fillColor, 0, "")
-		pdf.Ln(-1)
+		table.wrappedRow(i, cells...)
 	}
 	pdf.Ln(5)
 
 	// ─────────────────────────────────────────
-	// PENUTUP
+	// PENUTUP, TANGGAL & TANDA TANGAN
 	// ─────────────────────────────────────────
-	pdf.SetFont("Arial", "", 10)
-	pdf.MultiCell(0, 6,
-		"Surat keterangan ini dibuat dengan sebenarnya untuk dapat dipergunakan sebagaimana mestinya.",
-		"", "L", false)
-	pdf.Ln(5)
+	// Dicetak utuh di halaman terakhir: pindah halaman dulu jika tidak muat
+	pdf.SetFont(pdfFontFamily, "", 10)
+	closingHeight := 0.0
+	if texts.closing != "" {
+		closingHeight = float64(len(splitPDFText(pdf, texts.closing, contentWidth-2)))*6 + 5
+	}
+	signatureWidth := min(certificateSignatureWidth, (contentWidth-certificateQRBlockWidth)/float64(max(1, len(texts.signatures))))
+	ensurePDFSpace(pdf, closingHeight+max(certificateQRBlockHeight, signatureBlockHeight(pdf, texts.signatures, signatureWidth)))
 
-	// ─────────────────────────────────────────
-	// TANGGAL & TANDA TANGAN
-	// ─────────────────────────────────────────
-	bulan := [...]string{"", "Januari", "Februari", "Maret", "April", "Mei", "Juni",
-		"Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+	if texts.closing != "" {
+		pdf.MultiCell(0, 6, texts.closing, "", "L", false)
+		pdf.Ln(5)
+	}
 
-	issuedDate := fmt.Sprintf("%d %s %d",
-		data.IssuedAt.Day(),
-		bulan[data.IssuedAt.Month()],
-		data.IssuedAt.Year(),
-	)
+	issuedDate := FormatCertificateDate(layout.Language, data.IssuedAt)
 
 	// Kolom kiri: QR code, Kolom kanan: TTD
 	currentY := pdf.GetY()
-	pageWidth := 170.0
 
 	// QR Code (kiri)
 	if len(data.QRCodePNG) > 0 {
-		pdf.SetFont("Arial", "", 8)
-		pdf.SetXY(20, currentY)
-		pdf.CellFormat(40, 5, "Scan untuk verifikasi:", "", 1, "L", false, 0, "")
+		pdf.SetFont(pdfFontFamily, "", 8)
+		pdf.SetXY(left, currentY)
+		pdf.CellFormat(40, 5, labels.scanToVerify, "", 1, "L", false, 0, "")
 
 		qrReader := bytes.NewReader(data.QRCodePNG)
 		pdf.RegisterImageOptionsReader("qrcode", gofpdf.ImageOptions{ImageType: "PNG"}, qrReader)
-		pdf.ImageOptions("qrcode", 20, currentY+6, 35, 35, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
-	}
-
-	// TTD (kanan)
-	signX := 20 + pageWidth - 65
-	pdf.SetXY(signX, currentY)
-	pdf.SetFont("Arial", "", 10)
-	pdf.CellFormat(65, 5, fmt.Sprintf("Diterbitkan, %s", issuedDate), "", 1, "C", false, 0, "")
-	pdf.SetX(signX)
-	pdf.CellFormat(65, 5, "Kepala Sekolah,", "", 1, "C", false, 0, "")
-	pdf.Ln(18) // ruang tanda tangan
-	pdf.SetX(signX)
-	pdf.SetFont("Arial", "B", 10)
-	pdf.CellFormat(65, 5, data.HeadmasterName, "", 1, "C", false, 0, "")
-	if data.HeadmasterNIP != "" {
-		pdf.SetX(signX)
-		pdf.SetFont("Arial", "", 9)
-		pdf.CellFormat(65, 5, fmt.Sprintf("NIP. %s", data.HeadmasterNIP), "", 1, "C", false, 0, "")
+		pdf.ImageOptions("qrcode", left, currentY+6, 35, 35, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
 	}
 
-	// ─────────────────────────────────────────
-	// FOOTER
-	// ─────────────────────────────────────────
-	pdf.SetY(-15)
-	pdf.SetFont("Arial", "I", 7)
-	pdf.SetTextColor(128, 128, 128)
-	pdf.CellFormat(0, 5,
-		fmt.Sprintf("Dokumen ini diterbitkan secara digital pada %s | Verifikasi keaslian dokumen dengan scan QR Code",
-			data.IssuedAt.Format("02/01/2006 15:04")),
-		"", 1, "C", false, 0, "")
+	// TTD (kanan), tanggal terbit di atas blok paling kanan
+	bottom := currentY + certificateQRBlockHeight
+	signX := pageWidth - right - signatureWidth*float64(len(texts.signatures))
+	for i, sig := range texts.signatures {
+		date := ""
+		if i == len(texts.signatures)-1 {
+			date = fmt.Sprintf(labels.issued, issuedDate)
+		}
+		bottom = max(bottom, drawSignatureBlock(pdf, signX, currentY, signatureWidth, date, sig))
+		signX += signatureWidth
+	}
+	pdf.SetXY(left, bottom)
 
 	// Output ke bytes
 	var buf bytes.Buffer
@@ -222,9 +228,81 @@ func GenerateCertificatePDF(data CertificatePDFData) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func truncate(s string, max int) string {
-	if len(s) <= max {
-		return s
+// drawCertificateHeaderImage kop surat bergambar selebar area isi (tinggi maks. 40 mm)
+func drawCertificateHeaderImage(pdf *gofpdf.Fpdf, jpegData []byte, width float64) bool {
+	const maxHeight = 40.0
+	left, top, _, _ := pdf.GetMargins()
+	opts := gofpdf.ImageOptions{ImageType: "JPG"}
+	info := pdf.RegisterImageOptionsReader("header-image", opts, bytes.NewReader(jpegData))
+	if !pdf.Ok() || info == nil || info.Width() <= 0 {
+		pdf.ClearError()
+		return false
+	}
+
+	height := min(maxHeight, width*info.Height()/info.Width())
+	if !drawPDFImage(pdf, "header-image", jpegData, left, top, width, height) {
+		return false
+	}
+	pdf.SetY(top + height + 6)
+	return true
+}
+
+// signatureBlockHeight tinggi blok tanda tangan tertinggi (mm)
+func signatureBlockHeight(pdf *gofpdf.Fpdf, signatures []CertificateSignature, width float64) float64 {
+	height := 0.0
+	for _, sig := range signatures {
+		lines := 1 + len(splitPDFText(pdf, sig.Title, width-2)) + len(splitPDFText(pdf, sig.Name, width-2))
+		if sig.NIP != "" {
+			lines++
+		}
+		height = max(height, float64(lines)*5+certificateSignatureSpace)
+	}
+	return height
+}
+
+// drawSignatureBlock mencetak satu blok tanda tangan mulai dari (x, y) dan
+// mengembalikan posisi Y di bawahnya
+func drawSignatureBlock(pdf *gofpdf.Fpdf, x, y, width float64, date string, sig CertificateSignature) float64 {
+	line := func(style string, size float64, text string) {
+		pdf.SetFont(pdfFontFamily, style, size)
+		for _, l := range splitPDFText(pdf, text, width-2) {
+			pdf.SetX(x)
+			pdf.CellFormat(width, 5, l, "", 1, "C", false, 0, "")
+		}
+	}
+
+	pdf.SetXY(x, y)
+	pdf.SetFont(pdfFontFamily, "", 10)
+	pdf.CellFormat(width, 5, date, "", 1, "C", false, 0, "")
+	line("", 10, sig.Title)
+	pdf.Ln(certificateSignatureSpace) // ruang tanda tangan
+	line("B", 10, sig.Name)
+	if sig.NIP != "" {
+		pdf.SetX(x)
+		pdf.SetFont(pdfFontFamily, "", 9)
+		pdf.CellFormat(width, 5, fitText(pdf, fmt.Sprintf("NIP. %s", sig.NIP), width-2), "", 1, "C", false, 0, "")
 	}
-	return s[:max-3] + "..."
-}
\ No newline at end of file
+	return pdf.GetY()
+}
+
+// certificateFooter keterangan penerbitan digital dan nomor halaman di setiap halaman
+func certificateFooter(pdf *gofpdf.Fpdf, issuedAt time.Time, labels certificateLabels) {
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(pdfFontFamily, "I", 7)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 4, fmt.Sprintf(labels.footer, issuedAt.Format("02/01/2006 15:04")),
+			"", 1, "C", false, 0, "")
+		pdf.CellFormat(0, 4, fmt.Sprintf(labels.page, pdf.PageNo()), "", 1, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+}
+
+var bulan = [...]string{"", "Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// FormatIndonesianDate format tanggal seperti "17 Agustus 2026"
+func FormatIndonesianDate(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), bulan[t.Month()], t.Year())
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// pdfFontFamily font yang dipakai semua dokumen PDF. Default font inti "Arial"
+// (hanya Latin-1); diganti ke TTF Unicode oleh LoadPDFFonts saat startup.
+var pdfFontFamily = "Arial"
+
+// unicodeFontFamily nama keluarga font TTF yang didaftarkan ke setiap dokumen
+const unicodeFontFamily = "DejaVu"
+
+// pdfFontFiles file TTF per gaya font (paket font-dejavu di Alpine/Debian)
+var pdfFontFiles = []struct {
+	style    string
+	file     string
+	fallback string // dipakai jika file tidak ada
+}{
+	{style: "", file: "DejaVuSans.ttf"},
+	{style: "B", file: "DejaVuSans-Bold.ttf"},
+	{style: "I", file: "DejaVuSans-Oblique.ttf", fallback: "DejaVuSans.ttf"},
+	{style: "BI", file: "DejaVuSans-BoldOblique.ttf", fallback: "DejaVuSans-Bold.ttf"},
+}
+
+// pdfUnicodeFonts isi file TTF per gaya, nil jika memakai font inti
+var pdfUnicodeFonts map[string][]byte
+
+// LoadPDFFonts membaca font TTF Unicode dari dir agar nama dengan karakter di luar
+// Latin-1 tampil benar di PDF. Dir kosong berarti tetap memakai font inti Arial.
+// Dipanggil sekali saat startup sebelum dokumen pertama dibuat.
+func LoadPDFFonts(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	fonts := make(map[string][]byte, len(pdfFontFiles))
+	for _, f := range pdfFontFiles {
+		data, err := os.ReadFile(filepath.Join(dir, f.file))
+		if os.IsNotExist(err) && f.fallback != "" {
+			data, err = os.ReadFile(filepath.Join(dir, f.fallback))
+		}
+		if err != nil {
+			return fmt.Errorf("gagal membaca font %s: %w", f.file, err)
+		}
+		fonts[f.style] = data
+	}
+
+	pdfUnicodeFonts = fonts
+	pdfFontFamily = unicodeFontFamily
+	return nil
+}
+
+// newPDF membuat dokumen A4 portrait dengan font Unicode terdaftar (jika dimuat)
+func newPDF() *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	for style, data := range pdfUnicodeFonts {
+		pdf.AddUTF8FontFromBytes(unicodeFontFamily, style, data)
+	}
+	return pdf
+}
@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PDFColor warna RGB (0-255)
+type PDFColor struct {
+	R, G, B int
+}
+
+// pdfPrimaryColor warna utama dokumen (biru tua)
+var pdfPrimaryColor = PDFColor{R: 0, G: 51, B: 102}
+
+// ParseHexColor membaca warna format "#RRGGBB"
+func ParseHexColor(s string) (PDFColor, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return PDFColor{}, fmt.Errorf("warna harus berformat #RRGGBB")
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return PDFColor{}, fmt.Errorf("warna harus berformat #RRGGBB")
+	}
+	return PDFColor{R: int(v >> 16 & 0xff), G: int(v >> 8 & 0xff), B: int(v & 0xff)}, nil
+}
+
+// pdfLetterhead kop surat: nama dan alamat sekolah dengan garis pembatas
+func pdfLetterhead(pdf *gofpdf.Fpdf, schoolName, schoolAddress string, color PDFColor) {
+	left, _, right, _ := pdf.GetMargins()
+	pageWidth, _ := pdf.GetPageSize()
+
+	pdf.SetFont(pdfFontFamily, "B", 14)
+	pdf.SetTextColor(color.R, color.G, color.B)
+	pdf.CellFormat(0, 8, schoolName, "", 1, "C", false, 0, "")
+
+	pdf.SetFont(pdfFontFamily, "", 10)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(0, 5, schoolAddress, "", 1, "C", false, 0, "")
+
+	pdf.SetDrawColor(color.R, color.G, color.B)
+	pdf.SetLineWidth(0.8)
+	pdf.Line(left, pdf.GetY()+3, pageWidth-right, pdf.GetY()+3)
+	pdf.SetLineWidth(0.2)
+	pdf.Ln(8)
+}
+
+// pdfPageFooter nomor halaman ("Halaman 1 dari 3") dan waktu cetak di setiap halaman
+func pdfPageFooter(pdf *gofpdf.Fpdf, printedAt time.Time) {
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(pdfFontFamily, "I", 7)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(90, 5, fmt.Sprintf("Dicetak %s", printedAt.Format("02/01/2006 15:04")),
+			"", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Halaman %d dari {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+}
+
+// pdfHeadmasterSignature blok tanggal dan tanda tangan kepala sekolah di sisi kanan
+func pdfHeadmasterSignature(pdf *gofpdf.Fpdf, date time.Time, name, nip string) {
+	ensurePDFSpace(pdf, 40)
+	_, _, right, _ := pdf.GetMargins()
+	pageWidth, _ := pdf.GetPageSize()
+	signX := pageWidth - right - 65
+
+	pdf.SetX(signX)
+	pdf.SetFont(pdfFontFamily, "", 10)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(65, 5, FormatIndonesianDate(date), "", 1, "C", false, 0, "")
+	pdf.SetX(signX)
+	pdf.CellFormat(65, 5, "Kepala Sekolah,", "", 1, "C", false, 0, "")
+	pdf.Ln(18)
+	pdf.SetX(signX)
+	pdf.SetFont(pdfFontFamily, "B", 10)
+	pdf.CellFormat(65, 5, name, "", 1, "C", false, 0, "")
+	if nip != "" {
+		pdf.SetX(signX)
+		pdf.SetFont(pdfFontFamily, "", 9)
+		pdf.CellFormat(65, 5, fmt.Sprintf("NIP. %s", nip), "", 1, "C", false, 0, "")
+	}
+}
+
+// pdfSectionTitle judul bagian dokumen
+func pdfSectionTitle(pdf *gofpdf.Fpdf, title string) {
+	pdf.SetFont(pdfFontFamily, "B", 11)
+	pdf.SetTextColor(pdfPrimaryColor.R, pdfPrimaryColor.G, pdfPrimaryColor.B)
+	pdf.CellFormat(0, 7, title, "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(1)
+}
+
+// pdfTable tabel sederhana dengan baris berwarna selang-seling. Teks yang lebih lebar
+// dari kolom dipotong, dan judul kolom diulang setiap kali tabel pindah halaman.
+type pdfTable struct {
+	pdf     *gofpdf.Fpdf
+	headers []string
+	widths  []float64
+	aligns  []string
+	color   PDFColor // warna latar judul kolom
+}
+
+const pdfTableRowHeight = 6.0
+
+func newPDFTable(pdf *gofpdf.Fpdf, headers []string, widths []float64, aligns []string) *pdfTable {
+	return &pdfTable{pdf: pdf, headers: headers, widths: widths, aligns: aligns, color: pdfPrimaryColor}
+}
+
+func (t *pdfTable) header() {
+	t.pdf.SetFont(pdfFontFamily, "B", 8)
+	t.pdf.SetFillColor(t.color.R, t.color.G, t.color.B)
+	t.pdf.SetTextColor(255, 255, 255)
+	for i, h := range t.headers {
+		t.pdf.CellFormat(t.widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	t.pdf.Ln(-1)
+	t.pdf.SetFont(pdfFontFamily, "", 8)
+	t.pdf.SetTextColor(0, 0, 0)
+}
+
+func (t *pdfTable) row(i int, cells ...string) {
+	if ensurePDFSpace(t.pdf, pdfTableRowHeight) {
+		t.header()
+	}
+
+	fill := i%2 == 0
+	if fill {
+		t.pdf.SetFillColor(240, 245, 255)
+	} else {
+		t.pdf.SetFillColor(255, 255, 255)
+	}
+	for c, text := range cells {
+		t.pdf.CellFormat(t.widths[c], pdfTableRowHeight, fitText(t.pdf, text, t.widths[c]-2),
+			"1", 0, t.aligns[c], fill, 0, "")
+	}
+	t.pdf.Ln(-1)
+}
+
+// wrappedRow seperti row, tetapi teks panjang dibungkus ke beberapa baris sehingga
+// tidak ada isi yang terpotong. Tinggi baris mengikuti sel dengan baris terbanyak.
+func (t *pdfTable) wrappedRow(i int, cells ...string) {
+	const lineHeight = 4.5
+
+	lines := make([][]string, len(cells))
+	maxLines := 1
+	for c, text := range cells {
+		lines[c] = splitPDFText(t.pdf, text, t.widths[c]-2)
+		maxLines = max(maxLines, len(lines[c]))
+	}
+	height := float64(maxLines)*lineHeight + 1.5
+
+	if ensurePDFSpace(t.pdf, height) {
+		t.header()
+	}
+
+	style := "D"
+	if i%2 == 0 {
+		t.pdf.SetFillColor(240, 245, 255)
+		style = "FD"
+	}
+	left, _, _, _ := t.pdf.GetMargins()
+	x, y := left, t.pdf.GetY()
+	for c := range cells {
+		t.pdf.Rect(x, y, t.widths[c], height, style)
+		for l, line := range lines[c] {
+			t.pdf.SetXY(x, y+0.75+float64(l)*lineHeight)
+			t.pdf.CellFormat(t.widths[c], lineHeight, line, "", 0, t.aligns[c], false, 0, "")
+		}
+		x += t.widths[c]
+	}
+	t.pdf.SetXY(left, y+height)
+}
+
+// ensurePDFSpace pindah ke halaman baru jika sisa halaman kurang dari height.
+// Mengembalikan true jika halaman baru ditambahkan.
+func ensurePDFSpace(pdf *gofpdf.Fpdf, height float64) bool {
+	_, pageHeight := pdf.GetPageSize()
+	_, bottom := pdf.GetAutoPageBreak()
+	if pdf.GetY()+height <= pageHeight-bottom {
+		return false
+	}
+	pdf.AddPage()
+	return true
+}
+
+// fitText memotong teks (dengan "...") agar muat di lebar kolom pada font aktif
+func fitText(pdf *gofpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// splitPDFText membungkus teks per kata agar muat di lebar kolom pada font aktif.
+// Kata yang lebih lebar dari kolom dipecah per karakter. Berbeda dengan SplitText
+// milik gofpdf, aman untuk karakter di luar Latin-1 saat memakai font inti.
+func splitPDFText(pdf *gofpdf.Fpdf, s string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if pdf.GetStringWidth(candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && pdf.GetStringWidth(line+string(r)) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func newTestPDF() *gofpdf.Fpdf {
+	pdf := newPDF()
+	pdf.AddPage()
+	pdf.SetFont(pdfFontFamily, "", 10)
+	return pdf
+}
+
+func TestSplitPDFText(t *testing.T) {
+	pdf := newTestPDF()
+	width := pdf.GetStringWidth("Olimpiade Sains")
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "muat satu baris", text: "Olimpiade", want: []string{"Olimpiade"}},
+		{name: "kosong tetap satu baris", text: "", want: []string{""}},
+		{name: "dibungkus per kata", text: "Olimpiade Sains Nasional Matematika", want: []string{"Olimpiade Sains", "Nasional", "Matematika"}},
+		{name: "spasi berlebih dirapikan", text: "  Olimpiade   Sains  ", want: []string{"Olimpiade Sains"}},
+		{name: "baris baru dipertahankan", text: "Juara 1\nNasional", want: []string{"Juara 1", "Nasional"}},
+		{name: "paragraf kosong dipertahankan", text: "Juara 1\n\nNasional", want: []string{"Juara 1", "", "Nasional"}},
+	}
+	for _, tt := range tests {
+		got := splitPDFText(pdf, tt.text, width)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: splitPDFText(%q) = %q, seharusnya %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+
+	// Kata yang lebih panjang dari kolom dipotong per karakter tanpa kehilangan isi
+	long := strings.Repeat("Penyelenggara", 4)
+	lines := splitPDFText(pdf, long, width)
+	if len(lines) < 2 || strings.Join(lines, "") != long {
+		t.Fatalf("kata panjang = %q", lines)
+	}
+	for _, line := range lines {
+		if pdf.GetStringWidth(line) > width {
+			t.Errorf("baris %q lebih lebar dari kolom", line)
+		}
+	}
+}
+
+func TestFitText(t *testing.T) {
+	pdf := newTestPDF()
+	stringWidth := pdf.GetStringWidth
+
+	tests := []struct {
+		name  string
+		text  string
+		width float64
+		want  string
+	}{
+		{name: "muat tanpa dipotong", text: "Budi Santoso", width: stringWidth("Budi Santoso"), want: "Budi Santoso"},
+		{name: "dipotong dengan elipsis", text: "Budi Santoso", width: stringWidth("Budi S..."), want: "Budi S..."},
+		{name: "kolom terlalu sempit", text: "Budi", width: 0, want: "..."},
+		{name: "dipotong per rune", text: "Ñañá Ñoño", width: stringWidth("Ñañá..."), want: "Ñañá..."},
+	}
+	for _, tt := range tests {
+		got := fitText(pdf, tt.text, tt.width)
+		if got != tt.want {
+			t.Errorf("%s: fitText(%q) = %q, seharusnya %q", tt.name, tt.text, got, tt.want)
+		}
+		if tt.width > 0 && pdf.GetStringWidth(got) > tt.width {
+			t.Errorf("%s: hasil %q lebih lebar dari kolom", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,527 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+)
+
+// PDFSigner menyematkan tanda tangan PDF (CMS detached, PAdES-B-B) ke dokumen
+// menggunakan incremental update, sehingga isi PDF asli tidak berubah.
+type PDFSigner struct {
+	cert     *x509.Certificate
+	chain    []*x509.Certificate
+	key      crypto.Signer
+	reason   string
+	location string
+	contact  string
+}
+
+// ukuran placeholder /Contents dalam byte (hex = 2x)
+const pdfSignatureSize = 8192
+
+var (
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttrContentType      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningCertV2    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	asn1Null                = asn1.RawValue{Tag: asn1.TagNull}
+	pdfStartXrefRegex       = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	pdfTrailerSizeRegex     = regexp.MustCompile(`/Size\s+(\d+)`)
+	pdfTrailerRootRegex     = regexp.MustCompile(`/Root\s+(\d+)\s+0\s+R`)
+	pdfTrailerInfoRegex     = regexp.MustCompile(`/Info\s+(\d+)\s+0\s+R`)
+	pdfPagesRefRegex        = regexp.MustCompile(`/Pages\s+(\d+)\s+0\s+R`)
+	pdfKidsRegex            = regexp.MustCompile(`/Kids\s*\[([^\]]*)\]`)
+	pdfObjRefRegex          = regexp.MustCompile(`(\d+)\s+0\s+R`)
+	pdfXrefSubsectionRegex  = regexp.MustCompile(`^(\d+)\s+(\d+)$`)
+	errPDFStructureNotFound = errors.New("struktur PDF tidak dikenali")
+)
+
+// NewPDFSigner memuat sertifikat X.509 dan private key dari config.
+// Mengembalikan nil tanpa error jika penandatanganan PDF belum dikonfigurasi.
+func NewPDFSigner(cfg *config.PDFSigningConfig) (*PDFSigner, error) {
+	if cfg.CertPath == "" || cfg.KeyPath == "" {
+		return nil, nil
+	}
+
+	certPEM, err := os.ReadFile(cfg.CertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing certificate: %w", err)
+	}
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("signing certificate file contains no certificate")
+	}
+
+	keyPEM, err := os.ReadFile(cfg.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PDFSigner{
+		cert:     certs[0],
+		chain:    certs[1:],
+		key:      key,
+		reason:   cfg.Reason,
+		location: cfg.Location,
+		contact:  cfg.ContactInfo,
+	}, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case *ecdsa.PrivateKey:
+			return k, nil
+		}
+		return nil, errors.New("signing key must be RSA or ECDSA")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported signing key format")
+}
+
+// Sign menambahkan field tanda tangan tak terlihat ke halaman terakhir dan
+// menyematkan CMS SignedData atas seluruh byte dokumen (kecuali /Contents).
+func (s *PDFSigner) Sign(pdf []byte, signingTime time.Time) ([]byte, error) {
+	doc, err := parsePDFTrailer(pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := doc.objectDict(doc.root)
+	if err != nil {
+		return nil, err
+	}
+	pagesMatch := pdfPagesRefRegex.FindStringSubmatch(catalog)
+	if pagesMatch == nil {
+		return nil, errPDFStructureNotFound
+	}
+	pagesNum, _ := strconv.Atoi(pagesMatch[1])
+	pages, err := doc.objectDict(pagesNum)
+	if err != nil {
+		return nil, err
+	}
+	kids := pdfKidsRegex.FindStringSubmatch(pages)
+	if kids == nil {
+		return nil, errPDFStructureNotFound
+	}
+	refs := pdfObjRefRegex.FindAllStringSubmatch(kids[1], -1)
+	if len(refs) == 0 {
+		return nil, errPDFStructureNotFound
+	}
+	pageNum, _ := strconv.Atoi(refs[len(refs)-1][1])
+	page, err := doc.objectDict(pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	sigNum := doc.size
+	widgetNum := doc.size + 1
+	formNum := doc.size + 2
+
+	// Halaman terakhir & katalog ditulis ulang dengan referensi ke field tanda tangan
+	if strings.Contains(page, "/Annots [") {
+		page = strings.Replace(page, "/Annots [", fmt.Sprintf("/Annots [%d 0 R ", widgetNum), 1)
+	} else {
+		page = insertBeforeDictEnd(page, fmt.Sprintf("/Annots [%d 0 R]", widgetNum))
+	}
+	catalog = insertBeforeDictEnd(catalog, fmt.Sprintf("/AcroForm %d 0 R", formNum))
+
+	var buf bytes.Buffer
+	buf.Write(pdf)
+	if pdf[len(pdf)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	offsets := map[int]int{}
+
+	// Objek nilai tanda tangan dengan placeholder ByteRange & Contents
+	offsets[sigNum] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<</Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached\n", sigNum)
+	byteRangeOffset := buf.Len() + len("/ByteRange [")
+	fmt.Fprintf(&buf, "/ByteRange [%s]\n", strings.Repeat(" ", 43))
+	buf.WriteString("/Contents ")
+	contentsStart := buf.Len()
+	buf.WriteString("<" + strings.Repeat("0", pdfSignatureSize*2) + ">")
+	contentsEnd := buf.Len()
+	fmt.Fprintf(&buf, "\n/M %s", pdfString(pdfDate(signingTime)))
+	if s.reason != "" {
+		fmt.Fprintf(&buf, "\n/Reason %s", pdfString(s.reason))
+	}
+	if s.location != "" {
+		fmt.Fprintf(&buf, "\n/Location %s", pdfString(s.location))
+	}
+	if s.contact != "" {
+		fmt.Fprintf(&buf, "\n/ContactInfo %s", pdfString(s.contact))
+	}
+	fmt.Fprintf(&buf, "\n/Name %s>>\nendobj\n", pdfString(s.cert.Subject.CommonName))
+
+	offsets[widgetNum] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<</Type /Annot /Subtype /Widget /FT /Sig /T (Signature1) /V %d 0 R /F 132 /Rect [0 0 0 0] /P %d 0 R>>\nendobj\n",
+		widgetNum, sigNum, pageNum)
+
+	offsets[formNum] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n<</Fields [%d 0 R] /SigFlags 3>>\nendobj\n", formNum, widgetNum)
+
+	offsets[pageNum] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", pageNum, page)
+
+	offsets[doc.root] = buf.Len()
+	fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", doc.root, catalog)
+
+	// Xref incremental: satu subsection per objek
+	nums := make([]int, 0, len(offsets))
+	for n := range offsets {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	xrefOffset := buf.Len()
+	buf.WriteString("xref\n")
+	for _, n := range nums {
+		fmt.Fprintf(&buf, "%d 1\n%010d 00000 n \n", n, offsets[n])
+	}
+	buf.WriteString("trailer\n<<\n")
+	fmt.Fprintf(&buf, "/Size %d\n/Root %d 0 R\n", formNum+1, doc.root)
+	if doc.info > 0 {
+		fmt.Fprintf(&buf, "/Info %d 0 R\n", doc.info)
+	}
+	fmt.Fprintf(&buf, "/Prev %d\n>>\nstartxref\n%d\n%%%%EOF\n", doc.startXref, xrefOffset)
+
+	out := buf.Bytes()
+
+	byteRange := fmt.Sprintf("0 %d %d %d", contentsStart, contentsEnd, len(out)-contentsEnd)
+	if len(byteRange) > 43 {
+		return nil, errors.New("PDF terlalu besar untuk ditandatangani")
+	}
+	copy(out[byteRangeOffset:], byteRange)
+
+	digest := sha256.New()
+	digest.Write(out[:contentsStart])
+	digest.Write(out[contentsEnd:])
+
+	cms, err := s.buildCMS(digest.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	if len(cms) > pdfSignatureSize {
+		return nil, errors.New("ukuran tanda tangan melebihi placeholder")
+	}
+	copy(out[contentsStart+1:], hex.EncodeToString(cms))
+
+	return out, nil
+}
+
+// ─────────────────────────────────────────
+// CMS SignedData (RFC 5652) + ESS signing-certificate-v2 (PAdES-B-B)
+// ─────────────────────────────────────────
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type issuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerial
+	DigestAlgorithm    algorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm algorithmIdentifier
+	Signature          []byte
+}
+
+type encapContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      asn1.RawValue
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+func (s *PDFSigner) buildCMS(messageDigest []byte) ([]byte, error) {
+	sha256Alg := algorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1Null}
+
+	certHash := sha256.Sum256(s.cert.Raw)
+	attrs := []struct {
+		oid   asn1.ObjectIdentifier
+		value interface{}
+	}{
+		{oidAttrContentType, oidData},
+		{oidAttrMessageDigest, messageDigest},
+		{oidAttrSigningCertV2, signingCertificateV2{Certs: []essCertIDv2{{CertHash: certHash[:]}}}},
+	}
+
+	encodedAttrs := make([][]byte, 0, len(attrs))
+	for _, a := range attrs {
+		value, err := asn1.Marshal(a.value)
+		if err != nil {
+			return nil, err
+		}
+		attr, err := asn1.Marshal(cmsAttribute{
+			Type:   a.oid,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encodedAttrs = append(encodedAttrs, attr)
+	}
+	attrSet := derSetOf(encodedAttrs)
+
+	// Tanda tangan dihitung atas DER SET OF, lalu disimpan sebagai [0] IMPLICIT
+	signedAttrs, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrSet})
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(signedAttrs)
+
+	var sigAlg algorithmIdentifier
+	switch s.key.(type) {
+	case *rsa.PrivateKey:
+		sigAlg = algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1Null}
+	case *ecdsa.PrivateKey:
+		sigAlg = algorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	default:
+		return nil, errors.New("unsupported signing key")
+	}
+
+	signature, err := s.key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign PDF: %w", err)
+	}
+
+	signer, err := asn1.Marshal(signerInfo{
+		Version: 1,
+		SID: issuerAndSerial{
+			Issuer:       asn1.RawValue{FullBytes: s.cert.RawIssuer},
+			SerialNumber: s.cert.SerialNumber,
+		},
+		DigestAlgorithm:    sha256Alg,
+		SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrSet},
+		SignatureAlgorithm: sigAlg,
+		Signature:          signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digestAlg, err := asn1.Marshal(sha256Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []byte
+	certs = append(certs, s.cert.Raw...)
+	for _, c := range s.chain {
+		certs = append(certs, c.Raw...)
+	}
+
+	sd, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: digestAlg},
+		EncapContentInfo: encapContentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: signer},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+// derSetOf mengurutkan elemen sesuai aturan DER untuk SET OF
+func derSetOf(elements [][]byte) []byte {
+	sort.Slice(elements, func(i, j int) bool {
+		return bytes.Compare(elements[i], elements[j]) < 0
+	})
+	return bytes.Join(elements, nil)
+}
+
+// ─────────────────────────────────────────
+// Parser minimal untuk PDF keluaran gofpdf (xref table klasik)
+// ─────────────────────────────────────────
+
+type pdfDocument struct {
+	data      []byte
+	startXref int
+	size      int
+	root      int
+	info      int
+	offsets   map[int]int
+}
+
+func parsePDFTrailer(data []byte) (*pdfDocument, error) {
+	m := pdfStartXrefRegex.FindSubmatch(data)
+	if m == nil {
+		return nil, errPDFStructureNotFound
+	}
+	startXref, _ := strconv.Atoi(string(m[1]))
+	if startXref <= 0 || startXref >= len(data) {
+		return nil, errPDFStructureNotFound
+	}
+
+	section := string(data[startXref:])
+	if !strings.HasPrefix(section, "xref") {
+		return nil, errors.New("PDF dengan xref stream belum didukung")
+	}
+	trailerIdx := strings.Index(section, "trailer")
+	if trailerIdx < 0 {
+		return nil, errPDFStructureNotFound
+	}
+	trailer := section[trailerIdx:]
+
+	doc := &pdfDocument{data: data, startXref: startXref, offsets: map[int]int{}}
+	if sm := pdfTrailerSizeRegex.FindStringSubmatch(trailer); sm != nil {
+		doc.size, _ = strconv.Atoi(sm[1])
+	}
+	if rm := pdfTrailerRootRegex.FindStringSubmatch(trailer); rm != nil {
+		doc.root, _ = strconv.Atoi(rm[1])
+	}
+	if im := pdfTrailerInfoRegex.FindStringSubmatch(trailer); im != nil {
+		doc.info, _ = strconv.Atoi(im[1])
+	}
+	if doc.size == 0 || doc.root == 0 {
+		return nil, errPDFStructureNotFound
+	}
+
+	lines := strings.Split(strings.ReplaceAll(section[len("xref"):trailerIdx], "\r", ""), "\n")
+	current := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if sm := pdfXrefSubsectionRegex.FindStringSubmatch(line); sm != nil {
+			current, _ = strconv.Atoi(sm[1])
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "n" {
+			offset, _ := strconv.Atoi(fields[0])
+			doc.offsets[current] = offset
+		}
+		current++
+	}
+
+	return doc, nil
+}
+
+// objectDict mengembalikan dictionary "<<...>>" dari objek tidak langsung
+func (d *pdfDocument) objectDict(num int) (string, error) {
+	offset, ok := d.offsets[num]
+	if !ok || offset >= len(d.data) {
+		return "", fmt.Errorf("objek PDF %d tidak ditemukan", num)
+	}
+	body := string(d.data[offset:])
+	end := strings.Index(body, "endobj")
+	if end < 0 {
+		return "", errPDFStructureNotFound
+	}
+	body = body[:end]
+
+	start := strings.Index(body, "<<")
+	stop := strings.LastIndex(body, ">>")
+	if start < 0 || stop < start || strings.Contains(body, "stream") {
+		return "", fmt.Errorf("objek PDF %d bukan dictionary", num)
+	}
+	return body[start : stop+2], nil
+}
+
+func insertBeforeDictEnd(dict, entry string) string {
+	idx := strings.LastIndex(dict, ">>")
+	return dict[:idx] + "\n" + entry + "\n" + dict[idx:]
+}
+
+func pdfString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	return "(" + r.Replace(s) + ")"
+}
+
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("D:%s%s%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, (offset%3600)/60)
+}
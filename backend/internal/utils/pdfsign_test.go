@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+)
+
+var testByteRangeRegex = regexp.MustCompile(`/ByteRange \[(\d+) (\d+) (\d+) (\d+)\s*\]`)
+
+func TestPDFSignerSignCertificate(t *testing.T) {
+	keys := map[string]func() (crypto.Signer, error){
+		"rsa": func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 2048) },
+		"ecdsa": func() (crypto.Signer, error) {
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		},
+	}
+	for name, newKey := range keys {
+		t.Run(name, func(t *testing.T) {
+			key, err := newKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			certPath, keyPath := writeTestSigningCert(t, key)
+			signer, err := NewPDFSigner(&config.PDFSigningConfig{
+				CertPath: certPath, KeyPath: keyPath, Reason: "Surat keterangan prestasi",
+			})
+			if err != nil {
+				t.Fatalf("NewPDFSigner: %v", err)
+			}
+
+			pdf := generateTestCertificatePDF(t)
+			signed, err := signer.Sign(pdf, time.Now())
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			if !bytes.HasPrefix(signed, pdf) {
+				t.Fatal("isi PDF asli berubah, seharusnya incremental update")
+			}
+
+			content, cms := checkByteRange(t, signed)
+			checkCMS(t, signer.cert, content, cms)
+			verifyWithOpenSSL(t, certPath, content, cms)
+		})
+	}
+}
+
+// checkByteRange memastikan ByteRange mencakup seluruh file kecuali nilai hex
+// /Contents, lalu mengembalikan byte yang ditandatangani beserta CMS-nya
+func checkByteRange(t *testing.T, signed []byte) ([]byte, []byte) {
+	t.Helper()
+
+	m := testByteRangeRegex.FindSubmatch(signed)
+	if m == nil {
+		t.Fatal("ByteRange tidak ditemukan")
+	}
+	var r [4]int
+	for i := range r {
+		r[i], _ = strconv.Atoi(string(m[i+1]))
+	}
+	if r[0] != 0 {
+		t.Fatalf("ByteRange harus mulai dari 0, dapat %d", r[0])
+	}
+	if r[2]+r[3] != len(signed) {
+		t.Fatalf("ByteRange berakhir di %d, panjang file %d", r[2]+r[3], len(signed))
+	}
+	gapStart, gapEnd := r[1], r[2]
+	if signed[gapStart] != '<' || signed[gapEnd-1] != '>' {
+		t.Fatalf("celah ByteRange bukan string hex /Contents: %q...%q", signed[gapStart], signed[gapEnd-1])
+	}
+	if !bytes.HasSuffix(signed[:gapStart], []byte("/Contents ")) {
+		t.Fatal("celah ByteRange tidak tepat setelah /Contents")
+	}
+
+	raw, err := hex.DecodeString(string(signed[gapStart+1 : gapEnd-1]))
+	if err != nil {
+		t.Fatalf("/Contents bukan hex: %v", err)
+	}
+	// Placeholder diisi nol setelah DER; ambil satu elemen DER saja
+	var outer asn1.RawValue
+	if _, err := asn1.Unmarshal(raw, &outer); err != nil {
+		t.Fatalf("/Contents bukan DER: %v", err)
+	}
+
+	content := append(append([]byte{}, signed[:gapStart]...), signed[gapEnd:]...)
+	return content, outer.FullBytes
+}
+
+// checkCMS memverifikasi SignedData tanpa alat luar: message digest cocok dengan
+// isi ByteRange dan tanda tangan atas signed attributes valid untuk sertifikat
+func checkCMS(t *testing.T, cert *x509.Certificate, content, cms []byte) {
+	t.Helper()
+
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(cms, &ci); err != nil {
+		t.Fatalf("ContentInfo: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		t.Fatalf("content type %v, seharusnya signedData", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		t.Fatalf("SignedData: %v", err)
+	}
+	embedded, err := x509.ParseCertificate(sd.Certificates.Bytes)
+	if err != nil || !embedded.Equal(cert) {
+		t.Fatalf("sertifikat penanda tangan tidak tersemat: %v", err)
+	}
+	var si signerInfo
+	if _, err := asn1.Unmarshal(sd.SignerInfos.Bytes, &si); err != nil {
+		t.Fatalf("SignerInfo: %v", err)
+	}
+
+	var digest []byte
+	for rest := si.SignedAttrs.Bytes; len(rest) > 0; {
+		var attr cmsAttribute
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			t.Fatalf("signed attribute: %v", err)
+		}
+		if attr.Type.Equal(oidAttrMessageDigest) {
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+				t.Fatalf("messageDigest: %v", err)
+			}
+		}
+	}
+	want := sha256.Sum256(content)
+	if !bytes.Equal(digest, want[:]) {
+		t.Fatal("messageDigest tidak cocok dengan isi ByteRange")
+	}
+
+	// Tanda tangan dihitung atas signed attributes dengan tag SET, bukan [0]
+	attrs, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: si.SignedAttrs.Bytes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alg := x509.SHA256WithRSA
+	if _, ok := cert.PublicKey.(*ecdsa.PublicKey); ok {
+		alg = x509.ECDSAWithSHA256
+	}
+	if err := cert.CheckSignature(alg, attrs, si.Signature); err != nil {
+		t.Fatalf("tanda tangan CMS tidak valid: %v", err)
+	}
+}
+
+// verifyWithOpenSSL memverifikasi ulang dengan openssl cms jika tersedia
+func verifyWithOpenSSL(t *testing.T, certPath string, content, cms []byte) {
+	t.Helper()
+
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Log("openssl tidak tersedia, verifikasi openssl cms dilewati")
+		return
+	}
+	dir := t.TempDir()
+	sigPath := filepath.Join(dir, "signature.der")
+	contentPath := filepath.Join(dir, "content.bin")
+	if err := os.WriteFile(sigPath, cms, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(contentPath, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(openssl, "cms", "-verify", "-binary", "-inform", "DER",
+		"-in", sigPath, "-content", contentPath, "-CAfile", certPath, "-purpose", "any",
+		"-out", os.DevNull).CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl cms -verify gagal: %v\n%s", err, out)
+	}
+}
+
+func writeTestSigningCert(t *testing.T, key crypto.Signer) (string, string) {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Kepala Sekolah Uji"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func generateTestCertificatePDF(t *testing.T) []byte {
+	t.Helper()
+
+	qr, err := GenerateQRCodePNG("https://example.com/verify/abc", 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	color, _ := ParseHexColor("#1e3a8a")
+	layout := CertificateLayout{
+		Language:    "id",
+		Title:       "SURAT KETERANGAN PRESTASI",
+		Color:       color,
+		OpeningText: "Yang bertanda tangan di bawah ini menerangkan bahwa:",
+		BodyText:    "telah meraih prestasi sebagai berikut:",
+		ClosingText: "Demikian surat keterangan ini dibuat untuk dipergunakan sebagaimana mestinya.",
+		Columns: []CertificateColumn{
+			{Field: "no", Label: "No", Width: 1, Align: "C"},
+			{Field: "competition_name", Label: "Nama Lomba", Width: 5, Align: "L"},
+			{Field: "rank", Label: "Juara", Width: 2, Align: "C"},
+			{Field: "year", Label: "Tahun", Width: 2, Align: "C"},
+		},
+		Signatures: []CertificateSignature{{Title: "Kepala Sekolah", Name: "{{.School.HeadmasterName}}", NIP: "{{.School.HeadmasterNIP}}"}},
+	}
+	pdf, err := GenerateCertificatePDF(CertificatePDFData{
+		CertificateNumber: "001/SKP/2026",
+		IssuedAt:          time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		SchoolName:        "SMA Negeri 1",
+		SchoolAddress:     "Jl. Pendidikan No. 1",
+		Student:           PDFStudent{FullName: "Budi Santoso", NISN: "0012345678", BirthPlace: "Bandung", BirthDate: "1 Januari 2010", Class: "XII IPA 1"},
+		Achievements: []PDFAchievement{
+			{No: 1, CompetitionName: "Olimpiade Sains Nasional", Organizer: "Kemendikbud", Category: "Akademik", Rank: "Juara 1", Level: "Nasional", Year: 2026},
+		},
+		QRCodePNG:      qr,
+		HeadmasterName: "Dra. Siti Aminah",
+		HeadmasterNIP:  "196501011990032001",
+	}, layout)
+	if err != nil {
+		t.Fatalf("GenerateCertificatePDF: %v", err)
+	}
+	return pdf
+}
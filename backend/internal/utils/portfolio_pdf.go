@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Ukuran foto siswa dan thumbnail lampiran di portofolio (mm)
+const (
+	portfolioPhotoWidth  = 30.0
+	portfolioPhotoHeight = 40.0
+	portfolioThumbWidth  = 40.0
+	portfolioThumbHeight = 30.0
+)
+
+type PortfolioPDFData struct {
+	SchoolName     string
+	SchoolAddress  string
+	GeneratedAt    time.Time
+	HeadmasterName string
+	HeadmasterNIP  string
+
+	Student      PDFStudent
+	YearEntry    string
+	YearGraduate string
+	PhotoJPEG    []byte // opsional, hasil MakeThumbnail
+	Achievements []PortfolioAchievement
+}
+
+type PortfolioAchievement struct {
+	No              int
+	CompetitionName string
+	Organizer       string
+	Category        string
+	Level           string
+	Rank            string
+	Year            int
+	Team            string // mis. "Tim Robotik (Kapten)", kosong untuk prestasi perorangan
+	Description     string
+	Thumbnails      []PDFThumbnail
+}
+
+// PDFThumbnail gambar lampiran yang sudah dikecilkan (JPEG)
+type PDFThumbnail struct {
+	Label string
+	JPEG  []byte
+}
+
+// GeneratePortfolioPDF membuat portofolio prestasi siswa bergaya transkrip: identitas
+// dan foto siswa, tabel seluruh prestasi lintas tahun, lalu rincian tiap prestasi
+// beserta deskripsi dan thumbnail lampiran gambar. Dokumen bisa lebih dari satu
+// halaman; judul tabel diulang di setiap halaman dan tidak ada teks yang dipotong.
+func GeneratePortfolioPDF(data PortfolioPDFData) ([]byte, error) {
+	pdf := newPDF()
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(fmt.Sprintf("Portofolio Prestasi %s", data.Student.FullName), true)
+	pdf.SetAuthor(data.SchoolName, true)
+	pdfPageFooter(pdf, data.GeneratedAt)
+	pdf.AddPage()
+
+	pdfLetterhead(pdf, data.SchoolName, data.SchoolAddress, pdfPrimaryColor)
+
+	pdf.SetFont(pdfFontFamily, "B", 13)
+	pdf.CellFormat(0, 7, "PORTOFOLIO PRESTASI SISWA", "", 1, "C", false, 0, "")
+	pdf.Ln(5)
+
+	// ─────────────────────────────────────────
+	// IDENTITAS SISWA & FOTO
+	// ─────────────────────────────────────────
+	left, _, right, _ := pdf.GetMargins()
+	pageWidth, _ := pdf.GetPageSize()
+	identityY := pdf.GetY()
+
+	photoDrawn := false
+	if len(data.PhotoJPEG) > 0 {
+		photoDrawn = drawPDFImage(pdf, "student-photo", data.PhotoJPEG,
+			pageWidth-right-portfolioPhotoWidth, identityY, portfolioPhotoWidth, portfolioPhotoHeight)
+	}
+
+	rows := [][]string{
+		{"Nama Lengkap", data.Student.FullName},
+		{"NISN", data.Student.NISN},
+		{"Tempat, Tanggal Lahir", fmt.Sprintf("%s, %s", data.Student.BirthPlace, data.Student.BirthDate)},
+		{"Kelas", data.Student.Class},
+		{"Tahun Masuk / Lulus", fmt.Sprintf("%s / %s", data.YearEntry, data.YearGraduate)},
+		{"Jumlah Prestasi", fmt.Sprintf("%d", len(data.Achievements))},
+	}
+	valueWidth := pageWidth - left - right - 50 - 5
+	if photoDrawn {
+		valueWidth -= portfolioPhotoWidth + 5
+	}
+	pdf.SetFont(pdfFontFamily, "", 10)
+	for _, row := range rows {
+		pdf.CellFormat(50, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(5, 6, ":", "", 0, "C", false, 0, "")
+		pdf.CellFormat(valueWidth, 6, fitText(pdf, row[1], valueWidth), "", 1, "L", false, 0, "")
+	}
+	if photoDrawn {
+		pdf.SetY(max(pdf.GetY(), identityY+portfolioPhotoHeight))
+	}
+	pdf.Ln(6)
+
+	// ─────────────────────────────────────────
+	// TABEL PRESTASI
+	// ─────────────────────────────────────────
+	pdfSectionTitle(pdf, "Daftar Prestasi")
+	if len(data.Achievements) == 0 {
+		pdf.SetFont(pdfFontFamily, "I", 10)
+		pdf.CellFormat(0, 6, "Belum ada prestasi yang terverifikasi.", "", 1, "L", false, 0, "")
+	} else {
+		table := newPDFTable(pdf,
+			[]string{"No", "Tahun", "Nama Lomba", "Penyelenggara", "Tingkat", "Juara", "Kategori"},
+			[]float64{8, 12, 52, 38, 24, 22, 24},
+			[]string{"C", "C", "L", "L", "C", "C", "C"})
+		table.header()
+		for i, a := range data.Achievements {
+			name := a.CompetitionName
+			if a.Team != "" {
+				name += " - " + a.Team
+			}
+			table.wrappedRow(i, fmt.Sprintf("%d", a.No), fmt.Sprintf("%d", a.Year), name,
+				a.Organizer, a.Level, a.Rank, a.Category)
+		}
+	}
+	pdf.Ln(6)
+
+	// ─────────────────────────────────────────
+	// RINCIAN & BUKTI PRESTASI
+	// ─────────────────────────────────────────
+	if hasPortfolioDetails(data.Achievements) {
+		ensurePDFSpace(pdf, 30)
+		pdfSectionTitle(pdf, "Rincian Prestasi")
+
+		for _, a := range data.Achievements {
+			if a.Description == "" && len(a.Thumbnails) == 0 {
+				continue
+			}
+			ensurePDFSpace(pdf, 20)
+			pdf.SetFont(pdfFontFamily, "B", 10)
+			pdf.MultiCell(0, 5, fmt.Sprintf("%d. %s (%d)", a.No, a.CompetitionName, a.Year), "", "L", false)
+			pdf.SetFont(pdfFontFamily, "", 9)
+			pdf.SetTextColor(80, 80, 80)
+			pdf.MultiCell(0, 5, joinNonEmpty(" - ", a.Rank, a.Level, a.Organizer, a.Team), "", "L", false)
+			pdf.SetTextColor(0, 0, 0)
+			if a.Description != "" {
+				pdf.MultiCell(0, 5, a.Description, "", "L", false)
+			}
+			if len(a.Thumbnails) > 0 {
+				pdf.Ln(2)
+				drawPortfolioThumbnails(pdf, a.No, a.Thumbnails)
+			}
+			pdf.Ln(4)
+		}
+	}
+
+	pdfHeadmasterSignature(pdf, data.GeneratedAt, data.HeadmasterName, data.HeadmasterNIP)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("gagal generate PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var items []string
+	for _, p := range parts {
+		if p != "" {
+			items = append(items, p)
+		}
+	}
+	return strings.Join(items, sep)
+}
+
+func hasPortfolioDetails(achievements []PortfolioAchievement) bool {
+	for _, a := range achievements {
+		if a.Description != "" || len(a.Thumbnails) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// drawPortfolioThumbnails menyusun thumbnail lampiran dalam baris, pindah baris atau
+// halaman jika tidak muat. Label dicetak di bawah setiap gambar.
+func drawPortfolioThumbnails(pdf *gofpdf.Fpdf, no int, thumbnails []PDFThumbnail) {
+	left, _, right, _ := pdf.GetMargins()
+	pageWidth, _ := pdf.GetPageSize()
+	const gap, labelHeight = 4.0, 4.0
+
+	x := left
+	ensurePDFSpace(pdf, portfolioThumbHeight+labelHeight)
+	y := pdf.GetY()
+	for i, thumb := range thumbnails {
+		if x+portfolioThumbWidth > pageWidth-right {
+			x = left
+			pdf.SetY(y + portfolioThumbHeight + labelHeight + gap)
+			ensurePDFSpace(pdf, portfolioThumbHeight+labelHeight)
+			y = pdf.GetY()
+		}
+		if !drawPDFImage(pdf, fmt.Sprintf("attachment-%d-%d", no, i), thumb.JPEG,
+			x, y, portfolioThumbWidth, portfolioThumbHeight) {
+			continue
+		}
+		pdf.SetFont(pdfFontFamily, "", 7)
+		pdf.SetXY(x, y+portfolioThumbHeight)
+		pdf.CellFormat(portfolioThumbWidth, labelHeight, fitText(pdf, thumb.Label, portfolioThumbWidth),
+			"", 0, "C", false, 0, "")
+		x += portfolioThumbWidth + gap
+	}
+	pdf.SetXY(left, y+portfolioThumbHeight+labelHeight)
+}
+
+// drawPDFImage menggambar JPEG di dalam kotak (x, y, w, h) dengan rasio asli, di
+// tengah kotak. Gambar yang gagal dimuat dilewati tanpa menggagalkan dokumen.
+func drawPDFImage(pdf *gofpdf.Fpdf, name string, jpegData []byte, x, y, w, h float64) bool {
+	opts := gofpdf.ImageOptions{ImageType: "JPG"}
+	info := pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(jpegData))
+	if !pdf.Ok() || info == nil {
+		pdf.ClearError()
+		return false
+	}
+
+	iw, ih := info.Width(), info.Height()
+	if iw <= 0 || ih <= 0 {
+		return false
+	}
+	scale := min(w/iw, h/ih)
+	dw, dh := iw*scale, ih*scale
+	pdf.ImageOptions(name, x+(w-dw)/2, y+(h-dh)/2, dw, dh, false, opts, 0, "")
+	return true
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+type AnnualReportPDFData struct {
+	SchoolName     string
+	SchoolAddress  string
+	Period         string // mis. "Tahun 2025" atau "Tahun 2023 - 2025"
+	GeneratedAt    time.Time
+	HeadmasterName string
+	HeadmasterNIP  string
+
+	TotalAchievements int64
+	TotalStudents     int64 // siswa berbeda yang meraih prestasi
+	SummaryTables     []PDFSummaryTable
+	Levels            []PDFLevelListing
+}
+
+// PDFSummaryTable tabel rekap satu dimensi, mis. per tingkat atau per kategori
+type PDFSummaryTable struct {
+	Title string
+	Label string // judul kolom pertama
+	Rows  []PDFSummaryRow
+}
+
+type PDFSummaryRow struct {
+	Label        string
+	Achievements int64
+	Students     int64
+}
+
+// PDFLevelListing daftar prestasi untuk satu tingkat lomba
+type PDFLevelListing struct {
+	Level        string
+	Achievements []PDFReportAchievement
+}
+
+type PDFReportAchievement struct {
+	No              int
+	StudentName     string // untuk prestasi tim berisi nama tim dan anggotanya
+	Class           string
+	CompetitionName string
+	Organizer       string
+	Category        string
+	Rank            string
+	Year            int
+}
+
+// GenerateAnnualReportPDF membuat rekap prestasi tahunan untuk Dinas Pendidikan:
+// kop sekolah, ringkasan, tabel rekap dan daftar prestasi per tingkat. Tabel yang
+// terpotong halaman mengulang judul kolomnya di halaman berikutnya.
+func GenerateAnnualReportPDF(data AnnualReportPDFData) ([]byte, error) {
+	pdf := newPDF()
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(fmt.Sprintf("Rekapitulasi Prestasi Siswa %s", data.Period), true)
+	pdf.SetAuthor(data.SchoolName, true)
+	pdfPageFooter(pdf, data.GeneratedAt)
+	pdf.AddPage()
+
+	// ─────────────────────────────────────────
+	// HEADER - Kop Surat
+	// ─────────────────────────────────────────
+	pdfLetterhead(pdf, data.SchoolName, data.SchoolAddress, pdfPrimaryColor)
+
+	// ─────────────────────────────────────────
+	// JUDUL & RINGKASAN
+	// ─────────────────────────────────────────
+	pdf.SetFont(pdfFontFamily, "B", 13)
+	pdf.CellFormat(0, 7, "REKAPITULASI PRESTASI SISWA", "", 1, "C", false, 0, "")
+	pdf.SetFont(pdfFontFamily, "", 10)
+	pdf.CellFormat(0, 5, data.Period, "", 1, "C", false, 0, "")
+	pdf.Ln(5)
+
+	pdf.CellFormat(60, 6, "Jumlah prestasi", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "C", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("%d", data.TotalAchievements), "", 1, "L", false, 0, "")
+	pdf.CellFormat(60, 6, "Jumlah siswa berprestasi", "", 0, "L", false, 0, "")
+	pdf.CellFormat(5, 6, ":", "", 0, "C", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("%d", data.TotalStudents), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	for _, summary := range data.SummaryTables {
+		pdfSectionTitle(pdf, summary.Title)
+		table := newPDFTable(pdf,
+			[]string{"No", summary.Label, "Jumlah Prestasi", "Jumlah Siswa"},
+			[]float64{10, 90, 40, 40},
+			[]string{"C", "L", "C", "C"})
+		table.header()
+		for i, row := range summary.Rows {
+			table.row(i,
+				fmt.Sprintf("%d", i+1), row.Label,
+				fmt.Sprintf("%d", row.Achievements), fmt.Sprintf("%d", row.Students))
+		}
+		pdf.Ln(6)
+	}
+
+	// ─────────────────────────────────────────
+	// DAFTAR PRESTASI PER TINGKAT
+	// ─────────────────────────────────────────
+	for _, level := range data.Levels {
+		// Judul bagian tidak boleh tertinggal sendirian di bawah halaman
+		ensurePDFSpace(pdf, 25)
+		pdfSectionTitle(pdf, fmt.Sprintf("Prestasi Tingkat %s (%d)", level.Level, len(level.Achievements)))
+
+		table := newPDFTable(pdf,
+			[]string{"No", "Nama Siswa", "Kelas", "Nama Lomba", "Penyelenggara", "Kategori", "Juara", "Tahun"},
+			[]float64{8, 38, 14, 44, 30, 18, 16, 12},
+			[]string{"C", "L", "C", "L", "L", "C", "C", "C"})
+		table.header()
+		for i, a := range level.Achievements {
+			table.row(i,
+				fmt.Sprintf("%d", a.No), a.StudentName, a.Class, a.CompetitionName,
+				a.Organizer, a.Category, a.Rank, fmt.Sprintf("%d", a.Year))
+		}
+		pdf.Ln(6)
+	}
+
+	// ─────────────────────────────────────────
+	// TANDA TANGAN
+	// ─────────────────────────────────────────
+	pdfHeadmasterSignature(pdf, data.GeneratedAt, data.HeadmasterName, data.HeadmasterNIP)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("gagal generate PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
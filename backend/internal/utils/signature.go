@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+)
+
+// CertificateSignaturePayload adalah data sertifikat yang ditandatangani.
+// Payload ini ikut disematkan di QR code sehingga pihak ketiga bisa
+// memverifikasi keaslian surat secara offline dengan public key sekolah.
+type CertificateSignaturePayload struct {
+	Version           int    `json:"v"`
+	KeyID             string `json:"kid"`
+	CertificateNumber string `json:"no"`
+	QRToken           string `json:"tok"`
+	StudentName       string `json:"name"`
+	StudentNISN       string `json:"nisn"`
+	IssuedAt          string `json:"iss"`           // YYYY-MM-DD
+	ValidUntil        string `json:"exp,omitempty"` // YYYY-MM-DD
+	AchievementsHash  string `json:"ach"`           // SHA-256 hex, lihat HashAchievementLines
+}
+
+// SignedCertificate hasil tanda tangan dalam bentuk base64url (tanpa padding)
+type SignedCertificate struct {
+	Payload   string
+	Signature string
+	KeyID     string
+}
+
+var (
+	ErrInvalidSignature  = errors.New("tanda tangan digital tidak valid")
+	ErrUnknownSigningKey = errors.New("kunci penanda tangan tidak dikenal")
+)
+
+const signaturePayloadVersion = 1
+
+type CertificateSigner struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	keyID      string
+
+	// previousKeys public key lama per kid, hanya untuk verifikasi
+	previousKeys map[string]ed25519.PublicKey
+}
+
+// NewCertificateSigner memuat kunci Ed25519 dari config.
+// Jika kunci belum diset, dibuat kunci sementara (hanya untuk development);
+// dengan requireKey (production) startup gagal karena sertifikat yang sudah
+// terbit tidak akan bisa diverifikasi lagi setelah restart.
+func NewCertificateSigner(cfg *config.SigningConfig, requireKey bool) (*CertificateSigner, error) {
+	var priv ed25519.PrivateKey
+
+	if cfg.PrivateKey == "" {
+		if requireKey {
+			return nil, errors.New("CERT_SIGNING_KEY wajib diset di production")
+		}
+		_, generated, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		priv = generated
+		log.Println("⚠️  CERT_SIGNING_KEY belum diset, memakai kunci sementara. Tanda tangan tidak akan valid setelah restart!")
+	} else {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(cfg.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CERT_SIGNING_KEY: %w", err)
+		}
+		switch len(raw) {
+		case ed25519.SeedSize:
+			priv = ed25519.NewKeyFromSeed(raw)
+		case ed25519.PrivateKeySize:
+			priv = ed25519.PrivateKey(raw)
+		default:
+			return nil, fmt.Errorf("invalid CERT_SIGNING_KEY: expected %d or %d bytes, got %d",
+				ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+		}
+	}
+
+	previous, err := parsePreviousKeys(cfg.PreviousKeys)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := previous[cfg.KeyID]; exists {
+		return nil, fmt.Errorf("invalid CERT_SIGNING_PREVIOUS_KEYS: kid %q sama dengan CERT_SIGNING_KEY_ID", cfg.KeyID)
+	}
+
+	return &CertificateSigner{
+		privateKey:   priv,
+		publicKey:    priv.Public().(ed25519.PublicKey),
+		keyID:        cfg.KeyID,
+		previousKeys: previous,
+	}, nil
+}
+
+// parsePreviousKeys membaca daftar "kid=base64,kid=base64"
+func parsePreviousKeys(raw string) (map[string]ed25519.PublicKey, error) {
+	keys := make(map[string]ed25519.PublicKey)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, encoded, ok := strings.Cut(entry, "=")
+		kid = strings.TrimSpace(kid)
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("invalid CERT_SIGNING_PREVIOUS_KEYS: expected kid=public_key, got %q", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid CERT_SIGNING_PREVIOUS_KEYS: public key %q harus %d byte base64", kid, ed25519.PublicKeySize)
+		}
+		keys[kid] = ed25519.PublicKey(key)
+	}
+	return keys, nil
+}
+
+func (s *CertificateSigner) KeyID() string {
+	return s.keyID
+}
+
+// Sign menandatangani payload; Version dan KeyID diisi otomatis
+func (s *CertificateSigner) Sign(payload CertificateSignaturePayload) (*SignedCertificate, error) {
+	payload.Version = signaturePayloadVersion
+	payload.KeyID = s.keyID
+
+	encoded, err := EncodeSignaturePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := ed25519.Sign(s.privateKey, []byte(encoded))
+	return &SignedCertificate{
+		Payload:   encoded,
+		Signature: base64.RawURLEncoding.EncodeToString(sig),
+		KeyID:     s.keyID,
+	}, nil
+}
+
+// Verify memvalidasi tanda tangan dengan public key milik keyID yang tersimpan
+// bersama sertifikat; keyID kosong (data lama) memakai kunci saat ini
+func (s *CertificateSigner) Verify(keyID, payload, signature string) (*CertificateSignaturePayload, error) {
+	publicKey, ok := s.publicKeyFor(keyID)
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+
+	decoded, err := VerifyCertificateSignature(publicKey, payload, signature)
+	if err != nil {
+		return nil, err
+	}
+	if keyID != "" && decoded.KeyID != keyID {
+		return nil, ErrInvalidSignature
+	}
+	return decoded, nil
+}
+
+func (s *CertificateSigner) publicKeyFor(keyID string) (ed25519.PublicKey, bool) {
+	if keyID == "" || keyID == s.keyID {
+		return s.publicKey, true
+	}
+	key, ok := s.previousKeys[keyID]
+	return key, ok
+}
+
+// PreviousKeysBase64 public key lama per kid dalam base64, untuk dipublikasikan
+func (s *CertificateSigner) PreviousKeysBase64() map[string]string {
+	keys := make(map[string]string, len(s.previousKeys))
+	for kid, key := range s.previousKeys {
+		keys[kid] = base64.StdEncoding.EncodeToString(key)
+	}
+	return keys
+}
+
+// PublicKeyBase64 public key mentah (32 byte) dalam base64
+func (s *CertificateSigner) PublicKeyBase64() string {
+	return base64.StdEncoding.EncodeToString(s.publicKey)
+}
+
+// PublicKeyPEM public key dalam format PEM (SubjectPublicKeyInfo) untuk dipublikasikan
+func (s *CertificateSigner) PublicKeyPEM() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(s.publicKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// EncodeSignaturePayload mengubah payload menjadi JSON base64url (bentuk yang ditandatangani)
+func EncodeSignaturePayload(payload CertificateSignaturePayload) (string, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// VerifyCertificateSignature dapat dipakai tanpa akses ke database maupun private key
+func VerifyCertificateSignature(publicKey ed25519.PublicKey, payload, signature string) (*CertificateSignaturePayload, error) {
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if !ed25519.Verify(publicKey, []byte(payload), sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	var decoded CertificateSignaturePayload
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return &decoded, nil
+}
+
+// HashAchievementLines menghitung SHA-256 dari daftar prestasi.
+// Setiap baris berformat "nama lomba|juara|tingkat|tahun", digabung dengan "\n".
+func HashAchievementLines(lines []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
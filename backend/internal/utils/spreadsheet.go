@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const MaxSpreadsheetSize = 10 * 1024 * 1024 // 10 MB
+
+var (
+	ErrUnsupportedSpreadsheet = errors.New("format file tidak didukung, gunakan CSV atau XLSX")
+	ErrInvalidSpreadsheet     = errors.New("file tidak dapat dibaca")
+)
+
+// ReadSpreadsheet membaca sheet pertama XLSX atau file CSV menjadi baris-baris sel teks.
+// Format ditentukan dari ekstensi nama file atau signature zip untuk XLSX.
+func ReadSpreadsheet(filename string, data []byte) ([][]string, error) {
+	var read func([]byte) ([][]string, error)
+	switch ext := strings.ToLower(path.Ext(filename)); {
+	case ext == ".xlsx", bytes.HasPrefix(data, []byte("PK")): // XLSX adalah file zip
+		read = readXLSX
+	case ext == ".csv", ext == ".txt":
+		read = readCSV
+	default:
+		return nil, ErrUnsupportedSpreadsheet
+	}
+
+	rows, err := read(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpreadsheet, err)
+	}
+	return rows, nil
+}
+
+// readCSV mendukung pemisah koma maupun titik koma (default Excel berbahasa Indonesia)
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM UTF-8
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV tidak valid: %w", err)
+	}
+	return rows, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt xlsxRichText) String() string {
+	if len(rt.R) == 0 {
+		return rt.T
+	}
+	var sb strings.Builder
+	for _, r := range rt.R {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string        `xml:"r,attr"`
+			Type   string        `xml:"t,attr"`
+			Value  string        `xml:"v"`
+			Inline *xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("bukan file XLSX yang valid")
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("tidak ada sheet")
+	}
+
+	var rels xlsxRelationships
+	if err := decodeZipXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, errors.New("sheet pertama tidak ditemukan")
+	}
+
+	// sharedStrings.xml tidak ada jika semua sel berupa angka/inline string
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decodeZipXML(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range sheet.Rows {
+		rowIdx := i
+		if row.R > 0 {
+			rowIdx = row.R - 1
+		}
+		for len(rows) <= rowIdx {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for j, c := range row.Cells {
+			col := j
+			if c.Ref != "" {
+				col = xlsxColumnIndex(c.Ref)
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err == nil && idx >= 0 && idx < len(shared.Items) {
+					cells[col] = shared.Items[idx].String()
+				}
+			case "inlineStr":
+				if c.Inline != nil {
+					cells[col] = c.Inline.String()
+				}
+			default:
+				cells[col] = c.Value
+			}
+		}
+		rows[rowIdx] = cells
+	}
+	return rows, nil
+}
+
+func decodeZipXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s tidak ditemukan", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, 100*MaxSpreadsheetSize)).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex "B12" -> 1
+func xlsxColumnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+// NormalizeHeader menyamakan judul kolom: huruf kecil tanpa spasi dan tanda baca
+// ("Tanggal Lahir" -> "tanggallahir", "L/P" -> "lp")
+func NormalizeHeader(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// FindHeaderRow mencari baris judul kolom di beberapa baris pertama. Export Dapodik
+// diawali beberapa baris judul laporan sebelum baris header. Baris dianggap header
+// jika memuat semua kolom wajib; hasilnya indeks baris dan peta kolom -> indeks sel.
+func FindHeaderRow(rows [][]string, aliases map[string][]string, required []string) (int, map[string]int, bool) {
+	lookup := make(map[string]string)
+	for field, names := range aliases {
+		for _, name := range names {
+			lookup[NormalizeHeader(name)] = field
+		}
+	}
+
+	limit := len(rows)
+	if limit > 20 {
+		limit = 20
+	}
+
+	for i := 0; i < limit; i++ {
+		columns := make(map[string]int)
+		for j, cell := range rows[i] {
+			if field, ok := lookup[NormalizeHeader(cell)]; ok {
+				if _, exists := columns[field]; !exists {
+					columns[field] = j
+				}
+			}
+		}
+
+		found := true
+		for _, field := range required {
+			if _, ok := columns[field]; !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i, columns, true
+		}
+	}
+	return -1, nil, false
+}
+
+// ParseSpreadsheetDate menerima YYYY-MM-DD, DD/MM/YYYY, DD-MM-YYYY atau nomor seri tanggal Excel
+func ParseSpreadsheetDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	// Sel tanggal di XLSX disimpan sebagai jumlah hari sejak 30 Desember 1899
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 && serial < 100000 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(math.Floor(serial))), nil
+	}
+	return time.Time{}, fmt.Errorf("format tanggal tidak dikenali: %s", s)
+}
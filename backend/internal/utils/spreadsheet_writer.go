@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// xlsxMaxSheetName batas panjang nama sheet di Excel
+const xlsxMaxSheetName = 31
+
+// XLSXSheet satu sheet workbook. Nilai sel boleh string, int, int64, float64 atau nil.
+type XLSXSheet struct {
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// WriteXLSX menyusun workbook XLSX minimal: baris judul tebal dan dibekukan, lebar
+// kolom menyesuaikan isi. Nama sheet dibersihkan dari karakter yang ditolak Excel.
+func WriteXLSX(sheets []XLSXSheet) ([]byte, error) {
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("workbook minimal berisi satu sheet")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	write := func(name, content string) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(xml.Header + content))
+		return err
+	}
+
+	var contentTypes, workbookSheets, workbookRels strings.Builder
+	used := make(map[string]bool)
+	for i, sheet := range sheets {
+		n := i + 1
+		name := xlsxSheetName(sheet.Name, used)
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbookSheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+
+		if err := write(fmt.Sprintf("xl/worksheets/sheet%d.xml", n), xlsxSheetXML(sheet)); err != nil {
+			return nil, err
+		}
+	}
+	stylesRel := len(sheets) + 1
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			contentTypes.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + workbookSheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			workbookRels.String() +
+			fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, stylesRel) +
+			`</Relationships>`},
+		// Style 0 default, style 1 tebal untuk baris judul
+		{"xl/styles.xml", `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+	}
+	for _, f := range files {
+		if err := write(f.name, f.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func xlsxSheetXML(sheet XLSXSheet) string {
+	columns := len(sheet.Header)
+	for _, row := range sheet.Rows {
+		columns = max(columns, len(row))
+	}
+
+	widths := make([]int, columns)
+	for i, h := range sheet.Header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+
+	var data strings.Builder
+	rowNum := 0
+	writeRow := func(cells []interface{}, style int) {
+		rowNum++
+		fmt.Fprintf(&data, `<row r="%d">`, rowNum)
+		for i, v := range cells {
+			ref := xlsxColumnName(i) + strconv.Itoa(rowNum)
+			styleAttr := ""
+			if style > 0 {
+				styleAttr = fmt.Sprintf(` s="%d"`, style)
+			}
+
+			var text string
+			switch val := v.(type) {
+			case nil:
+				continue
+			case string:
+				text = val
+				fmt.Fprintf(&data, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, xmlEscape(val))
+			case int:
+				text = strconv.Itoa(val)
+				fmt.Fprintf(&data, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, text)
+			case int64:
+				text = strconv.FormatInt(val, 10)
+				fmt.Fprintf(&data, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, text)
+			case float64:
+				text = strconv.FormatFloat(val, 'f', -1, 64)
+				fmt.Fprintf(&data, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, text)
+			default:
+				text = fmt.Sprint(val)
+				fmt.Fprintf(&data, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, xmlEscape(text))
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(text))
+		}
+		data.WriteString(`</row>`)
+	}
+
+	if len(sheet.Header) > 0 {
+		header := make([]interface{}, len(sheet.Header))
+		for i, h := range sheet.Header {
+			header[i] = h
+		}
+		writeRow(header, 1)
+	}
+	for _, row := range sheet.Rows {
+		writeRow(row, 0)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(sheet.Header) > 0 {
+		sb.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	if columns > 0 {
+		sb.WriteString(`<cols>`)
+		for i, w := range widths {
+			fmt.Fprintf(&sb, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, min(max(w, 4), 60)+2)
+		}
+		sb.WriteString(`</cols>`)
+	}
+	sb.WriteString(`<sheetData>` + data.String() + `</sheetData></worksheet>`)
+	return sb.String()
+}
+
+// xlsxSheetName membuang karakter yang tidak boleh dipakai nama sheet, memotong
+// ke 31 karakter dan menambahkan nomor jika namanya sudah dipakai
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Sheet"
+	}
+
+	base := truncateRunes(name, xlsxMaxSheetName)
+	candidate := base
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		candidate = truncateRunes(name, xlsxMaxSheetName-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// xlsxColumnName mengubah indeks kolom (mulai 0) menjadi huruf kolom: 0=A, 26=AA
+func xlsxColumnName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
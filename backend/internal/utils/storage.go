@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 	"time"
@@ -109,6 +110,13 @@ func (s *StorageService) UploadFile(ctx context.Context, folder string, data []b
 	}, nil
 }
 
+// StoredFile objek yang dibaca dari MinIO; Body wajib di-Close pemanggil
+type StoredFile struct {
+	Body        io.ReadCloser
+	Size        int64
+	ContentType string
+}
+
 // UploadPDF upload file PDF ke MinIO (untuk sertifikat)
 func (s *StorageService) UploadPDF(ctx context.Context, folder string, data []byte, name string) (string, error) {
 	// Sanitasi nama file
@@ -127,11 +135,30 @@ func (s *StorageService) UploadPDF(ctx context.Context, folder string, data []by
 	return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, fileName), nil
 }
 
+// GetFile membuka file di MinIO berdasarkan URL hasil upload
+func (s *StorageService) GetFile(ctx context.Context, fileURL string) (*StoredFile, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.objectName(fileURL), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca file: %w", err)
+	}
+
+	// Stat memastikan objek benar-benar ada sebelum response mulai dikirim
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, fmt.Errorf("gagal membaca file: %w", err)
+	}
+
+	return &StoredFile{Body: obj, Size: info.Size, ContentType: info.ContentType}, nil
+}
+
 // DeleteFile hapus file dari MinIO
 func (s *StorageService) DeleteFile(ctx context.Context, fileURL string) error {
-	// Extract object name dari URL
-	prefix := fmt.Sprintf("%s/%s/", s.endpoint, s.bucket)
-	objectName := strings.TrimPrefix(fileURL, prefix)
+	return s.client.RemoveObject(ctx, s.bucket, s.objectName(fileURL), minio.RemoveObjectOptions{})
+}
 
-	return s.client.RemoveObject(ctx, s.bucket, objectName, minio.RemoveObjectOptions{})
+// objectName extract object name dari URL
+func (s *StorageService) objectName(fileURL string) string {
+	prefix := fmt.Sprintf("%s/%s/", s.endpoint, s.bucket)
+	return strings.TrimPrefix(fileURL, prefix)
 }
\ No newline at end of file
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// Kunci SHA1 dari RFC 6238 lampiran B: ASCII "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// Vektor uji RFC 6238 lampiran B (SHA1), dipotong ke 6 digit terakhir karena
+// kode 8 digit di RFC adalah nilai truncation yang sama modulo 10^8
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		code, err := TOTPCode(rfc6238Secret, v.unix/totpPeriod)
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("TOTPCode(%d) = %s, seharusnya %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+
+	got, ok := ValidateTOTP(rfc6238Secret, "005924", now)
+	if !ok || got != step {
+		t.Fatalf("kode saat ini ditolak: step=%d ok=%v", got, ok)
+	}
+
+	// Toleransi ±1 periode untuk jam perangkat yang meleset
+	prev, _ := TOTPCode(rfc6238Secret, step-1)
+	if got, ok := ValidateTOTP(rfc6238Secret, prev[:3]+" "+prev[3:], now); !ok || got != step-1 {
+		t.Errorf("kode periode sebelumnya ditolak: step=%d ok=%v", got, ok)
+	}
+	tooOld, _ := TOTPCode(rfc6238Secret, step-2)
+	if _, ok := ValidateTOTP(rfc6238Secret, tooOld, now); ok {
+		t.Error("kode dua periode lalu seharusnya ditolak")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "05924", now); ok {
+		t.Error("kode kurang dari 6 digit seharusnya ditolak")
+	}
+}
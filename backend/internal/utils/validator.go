@@ -1,10 +1,17 @@
 package utils
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
 	"regexp"
 	"strings"
+	"unicode"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
 )
 
 // DecodeJSON decode request body ke struct
@@ -27,14 +34,114 @@ func IsValidEmail(email string) bool {
 	return emailRegex.MatchString(email)
 }
 
+// Batas panjang password: minimal yang masih wajar dan maksimal yang dibaca bcrypt
+const (
+	passwordMinLengthFloor = 8
+	passwordMaxLength      = 72
+)
+
+// passwordPolicy aturan password yang berlaku, diatur lewat SetPasswordPolicy
+var passwordPolicy = config.PasswordPolicyConfig{MinLength: passwordMinLengthFloor, RequireDigit: true}
+
+// SetPasswordPolicy mengganti aturan kekuatan password (dipanggil sekali saat start)
+func SetPasswordPolicy(cfg *config.PasswordPolicyConfig) error {
+	if cfg.MinLength < passwordMinLengthFloor || cfg.MinLength > passwordMaxLength {
+		return fmt.Errorf("PASSWORD_MIN_LENGTH harus antara %d dan %d", passwordMinLengthFloor, passwordMaxLength)
+	}
+	passwordPolicy = *cfg
+	return nil
+}
+
+// CheckPassword memeriksa password terhadap aturan yang berlaku; pesan error
+// menjelaskan seluruh aturan agar user tahu apa yang kurang
+func CheckPassword(password string) error {
+	if len(password) > passwordMaxLength {
+		return fmt.Errorf("Password maksimal %d karakter", passwordMaxLength)
+	}
+
+	var hasLetter, hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasLetter, hasUpper = true, true
+		case unicode.IsLower(r):
+			hasLetter, hasLower = true, true
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	p := passwordPolicy
+	if len([]rune(password)) < p.MinLength || !hasLetter ||
+		(p.RequireUpper && !hasUpper) || (p.RequireLower && !hasLower) ||
+		(p.RequireDigit && !hasDigit) || (p.RequireSymbol && !hasSymbol) {
+		return errors.New(passwordPolicyMessage())
+	}
+	return nil
+}
+
+// passwordPolicyMessage mis. "Password minimal 8 karakter dan harus mengandung huruf dan angka"
+func passwordPolicyMessage() string {
+	p := passwordPolicy
+	var parts []string
+	switch {
+	case p.RequireUpper && p.RequireLower:
+		parts = append(parts, "huruf besar", "huruf kecil")
+	case p.RequireUpper:
+		parts = append(parts, "huruf besar")
+	case p.RequireLower:
+		parts = append(parts, "huruf kecil")
+	default:
+		parts = append(parts, "huruf")
+	}
+	if p.RequireDigit {
+		parts = append(parts, "angka")
+	}
+	if p.RequireSymbol {
+		parts = append(parts, "simbol")
+	}
+
+	required := parts[len(parts)-1]
+	if len(parts) > 1 {
+		required = strings.Join(parts[:len(parts)-1], ", ") + " dan " + required
+	}
+	return fmt.Sprintf("Password minimal %d karakter dan harus mengandung %s", p.MinLength, required)
+}
+
 func IsValidPassword(password string) bool {
-	// Minimal 8 karakter, ada huruf dan angka
-	if len(password) < 8 {
-		return false
+	return CheckPassword(password) == nil
+}
+
+// Tanpa karakter yang mudah tertukar (0/O, 1/l/I)
+const temporaryPasswordLetters = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+const temporaryPasswordDigits = "23456789"
+const temporaryPasswordSymbols = "!@#$%*-_+?"
+
+// GenerateTemporaryPassword membuat password acak yang lolos IsValidPassword,
+// untuk reset password oleh admin
+func GenerateTemporaryPassword(length int) (string, error) {
+	length = max(length, passwordPolicy.MinLength)
+	alphabet := temporaryPasswordLetters + temporaryPasswordDigits
+	if passwordPolicy.RequireSymbol {
+		alphabet += temporaryPasswordSymbols
+	}
+	for {
+		b := make([]byte, length)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return "", err
+			}
+			b[i] = alphabet[n.Int64()]
+		}
+		if password := string(b); IsValidPassword(password) {
+			return password, nil
+		}
 	}
-	hasLetter := regexp.MustCompile(`[a-zA-Z]`).MatchString(password)
-	hasDigit := regexp.MustCompile(`[0-9]`).MatchString(password)
-	return hasLetter && hasDigit
 }
 
 func SanitizeString(s string) string {
@@ -0,0 +1,198 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ahmadqo/digital-achievement-ledger/internal/config"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/model"
+	"github.com/ahmadqo/digital-achievement-ledger/internal/repository"
+)
+
+// HandlerFunc menjalankan satu job. Error yang dikembalikan membuat job dijadwalkan ulang.
+type HandlerFunc func(ctx context.Context, job *model.Job) error
+
+// DeadFunc dipanggil sekali ketika job gagal permanen (masuk dead-letter)
+type DeadFunc func(ctx context.Context, job *model.Job, jobErr error)
+
+type registration struct {
+	handle HandlerFunc
+	onDead DeadFunc
+}
+
+// bookkeepingTimeout batas waktu pencatatan hasil job dan callback onDead. Sengaja
+// terpisah dari jobTimeout: job yang habis waktunya tetap harus bisa ditandai.
+const bookkeepingTimeout = 30 * time.Second
+
+// Pool menjalankan job dari tabel jobs dengan beberapa goroutine worker
+type Pool struct {
+	repo         repository.JobRepository
+	concurrency  int
+	pollInterval time.Duration
+	lockTimeout  time.Duration
+	jobTimeout   time.Duration
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+
+	handlers map[string]registration
+	types    []string
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPool(repo repository.JobRepository, cfg *config.WorkerConfig) *Pool {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Pool{
+		repo:         repo,
+		concurrency:  concurrency,
+		pollInterval: cfg.PollInterval,
+		lockTimeout:  cfg.LockTimeout,
+		jobTimeout:   cfg.JobTimeout,
+		baseBackoff:  cfg.BaseBackoff,
+		maxBackoff:   cfg.MaxBackoff,
+		handlers:     make(map[string]registration),
+	}
+}
+
+// Register mendaftarkan handler untuk satu tipe job; onDead boleh nil.
+// Harus dipanggil sebelum Start.
+func (p *Pool) Register(jobType string, handle HandlerFunc, onDead DeadFunc) {
+	if _, exists := p.handlers[jobType]; !exists {
+		p.types = append(p.types, jobType)
+	}
+	p.handlers[jobType] = registration{handle: handle, onDead: onDead}
+}
+
+func (p *Pool) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	for i := 0; i < p.concurrency; i++ {
+		p.wg.Add(1)
+		go p.loop(ctx)
+	}
+	log.Printf("Worker pool berjalan (%d worker)", p.concurrency)
+}
+
+// Stop berhenti mengambil job baru dan menunggu job yang sedang berjalan selesai.
+// Job yang belum selesai saat ctx habis akan diambil ulang setelah lock timeout.
+func (p *Pool) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) loop(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		// Kerjakan job selama masih ada yang siap, baru tidur
+		for ctx.Err() == nil && p.runNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// runNext mengambil dan menjalankan satu job; false jika antrean kosong atau terjadi error
+func (p *Pool) runNext(ctx context.Context) bool {
+	job, err := p.repo.ClaimNext(ctx, p.types, p.lockTimeout)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("worker: gagal mengambil job: %v", err)
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	// Job yang sudah diambil diselesaikan walaupun pool sedang berhenti
+	runCtx, cancel := context.WithTimeout(context.Background(), p.jobTimeout)
+	defer cancel()
+
+	reg := p.handlers[job.Type]
+	jobErr := p.execute(runCtx, reg.handle, job)
+
+	// runCtx mungkin sudah habis (job timeout), jadi pencatatan memakai context sendiri
+	bkCtx, bkCancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer bkCancel()
+
+	if jobErr == nil {
+		if err := p.repo.MarkDone(bkCtx, job.ID); err != nil {
+			log.Printf("worker: gagal menandai job %s selesai: %v", job.ID, err)
+		}
+		return true
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		log.Printf("worker: job %s (%s) gagal permanen setelah %d percobaan: %v",
+			job.ID, job.Type, job.Attempts, jobErr)
+		if err := p.repo.MarkDead(bkCtx, job.ID, jobErr.Error()); err != nil {
+			log.Printf("worker: gagal menandai job %s dead: %v", job.ID, err)
+		}
+		if reg.onDead != nil {
+			reg.onDead(bkCtx, job, jobErr)
+		}
+		return true
+	}
+
+	runAt := time.Now().Add(p.backoff(job.Attempts))
+	log.Printf("worker: job %s (%s) gagal (percobaan %d/%d), dicoba lagi pada %s: %v",
+		job.ID, job.Type, job.Attempts, job.MaxAttempts, runAt.Format(time.RFC3339), jobErr)
+	if err := p.repo.MarkRetry(bkCtx, job.ID, runAt, jobErr.Error()); err != nil {
+		log.Printf("worker: gagal menjadwalkan ulang job %s: %v", job.ID, err)
+	}
+	return true
+}
+
+func (p *Pool) execute(ctx context.Context, handle HandlerFunc, job *model.Job) (err error) {
+	if handle == nil {
+		return fmt.Errorf("tidak ada handler untuk tipe job %q", job.Type)
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return handle(ctx, job)
+}
+
+// backoff eksponensial: base * 2^(attempt-1), maksimal maxBackoff, dengan jitter ±10%
+func (p *Pool) backoff(attempt int) time.Duration {
+	d := p.baseBackoff
+	for i := 1; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(d)/5+1)) - d/10
+	return d + jitter
+}
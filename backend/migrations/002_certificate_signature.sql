@@ -0,0 +1,7 @@
+-- migrations/002_certificate_signature.sql
+
+-- Tanda tangan digital Ed25519 untuk verifikasi offline
+ALTER TABLE certificates
+    ADD COLUMN IF NOT EXISTS signed_payload   TEXT,          -- payload base64url yang ditandatangani
+    ADD COLUMN IF NOT EXISTS signature        TEXT,          -- signature Ed25519 base64url
+    ADD COLUMN IF NOT EXISTS signature_key_id VARCHAR(100);  -- ID kunci sekolah yang dipakai
@@ -0,0 +1,24 @@
+-- migrations/003_certificate_status_events.sql
+
+-- Riwayat perubahan status sertifikat (terbit, dicabut, dipulihkan)
+CREATE TABLE IF NOT EXISTS certificate_status_events (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    certificate_id  UUID        NOT NULL REFERENCES certificates(id) ON DELETE CASCADE,
+    from_status     VARCHAR(20),                 -- NULL untuk event penerbitan
+    to_status       VARCHAR(20) NOT NULL,
+    reason          TEXT,
+    actor_id        UUID        REFERENCES users(id),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_certificate_status_events_certificate
+    ON certificate_status_events (certificate_id, created_at);
+
+-- Backfill: event penerbitan untuk sertifikat yang sudah ada
+INSERT INTO certificate_status_events (certificate_id, from_status, to_status, actor_id, created_at)
+SELECT id, NULL, 'active', issued_by, issued_at FROM certificates;
+
+-- Sertifikat yang sudah dicabut sebelum fitur ini tidak punya tanggal & alasan
+INSERT INTO certificate_status_events (certificate_id, from_status, to_status, reason, created_at)
+SELECT id, 'active', 'revoked', 'Dicabut sebelum riwayat status dicatat', NOW()
+FROM certificates WHERE status = 'revoked';
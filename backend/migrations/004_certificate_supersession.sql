@@ -0,0 +1,9 @@
+-- migrations/004_certificate_supersession.sql
+
+-- Rantai penerbitan ulang: sertifikat lama berstatus 'superseded'
+-- dan menunjuk ke penggantinya
+ALTER TABLE certificates
+    ADD COLUMN IF NOT EXISTS supersedes    UUID REFERENCES certificates(id),
+    ADD COLUMN IF NOT EXISTS superseded_by UUID REFERENCES certificates(id);
+
+CREATE INDEX IF NOT EXISTS idx_certificates_superseded_by ON certificates (superseded_by);
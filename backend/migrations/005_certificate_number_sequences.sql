@@ -0,0 +1,35 @@
+-- migrations/005_certificate_number_sequences.sql
+
+-- Counter nomor surat per scope (mis. "year:2026", "month:2026-10", "all").
+-- Diambil dengan INSERT ... ON CONFLICT DO UPDATE di dalam transaksi penerbitan,
+-- sehingga penerbitan bersamaan tidak mendapat nomor yang sama.
+CREATE TABLE IF NOT EXISTS certificate_number_sequences (
+    scope       VARCHAR(50) PRIMARY KEY,
+    last_value  INT         NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Lanjutkan counter dari data lama (format lama: 421.2/SKP/{YEAR}/{INCREMENT}).
+-- Dipakai counter tertinggi, bukan COUNT(*): baris yang terhapus atau hilang
+-- membuat jumlah baris lebih kecil dari nomor terakhir sehingga nomor berikutnya
+-- bisa bentrok dengan UNIQUE certificate_number. Semua scope kebijakan reset
+-- diisi agar CERT_NUMBER_RESET bisa diganti tanpa mengulang nomor.
+WITH numbered AS (
+    SELECT issued_at, SUBSTRING(certificate_number FROM '(\d+)$')::INT AS counter
+    FROM certificates
+    WHERE certificate_number ~ '\d+$'
+)
+INSERT INTO certificate_number_sequences (scope, last_value)
+SELECT 'year:' || TO_CHAR(issued_at, 'YYYY'), MAX(counter)
+FROM numbered
+GROUP BY TO_CHAR(issued_at, 'YYYY')
+UNION ALL
+SELECT 'month:' || TO_CHAR(issued_at, 'YYYY-MM'), MAX(counter)
+FROM numbered
+GROUP BY TO_CHAR(issued_at, 'YYYY-MM')
+UNION ALL
+SELECT 'all', MAX(counter)
+FROM numbered
+HAVING COUNT(*) > 0
+ON CONFLICT (scope) DO UPDATE
+SET last_value = GREATEST(certificate_number_sequences.last_value, EXCLUDED.last_value);
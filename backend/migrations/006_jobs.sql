@@ -0,0 +1,31 @@
+-- migrations/006_jobs.sql
+
+-- Antrean job background (generate PDF, dll) dengan retry & dead-letter
+CREATE TABLE IF NOT EXISTS jobs (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type          VARCHAR(100) NOT NULL,
+    payload       JSONB        NOT NULL DEFAULT '{}',
+    status        VARCHAR(20)  NOT NULL DEFAULT 'pending', -- pending | running | done | dead
+    attempts      INT          NOT NULL DEFAULT 0,
+    max_attempts  INT          NOT NULL DEFAULT 5,
+    run_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),     -- jadwal eksekusi berikutnya (backoff)
+    locked_at     TIMESTAMPTZ,
+    last_error    TEXT,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs (status, run_at);
+
+-- Status PDF sertifikat
+ALTER TABLE certificates
+    ADD COLUMN IF NOT EXISTS pdf_status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending | ready | failed
+    ADD COLUMN IF NOT EXISTS pdf_error  TEXT;
+
+UPDATE certificates SET pdf_status = 'ready' WHERE pdf_url IS NOT NULL;
+
+-- Sertifikat lama yang PDF-nya tidak pernah terupload dijadwalkan ulang
+INSERT INTO jobs (type, payload)
+SELECT 'certificate.generate_pdf', jsonb_build_object('certificate_id', id)
+FROM certificates
+WHERE pdf_url IS NULL;
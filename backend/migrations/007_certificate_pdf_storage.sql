@@ -0,0 +1,23 @@
+-- migrations/007_certificate_pdf_storage.sql
+
+-- PDF hasil penerbitan dibekukan; hash dipakai untuk memastikan file tidak berubah
+ALTER TABLE certificates
+    ADD COLUMN IF NOT EXISTS pdf_sha256 VARCHAR(64);
+
+-- Regenerasi PDF hanya lewat aksi admin dan selalu tercatat
+CREATE TABLE IF NOT EXISTS certificate_pdf_regenerations (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    certificate_id      UUID         NOT NULL REFERENCES certificates(id) ON DELETE CASCADE,
+    requested_by        UUID         REFERENCES users(id) ON DELETE SET NULL,
+    reason              TEXT         NOT NULL,
+    job_id              UUID         REFERENCES jobs(id) ON DELETE SET NULL,
+    previous_pdf_url    TEXT,
+    previous_pdf_sha256 VARCHAR(64),
+    new_pdf_url         TEXT,
+    new_pdf_sha256      VARCHAR(64),
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    completed_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_certificate_pdf_regenerations_certificate
+    ON certificate_pdf_regenerations (certificate_id, created_at DESC);
@@ -0,0 +1,40 @@
+-- migrations/008_certificate_snapshot.sql
+
+-- Data siswa & prestasi dibekukan saat penerbitan; verifikasi dan PDF membaca dari sini
+ALTER TABLE certificates
+    ADD COLUMN IF NOT EXISTS snapshot JSONB;
+
+-- Backfill sertifikat lama dari data live saat migrasi dijalankan.
+-- Ditandai "backfilled" karena isinya bukan data pada saat penerbitan.
+-- birth_date (DATE) diformat RFC 3339 agar bisa dibaca sebagai time.Time.
+UPDATE certificates c
+SET snapshot = jsonb_build_object(
+    'captured_at', NOW(),
+    'backfilled', true,
+    'student', (
+        SELECT to_jsonb(s) || jsonb_build_object(
+            'birth_date', to_char(s.birth_date, 'YYYY-MM-DD"T"00:00:00"Z"'))
+        FROM students s
+        WHERE s.id = c.student_id
+    ),
+    'achievements', COALESCE((
+        SELECT jsonb_agg(
+            to_jsonb(a) || jsonb_build_object(
+                'category_name', ac.name,
+                'level_name', cl.name,
+                'attachments', COALESCE((
+                    SELECT jsonb_agg(to_jsonb(att) ORDER BY att.uploaded_at)
+                    FROM achievement_attachments att
+                    WHERE att.achievement_id = a.id
+                ), '[]'::jsonb)
+            )
+            ORDER BY a.year DESC
+        )
+        FROM certificate_achievements ca
+        JOIN achievements a ON a.id = ca.achievement_id
+        LEFT JOIN achievement_categories ac ON a.category_id = ac.id
+        LEFT JOIN competition_levels cl ON a.level_id = cl.id
+        WHERE ca.certificate_id = c.id
+    ), '[]'::jsonb)
+)
+WHERE c.snapshot IS NULL;
@@ -0,0 +1,40 @@
+-- migrations/009_audit_logs.sql
+
+-- Ledger audit append-only dengan rantai hash (lihat model.AuditLog.ComputeHash)
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id           BIGSERIAL PRIMARY KEY,
+    occurred_at  TIMESTAMPTZ  NOT NULL,
+    actor_id     UUID,                 -- sengaja tanpa FK agar entri tidak ikut berubah
+    actor_name   VARCHAR(100),
+    actor_role   VARCHAR(20),
+    action       VARCHAR(100) NOT NULL,
+    entity_type  VARCHAR(50)  NOT NULL,
+    entity_id    VARCHAR(100),
+    before       JSONB,
+    after        JSONB,
+    ip_address   VARCHAR(64),
+    request_id   VARCHAR(100),
+    prev_hash    CHAR(64)     NOT NULL,
+    hash         CHAR(64)     NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity   ON audit_logs (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor    ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_occurred ON audit_logs (occurred_at DESC);
+
+-- Tolak UPDATE, DELETE dan TRUNCATE
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs bersifat append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_no_modify ON audit_logs;
+CREATE TRIGGER trg_audit_logs_no_modify
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+DROP TRIGGER IF EXISTS trg_audit_logs_no_truncate ON audit_logs;
+CREATE TRIGGER trg_audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
@@ -0,0 +1,51 @@
+-- migrations/010_achievement_import.sql
+
+-- Import prestasi dari spreadsheet ditampung dulu sebagai batch staging;
+-- prestasi baru dibuat setelah operator meninjau dan menyetujui batch
+CREATE TABLE IF NOT EXISTS achievement_import_batches (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_name    VARCHAR(255) NOT NULL,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'pending', -- pending | approved | discarded
+    total_rows   INT          NOT NULL DEFAULT 0,
+    created_by   UUID         REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_by  UUID         REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at  TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_import_batches_created
+    ON achievement_import_batches (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS achievement_import_rows (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    batch_id         UUID         NOT NULL REFERENCES achievement_import_batches(id) ON DELETE CASCADE,
+    line_number      INT          NOT NULL, -- nomor baris di file
+    -- Nilai sel apa adanya
+    nisn             VARCHAR(20)  NOT NULL DEFAULT '',
+    student_name     VARCHAR(255) NOT NULL DEFAULT '',
+    class            VARCHAR(50)  NOT NULL DEFAULT '',
+    competition_name VARCHAR(255) NOT NULL DEFAULT '',
+    organizer        VARCHAR(255) NOT NULL DEFAULT '',
+    category_name    VARCHAR(100) NOT NULL DEFAULT '',
+    level_name       VARCHAR(100) NOT NULL DEFAULT '',
+    rank             VARCHAR(50)  NOT NULL DEFAULT '',
+    year             INT,
+    description      TEXT         NOT NULL DEFAULT '',
+    -- Hasil pencocokan
+    student_id       UUID         REFERENCES students(id) ON DELETE SET NULL,
+    match_method     VARCHAR(20),  -- nisn | name_class | fuzzy | manual
+    match_score      DOUBLE PRECISION,
+    category_id      INT          REFERENCES achievement_categories(id),
+    level_id         INT          REFERENCES competition_levels(id),
+    duplicate_of     UUID         REFERENCES achievements(id) ON DELETE SET NULL,
+    errors           JSONB,        -- harus diperbaiki sebelum disetujui
+    warnings         JSONB,        -- harus dikonfirmasi operator
+    confirmed        BOOLEAN      NOT NULL DEFAULT FALSE,
+    skipped          BOOLEAN      NOT NULL DEFAULT FALSE,
+    status           VARCHAR(20)  NOT NULL, -- ready | needs_review | invalid | skipped | imported
+    achievement_id   UUID         REFERENCES achievements(id) ON DELETE SET NULL,
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_import_rows_batch
+    ON achievement_import_rows (batch_id, line_number);
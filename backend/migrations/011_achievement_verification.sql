@@ -0,0 +1,32 @@
+-- migrations/011_achievement_verification.sql
+
+-- Alur verifikasi prestasi: draft -> submitted -> verified / rejected.
+-- Prestasi yang sudah ada sebelum fitur ini dianggap terverifikasi (sudah dipakai
+-- di surat keterangan); prestasi baru dimulai dari draft.
+ALTER TABLE achievements
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'verified';
+
+ALTER TABLE achievements
+    ALTER COLUMN status SET DEFAULT 'draft';
+
+CREATE INDEX IF NOT EXISTS idx_achievements_status ON achievements (status);
+
+-- Riwayat perubahan status beserta komentar reviewer
+CREATE TABLE IF NOT EXISTS achievement_status_events (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_id  UUID        NOT NULL REFERENCES achievements(id) ON DELETE CASCADE,
+    from_status     VARCHAR(20),                 -- NULL untuk event pembuatan
+    to_status       VARCHAR(20) NOT NULL,
+    comment         TEXT,
+    actor_id        UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_status_events_achievement
+    ON achievement_status_events (achievement_id, created_at);
+
+-- Backfill: tandai prestasi lama sebagai terverifikasi di riwayat
+INSERT INTO achievement_status_events (achievement_id, from_status, to_status, comment, actor_id, created_at)
+SELECT id, NULL, 'verified', 'Dibuat sebelum alur verifikasi diterapkan', created_by, created_at
+FROM achievements
+WHERE NOT EXISTS (SELECT 1 FROM achievement_status_events e WHERE e.achievement_id = achievements.id);
@@ -0,0 +1,36 @@
+-- migrations/012_scoring_rules.sql
+
+-- Aturan poin prestasi untuk pemeringkatan siswa (mis. portofolio SNBP).
+-- Poin = poin tingkat x pengali peringkat x bobot kategori
+--   level    : key = competition_levels.order_rank
+--   rank     : key = kode peringkat ternormalisasi (juara_1, harapan_2, finalis, ...)
+--   category : key = achievement_categories.type
+CREATE TABLE IF NOT EXISTS scoring_rules (
+    id          SERIAL PRIMARY KEY,
+    kind        VARCHAR(20)   NOT NULL,
+    key         VARCHAR(50)   NOT NULL,
+    value       NUMERIC(8, 2) NOT NULL CHECK (value >= 0),
+    description VARCHAR(255)  NOT NULL DEFAULT '',
+    updated_by  UUID          REFERENCES users(id) ON DELETE SET NULL,
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, key)
+);
+
+INSERT INTO scoring_rules (kind, key, value, description) VALUES
+    ('level',    '1',            10,   'Sekolah'),
+    ('level',    '2',            20,   'Kecamatan'),
+    ('level',    '3',            40,   'Kabupaten/Kota'),
+    ('level',    '4',            60,   'Provinsi'),
+    ('level',    '5',            80,   'Nasional'),
+    ('level',    '6',            100,  'Internasional'),
+    ('rank',     'juara_1',      1.00, 'Juara 1 / medali emas'),
+    ('rank',     'juara_2',      0.80, 'Juara 2 / medali perak'),
+    ('rank',     'juara_3',      0.60, 'Juara 3 / medali perunggu'),
+    ('rank',     'harapan_1',    0.40, 'Harapan 1'),
+    ('rank',     'harapan_2',    0.30, 'Harapan 2'),
+    ('rank',     'harapan_3',    0.20, 'Harapan 3'),
+    ('rank',     'finalis',      0.15, 'Finalis'),
+    ('rank',     'peserta',      0.05, 'Peserta'),
+    ('category', 'academic',     1.00, 'Akademik'),
+    ('category', 'non_academic', 0.80, 'Non akademik')
+ON CONFLICT (kind, key) DO NOTHING;
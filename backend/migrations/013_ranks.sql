@@ -0,0 +1,115 @@
+-- migrations/013_ranks.sql
+
+-- Referensi peringkat/juara, menggantikan teks bebas achievements.rank untuk filter dan poin.
+-- code dipakai sebagai key aturan poin jenis rank (scoring_rules).
+CREATE TABLE IF NOT EXISTS ranks (
+    id         SERIAL PRIMARY KEY,
+    code       VARCHAR(50)  UNIQUE NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    kind       VARCHAR(30)  NOT NULL,          -- position | medal | honourable_mention | participation
+    order_rank INT          NOT NULL DEFAULT 0 -- untuk sorting (juara 1/emas=1 ... peserta=8)
+);
+
+INSERT INTO ranks (code, name, kind, order_rank) VALUES
+    ('juara_1',         'Juara 1',         'position',           1),
+    ('medali_emas',     'Medali Emas',     'medal',              1),
+    ('juara_2',         'Juara 2',         'position',           2),
+    ('medali_perak',    'Medali Perak',    'medal',              2),
+    ('juara_3',         'Juara 3',         'position',           3),
+    ('medali_perunggu', 'Medali Perunggu', 'medal',              3),
+    ('harapan_1',       'Harapan 1',       'honourable_mention', 4),
+    ('harapan_2',       'Harapan 2',       'honourable_mention', 5),
+    ('harapan_3',       'Harapan 3',       'honourable_mention', 6),
+    ('finalis',         'Finalis',         'participation',      7),
+    ('peserta',         'Peserta',         'participation',      8)
+ON CONFLICT (code) DO NOTHING;
+
+-- Variasi penulisan yang dikenali. alias sudah dinormalisasi: huruf kecil, tanpa tanda baca,
+-- spasi tunggal ("Juara-I" -> "juara i").
+CREATE TABLE IF NOT EXISTS rank_aliases (
+    alias   VARCHAR(100) PRIMARY KEY,
+    rank_id INT NOT NULL REFERENCES ranks(id) ON DELETE CASCADE
+);
+
+INSERT INTO rank_aliases (alias, rank_id)
+SELECT v.alias, r.id
+FROM (VALUES
+    ('juara 1', 'juara_1'), ('juara i', 'juara_1'), ('juara satu', 'juara_1'),
+    ('juara pertama', 'juara_1'), ('juara ke 1', 'juara_1'), ('juara ke i', 'juara_1'),
+    ('1', 'juara_1'), ('i', 'juara_1'), ('1st', 'juara_1'), ('1st place', 'juara_1'),
+    ('first', 'juara_1'), ('first place', 'juara_1'), ('peringkat 1', 'juara_1'),
+    ('peringkat i', 'juara_1'), ('peringkat pertama', 'juara_1'), ('winner', 'juara_1'),
+    ('champion', 'juara_1'),
+
+    ('juara 2', 'juara_2'), ('juara ii', 'juara_2'), ('juara dua', 'juara_2'),
+    ('juara kedua', 'juara_2'), ('juara ke 2', 'juara_2'), ('juara ke ii', 'juara_2'),
+    ('2', 'juara_2'), ('ii', 'juara_2'), ('2nd', 'juara_2'), ('2nd place', 'juara_2'),
+    ('second', 'juara_2'), ('second place', 'juara_2'), ('peringkat 2', 'juara_2'),
+    ('peringkat ii', 'juara_2'), ('peringkat kedua', 'juara_2'), ('runner up', 'juara_2'),
+
+    ('juara 3', 'juara_3'), ('juara iii', 'juara_3'), ('juara tiga', 'juara_3'),
+    ('juara ketiga', 'juara_3'), ('juara ke 3', 'juara_3'), ('juara ke iii', 'juara_3'),
+    ('3', 'juara_3'), ('iii', 'juara_3'), ('3rd', 'juara_3'), ('3rd place', 'juara_3'),
+    ('third', 'juara_3'), ('third place', 'juara_3'), ('peringkat 3', 'juara_3'),
+    ('peringkat iii', 'juara_3'), ('peringkat ketiga', 'juara_3'),
+
+    ('medali emas', 'medali_emas'), ('emas', 'medali_emas'), ('gold', 'medali_emas'),
+    ('gold medal', 'medali_emas'),
+    ('medali perak', 'medali_perak'), ('perak', 'medali_perak'), ('silver', 'medali_perak'),
+    ('silver medal', 'medali_perak'),
+    ('medali perunggu', 'medali_perunggu'), ('perunggu', 'medali_perunggu'),
+    ('bronze', 'medali_perunggu'), ('bronze medal', 'medali_perunggu'),
+
+    ('harapan 1', 'harapan_1'), ('harapan i', 'harapan_1'), ('juara harapan 1', 'harapan_1'),
+    ('juara harapan i', 'harapan_1'), ('harapan satu', 'harapan_1'),
+    ('harapan 2', 'harapan_2'), ('harapan ii', 'harapan_2'), ('juara harapan 2', 'harapan_2'),
+    ('juara harapan ii', 'harapan_2'), ('harapan dua', 'harapan_2'),
+    ('harapan 3', 'harapan_3'), ('harapan iii', 'harapan_3'), ('juara harapan 3', 'harapan_3'),
+    ('juara harapan iii', 'harapan_3'), ('harapan tiga', 'harapan_3'),
+
+    ('finalis', 'finalis'), ('finalist', 'finalis'), ('final', 'finalis'),
+    ('peserta', 'peserta'), ('participant', 'peserta'), ('partisipan', 'peserta'),
+    ('peserta lomba', 'peserta')
+) AS v(alias, code)
+JOIN ranks r ON r.code = v.code
+ON CONFLICT (alias) DO NOTHING;
+
+-- Teks rank lama tetap disimpan apa adanya (dicetak di surat keterangan), rank_id menunjuk referensinya
+ALTER TABLE achievements
+    ADD COLUMN IF NOT EXISTS rank_id INT REFERENCES ranks(id);
+
+CREATE INDEX IF NOT EXISTS idx_achievements_rank_id ON achievements (rank_id);
+
+-- Normalisasi data lama lewat alias
+UPDATE achievements a
+SET rank_id = ra.rank_id
+FROM rank_aliases ra
+WHERE a.rank_id IS NULL
+  AND ra.alias = btrim(regexp_replace(lower(a.rank), '[^[:alnum:]]+', ' ', 'g'));
+
+-- Laporan teks rank yang belum terpetakan; perbaiki lewat edit prestasi atau tambah alias
+CREATE OR REPLACE VIEW achievement_rank_unmatched AS
+SELECT a.rank, COUNT(*) AS total
+FROM achievements a
+WHERE a.rank_id IS NULL AND btrim(COALESCE(a.rank, '')) <> ''
+GROUP BY a.rank;
+
+DO $$
+DECLARE
+    u RECORD;
+BEGIN
+    FOR u IN SELECT * FROM achievement_rank_unmatched ORDER BY total DESC LOOP
+        RAISE NOTICE 'rank belum terpetakan: "%" (% prestasi)', u.rank, u.total;
+    END LOOP;
+END $$;
+
+-- Poin medali mengikuti juara dengan urutan yang sama
+INSERT INTO scoring_rules (kind, key, value, description) VALUES
+    ('rank', 'medali_emas',     1.00, 'Medali emas'),
+    ('rank', 'medali_perak',    0.80, 'Medali perak'),
+    ('rank', 'medali_perunggu', 0.60, 'Medali perunggu')
+ON CONFLICT (kind, key) DO NOTHING;
+
+UPDATE scoring_rules SET description = 'Juara 1' WHERE kind = 'rank' AND key = 'juara_1';
+UPDATE scoring_rules SET description = 'Juara 2' WHERE kind = 'rank' AND key = 'juara_2';
+UPDATE scoring_rules SET description = 'Juara 3' WHERE kind = 'rank' AND key = 'juara_3';
@@ -0,0 +1,17 @@
+-- migrations/014_team_achievements.sql
+
+-- Prestasi tim: satu baris achievements dipakai bersama seluruh anggota, sehingga lampiran
+-- dan perubahan data berlaku untuk semua. achievements.student_id tetap diisi siswa utama
+-- (kapten, atau anggota pertama) agar query lama tetap berjalan.
+ALTER TABLE achievements
+    ADD COLUMN IF NOT EXISTS team_name VARCHAR(255); -- NULL untuk prestasi perorangan
+
+CREATE TABLE IF NOT EXISTS achievement_participants (
+    achievement_id UUID        NOT NULL REFERENCES achievements(id) ON DELETE CASCADE,
+    student_id     UUID        NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    role           VARCHAR(20) NOT NULL CHECK (role IN ('captain', 'member', 'coach')),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (achievement_id, student_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_achievement_participants_student ON achievement_participants (student_id);
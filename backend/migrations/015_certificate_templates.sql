@@ -0,0 +1,70 @@
+-- migrations/015_certificate_templates.sql
+
+-- Template surat keterangan prestasi yang dikelola admin. Isi template (bahasa, kop,
+-- teks dengan placeholder, kolom tabel, blok tanda tangan) disimpan per versi; setiap
+-- perubahan membuat versi baru sehingga surat lama tetap bisa dibuat ulang persis sama.
+CREATE TABLE IF NOT EXISTS certificate_templates (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name            VARCHAR(100) UNIQUE NOT NULL,
+    description     TEXT         NOT NULL DEFAULT '',
+    is_default      BOOLEAN      NOT NULL DEFAULT FALSE,
+    current_version INT          NOT NULL DEFAULT 1,
+    created_by      UUID         REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Hanya satu template default
+CREATE UNIQUE INDEX IF NOT EXISTS idx_certificate_templates_default
+    ON certificate_templates (is_default) WHERE is_default;
+
+-- Versi template tidak pernah diubah atau dihapus (lihat model.CertificateTemplateDefinition)
+CREATE TABLE IF NOT EXISTS certificate_template_versions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id UUID        NOT NULL REFERENCES certificate_templates(id) ON DELETE RESTRICT,
+    version     INT         NOT NULL CHECK (version > 0),
+    definition  JSONB       NOT NULL,
+    created_by  UUID        REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (template_id, version)
+);
+
+-- Versi template yang dipakai saat surat diterbitkan
+ALTER TABLE certificates
+    ADD COLUMN IF NOT EXISTS template_version_id UUID REFERENCES certificate_template_versions(id) ON DELETE RESTRICT;
+
+-- Template bawaan, sama dengan tata letak surat sebelum template tersedia
+WITH template AS (
+    INSERT INTO certificate_templates (name, description, is_default)
+    VALUES ('Standar', 'Template bawaan surat keterangan prestasi', TRUE)
+    ON CONFLICT (name) DO NOTHING
+    RETURNING id
+), version AS (
+    INSERT INTO certificate_template_versions (template_id, version, definition)
+    SELECT id, 1, '{
+        "language": "id",
+        "title": "SURAT KETERANGAN PRESTASI",
+        "header_image_url": "",
+        "primary_color": "#003366",
+        "opening_text": "Yang bertanda tangan di bawah ini, Kepala Sekolah menerangkan bahwa siswa berikut:",
+        "body_text": "Telah meraih prestasi sebagai berikut:",
+        "closing_text": "Surat keterangan ini dibuat dengan sebenarnya untuk dapat dipergunakan sebagaimana mestinya.",
+        "columns": [
+            {"field": "no",               "label": "No",            "width": 8,  "align": "C"},
+            {"field": "competition_name", "label": "Nama Lomba",    "width": 45, "align": "L"},
+            {"field": "organizer",        "label": "Penyelenggara", "width": 35, "align": "L"},
+            {"field": "category",         "label": "Kategori",      "width": 22, "align": "C"},
+            {"field": "rank",             "label": "Juara",         "width": 18, "align": "C"},
+            {"field": "level",            "label": "Tingkat",       "width": 27, "align": "C"},
+            {"field": "year",             "label": "Tahun",         "width": 15, "align": "C"}
+        ],
+        "signatures": [
+            {"title": "Kepala Sekolah,", "name": "{{.School.HeadmasterName}}", "nip": "{{.School.HeadmasterNIP}}"}
+        ]
+    }'::jsonb
+    FROM template
+    RETURNING id
+)
+-- Surat yang sudah terbit dibuat dengan tata letak bawaan
+UPDATE certificates SET template_version_id = (SELECT id FROM version)
+WHERE template_version_id IS NULL;
@@ -0,0 +1,19 @@
+-- migrations/016_sessions.sql
+
+-- Sesi login. Setiap sesi hanya punya satu refresh token yang berlaku (disimpan sebagai
+-- hash SHA-256); token diganti setiap kali dipakai. Token lama yang dipakai lagi
+-- dianggap dicuri dan sesinya langsung dicabut.
+CREATE TABLE IF NOT EXISTS sessions (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id             UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_hash  CHAR(64)     NOT NULL,
+    user_agent          TEXT         NOT NULL DEFAULT '',
+    ip_address          VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_used_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ  NOT NULL,
+    revoked_at          TIMESTAMPTZ,
+    revoked_reason      VARCHAR(50)                              -- logout | logout_all | admin | token_reuse
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions (user_id, created_at DESC);
@@ -0,0 +1,7 @@
+-- migrations/017_password_change.sql
+
+-- User dengan password yang ditentukan orang lain (admin bawaan, reset oleh admin)
+-- wajib menggantinya sebelum bisa memakai API lain
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS password_changed_at  TIMESTAMPTZ;
@@ -0,0 +1,20 @@
+-- migrations/018_two_factor.sql
+
+-- Verifikasi dua langkah (TOTP, RFC 6238). totp_secret terisi sejak enrolment dimulai,
+-- 2FA baru aktif setelah kode pertama dikonfirmasi (totp_enabled_at). totp_last_step
+-- mencegah kode yang sama dipakai dua kali.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret      VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS totp_enabled_at  TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS totp_last_step   BIGINT NOT NULL DEFAULT 0;
+
+-- Recovery code sekali pakai, disimpan sebagai hash SHA-256
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash   CHAR(64)     NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON user_recovery_codes (user_id);
@@ -0,0 +1,16 @@
+-- migrations/019_login_throttles.sql
+
+-- Percobaan login gagal per akun (email) dan per IP. Jeda antar percobaan bertambah
+-- seiring jumlah kegagalan; setelah batas tercapai subjek dikunci sampai locked_until.
+-- Email yang tidak terdaftar juga dicatat agar perilakunya sama dengan email terdaftar.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope           VARCHAR(10)   NOT NULL,                 -- account | ip
+    subject         VARCHAR(255)  NOT NULL,                 -- email atau alamat IP
+    failures        INT           NOT NULL DEFAULT 0,
+    last_failed_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_locked ON login_throttles (locked_until)
+    WHERE locked_until IS NOT NULL;